@@ -32,6 +32,8 @@ func main() {
 	switch command {
 	case "reset-password":
 		resetPassword()
+	case "reconcile-notes":
+		reconcileNotes(os.Args[2:])
 	case "help", "--help", "-h":
 		printUsage()
 	default:
@@ -49,6 +51,7 @@ func printUsage() {
 	fmt.Println()
 	fmt.Println("Available commands:")
 	fmt.Println("  reset-password    Reset user password interactively")
+	fmt.Println("  reconcile-notes   Repair notes that differ from their collaborative documents")
 	fmt.Println("  help              Show this help message")
 	fmt.Println()
 }
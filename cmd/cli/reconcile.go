@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/bootstrap"
+	"github.com/collabreef/collabreef/internal/collab"
+	"github.com/collabreef/collabreef/internal/config"
+	"github.com/collabreef/collabreef/internal/model"
+	"gorm.io/gorm"
+)
+
+func reconcileNotes(args []string) {
+	flags := flag.NewFlagSet("reconcile-notes", flag.ExitOnError)
+	prefer := flags.String("prefer", string(collab.PreferNewest), "side that wins when they differ: newest, document or note")
+	dryRun := flags.Bool("dry-run", false, "only report notes that differ")
+	flags.Parse(args)
+
+	direction := collab.Direction(*prefer)
+	if !collab.IsValidDirection(direction) {
+		log.Fatalf("Invalid -prefer value: %s", *prefer)
+	}
+
+	fmt.Println("=== Collabreef Note Reconciliation ===")
+	fmt.Println()
+
+	config.Init()
+
+	if err := config.CheckCollabSecret(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	db, err := bootstrap.NewDB()
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+
+	collabURL, err := url.Parse(config.C.GetString(config.COLLAB_URL))
+	if err != nil {
+		log.Fatalf("Invalid COLLAB_URL: %v", err)
+	}
+
+	sync := collab.NewNoteSync(db, collab.NewRemote(collabURL, []byte(config.C.GetString(config.COLLAB_SECRET))))
+
+	names, err := db.FindYjsDocumentNames(collab.NoteDocumentName(""))
+	if err != nil {
+		log.Fatalf("Failed to list documents: %v", err)
+	}
+
+	counts := map[collab.Drift]int{}
+	failed := 0
+
+	for _, name := range names {
+		noteID := strings.TrimPrefix(name, collab.NoteDocumentName(""))
+
+		note, err := db.FindNote(model.Note{ID: noteID})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			fmt.Printf("- %s: note no longer exists, skipped\n", name)
+			continue
+		}
+		if err != nil {
+			log.Fatalf("Failed to load note %s: %v", noteID, err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		drift, err := sync.Reconcile(ctx, note, direction, *dryRun)
+		cancel()
+
+		if err != nil {
+			fmt.Printf("✗ %s: %v\n", name, err)
+			failed++
+			continue
+		}
+		counts[drift]++
+
+		switch drift {
+		case collab.NoteBehind:
+			fmt.Printf("• %s (%s): note %s from document\n", name, note.Title, action(*dryRun))
+		case collab.DocumentBehind:
+			fmt.Printf("• %s (%s): document %s from note\n", name, note.Title, action(*dryRun))
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf("Checked %d documents: %d in sync, %d notes and %d documents %s, %d failed\n",
+		len(names), counts[collab.InSync], counts[collab.NoteBehind], counts[collab.DocumentBehind], action(*dryRun), failed)
+}
+
+func action(dryRun bool) string {
+	if dryRun {
+		return "would be updated"
+	}
+	return "updated"
+}
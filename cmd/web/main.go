@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
+	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
@@ -11,6 +13,7 @@ import (
 
 	"github.com/collabreef/collabreef/internal/bootstrap"
 	"github.com/collabreef/collabreef/internal/config"
+	"github.com/collabreef/collabreef/internal/eventbus"
 	"github.com/collabreef/collabreef/internal/server"
 )
 
@@ -22,6 +25,10 @@ func main() {
 
 	config.Init()
 
+	if err := config.CheckCollabSecret(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
 	if err := bootstrap.RunMigration(); err != nil {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
@@ -36,20 +43,57 @@ func main() {
 		log.Fatalf("Failed to initialize storage: %v", err)
 	}
 
+	mailer, err := bootstrap.NewMailer()
+	if err != nil {
+		log.Fatalf("Failed to initialize mailer: %v", err)
+	}
+
+	jobs, err := bootstrap.NewJobRunner(db, mailer)
+	if err != nil {
+		log.Fatalf("Failed to initialize jobs: %v", err)
+	}
+
+	limiter, err := bootstrap.NewLimiter()
+	if err != nil {
+		log.Fatalf("Failed to initialize rate limiter: %v", err)
+	}
+
+	webhooks, err := bootstrap.NewWebhookDispatcher(db)
+	if err != nil {
+		log.Fatalf("Failed to initialize webhooks: %v", err)
+	}
+	bus := eventbus.New(config.C.GetInt(config.EVENTS_LOG_SIZE))
+	if webhooks != nil {
+		bus.Listen(webhooks.Publish)
+		webhooks.Start(context.Background())
+	}
+
 	// Parse collab service URL
 	collabURLStr := config.C.GetString(config.COLLAB_URL)
 	collabURL, err := url.Parse(collabURLStr)
 	if err != nil {
 		log.Fatalf("Invalid COLLAB_URL: %v", err)
 	}
-	log.Printf("Collab service URL: %s", collabURLStr)
 
-	// Setup server with reverse proxy to collab service
-	e, err := server.New(db, storage, collabURL)
+	collabServer, err := bootstrap.NewCollabServer(db)
+	if err != nil {
+		log.Fatalf("Failed to initialize collab server: %v", err)
+	}
+	if collabServer != nil {
+		log.Printf("Serving collaborative documents natively")
+	} else {
+		log.Printf("Collab service URL: %s", collabURLStr)
+	}
+
+	// Setup server with the collab server or a reverse proxy to the collab service
+	e, err := server.New(db, storage, mailer, limiter, webhooks, jobs, bus, collabURL, collabServer)
 	if err != nil {
 		log.Fatalf("Failed to setup server: %v", err)
 	}
 
+	// Handlers are registered by now
+	jobs.Start(context.Background())
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
@@ -58,7 +102,7 @@ func main() {
 	// Start server in a goroutine
 	go func() {
 		log.Printf("Starting server on port %s", port)
-		if err := e.Start(":" + port); err != nil {
+		if err := e.Start(":" + port); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			e.Logger.Fatal(err)
 		}
 	}()
@@ -78,5 +122,10 @@ func main() {
 		e.Logger.Fatal(err)
 	}
 
+	// Store what editors changed since the last save
+	if collabServer != nil {
+		collabServer.Close()
+	}
+
 	log.Println("Server stopped")
 }
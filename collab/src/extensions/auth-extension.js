@@ -1,12 +1,87 @@
+import crypto from 'node:crypto'
+
+export const TOKEN_HEADER = 'x-collab-token'
+const TOKEN_AUDIENCE = 'collab'
+
+function base64url(buffer) {
+  return buffer.toString('base64').replace(/=+$/, '').replace(/\+/g, '-').replace(/\//g, '_')
+}
+
+/**
+ * Verify a handshake token signed by the Go web server (HS256 JWT).
+ * Returns the claims, or throws when the token is missing, forged or expired.
+ */
+export function verifyToken(token, secret, now = Date.now()) {
+  if (typeof token !== 'string' || token === '') {
+    throw new Error('missing token')
+  }
+
+  const parts = token.split('.')
+  if (parts.length !== 3) {
+    throw new Error('malformed token')
+  }
+  const [header, payload, signature] = parts
+
+  const expected = base64url(crypto.createHmac('sha256', secret).update(`${header}.${payload}`).digest())
+  const a = Buffer.from(signature)
+  const b = Buffer.from(expected)
+  if (a.length !== b.length || !crypto.timingSafeEqual(a, b)) {
+    throw new Error('invalid signature')
+  }
+
+  let alg, claims
+  try {
+    alg = JSON.parse(Buffer.from(header, 'base64url').toString()).alg
+    claims = JSON.parse(Buffer.from(payload, 'base64url').toString())
+  } catch {
+    throw new Error('malformed token')
+  }
+
+  if (alg !== 'HS256') {
+    throw new Error('unexpected signing method')
+  }
+  const audience = Array.isArray(claims.aud) ? claims.aud : [claims.aud]
+  if (!audience.includes(TOKEN_AUDIENCE)) {
+    throw new Error('wrong audience')
+  }
+  if (typeof claims.exp !== 'number' || claims.exp * 1000 <= now) {
+    throw new Error('token expired')
+  }
+  if (!claims.sub || !claims.doc) {
+    throw new Error('incomplete token')
+  }
+
+  return claims
+}
+
 /**
  * Auth extension for Hocuspocus
- * Reads authentication headers set by Go reverse proxy
+ * Only accepts connections opened through the Go web server, which signs a
+ * short-lived token with the user, the document and whether it is read-only.
  */
 export class AuthExtension {
+  constructor({ secret }) {
+    this.secret = secret
+  }
+
   async onConnect(data) {
-    const readOnly = data.requestHeaders['x-read-only'] === 'true'
-    if (readOnly) {
+    let claims
+    try {
+      claims = verifyToken(data.requestHeaders[TOKEN_HEADER], this.secret)
+    } catch (err) {
+      console.log(`[Auth] Rejected connection to ${data.documentName}: ${err.message}`)
+      throw err
+    }
+
+    if (claims.doc !== data.documentName) {
+      console.log(`[Auth] Rejected connection to ${data.documentName}: token is for ${claims.doc}`)
+      throw new Error('token is for another document')
+    }
+
+    if (claims.read_only === true) {
       data.connection.readOnly = true
     }
+
+    return { user: { id: claims.sub, name: claims.name } }
   }
 }
@@ -240,8 +240,8 @@ export class DatabaseExtension {
     const title = yMeta.get('title')
     const now = new Date().toISOString()
 
-    // Get the user who made the last change from request headers
-    const updatedBy = data.requestHeaders?.['x-user-id'] || 'system'
+    // Get the user who made the last change from the verified handshake token
+    const updatedBy = data.context?.user?.id || 'system'
 
     const note = await this.db.findNote(noteId)
     if (!note) return
@@ -4,6 +4,13 @@ import { AuthExtension } from './extensions/auth-extension.js'
 import { createDB } from './db/db.js'
 
 const PORT = parseInt(process.env.PORT || '3000', 10)
+// Must match the web server's COLLAB_SECRET, which signs the handshake tokens.
+// The old default is public, so it is refused like a missing secret.
+const SECRET = process.env.COLLAB_SECRET
+if (!SECRET || SECRET === 'default_collab_secret') {
+  console.error('COLLAB_SECRET must be set to a secret value shared with the web server')
+  process.exit(1)
+}
 
 // Initialize Database
 const db = createDB()
@@ -13,7 +20,7 @@ console.log(`Database initialized (driver: ${process.env.DB_DRIVER || 'sqlite3'}
 const server = Server.configure({
   port: PORT,
   extensions: [
-    new AuthExtension(),
+    new AuthExtension({ secret: SECRET }),
     new DatabaseExtension({ db }),
   ],
   async onListen(data) {
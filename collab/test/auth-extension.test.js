@@ -0,0 +1,77 @@
+import { test } from 'node:test'
+import assert from 'node:assert/strict'
+import crypto from 'node:crypto'
+import { AuthExtension, TOKEN_HEADER } from '../src/extensions/auth-extension.js'
+
+const SECRET = 'test-secret'
+
+function sign(claims, { secret = SECRET, alg = 'HS256' } = {}) {
+  const encode = (v) => Buffer.from(JSON.stringify(v)).toString('base64url')
+  const unsigned = `${encode({ alg, typ: 'JWT' })}.${encode(claims)}`
+  const signature = crypto.createHmac('sha256', secret).update(unsigned).digest('base64url')
+  return `${unsigned}.${signature}`
+}
+
+function claims(overrides = {}) {
+  return {
+    aud: 'collab',
+    sub: 'user-1',
+    name: 'Alice',
+    doc: 'note:1',
+    read_only: false,
+    exp: Math.floor(Date.now() / 1000) + 60,
+    ...overrides,
+  }
+}
+
+function connect(headers, documentName = 'note:1') {
+  const data = { documentName, requestHeaders: headers, connection: { readOnly: false } }
+  return new AuthExtension({ secret: SECRET }).onConnect(data).then((context) => ({ context, data }))
+}
+
+test('rejects direct connections without a token', async () => {
+  await assert.rejects(connect({}), /missing token/)
+})
+
+test('rejects the identity headers the proxy used to set', async () => {
+  await assert.rejects(connect({ 'x-user-id': 'user-1', 'x-user-name': 'Alice', 'x-read-only': 'false' }), /missing token/)
+})
+
+test('rejects tokens signed with another secret', async () => {
+  await assert.rejects(connect({ [TOKEN_HEADER]: sign(claims(), { secret: 'guessed' }) }), /invalid signature/)
+})
+
+test('rejects unsigned tokens', async () => {
+  const [header, payload] = sign(claims(), { alg: 'none' }).split('.')
+  await assert.rejects(connect({ [TOKEN_HEADER]: `${header}.${payload}.` }), /invalid signature/)
+})
+
+test('rejects tampered tokens', async () => {
+  const [header, , signature] = sign(claims()).split('.')
+  const payload = Buffer.from(JSON.stringify(claims({ sub: 'admin' }))).toString('base64url')
+  await assert.rejects(connect({ [TOKEN_HEADER]: `${header}.${payload}.${signature}` }), /invalid signature/)
+})
+
+test('rejects expired tokens', async () => {
+  const token = sign(claims({ exp: Math.floor(Date.now() / 1000) - 1 }))
+  await assert.rejects(connect({ [TOKEN_HEADER]: token }), /token expired/)
+})
+
+test('rejects tokens for other audiences', async () => {
+  await assert.rejects(connect({ [TOKEN_HEADER]: sign(claims({ aud: undefined })) }), /wrong audience/)
+})
+
+test('rejects tokens for another document', async () => {
+  await assert.rejects(connect({ [TOKEN_HEADER]: sign(claims()) }, 'note:2'), /another document/)
+})
+
+test('accepts valid tokens and takes the user from them', async () => {
+  const { context, data } = await connect({ [TOKEN_HEADER]: sign(claims()) })
+  assert.deepEqual(context, { user: { id: 'user-1', name: 'Alice' } })
+  assert.equal(data.connection.readOnly, false)
+})
+
+test('makes connections read-only when the token says so', async () => {
+  const { data } = await connect({ [TOKEN_HEADER]: sign(claims({ read_only: true })) })
+  assert.equal(data.connection.readOnly, true)
+})
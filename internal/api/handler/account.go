@@ -0,0 +1,405 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/api/auth"
+	"github.com/collabreef/collabreef/internal/config"
+	"github.com/collabreef/collabreef/internal/db"
+	"github.com/collabreef/collabreef/internal/mailer"
+	"github.com/collabreef/collabreef/internal/model"
+	"github.com/collabreef/collabreef/internal/util"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	passwordResetTokenTTL     = time.Hour
+	emailVerificationTokenTTL = 24 * time.Hour
+)
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=6"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// appLink builds an absolute link to a frontend page carrying a token
+func appLink(path string, token string) string {
+	base := strings.TrimRight(config.C.GetString(config.APP_BASE_URL), "/")
+	return base + path + "?token=" + url.QueryEscape(token)
+}
+
+// sendMail delivers a message in the background so slow mail servers
+// don't block the request (or reveal whether an account exists)
+func (h Handler) sendMail(m mailer.Message) {
+	if h.mailer == nil {
+		return
+	}
+
+	go func() {
+		if err := h.mailer.Send(m); err != nil {
+			log.Printf("Failed to send mail to %s: %v", strings.Join(m.To, ","), err)
+		}
+	}()
+}
+
+// createUserToken replaces any outstanding tokens of the same type for the user
+// and returns the plain token to be sent by email
+func createUserToken(tx db.DB, userID string, tokenType model.UserTokenType, ttl time.Duration) (string, error) {
+	if err := tx.DeleteUserTokens(model.UserTokenFilter{UserID: userID, Type: string(tokenType)}); err != nil {
+		return "", err
+	}
+
+	token, hash, err := util.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+
+	err = tx.CreateUserToken(model.UserToken{
+		ID:        util.NewId(),
+		UserID:    userID,
+		Type:      string(tokenType),
+		TokenHash: hash,
+		ExpiresAt: now.Add(ttl).Format(time.RFC3339),
+		CreatedAt: now.Format(time.RFC3339),
+	})
+
+	return token, err
+}
+
+// redeemUserToken looks up a token and checks that it is of the expected type,
+// unused and not expired
+func redeemUserToken(tx db.DB, token string, tokenType model.UserTokenType) (model.UserToken, bool) {
+	t, err := tx.FindUserTokenByHash(util.HashToken(token))
+	if err != nil {
+		return model.UserToken{}, false
+	}
+
+	if t.Type != string(tokenType) || t.UsedAt != "" {
+		return model.UserToken{}, false
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, t.ExpiresAt)
+	if err != nil || time.Now().UTC().After(expiresAt) {
+		return model.UserToken{}, false
+	}
+
+	return t, true
+}
+
+// sendEmailVerification issues a new verification token for the user and emails it
+func (h Handler) sendEmailVerification(tx db.DB, user model.User) error {
+	token, err := createUserToken(tx, user.ID, model.UserTokenTypeEmailVerification, emailVerificationTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	h.sendMail(mailer.EmailVerificationMessage(user.Email, user.Name, appLink("/verify-email", token)))
+
+	return nil
+}
+
+func (h Handler) ForgotPassword(c echo.Context) error {
+	req := new(ForgotPasswordRequest)
+
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request data",
+		})
+	}
+
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Validation failed: " + err.Error(),
+		})
+	}
+
+	// Always answer the same way so the endpoint can't be used to discover accounts
+	resp := MessageResponse{
+		Message: "If an account exists for this email, a password reset link has been sent.",
+	}
+
+	tx, err := h.db.Begin(context.Background())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	defer tx.Rollback()
+
+	users, err := tx.FindUsers(model.UserFilter{Email: req.Email})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	if len(users) == 0 || users[0].Disabled {
+		return c.JSON(http.StatusOK, resp)
+	}
+
+	user := users[0]
+
+	token, err := createUserToken(tx, user.ID, model.UserTokenTypePasswordReset, passwordResetTokenTTL)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	h.sendMail(mailer.PasswordResetMessage(user.Email, user.Name, appLink("/reset-password", token)))
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h Handler) ResetPassword(c echo.Context) error {
+	req := new(ResetPasswordRequest)
+
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request data",
+		})
+	}
+
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Validation failed: " + err.Error(),
+		})
+	}
+
+	tx, err := h.db.Begin(context.Background())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	defer tx.Rollback()
+
+	token, ok := redeemUserToken(tx, req.Token, model.UserTokenTypePasswordReset)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "This password reset link is invalid or has expired.",
+		})
+	}
+
+	user, err := tx.FindUserByID(token.UserID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "This password reset link is invalid or has expired.",
+		})
+	}
+
+	if user.Disabled {
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": "Account has been disabled",
+		})
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "failed to hash password",
+		})
+	}
+
+	now := time.Now().UTC()
+
+	// The reset link was delivered to the user's inbox, which also proves ownership of the address
+	user.PasswordHash = string(hashedPassword)
+	user.EmailVerified = true
+	user.UpdatedBy = user.ID
+	user.UpdatedAt = now.String()
+
+	if err := tx.UpdateUser(user); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	token.UsedAt = now.Format(time.RFC3339)
+	if err := tx.UpdateUserToken(token); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, MessageResponse{
+		Message: "Password has been reset. You can now sign in with your new password.",
+	})
+}
+
+func (h Handler) VerifyEmail(c echo.Context) error {
+	req := new(VerifyEmailRequest)
+
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request data",
+		})
+	}
+
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Validation failed: " + err.Error(),
+		})
+	}
+
+	tx, err := h.db.Begin(context.Background())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	defer tx.Rollback()
+
+	token, ok := redeemUserToken(tx, req.Token, model.UserTokenTypeEmailVerification)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "This verification link is invalid or has expired.",
+		})
+	}
+
+	user, err := tx.FindUserByID(token.UserID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "This verification link is invalid or has expired.",
+		})
+	}
+
+	now := time.Now().UTC()
+
+	user.EmailVerified = true
+	user.UpdatedBy = user.ID
+	user.UpdatedAt = now.String()
+
+	if err := tx.UpdateUser(user); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	token.UsedAt = now.Format(time.RFC3339)
+	if err := tx.UpdateUserToken(token); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	// Now that the address is confirmed, join any workspaces it was invited to
+	joined, err := acceptPendingInvitations(tx, user)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	// The link signs nobody in, but the user who verified it is who joins
+	c.Set("user", user)
+	for _, i := range joined {
+		audit(tx, c, auditEntry{
+			WorkspaceID: i.WorkspaceID,
+			Action:      model.AuditActionMemberJoin,
+			TargetType:  model.AuditTargetWorkspaceMember,
+			TargetID:    user.ID,
+			After:       map[string]string{"role": i.Role, "invitation_id": i.ID},
+		})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	for _, i := range joined {
+		h.publish(c, i.WorkspaceID, model.EventMemberAdded, WorkspaceMemberResponse{
+			WorkspaceID: i.WorkspaceID,
+			UserID:      user.ID,
+			UserName:    user.Name,
+			UserEmail:   user.Email,
+			Role:        i.Role,
+		})
+	}
+
+	return c.JSON(http.StatusOK, MessageResponse{
+		Message: "Email address verified",
+	})
+}
+
+func (h Handler) ResendVerificationEmail(c echo.Context) error {
+	cookie, err := c.Cookie("token")
+	if err != nil || cookie.Value == "" {
+		return echo.NewHTTPError(http.StatusUnauthorized, "missing or invalid token")
+	}
+
+	u, err := auth.GetUserFromCookie(cookie)
+	if err != nil || u == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+	}
+
+	tx, err := h.db.Begin(context.Background())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	defer tx.Rollback()
+
+	user, err := tx.FindUserByID(u.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "failed to get user by ID")
+	}
+
+	if user.EmailVerified {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Email address is already verified",
+		})
+	}
+
+	if err := h.sendEmailVerification(tx, user); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, MessageResponse{
+		Message: "Verification email sent",
+	})
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/collabreef/collabreef/internal/model"
@@ -51,6 +52,15 @@ func (h Handler) CreateUser(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "role is invalid")
 	}
 
+	email := strings.ToLower(req.Email)
+	registered, err := h.db.FindUsers(model.UserFilter{Email: email})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if len(registered) > 0 {
+		return echo.NewHTTPError(http.StatusConflict, "This email is already registered")
+	}
+
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 
 	if err != nil {
@@ -59,10 +69,11 @@ func (h Handler) CreateUser(c echo.Context) error {
 
 	user.ID = util.NewId()
 	user.Name = req.Name
-	user.Email = req.Email
+	user.Email = email
 	user.Role = req.Role
 	user.AvatarUrl = ""
 	user.PasswordHash = string(hashedPassword)
+	user.EmailVerified = true
 	user.CreatedAt = time.Now().UTC().String()
 	user.CreatedBy = c.Get("user").(model.User).ID
 
@@ -70,6 +81,13 @@ func (h Handler) CreateUser(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	audit(h.db, c, auditEntry{
+		Action:     model.AuditActionUserCreate,
+		TargetType: model.AuditTargetUser,
+		TargetID:   user.ID,
+		After:      map[string]string{"name": user.Name, "email": user.Email, "role": user.Role},
+	})
+
 	return c.JSON(http.StatusCreated, user)
 }
 
@@ -103,12 +121,21 @@ func (h Handler) UpdateUserRole(c echo.Context) error {
 		return c.JSON(http.StatusForbidden, "Only the owner can update an administrator.")
 	}
 
+	previousRole := user.Role
 	user.Role = req.Role
 
 	if err := h.db.UpdateUser(user); err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	audit(h.db, c, auditEntry{
+		Action:     model.AuditActionUserRoleUpdate,
+		TargetType: model.AuditTargetUser,
+		TargetID:   user.ID,
+		Before:     map[string]string{"role": previousRole},
+		After:      map[string]string{"role": user.Role},
+	})
+
 	return c.JSON(http.StatusOK, user)
 }
 
@@ -150,6 +177,12 @@ func (h Handler) UpdateUserPassword(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	audit(h.db, c, auditEntry{
+		Action:     model.AuditActionUserPasswordUpdate,
+		TargetType: model.AuditTargetUser,
+		TargetID:   user.ID,
+	})
+
 	return c.JSON(http.StatusOK, user)
 }
 
@@ -174,6 +207,7 @@ func (h Handler) DisableUser(c echo.Context) error {
 		return c.JSON(http.StatusForbidden, "Only the owner can update an administrator.")
 	}
 
+	wasDisabled := user.Disabled
 	user.Disabled = true
 	user.UpdatedBy = u.ID
 	user.UpdatedAt = time.Now().UTC().String()
@@ -182,6 +216,14 @@ func (h Handler) DisableUser(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	audit(h.db, c, auditEntry{
+		Action:     model.AuditActionUserDisable,
+		TargetType: model.AuditTargetUser,
+		TargetID:   user.ID,
+		Before:     map[string]bool{"disabled": wasDisabled},
+		After:      map[string]bool{"disabled": user.Disabled},
+	})
+
 	return c.JSON(http.StatusOK, user)
 }
 
@@ -206,6 +248,7 @@ func (h Handler) EnableUser(c echo.Context) error {
 		return c.JSON(http.StatusForbidden, "Only the owner can update an administrator.")
 	}
 
+	wasDisabled := user.Disabled
 	user.Disabled = false
 	user.UpdatedBy = u.ID
 	user.UpdatedAt = time.Now().UTC().String()
@@ -214,6 +257,14 @@ func (h Handler) EnableUser(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	audit(h.db, c, auditEntry{
+		Action:     model.AuditActionUserEnable,
+		TargetType: model.AuditTargetUser,
+		TargetID:   user.ID,
+		Before:     map[string]bool{"disabled": wasDisabled},
+		After:      map[string]bool{"disabled": user.Disabled},
+	})
+
 	return c.JSON(http.StatusOK, user)
 }
 func (h Handler) DeleteUser(c echo.Context) error {
@@ -241,5 +292,12 @@ func (h Handler) DeleteUser(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	audit(h.db, c, auditEntry{
+		Action:     model.AuditActionUserDelete,
+		TargetType: model.AuditTargetUser,
+		TargetID:   user.ID,
+		Before:     map[string]string{"name": user.Name, "email": user.Email, "role": user.Role},
+	})
+
 	return c.JSON(http.StatusNoContent, "")
 }
@@ -2,10 +2,12 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/collabreef/collabreef/internal/api/auth"
+	"github.com/collabreef/collabreef/internal/config"
 	"github.com/collabreef/collabreef/internal/model"
 	"github.com/collabreef/collabreef/internal/util"
 	"golang.org/x/crypto/bcrypt"
@@ -14,6 +16,7 @@ import (
 type CreateAPIKeyRequest struct {
 	Name      string `json:"name" validate:"required"`
 	ExpiresAt string `json:"expires_at"` // Optional, RFC3339 format
+	RateLimit int    `json:"rate_limit"` // Optional, requests per minute
 }
 
 // ListAPIKeys returns all API keys for a user (masked)
@@ -50,6 +53,7 @@ func (h Handler) ListAPIKeys(c echo.Context) error {
 			UserID:     key.UserID,
 			Name:       key.Name,
 			Prefix:     key.Prefix,
+			RateLimit:  key.RateLimit,
 			LastUsedAt: key.LastUsedAt,
 			ExpiresAt:  key.ExpiresAt,
 			CreatedAt:  key.CreatedAt,
@@ -102,6 +106,11 @@ func (h Handler) CreateAPIKey(c echo.Context) error {
 		expiresAt = parsedTime.UTC().Format(time.RFC3339)
 	}
 
+	// The quota can be lowered freely but not raised above the server maximum
+	if req.RateLimit < 0 || req.RateLimit > config.C.GetInt(config.RATELIMIT_API_KEY_MAX_PER_MINUTE) {
+		return echo.NewHTTPError(http.StatusBadRequest, "rate_limit must be between 0 and "+strconv.Itoa(config.C.GetInt(config.RATELIMIT_API_KEY_MAX_PER_MINUTE)))
+	}
+
 	// Generate API key
 	fullKey, prefix, err := util.GenerateAPIKey()
 	if err != nil {
@@ -121,6 +130,7 @@ func (h Handler) CreateAPIKey(c echo.Context) error {
 		Name:      req.Name,
 		KeyHash:   string(keyHash),
 		Prefix:    prefix,
+		RateLimit: req.RateLimit,
 		ExpiresAt: expiresAt,
 		CreatedAt: time.Now().UTC().Format(time.RFC3339),
 		CreatedBy: currentUser.ID,
@@ -131,6 +141,13 @@ func (h Handler) CreateAPIKey(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to create API key")
 	}
 
+	audit(h.db, c, auditEntry{
+		Action:     model.AuditActionAPIKeyCreate,
+		TargetType: model.AuditTargetAPIKey,
+		TargetID:   apiKey.ID,
+		After:      map[string]any{"name": apiKey.Name, "prefix": apiKey.Prefix, "expires_at": apiKey.ExpiresAt, "rate_limit": apiKey.RateLimit},
+	})
+
 	// Return response with full key (ONLY TIME IT'S RETURNED)
 	response := model.APIKeyCreationResponse{
 		APIKeyResponse: model.APIKeyResponse{
@@ -138,6 +155,7 @@ func (h Handler) CreateAPIKey(c echo.Context) error {
 			UserID:    apiKey.UserID,
 			Name:      apiKey.Name,
 			Prefix:    apiKey.Prefix,
+			RateLimit: apiKey.RateLimit,
 			ExpiresAt: apiKey.ExpiresAt,
 			CreatedAt: apiKey.CreatedAt,
 		},
@@ -183,5 +201,12 @@ func (h Handler) DeleteAPIKey(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to delete API key")
 	}
 
+	audit(h.db, c, auditEntry{
+		Action:     model.AuditActionAPIKeyDelete,
+		TargetType: model.AuditTargetAPIKey,
+		TargetID:   apiKey.ID,
+		Before:     map[string]string{"name": apiKey.Name, "prefix": apiKey.Prefix},
+	})
+
 	return c.NoContent(http.StatusNoContent)
 }
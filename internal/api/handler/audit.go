@@ -0,0 +1,330 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/db"
+	"github.com/collabreef/collabreef/internal/model"
+	"github.com/collabreef/collabreef/internal/util"
+
+	"github.com/labstack/echo/v4"
+)
+
+const auditExportBatchSize = 500
+
+type auditEntry struct {
+	WorkspaceID string
+	Action      string
+	TargetType  string
+	TargetID    string
+	Before      any
+	After       any
+}
+
+type AuditEventResponse struct {
+	ID          string          `json:"id"`
+	WorkspaceID string          `json:"workspace_id"`
+	ActorID     string          `json:"actor_id"`
+	ActorName   string          `json:"actor_name"`
+	Action      string          `json:"action"`
+	TargetType  string          `json:"target_type"`
+	TargetID    string          `json:"target_id"`
+	Before      json.RawMessage `json:"before"`
+	After       json.RawMessage `json:"after"`
+	IP          string          `json:"ip"`
+	UserAgent   string          `json:"user_agent"`
+	CreatedAt   string          `json:"created_at"`
+}
+
+type GetAuditEventsResponse struct {
+	Events []AuditEventResponse `json:"events"`
+	Total  int64                `json:"total"`
+}
+
+// audit records who did what from where. Pass the transaction the change is made
+// in so the event is only kept if the change is committed. Failing to write the
+// event is logged but never fails the request.
+func audit(r db.DB, c echo.Context, e auditEntry) {
+	event := model.AuditEvent{
+		ID:          util.NewId(),
+		WorkspaceID: e.WorkspaceID,
+		Action:      e.Action,
+		TargetType:  e.TargetType,
+		TargetID:    e.TargetID,
+		Before:      auditSnapshot(e.Before),
+		After:       auditSnapshot(e.After),
+		IP:          c.RealIP(),
+		UserAgent:   c.Request().UserAgent(),
+		CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+	}
+
+	if actor, ok := c.Get("user").(model.User); ok {
+		event.ActorID = actor.ID
+		event.ActorName = actor.Name
+	}
+
+	if err := r.CreateAuditEvent(event); err != nil {
+		log.Printf("Failed to record audit event %s: %v", e.Action, err)
+	}
+}
+
+func auditSnapshot(v any) string {
+	if v == nil {
+		return ""
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+
+	return string(b)
+}
+
+func toAuditEventResponse(e model.AuditEvent) AuditEventResponse {
+	res := AuditEventResponse{
+		ID:          e.ID,
+		WorkspaceID: e.WorkspaceID,
+		ActorID:     e.ActorID,
+		ActorName:   e.ActorName,
+		Action:      e.Action,
+		TargetType:  e.TargetType,
+		TargetID:    e.TargetID,
+		IP:          e.IP,
+		UserAgent:   e.UserAgent,
+		CreatedAt:   e.CreatedAt,
+	}
+
+	if e.Before != "" {
+		res.Before = json.RawMessage(e.Before)
+	}
+	if e.After != "" {
+		res.After = json.RawMessage(e.After)
+	}
+
+	return res
+}
+
+// parseAuditEventFilter reads the filter and paging query parameters shared by
+// the admin and workspace endpoints
+func parseAuditEventFilter(c echo.Context) (model.AuditEventFilter, error) {
+	filter := model.AuditEventFilter{
+		ActorID:    c.QueryParam("actorId"),
+		Action:     c.QueryParam("action"),
+		TargetType: c.QueryParam("targetType"),
+		TargetID:   c.QueryParam("targetId"),
+		PageSize:   50,
+		PageNumber: 1,
+	}
+
+	if ps := c.QueryParam("pageSize"); ps != "" {
+		if v, err := strconv.Atoi(ps); err == nil && v > 0 && v <= 500 {
+			filter.PageSize = v
+		}
+	}
+	if pn := c.QueryParam("pageNumber"); pn != "" {
+		if v, err := strconv.Atoi(pn); err == nil && v > 0 {
+			filter.PageNumber = v
+		}
+	}
+
+	for param, dst := range map[string]*string{"from": &filter.From, "to": &filter.To} {
+		value := c.QueryParam(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, echo.NewHTTPError(http.StatusBadRequest, "invalid "+param+" format, use RFC3339")
+		}
+		*dst = t.UTC().Format(time.RFC3339)
+	}
+
+	return filter, nil
+}
+
+func (h Handler) getAuditEvents(c echo.Context, filter model.AuditEventFilter) error {
+	events, err := h.db.FindAuditEvents(filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	total, err := h.db.CountAuditEvents(filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	res := GetAuditEventsResponse{
+		Events: make([]AuditEventResponse, 0, len(events)),
+		Total:  total,
+	}
+
+	for _, e := range events {
+		res.Events = append(res.Events, toAuditEventResponse(e))
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// exportAuditEvents streams every event matching the filter as CSV or JSON
+func (h Handler) exportAuditEvents(c echo.Context, filter model.AuditEventFilter) error {
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		return echo.NewHTTPError(http.StatusBadRequest, "format must be csv or json")
+	}
+
+	filename := "audit-events-" + time.Now().UTC().Format("20060102-150405") + "." + format
+
+	resp := c.Response()
+	resp.Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+
+	var (
+		csvWriter *csv.Writer
+		first     = true
+	)
+
+	if format == "csv" {
+		resp.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+		resp.WriteHeader(http.StatusOK)
+		csvWriter = csv.NewWriter(resp)
+		csvWriter.Write([]string{"id", "created_at", "workspace_id", "actor_id", "actor_name", "action", "target_type", "target_id", "before", "after", "ip", "user_agent"})
+	} else {
+		resp.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		resp.WriteHeader(http.StatusOK)
+		resp.Write([]byte("["))
+	}
+
+	// Events are paged by the last one written rather than by offset, so
+	// events added during the download don't shift the pages
+	filter.PageSize = auditExportBatchSize
+	filter.PageNumber = 1
+
+	for {
+		events, err := h.db.FindAuditEvents(filter)
+		if err != nil {
+			// Headers are already sent, all that's left is to cut the download short
+			log.Printf("Failed to export audit events: %v", err)
+			return nil
+		}
+
+		for _, e := range events {
+			if csvWriter != nil {
+				csvWriter.Write([]string{e.ID, e.CreatedAt, e.WorkspaceID, e.ActorID, e.ActorName, e.Action, e.TargetType, e.TargetID, e.Before, e.After, e.IP, e.UserAgent})
+				continue
+			}
+
+			b, err := json.Marshal(toAuditEventResponse(e))
+			if err != nil {
+				continue
+			}
+			if !first {
+				resp.Write([]byte(","))
+			}
+			first = false
+			resp.Write(b)
+		}
+
+		if csvWriter != nil {
+			csvWriter.Flush()
+		}
+		resp.Flush()
+
+		if len(events) < filter.PageSize {
+			break
+		}
+		last := events[len(events)-1]
+		filter.BeforeCreatedAt = last.CreatedAt
+		filter.BeforeID = last.ID
+	}
+
+	if csvWriter == nil {
+		resp.Write([]byte("]"))
+	}
+
+	return nil
+}
+
+func (h Handler) GetAuditEvents(c echo.Context) error {
+	filter, err := parseAuditEventFilter(c)
+	if err != nil {
+		return err
+	}
+	filter.WorkspaceID = c.QueryParam("workspaceId")
+
+	return h.getAuditEvents(c, filter)
+}
+
+func (h Handler) ExportAuditEvents(c echo.Context) error {
+	filter, err := parseAuditEventFilter(c)
+	if err != nil {
+		return err
+	}
+	filter.WorkspaceID = c.QueryParam("workspaceId")
+
+	return h.exportAuditEvents(c, filter)
+}
+
+// checkWorkspaceOwner allows only the owner of the workspace through
+func (h Handler) checkWorkspaceOwner(c echo.Context, workspaceId string) error {
+	user := c.Get("user").(model.User)
+
+	members, err := h.db.FindWorkspaceUsers(model.WorkspaceUserFilter{
+		WorkspaceID: workspaceId,
+		UserID:      user.ID,
+	})
+	if err != nil || len(members) == 0 {
+		return echo.NewHTTPError(http.StatusForbidden, "You are not a member of this workspace")
+	}
+
+	if members[0].Role != model.WorkspaceUserRoleOwner {
+		return echo.NewHTTPError(http.StatusForbidden, "Only the workspace owner can view the audit log")
+	}
+
+	return nil
+}
+
+func (h Handler) GetWorkspaceAuditEvents(c echo.Context) error {
+	workspaceId := c.Param("workspaceId")
+	if workspaceId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "workspace id is required")
+	}
+
+	if err := h.checkWorkspaceOwner(c, workspaceId); err != nil {
+		return err
+	}
+
+	filter, err := parseAuditEventFilter(c)
+	if err != nil {
+		return err
+	}
+	filter.WorkspaceID = workspaceId
+
+	return h.getAuditEvents(c, filter)
+}
+
+func (h Handler) ExportWorkspaceAuditEvents(c echo.Context) error {
+	workspaceId := c.Param("workspaceId")
+	if workspaceId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "workspace id is required")
+	}
+
+	if err := h.checkWorkspaceOwner(c, workspaceId); err != nil {
+		return err
+	}
+
+	filter, err := parseAuditEventFilter(c)
+	if err != nil {
+		return err
+	}
+	filter.WorkspaceID = workspaceId
+
+	return h.exportAuditEvents(c, filter)
+}
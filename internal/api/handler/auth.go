@@ -1,13 +1,19 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/collabreef/collabreef/internal/api/auth"
 	"github.com/collabreef/collabreef/internal/config"
 	"github.com/collabreef/collabreef/internal/model"
+	"github.com/collabreef/collabreef/internal/ratelimit"
 	"github.com/collabreef/collabreef/internal/util"
 
 	"github.com/labstack/echo/v4"
@@ -32,6 +38,8 @@ type SignUpRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=6"`
 	Username string `json:"username" validate:"required,min=3"`
+	// Optional workspace invite link token, lets people register when sign-up is disabled
+	InviteToken string `json:"invite_token"`
 }
 
 type SignUpResponse struct {
@@ -40,17 +48,18 @@ type SignUpResponse struct {
 }
 
 type GetUserInfoResponse struct {
-	ID          string          `json:"id"`
-	Email       string          `json:"email"`
-	Name        string          `json:"name"`
-	Role        string          `json:"role"`
-	AvatarUrl   string          `json:"avatar_url"`
-	Disabled    bool            `json:"disabled"`
-	CreatedBy   string          `json:"created_by"`
-	CreatedAt   string          `json:"created_at"`
-	UpdatedBy   string          `json:"updated_by"`
-	UpdatedAt   string          `json:"updated_at"`
-	Preferences json.RawMessage `json:"preferences"`
+	ID            string          `json:"id"`
+	Email         string          `json:"email"`
+	EmailVerified bool            `json:"email_verified"`
+	Name          string          `json:"name"`
+	Role          string          `json:"role"`
+	AvatarUrl     string          `json:"avatar_url"`
+	Disabled      bool            `json:"disabled"`
+	CreatedBy     string          `json:"created_by"`
+	CreatedAt     string          `json:"created_at"`
+	UpdatedBy     string          `json:"updated_by"`
+	UpdatedAt     string          `json:"updated_at"`
+	Preferences   json.RawMessage `json:"preferences"`
 }
 
 func (h *Handler) SignIn(c echo.Context) error {
@@ -68,6 +77,13 @@ func (h *Handler) SignIn(c echo.Context) error {
 		})
 	}
 
+	accountKey := "signin:account:" + strings.ToLower(req.Username)
+	ipKey := "signin:ip:" + c.RealIP()
+
+	if res, ok := h.checkSignInThrottle(accountKey, ipKey); !ok {
+		return tooManyRequests(c, res)
+	}
+
 	users, err := h.db.FindUsers(model.UserFilter{NameOrEmail: req.Username})
 
 	if err != nil {
@@ -77,6 +93,7 @@ func (h *Handler) SignIn(c echo.Context) error {
 	}
 
 	if len(users) == 0 {
+		h.recordSignInFailure(accountKey, ipKey)
 		return c.JSON(http.StatusUnauthorized, map[string]string{
 			"error": "User not found",
 		})
@@ -85,11 +102,18 @@ func (h *Handler) SignIn(c echo.Context) error {
 	existingUser := users[0]
 
 	if bcrypt.CompareHashAndPassword([]byte(existingUser.PasswordHash), []byte(req.Password)) != nil {
+		h.recordSignInFailure(accountKey, ipKey)
 		return c.JSON(http.StatusBadRequest, map[string]string{
 			"error": "Login failed",
 		})
 	}
 
+	// Only the account is cleared, otherwise signing in to an account of one's own
+	// would reset the lockout of an address guessing passwords for others
+	if err := h.limiter.Succeed(accountKey); err != nil {
+		log.Printf("Rate limiter error: %v", err)
+	}
+
 	// Check if user account is disabled
 	if existingUser.Disabled {
 		return c.JSON(http.StatusForbidden, map[string]string{
@@ -97,6 +121,12 @@ func (h *Handler) SignIn(c echo.Context) error {
 		})
 	}
 
+	if config.C.GetBool(config.APP_REQUIRE_EMAIL_VERIFICATION) && !existingUser.EmailVerified {
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": "Please verify your email address before signing in",
+		})
+	}
+
 	cookie, err := auth.CreateUserCookie(existingUser)
 
 	if err != nil {
@@ -115,6 +145,47 @@ func (h *Handler) SignIn(c echo.Context) error {
 	return c.JSON(http.StatusOK, resp)
 }
 
+// checkSignInThrottle applies the per-account request limit and the progressive
+// lockouts built up by failed attempts for both the account and the client address
+func (h *Handler) checkSignInThrottle(accountKey string, ipKey string) (ratelimit.Result, bool) {
+	perMinute := ratelimit.PerMinute(config.C.GetInt(config.RATELIMIT_SIGNIN_ACCOUNT_PER_MINUTE))
+
+	checks := []func() (ratelimit.Result, error){
+		func() (ratelimit.Result, error) { return h.limiter.Locked(accountKey) },
+		func() (ratelimit.Result, error) { return h.limiter.Locked(ipKey) },
+		func() (ratelimit.Result, error) { return h.limiter.Allow(accountKey, perMinute) },
+	}
+
+	for _, check := range checks {
+		res, err := check()
+		if err != nil {
+			// Fail open, an unavailable store shouldn't lock everyone out
+			log.Printf("Rate limiter error: %v", err)
+			continue
+		}
+		if !res.Allowed {
+			return res, false
+		}
+	}
+
+	return ratelimit.Result{Allowed: true}, true
+}
+
+func (h *Handler) recordSignInFailure(accountKey string, ipKey string) {
+	for _, key := range []string{accountKey, ipKey} {
+		if _, err := h.limiter.Fail(key); err != nil {
+			log.Printf("Rate limiter error: %v", err)
+		}
+	}
+}
+
+func tooManyRequests(c echo.Context, res ratelimit.Result) error {
+	c.Response().Header().Set("Retry-After", strconv.Itoa(res.RetryAfterSeconds()))
+	return c.JSON(http.StatusTooManyRequests, map[string]string{
+		"error": "Too many sign-in attempts, please try again later",
+	})
+}
+
 func (h *Handler) SignOut(c echo.Context) error {
 	cookie := auth.GetCleanCookie()
 
@@ -128,14 +199,6 @@ func (h *Handler) SignOut(c echo.Context) error {
 }
 
 func (h *Handler) SignUp(c echo.Context) error {
-	disableSignUp := config.C.GetBool(config.APP_DISABLE_SIGNUP)
-
-	if disableSignUp {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Registration is not allowed on this server.",
-		})
-	}
-
 	req := new(SignUpRequest)
 
 	if err := c.Bind(req); err != nil {
@@ -149,22 +212,65 @@ func (h *Handler) SignUp(c echo.Context) error {
 			"error": "Validation failed: " + err.Error(),
 		})
 	}
+	// Addresses are matched regardless of case, and stored in lower case
+	req.Email = strings.ToLower(req.Email)
 
-	users, err := h.db.FindUsers(model.UserFilter{})
-
+	tx, err := h.db.Begin(context.Background())
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": err.Error(),
 		})
 	}
+	defer tx.Rollback()
+
+	var inviteLink *model.WorkspaceInviteLink
+	if req.InviteToken != "" {
+		link, err := findInviteLink(tx, req.InviteToken, req.Email)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		inviteLink = &link
+	}
 
-	for _, user := range users {
-		if user.Email == req.Email {
-			return c.JSON(http.StatusConflict, map[string]string{
-				"error": "This email is already registered. Please use a different email.",
+	// Invited email addresses and invite link holders may still register when sign-up is disabled
+	if config.C.GetBool(config.APP_DISABLE_SIGNUP) && inviteLink == nil {
+		invited, err := hasPendingInvitation(tx, req.Email)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
 			})
 		}
 
+		if !invited {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Registration is not allowed on this server.",
+			})
+		}
+	}
+
+	registered, err := tx.FindUsers(model.UserFilter{Email: req.Email})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if len(registered) > 0 {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "This email is already registered. Please use a different email.",
+		})
+	}
+
+	users, err := tx.FindUsers(model.UserFilter{})
+
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	for _, user := range users {
 		if user.Name == req.Username {
 			return c.JSON(http.StatusConflict, map[string]string{
 				"error": "This username is taken. Please choose a different username.",
@@ -197,12 +303,34 @@ func (h *Handler) SignUp(c echo.Context) error {
 		user.Role = model.RoleOwner
 	}
 
-	err = h.db.CreateUser(user)
+	err = tx.CreateUser(user)
 
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, err.Error())
 	}
 
+	if err := h.sendEmailVerification(tx, user); err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	// Invitations are only accepted once the address is verified, or with the
+	// token from the invitation email, so nobody joins a workspace by signing
+	// up with an address they don't own
+
+	// Restricted links wait until the address is verified, the user can join later.
+	if inviteLink != nil {
+		err := redeemInviteLink(tx, *inviteLink, user)
+		if err != nil && !errors.Is(err, errInviteLinkUnverified) && !errors.Is(err, errInviteLinkAlreadyAMember) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
 	cookie, err := auth.CreateUserCookie(user)
 
 	if err != nil {
@@ -237,11 +365,12 @@ func (h *Handler) GetUserInfo(c echo.Context) error {
 	}
 
 	res := GetUserInfoResponse{
-		ID:        u.ID,
-		Name:      u.Name,
-		Email:     u.Email,
-		Role:      u.Role,
-		AvatarUrl: u.AvatarUrl,
+		ID:            u.ID,
+		Name:          u.Name,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified,
+		Role:          u.Role,
+		AvatarUrl:     u.AvatarUrl,
 	}
 
 	if u.Preferences != "" {
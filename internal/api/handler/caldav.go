@@ -0,0 +1,817 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/dav"
+	"github.com/collabreef/collabreef/internal/ical"
+	"github.com/collabreef/collabreef/internal/model"
+	"github.com/collabreef/collabreef/internal/util"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CalDAV serves calendar views to calendar apps under /dav: the principal
+// of the signed-in user, a calendar home with every calendar view they can
+// see, and the slots of those views as events.
+
+const (
+	calDAVRoot = "/dav/"
+
+	maxCalDAVObjectSize = 1 << 20
+
+	calDAVContentType = "text/calendar; charset=utf-8"
+)
+
+var (
+	propResourceType      = dav.Name(dav.NamespaceDAV, "resourcetype")
+	propDisplayName       = dav.Name(dav.NamespaceDAV, "displayname")
+	propCurrentPrincipal  = dav.Name(dav.NamespaceDAV, "current-user-principal")
+	propPrincipalURL      = dav.Name(dav.NamespaceDAV, "principal-URL")
+	propOwner             = dav.Name(dav.NamespaceDAV, "owner")
+	propPrivileges        = dav.Name(dav.NamespaceDAV, "current-user-privilege-set")
+	propETag              = dav.Name(dav.NamespaceDAV, "getetag")
+	propContentType       = dav.Name(dav.NamespaceDAV, "getcontenttype")
+	propContentLength     = dav.Name(dav.NamespaceDAV, "getcontentlength")
+	propLastModified      = dav.Name(dav.NamespaceDAV, "getlastmodified")
+	propCalendarHome      = dav.Name(dav.NamespaceCalDAV, "calendar-home-set")
+	propCalendarAddresses = dav.Name(dav.NamespaceCalDAV, "calendar-user-address-set")
+	propComponents        = dav.Name(dav.NamespaceCalDAV, "supported-calendar-component-set")
+	propCalendarDataTypes = dav.Name(dav.NamespaceCalDAV, "supported-calendar-data")
+	propCalendarDesc      = dav.Name(dav.NamespaceCalDAV, "calendar-description")
+	propCalendarData      = dav.Name(dav.NamespaceCalDAV, "calendar-data")
+	propCTag              = dav.Name(dav.NamespaceCalendarServer, "getctag")
+
+	reportCalendarQuery    = dav.Name(dav.NamespaceCalDAV, "calendar-query")
+	reportCalendarMultiget = dav.Name(dav.NamespaceCalDAV, "calendar-multiget")
+
+	conditionNoUIDConflict = dav.Name(dav.NamespaceCalDAV, "no-uid-conflict")
+)
+
+// davProps are the properties of a resource. Their values are worked out
+// when they are asked for.
+type davProps map[xml.Name]func() (string, error)
+
+// split sorts the properties a request asks for into those the resource has
+// and those it doesn't. allprop leaves out calendar-data, as it should.
+func (p davProps) split(req dav.Request) ([]dav.Property, []xml.Name, error) {
+	names := req.Props
+	if req.AllProps {
+		names = nil
+		for name := range p {
+			if name != propCalendarData {
+				names = append(names, name)
+			}
+		}
+		sort.Slice(names, func(i, j int) bool { return names[i].Local < names[j].Local })
+	}
+
+	var found []dav.Property
+	var missing []xml.Name
+	for _, name := range names {
+		value, ok := p[name]
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		v, err := value()
+		if err != nil {
+			return nil, nil, err
+		}
+		found = append(found, dav.Property{Name: name, Value: v})
+	}
+	return found, missing, nil
+}
+
+func davValue(v string) func() (string, error) {
+	return func() (string, error) { return v, nil }
+}
+
+func calDAVPrincipalHref(userID string) string {
+	return calDAVRoot + "principals/" + userID + "/"
+}
+
+func calDAVHomeHref() string {
+	return calDAVRoot + "calendars/"
+}
+
+func calDAVCalendarHref(viewID string) string {
+	return calDAVHomeHref() + viewID + "/"
+}
+
+func calDAVObjectHref(viewID, name string) string {
+	return calDAVCalendarHref(viewID) + url.PathEscape(name) + ".ics"
+}
+
+// calDAVObjectName is what a slot is called in its calendar: the name a
+// calendar app stored it under, or else its ID
+func calDAVObjectName(vo model.ViewObject, s calendarSlot) string {
+	if s.data.CalDAVName != "" {
+		return s.data.CalDAVName
+	}
+	return vo.ID
+}
+
+// depth reads the Depth header, taking infinity as 1
+func depth(c echo.Context) int {
+	if c.Request().Header.Get("Depth") == "0" {
+		return 0
+	}
+	return 1
+}
+
+func sendMultistatus(c echo.Context, ms *dav.Multistatus) error {
+	return c.Blob(http.StatusMultiStatus, "application/xml; charset=utf-8", ms.Bytes())
+}
+
+// readDAVRequest reads the XML body of a request
+func readDAVRequest(c echo.Context) (dav.Request, error) {
+	return dav.ParseRequest(io.LimitReader(c.Request().Body, maxCalDAVObjectSize))
+}
+
+// calDAVOptions answers OPTIONS and the methods that aren't supported, and
+// reports whether it did
+func calDAVOptions(c echo.Context) (bool, error) {
+	switch c.Request().Method {
+	case http.MethodOptions:
+		c.Response().Header().Set("DAV", "1, 3, calendar-access")
+		c.Response().Header().Set("Allow", "OPTIONS, GET, HEAD, PUT, DELETE, PROPFIND, REPORT")
+		return true, c.NoContent(http.StatusOK)
+	case "PROPPATCH", "MKCOL", "MKCALENDAR", "MOVE", "COPY":
+		return true, c.String(http.StatusForbidden, "Calendars can only be changed in Collabreef")
+	}
+	return false, nil
+}
+
+// CalDAVWellKnown sends calendar apps looking for CalDAV to its root
+func (h Handler) CalDAVWellKnown(c echo.Context) error {
+	return c.Redirect(http.StatusMovedPermanently, calDAVRoot)
+}
+
+// CalDAVRoot points to the signed-in user's principal
+func (h Handler) CalDAVRoot(c echo.Context) error {
+	if done, err := calDAVOptions(c); done {
+		return err
+	}
+	if c.Request().Method != "PROPFIND" {
+		return c.NoContent(http.StatusMethodNotAllowed)
+	}
+
+	user := c.Get("user").(model.User)
+	req, err := readDAVRequest(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	props := davProps{
+		propResourceType:     davValue("<d:collection/>"),
+		propDisplayName:      davValue("Collabreef"),
+		propCurrentPrincipal: davValue(dav.Href(calDAVPrincipalHref(user.ID))),
+	}
+	found, missing, err := props.split(req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	var ms dav.Multistatus
+	ms.Add(calDAVRoot, found, missing)
+	return sendMultistatus(c, &ms)
+}
+
+// CalDAVPrincipal describes the signed-in user and where their calendars are
+func (h Handler) CalDAVPrincipal(c echo.Context) error {
+	if done, err := calDAVOptions(c); done {
+		return err
+	}
+	user := c.Get("user").(model.User)
+	if c.Param("userId") != user.ID {
+		return c.NoContent(http.StatusNotFound)
+	}
+	if c.Request().Method != "PROPFIND" {
+		return c.NoContent(http.StatusMethodNotAllowed)
+	}
+
+	req, err := readDAVRequest(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	principal := dav.Href(calDAVPrincipalHref(user.ID))
+	props := davProps{
+		propResourceType:      davValue("<d:principal/>"),
+		propDisplayName:       davValue(dav.Escape(user.Name)),
+		propCurrentPrincipal:  davValue(principal),
+		propPrincipalURL:      davValue(principal),
+		propCalendarHome:      davValue(dav.Href(calDAVHomeHref())),
+		propCalendarAddresses: davValue(dav.Href("mailto:" + user.Email)),
+	}
+	found, missing, err := props.split(req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	var ms dav.Multistatus
+	ms.Add(calDAVPrincipalHref(user.ID), found, missing)
+	return sendMultistatus(c, &ms)
+}
+
+// CalDAVHome lists the calendar views the signed-in user can see
+func (h Handler) CalDAVHome(c echo.Context) error {
+	if done, err := calDAVOptions(c); done {
+		return err
+	}
+	if c.Request().Method != "PROPFIND" {
+		return c.NoContent(http.StatusMethodNotAllowed)
+	}
+
+	user := c.Get("user").(model.User)
+	req, err := readDAVRequest(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	principal := dav.Href(calDAVPrincipalHref(user.ID))
+	props := davProps{
+		propResourceType:     davValue("<d:collection/>"),
+		propDisplayName:      davValue("Calendars"),
+		propCurrentPrincipal: davValue(principal),
+		propOwner:            davValue(principal),
+	}
+	found, missing, err := props.split(req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	var ms dav.Multistatus
+	ms.Add(calDAVHomeHref(), found, missing)
+
+	if depth(c) > 0 {
+		views, err := h.calDAVViews(user)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		for _, v := range views {
+			found, missing, err := h.calDAVCalendarProps(v, user).split(req)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+			}
+			ms.Add(calDAVCalendarHref(v.ID), found, missing)
+		}
+	}
+
+	return sendMultistatus(c, &ms)
+}
+
+// calDAVViews returns the calendar views the user can see in all of their
+// workspaces
+func (h Handler) calDAVViews(user model.User) ([]model.View, error) {
+	members, err := h.db.FindWorkspaceUsers(model.WorkspaceUserFilter{UserID: user.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	var views []model.View
+	for _, m := range members {
+		filter := model.ViewFilter{
+			WorkspaceID: m.WorkspaceID,
+			ViewType:    "calendar",
+			PageSize:    calendarBatchSize,
+			PageNumber:  1,
+		}
+		for {
+			page, err := h.db.FindViews(filter)
+			if err != nil {
+				return nil, err
+			}
+			for _, v := range page {
+				if v.Visibility != "private" || v.CreatedBy == user.ID {
+					views = append(views, v)
+				}
+			}
+			if len(page) < filter.PageSize {
+				break
+			}
+			filter.PageNumber++
+		}
+	}
+	return views, nil
+}
+
+// findCalDAVView returns the calendar view of the request if the signed-in
+// user can see it
+func (h Handler) findCalDAVView(c echo.Context) (model.View, bool) {
+	user := c.Get("user").(model.User)
+
+	v, err := h.db.FindView(model.View{ID: c.Param("viewId")})
+	if err != nil || v.Type != "calendar" || !h.canSeeView(v, user.ID) {
+		return model.View{}, false
+	}
+	return v, true
+}
+
+func (h Handler) calDAVCalendarProps(v model.View, user model.User) davProps {
+	principal := dav.Href(calDAVPrincipalHref(user.ID))
+
+	return davProps{
+		propResourceType:      davValue("<d:collection/><c:calendar/>"),
+		propDisplayName:       davValue(dav.Escape(v.Name)),
+		propCurrentPrincipal:  davValue(principal),
+		propOwner:             davValue(principal),
+		propComponents:        davValue(`<c:comp name="VEVENT"/>`),
+		propCalendarDataTypes: davValue(`<c:calendar-data content-type="text/calendar" version="2.0"/>`),
+		propPrivileges: davValue("<d:privilege><d:read/></d:privilege><d:privilege><d:write/></d:privilege>" +
+			"<d:privilege><d:write-content/></d:privilege><d:privilege><d:bind/></d:privilege><d:privilege><d:unbind/></d:privilege>"),
+		propCalendarDesc: func() (string, error) {
+			w, err := h.db.FindWorkspaceByID(v.WorkspaceID)
+			if err != nil {
+				return "", err
+			}
+			return dav.Escape(w.Name), nil
+		},
+		// The ctag changes whenever any event of the calendar does
+		propCTag: func() (string, error) {
+			objects, err := h.calDAVObjects(v, user.ID)
+			if err != nil {
+				return "", err
+			}
+			sum := sha256.New()
+			for _, o := range objects {
+				sum.Write([]byte(o.etag))
+			}
+			return hex.EncodeToString(sum.Sum(nil))[:32], nil
+		},
+	}
+}
+
+// calDAVObject is a calendar slot as a CalDAV resource
+type calDAVObject struct {
+	vo   model.ViewObject
+	slot calendarSlot
+	name string
+	ics  []byte
+	etag string
+}
+
+func (h Handler) newCalDAVObject(vo model.ViewObject, viewerID string) (calDAVObject, bool, error) {
+	s, err := parseCalendarSlot(vo.Data)
+	if err != nil {
+		return calDAVObject{}, false, nil
+	}
+	e, ok, err := h.calendarEvent(vo, viewerID)
+	if err != nil || !ok {
+		return calDAVObject{}, false, err
+	}
+
+	var buf bytes.Buffer
+	cw := ical.NewObjectWriter(&buf)
+	if err := cw.Write(e); err != nil {
+		return calDAVObject{}, false, err
+	}
+	if err := cw.Close(); err != nil {
+		return calDAVObject{}, false, err
+	}
+
+	// The description comes from notes, so the ETag is that of the whole event
+	sum := sha256.Sum256(buf.Bytes())
+	return calDAVObject{
+		vo:   vo,
+		slot: s,
+		name: calDAVObjectName(vo, s),
+		ics:  buf.Bytes(),
+		etag: `"` + hex.EncodeToString(sum[:16]) + `"`,
+	}, true, nil
+}
+
+// calDAVObjects returns the slots of a calendar view as CalDAV resources,
+// leaving out those with data that isn't valid
+func (h Handler) calDAVObjects(v model.View, viewerID string) ([]calDAVObject, error) {
+	slots, err := h.findCalendarSlots(v.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []calDAVObject
+	for _, vo := range slots {
+		o, ok, err := h.newCalDAVObject(vo, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			objects = append(objects, o)
+		}
+	}
+	return objects, nil
+}
+
+// findCalDAVObject returns the slot with a resource name among the slots of a
+// calendar view. Only that slot is rendered.
+func (h Handler) findCalDAVObject(slots []model.ViewObject, name string, viewerID string) (calDAVObject, bool, error) {
+	for _, vo := range slots {
+		s, err := parseCalendarSlot(vo.Data)
+		if err == nil && calDAVObjectName(vo, s) == name {
+			return h.newCalDAVObject(vo, viewerID)
+		}
+	}
+	return calDAVObject{}, false, nil
+}
+
+// findUIDConflict returns the href of another slot of a calendar view that
+// is shown as an event with a UID, if there is one
+func findUIDConflict(v model.View, slots []model.ViewObject, uid string, id string) (string, bool) {
+	for _, vo := range slots {
+		s, err := parseCalendarSlot(vo.Data)
+		if err != nil || vo.ID == id {
+			continue
+		}
+		if calendarSlotUID(vo, s) == uid {
+			return calDAVObjectHref(v.ID, calDAVObjectName(vo, s)), true
+		}
+	}
+	return "", false
+}
+
+func calDAVObjectProps(o calDAVObject) davProps {
+	return davProps{
+		propResourceType:  davValue(""),
+		propETag:          davValue(dav.Escape(o.etag)),
+		propContentType:   davValue(calDAVContentType + "; component=vevent"),
+		propContentLength: davValue(strconv.Itoa(len(o.ics))),
+		propLastModified: func() (string, error) {
+			return parseStoredTime(o.vo.UpdatedAt).UTC().Format(http.TimeFormat), nil
+		},
+		propCalendarData: func() (string, error) {
+			return dav.Escape(string(o.ics)), nil
+		},
+	}
+}
+
+// CalDAVCalendar describes a calendar view and lists its events, and answers
+// calendar-query and calendar-multiget reports
+func (h Handler) CalDAVCalendar(c echo.Context) error {
+	if done, err := calDAVOptions(c); done {
+		return err
+	}
+	v, ok := h.findCalDAVView(c)
+	if !ok {
+		return c.NoContent(http.StatusNotFound)
+	}
+	user := c.Get("user").(model.User)
+
+	switch c.Request().Method {
+	case http.MethodGet, http.MethodHead:
+		return h.sendCalendar(c, v, user.ID)
+	case "PROPFIND", "REPORT":
+	default:
+		return c.NoContent(http.StatusMethodNotAllowed)
+	}
+
+	req, err := readDAVRequest(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	var ms dav.Multistatus
+
+	if c.Request().Method == "PROPFIND" {
+		found, missing, err := h.calDAVCalendarProps(v, user).split(req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		ms.Add(calDAVCalendarHref(v.ID), found, missing)
+		if depth(c) == 0 {
+			return sendMultistatus(c, &ms)
+		}
+	}
+
+	objects, err := h.calDAVObjects(v, user.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	switch {
+	case c.Request().Method == "PROPFIND":
+
+	case req.Type == reportCalendarMultiget:
+		byName := map[string]calDAVObject{}
+		for _, o := range objects {
+			byName[o.name] = o
+		}
+		for _, href := range req.Hrefs {
+			o, ok := byName[calDAVHrefName(href)]
+			if !ok {
+				ms.AddStatus(href, http.StatusNotFound)
+				continue
+			}
+			found, missing, err := calDAVObjectProps(o).split(req)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+			}
+			ms.Add(href, found, missing)
+		}
+		return sendMultistatus(c, &ms)
+
+	case req.Type == reportCalendarQuery:
+		// Calendar views only have events, there are no tasks or journals
+		for _, comp := range req.Components {
+			if comp != "VCALENDAR" && comp != "VEVENT" {
+				return sendMultistatus(c, &ms)
+			}
+		}
+		objects = calDAVInRange(objects, req.Start, req.End)
+
+	default:
+		return c.String(http.StatusNotImplemented, "Only calendar-query and calendar-multiget reports are supported")
+	}
+
+	for _, o := range objects {
+		found, missing, err := calDAVObjectProps(o).split(req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		ms.Add(calDAVObjectHref(v.ID, o.name), found, missing)
+	}
+	return sendMultistatus(c, &ms)
+}
+
+// calDAVHrefName returns the resource name an href points to
+func calDAVHrefName(href string) string {
+	if u, err := url.Parse(href); err == nil {
+		href = u.Path
+	}
+	name, err := url.PathUnescape(path.Base(href))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(name, ".ics")
+}
+
+// calDAVInRange keeps the objects with an occurrence that overlaps a time
+// range, which is open at the ends that are zero. Floating slots are taken
+// to be in UTC.
+func calDAVInRange(objects []calDAVObject, start, end time.Time) []calDAVObject {
+	if start.IsZero() && end.IsZero() {
+		return objects
+	}
+
+	var kept []calDAVObject
+	for _, o := range objects {
+		overlaps := false
+		o.slot.each(time.UTC, start, func(from, to time.Time) bool {
+			if !end.IsZero() && !from.Before(end) {
+				return false
+			}
+			if start.IsZero() || to.After(start) || (to.Equal(from) && !from.Before(start)) {
+				overlaps = true
+				return false
+			}
+			return true
+		})
+		if overlaps {
+			kept = append(kept, o)
+		}
+	}
+	return kept
+}
+
+// CalDAVObject reads, stores and deletes the event of a calendar slot
+func (h Handler) CalDAVObject(c echo.Context) error {
+	if done, err := calDAVOptions(c); done {
+		return err
+	}
+	v, ok := h.findCalDAVView(c)
+	if !ok {
+		return c.NoContent(http.StatusNotFound)
+	}
+	user := c.Get("user").(model.User)
+
+	name := calDAVHrefName(c.Request().URL.Path)
+	if name == "" {
+		return c.NoContent(http.StatusNotFound)
+	}
+	slots, err := h.findCalendarSlots(v.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	o, exists, err := h.findCalDAVObject(slots, name, user.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	switch c.Request().Method {
+	case http.MethodGet, http.MethodHead:
+		if !exists {
+			return c.NoContent(http.StatusNotFound)
+		}
+		c.Response().Header().Set("ETag", o.etag)
+		return c.Blob(http.StatusOK, calDAVContentType, o.ics)
+
+	case "PROPFIND":
+		if !exists {
+			return c.NoContent(http.StatusNotFound)
+		}
+		req, err := readDAVRequest(c)
+		if err != nil {
+			return c.String(http.StatusBadRequest, err.Error())
+		}
+		found, missing, err := calDAVObjectProps(o).split(req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		var ms dav.Multistatus
+		ms.Add(calDAVObjectHref(v.ID, o.name), found, missing)
+		return sendMultistatus(c, &ms)
+
+	case http.MethodPut:
+		if !calDAVPreconditions(c, o, exists) {
+			return c.NoContent(http.StatusPreconditionFailed)
+		}
+		return h.putCalDAVObject(c, v, slots, name, o, exists)
+
+	case http.MethodDelete:
+		if !exists {
+			return c.NoContent(http.StatusNotFound)
+		}
+		if !calDAVPreconditions(c, o, exists) {
+			return c.NoContent(http.StatusPreconditionFailed)
+		}
+		return h.deleteCalDAVObject(c, v, o)
+	}
+
+	return c.NoContent(http.StatusMethodNotAllowed)
+}
+
+// calDAVPreconditions checks If-Match and If-None-Match, which calendar apps
+// send so they don't overwrite changes they haven't seen
+func calDAVPreconditions(c echo.Context, o calDAVObject, exists bool) bool {
+	if ifMatch := c.Request().Header.Get("If-Match"); ifMatch != "" {
+		if !exists || (ifMatch != "*" && ifMatch != o.etag) {
+			return false
+		}
+	}
+	if c.Request().Header.Get("If-None-Match") == "*" && exists {
+		return false
+	}
+	return true
+}
+
+// putCalDAVObject stores an event as the slot with a resource name. The
+// description of a new event becomes a note attached to its slot; changes to
+// it later are left out, as the notes are edited in Collabreef. A UID can
+// only be used by one slot of a calendar.
+func (h Handler) putCalDAVObject(c echo.Context, v model.View, slots []model.ViewObject, name string, o calDAVObject, exists bool) error {
+	user := c.Get("user").(model.User)
+
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, maxCalDAVObjectSize+1))
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if len(body) > maxCalDAVObjectSize {
+		return c.NoContent(http.StatusRequestEntityTooLarge)
+	}
+
+	events, err := ical.Parse(bytes.NewReader(body))
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	var e *ical.Event
+	for i := range events {
+		if events[i].RecurrenceID.IsZero() {
+			e = &events[i]
+			break
+		}
+	}
+	if e == nil {
+		return c.String(http.StatusUnsupportedMediaType, "Only events can be stored")
+	}
+
+	data := calendarSlotData(*e, time.UTC)
+	if data.UID != "" {
+		if href, conflict := findUIDConflict(v, slots, data.UID, o.vo.ID); conflict {
+			return c.Blob(http.StatusConflict, "application/xml; charset=utf-8", dav.Error(conditionNoUIDConflict, dav.Href(href)))
+		}
+	}
+	data.CalDAVName = name
+	if exists {
+		data.Color = o.slot.data.Color
+		data.CalDAVName = o.slot.data.CalDAVName
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if _, err := parseCalendarSlot(string(b)); err != nil {
+		return c.String(http.StatusBadRequest, "Invalid event: "+err.Error())
+	}
+
+	title := strings.TrimSpace(e.Summary)
+	if title == "" {
+		title = "Untitled event"
+	}
+	now := time.Now().UTC().String()
+
+	if exists {
+		vo := o.vo
+		vo.Name = title
+		vo.Data = string(b)
+		vo.UpdatedAt = now
+		vo.UpdatedBy = user.ID
+		if err := h.db.UpdateViewObject(vo); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		h.publishViewObject(c, model.EventViewObjectUpdated, v, vo)
+		h.rescheduleReminders(vo)
+		return h.sendCalDAVETag(c, http.StatusNoContent, vo)
+	}
+
+	vo := model.ViewObject{
+		ID:        util.NewId(),
+		ViewID:    v.ID,
+		Name:      title,
+		Type:      "calendar_slot",
+		Data:      string(b),
+		CreatedAt: now,
+		CreatedBy: user.ID,
+		UpdatedAt: now,
+		UpdatedBy: user.ID,
+	}
+
+	tx, err := h.db.Begin(context.Background())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	if err := tx.CreateViewObject(vo); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	var note *model.Note
+	if strings.TrimSpace(e.Description) != "" {
+		n, err := addViewObjectNote(tx, v, vo, e.Description, user.ID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		note = &n
+	}
+	if err := tx.Commit(); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if note != nil {
+		h.publishNote(c, model.EventNoteCreated, *note)
+		h.indexTasks(*note)
+	}
+	h.publishViewObject(c, model.EventViewObjectCreated, v, vo)
+
+	return h.sendCalDAVETag(c, http.StatusCreated, vo)
+}
+
+// sendCalDAVETag answers a PUT with the new ETag of the event, so calendar
+// apps don't need to read it back
+func (h Handler) sendCalDAVETag(c echo.Context, status int, vo model.ViewObject) error {
+	user := c.Get("user").(model.User)
+
+	o, ok, err := h.newCalDAVObject(vo, user.ID)
+	if err != nil {
+		log.Printf("Failed to render calendar slot %s: %v", vo.ID, err)
+	}
+	if ok {
+		c.Response().Header().Set("ETag", o.etag)
+	}
+	return c.NoContent(status)
+}
+
+func (h Handler) deleteCalDAVObject(c echo.Context, v model.View, o calDAVObject) error {
+	if err := h.db.DeleteViewObject(model.ViewObject{ID: o.vo.ID, ViewID: v.ID}); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	h.deleteComments(model.CommentFilter{TargetType: model.CommentTargetViewObject, TargetID: o.vo.ID})
+	h.deleteReminders(model.ReminderFilter{TargetType: model.ReminderTargetViewObject, TargetID: o.vo.ID})
+
+	audit(h.db, c, auditEntry{
+		WorkspaceID: v.WorkspaceID,
+		Action:      model.AuditActionViewObjectDelete,
+		TargetType:  model.AuditTargetViewObject,
+		TargetID:    o.vo.ID,
+		Before:      map[string]string{"view_id": v.ID, "name": o.vo.Name, "type": o.vo.Type},
+	})
+
+	h.publishViewObject(c, model.EventViewObjectDeleted, v, o.vo)
+
+	return c.NoContent(http.StatusNoContent)
+}
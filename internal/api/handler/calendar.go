@@ -0,0 +1,609 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/config"
+	"github.com/collabreef/collabreef/internal/db"
+	"github.com/collabreef/collabreef/internal/ical"
+	"github.com/collabreef/collabreef/internal/model"
+	"github.com/collabreef/collabreef/internal/util"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	calendarFeedPrefixLength = 8
+
+	calendarBatchSize = 500
+
+	maxCalendarImportSize   = 10 << 20
+	maxCalendarImportEvents = 2000
+)
+
+type CalendarImportResponse struct {
+	Created int                `json:"created"`
+	Updated int                `json:"updated"`
+	Skipped int                `json:"skipped"`
+	Objects []model.ViewObject `json:"objects"`
+}
+
+// canSeeView reports whether a user can see a view: its creator if it's
+// private and members of its workspace otherwise
+func (h Handler) canSeeView(v model.View, userID string) bool {
+	if v.Visibility == "private" {
+		return v.CreatedBy == userID
+	}
+	return h.isUserWorkspaceMember(userID, v.WorkspaceID)
+}
+
+// findCalendarView returns the calendar view of the request if the signed-in
+// user can see it
+func (h Handler) findCalendarView(c echo.Context) (model.View, error) {
+	user := c.Get("user").(model.User)
+
+	v, err := h.db.FindView(model.View{ID: c.Param("id"), WorkspaceID: c.Param("workspaceId")})
+	if err != nil || !h.canSeeView(v, user.ID) {
+		return model.View{}, echo.NewHTTPError(http.StatusNotFound, "View not found")
+	}
+	if v.Type != "calendar" {
+		return model.View{}, echo.NewHTTPError(http.StatusBadRequest, "Only calendar views have calendar feeds")
+	}
+	return v, nil
+}
+
+// findCalendarSlots returns every calendar slot of a view
+func (h Handler) findCalendarSlots(viewID string) ([]model.ViewObject, error) {
+	return h.findAllViewObjects(viewID, "calendar_slot")
+}
+
+// findAllViewObjects returns every object of a view, or those of a type
+func (h Handler) findAllViewObjects(viewID string, objectType string) ([]model.ViewObject, error) {
+	filter := model.ViewObjectFilter{
+		ViewID:     viewID,
+		ObjectType: objectType,
+		PageSize:   calendarBatchSize,
+		PageNumber: 1,
+	}
+
+	var all []model.ViewObject
+	for {
+		objects, err := h.db.FindViewObjects(filter)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, objects...)
+		if len(objects) < filter.PageSize {
+			return all, nil
+		}
+		filter.PageNumber++
+	}
+}
+
+func calendarFeedURL(token string) string {
+	base := strings.TrimRight(config.C.GetString(config.APP_BASE_URL), "/")
+	return base + config.C.GetString(config.SERVER_API_ROOT_PATH) + "/feeds/calendar/" + token + ".ics"
+}
+
+// parseStoredTime reads the timestamps of views and view objects, which are
+// stored in Go's default format rather than RFC 3339
+func parseStoredTime(s string) time.Time {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05.999999999 -0700 MST"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// calendarSlotUID is the UID of the event a slot is shown as: that of the
+// event it was made from, or else one made up from its ID
+func calendarSlotUID(vo model.ViewObject, s calendarSlot) string {
+	if s.data.UID != "" {
+		return s.data.UID
+	}
+	return vo.ID + "@collabreef"
+}
+
+// calendarSlotEvent turns a calendar slot into an event. Slots without a
+// timezone are floating, at the wall clock time they were entered with.
+func calendarSlotEvent(vo model.ViewObject) (ical.Event, bool) {
+	s, err := parseCalendarSlot(vo.Data)
+	if err != nil {
+		return ical.Event{}, false
+	}
+
+	e := ical.Event{
+		UID:      calendarSlotUID(vo, s),
+		Summary:  vo.Name,
+		AllDay:   s.allDay(),
+		Floating: s.location(nil) == nil,
+		Stamp:    parseStoredTime(vo.UpdatedAt),
+	}
+
+	// UTC only stands in for the wall clock of floating slots
+	e.Start = s.first(time.UTC)
+	if end := s.endOf(e.Start); end.After(e.Start) {
+		e.End = end
+	}
+	// Fixed offsets aren't IANA timezones, their times are written in UTC
+	if name := e.Start.Location().String(); !e.Floating && !strings.HasPrefix(name, "+") && !strings.HasPrefix(name, "-") {
+		e.TZID = name
+	}
+
+	if s.rule != nil {
+		e.RRule = s.rule.String()
+		for _, ex := range s.data.ExDates {
+			d, _ := time.Parse(dailyNoteDateLayout, ex)
+			e.ExDates = append(e.ExDates, time.Date(d.Year(), d.Month(), d.Day(), e.Start.Hour(), e.Start.Minute(), 0, 0, e.Start.Location()))
+		}
+	}
+	return e, true
+}
+
+// calendarEvent turns a calendar slot into an event whose description is
+// made of the notes attached to the slot that the viewer can see
+func (h Handler) calendarEvent(vo model.ViewObject, viewerID string) (ical.Event, bool, error) {
+	e, ok := calendarSlotEvent(vo)
+	if !ok {
+		return e, false, nil
+	}
+
+	description, err := h.viewObjectNotesText(vo.ID, viewerID)
+	if err != nil {
+		return e, false, err
+	}
+	e.Description = description
+
+	return e, true, nil
+}
+
+// viewObjectNotesText is the text of the notes attached to a view object
+// that the viewer can see, for formats that describe objects with plain text
+func (h Handler) viewObjectNotesText(objectID string, viewerID string) (string, error) {
+	notes, err := h.db.FindNotesForViewObject(objectID)
+	if err != nil {
+		return "", err
+	}
+	var parts []string
+	for _, n := range notes {
+		if n.Visibility == "private" && n.CreatedBy != viewerID {
+			continue
+		}
+		text, _ := util.TipTapText(n.Content)
+		parts = append(parts, strings.TrimSpace(n.Title+"\n"+strings.TrimSpace(text)))
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// writeCalendar writes the slots of a calendar view as iCalendar data
+func (h Handler) writeCalendar(w io.Writer, v model.View, viewerID string) error {
+	objects, err := h.findCalendarSlots(v.ID)
+	if err != nil {
+		return err
+	}
+
+	cw := ical.NewWriter(w, v.Name)
+	for _, vo := range objects {
+		e, ok, err := h.calendarEvent(vo, viewerID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if err := cw.Write(e); err != nil {
+			return err
+		}
+	}
+	return cw.Close()
+}
+
+func (h Handler) sendCalendar(c echo.Context, v model.View, viewerID string) error {
+	var buf bytes.Buffer
+	if err := h.writeCalendar(&buf, v, viewerID); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	c.Response().Header().Set("Content-Disposition", `inline; filename="calendar.ics"`)
+	return c.Blob(http.StatusOK, "text/calendar; charset=utf-8", buf.Bytes())
+}
+
+// ExportCalendarView downloads a calendar view as an .ics file
+func (h Handler) ExportCalendarView(c echo.Context) error {
+	v, err := h.findCalendarView(c)
+	if err != nil {
+		return err
+	}
+
+	user := c.Get("user").(model.User)
+	return h.sendCalendar(c, v, user.ID)
+}
+
+// GetCalendarFeed returns the signed-in user's feed of a calendar view,
+// without its token
+func (h Handler) GetCalendarFeed(c echo.Context) error {
+	v, err := h.findCalendarView(c)
+	if err != nil {
+		return err
+	}
+
+	user := c.Get("user").(model.User)
+	feed, err := h.db.FindCalendarFeed(v.ID, user.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Calendar feed not found")
+	}
+
+	return c.JSON(http.StatusOK, feed)
+}
+
+// CreateCalendarFeed creates the signed-in user's feed of a calendar view,
+// revoking the one they had
+func (h Handler) CreateCalendarFeed(c echo.Context) error {
+	v, err := h.findCalendarView(c)
+	if err != nil {
+		return err
+	}
+	user := c.Get("user").(model.User)
+
+	token, hash, err := util.GenerateToken()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to generate calendar feed")
+	}
+
+	feed := model.CalendarFeed{
+		ID:          util.NewId(),
+		WorkspaceID: v.WorkspaceID,
+		ViewID:      v.ID,
+		UserID:      user.ID,
+		TokenHash:   hash,
+		Prefix:      token[:calendarFeedPrefixLength],
+		CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+	}
+
+	tx, err := h.db.Begin(context.Background())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if err := tx.DeleteCalendarFeeds(model.CalendarFeedFilter{ViewID: v.ID, UserID: user.ID}); err != nil {
+		tx.Rollback()
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if err := tx.CreateCalendarFeed(feed); err != nil {
+		tx.Rollback()
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if err := tx.Commit(); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	// The token is only returned once, the feed URL can't be shown again afterwards
+	url := calendarFeedURL(token)
+	webcal := url
+	if i := strings.Index(url, "://"); i >= 0 {
+		webcal = "webcal" + url[i:]
+	}
+	return c.JSON(http.StatusCreated, model.CalendarFeedCreationResponse{
+		CalendarFeed: feed,
+		Token:        token,
+		URL:          url,
+		WebcalURL:    webcal,
+	})
+}
+
+// DeleteCalendarFeed revokes the signed-in user's feed of a calendar view
+func (h Handler) DeleteCalendarFeed(c echo.Context) error {
+	v, err := h.findCalendarView(c)
+	if err != nil {
+		return err
+	}
+
+	user := c.Get("user").(model.User)
+	if err := h.db.DeleteCalendarFeeds(model.CalendarFeedFilter{ViewID: v.ID, UserID: user.ID}); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetCalendarFeedICS serves a calendar feed to calendar apps, which can't sign
+// in and authenticate with the token in the URL instead. The feed shows what
+// its user may see, and stops working once they no longer can see the view.
+func (h Handler) GetCalendarFeedICS(c echo.Context) error {
+	token := strings.TrimSuffix(c.Param("file"), ".ics")
+
+	feed, err := h.db.FindCalendarFeedByHash(util.HashToken(token))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Calendar feed not found")
+	}
+
+	v, err := h.db.FindView(model.View{ID: feed.ViewID, WorkspaceID: feed.WorkspaceID})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Calendar feed not found")
+	}
+	user, err := h.db.FindUserByID(feed.UserID)
+	if err != nil || user.Disabled || !h.canSeeView(v, user.ID) {
+		return echo.NewHTTPError(http.StatusNotFound, "Calendar feed not found")
+	}
+
+	if err := h.db.UpdateCalendarFeedLastUsed(feed.ID, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		log.Printf("Failed to update calendar feed %s: %v", feed.ID, err)
+	}
+
+	return h.sendCalendar(c, v, user.ID)
+}
+
+// readImportFile reads the uploaded file of a multipart request, or else the
+// request body, if it is at most maxSize bytes
+func readImportFile(c echo.Context, maxSize int, what string) ([]byte, error) {
+	var r io.Reader = c.Request().Body
+	if strings.HasPrefix(c.Request().Header.Get("Content-Type"), "multipart/form-data") {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "file is required")
+		}
+		f, err := fh.Open()
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		defer f.Close()
+		r = f
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, int64(maxSize)+1))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if len(data) > maxSize {
+		return nil, echo.NewHTTPError(http.StatusRequestEntityTooLarge, "The "+what+" is too large")
+	}
+	return data, nil
+}
+
+// calendarSlotData places an event on the calendar. Times in a timezone the
+// event names keep it, those in UTC are placed in loc, and floating times and
+// all-day events stay at their wall clock time.
+func calendarSlotData(e ical.Event, loc *time.Location) model.CalendarSlotData {
+	data := model.CalendarSlotData{UID: e.UID, IsAllDay: e.AllDay}
+
+	start, end := e.Start, e.End
+	timed := !e.Floating && !e.AllDay
+	if timed {
+		if e.TZID == "" {
+			start, end = start.In(loc), end.In(loc)
+		}
+		data.Timezone = start.Location().String()
+	}
+	data.Date = start.Format(dailyNoteDateLayout)
+
+	last := end
+	if e.AllDay {
+		last = end.AddDate(0, 0, -1)
+	}
+	if !end.IsZero() && last.Format(dailyNoteDateLayout) > data.Date {
+		data.EndDate = last.Format(dailyNoteDateLayout)
+	}
+
+	if !e.AllDay {
+		startTime := start.Format(calendarTimeLayout)
+		data.StartTime = &startTime
+		if !end.IsZero() && end.After(start) {
+			endTime := end.Format(calendarTimeLayout)
+			data.EndTime = &endTime
+		}
+	}
+
+	// Rules that aren't supported leave just the first occurrence
+	if rule, err := ical.ParseRecurrence(e.RRule); e.RRule != "" && err == nil {
+		data.RRule = rule.String()
+		for _, ex := range e.ExDates {
+			if timed {
+				ex = ex.In(start.Location())
+			}
+			data.ExDates = append(data.ExDates, ex.Format(dailyNoteDateLayout))
+		}
+	}
+	return data
+}
+
+// addViewObjectNote attaches a note made from Markdown, such as the
+// description of an imported event, to a view object. Whoever can see the
+// view can see the note, except on public views where that would make it
+// public.
+func addViewObjectNote(tx db.DB, v model.View, vo model.ViewObject, markdown string, userID string) (model.Note, error) {
+	content, err := util.MarkdownToTipTap(strings.TrimSpace(markdown))
+	if err != nil {
+		return model.Note{}, err
+	}
+
+	visibility := "workspace"
+	if v.Visibility == "private" {
+		visibility = "private"
+	}
+
+	now := time.Now().UTC()
+	n := model.Note{
+		WorkspaceID: v.WorkspaceID,
+		ID:          util.NewId(),
+		Title:       vo.Name,
+		Content:     content,
+		Visibility:  visibility,
+		CreatedAt:   now.Format(time.RFC3339),
+		CreatedBy:   userID,
+		UpdatedAt:   now.Format(time.RFC3339),
+		UpdatedBy:   userID,
+	}
+	if err := tx.CreateNote(n); err != nil {
+		return model.Note{}, err
+	}
+
+	err = tx.AddNoteToViewObject(model.ViewObjectNote{
+		ViewObjectID: vo.ID,
+		NoteID:       n.ID,
+		CreatedAt:    now.String(),
+		CreatedBy:    userID,
+	})
+	return n, err
+}
+
+// ImportCalendarView adds the VEVENTs of iCalendar data to a calendar view as
+// slots. Events imported before, going by their UID, are updated instead.
+// The description of a new event becomes a note attached to its slot.
+// Times are placed in the timezone parameter, or timezoneOffset.
+func (h Handler) ImportCalendarView(c echo.Context) error {
+	v, err := h.findCalendarView(c)
+	if err != nil {
+		return err
+	}
+	user := c.Get("user").(model.User)
+
+	tz := c.QueryParam("timezone")
+	if tz == "" {
+		tz = util.FormatUTCOffset(parseTimezoneOffset(c))
+	}
+	loc, err := util.LoadTimezone(tz)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	raw, err := readImportFile(c, maxCalendarImportSize, "calendar")
+	if err != nil {
+		return err
+	}
+	events, err := ical.Parse(bytes.NewReader(raw))
+	if errors.Is(err, ical.ErrNotCalendar) {
+		return echo.NewHTTPError(http.StatusBadRequest, "The file is not an iCalendar file")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if len(events) > maxCalendarImportEvents {
+		return echo.NewHTTPError(http.StatusBadRequest, "A calendar can have at most 2000 events")
+	}
+
+	existing, err := h.findCalendarSlots(v.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	byUID := map[string]model.ViewObject{}
+	for _, vo := range existing {
+		var data model.CalendarSlotData
+		if json.Unmarshal([]byte(vo.Data), &data) == nil && data.UID != "" {
+			byUID[data.UID] = vo
+		}
+	}
+
+	now := time.Now().UTC()
+	res := CalendarImportResponse{Objects: []model.ViewObject{}}
+	var created, updated []model.ViewObject
+	var notes []model.Note
+
+	tx, err := h.db.Begin(context.Background())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	for _, e := range events {
+		// Changes to single occurrences of recurring events can't be kept
+		if !e.RecurrenceID.IsZero() {
+			res.Skipped++
+			continue
+		}
+
+		name := strings.TrimSpace(e.Summary)
+		if name == "" {
+			name = "Untitled event"
+		}
+		data := calendarSlotData(e, loc)
+		vo, exists := byUID[e.UID]
+		if exists && e.UID != "" {
+			// The color and CalDAV name aren't part of the event
+			if old, err := parseCalendarSlot(vo.Data); err == nil {
+				data.Color = old.data.Color
+				data.CalDAVName = old.data.CalDAVName
+			}
+		}
+		b, err := json.Marshal(data)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		if _, err := parseCalendarSlot(string(b)); err != nil {
+			res.Skipped++
+			continue
+		}
+
+		if exists && e.UID != "" {
+			if vo.Name == name && vo.Data == string(b) {
+				res.Skipped++
+				continue
+			}
+			vo.Name = name
+			vo.Data = string(b)
+			vo.UpdatedAt = now.String()
+			vo.UpdatedBy = user.ID
+			if err := tx.UpdateViewObject(vo); err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+			}
+			updated = append(updated, vo)
+			continue
+		}
+
+		vo = model.ViewObject{
+			ID:        util.NewId(),
+			ViewID:    v.ID,
+			Name:      name,
+			Type:      "calendar_slot",
+			Data:      string(b),
+			CreatedAt: now.String(),
+			CreatedBy: user.ID,
+			UpdatedAt: now.String(),
+			UpdatedBy: user.ID,
+		}
+		if err := tx.CreateViewObject(vo); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		created = append(created, vo)
+		if e.UID != "" {
+			byUID[e.UID] = vo
+		}
+
+		if strings.TrimSpace(e.Description) != "" {
+			n, err := addViewObjectNote(tx, v, vo, e.Description, user.ID)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+			}
+			notes = append(notes, n)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	for _, n := range notes {
+		h.publishNote(c, model.EventNoteCreated, n)
+		h.indexTasks(n)
+	}
+	for _, vo := range created {
+		h.publishViewObject(c, model.EventViewObjectCreated, v, vo)
+	}
+	for _, vo := range updated {
+		h.publishViewObject(c, model.EventViewObjectUpdated, v, vo)
+		h.rescheduleReminders(vo)
+	}
+
+	res.Created = len(created)
+	res.Updated = len(updated)
+	res.Objects = append(append(res.Objects, created...), updated...)
+
+	return c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,339 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/ical"
+	"github.com/collabreef/collabreef/internal/model"
+	"github.com/collabreef/collabreef/internal/util"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	calendarTimeLayout = "15:04"
+
+	// Occurrences are listed for at most a year at a time
+	maxOccurrenceRangeDays = 366
+	maxOccurrences         = 5000
+
+	// Slots are on dates from minCalendarYear to maxCalendarYear
+	minCalendarYear = 1900
+	maxCalendarYear = 2200
+)
+
+// CalendarOccurrence is one occurrence of a calendar slot, with its date and
+// times in the timezone it was asked for in
+type CalendarOccurrence struct {
+	ObjectID string `json:"object_id"`
+	Name     string `json:"name"`
+	// OccurrenceDate is the date of the occurrence in the slot's own
+	// timezone, the one to add to exdates to skip it
+	OccurrenceDate string  `json:"occurrence_date"`
+	Date           string  `json:"date"`
+	EndDate        string  `json:"end_date,omitempty"`
+	StartTime      *string `json:"start_time"`
+	EndTime        *string `json:"end_time"`
+	IsAllDay       bool    `json:"is_all_day"`
+	Start          string  `json:"start"`
+	End            string  `json:"end"`
+	Recurring      bool    `json:"recurring"`
+	Color          string  `json:"color,omitempty"`
+}
+
+// calendarSlot is the validated data of a calendar slot
+type calendarSlot struct {
+	data model.CalendarSlotData
+	// loc is the slot's timezone, nil for floating slots
+	loc     *time.Location
+	rule    *ical.Recurrence
+	exdates map[string]bool
+
+	date  time.Time // First day, in UTC
+	span  int       // Days from the first day to the last
+	start *time.Time
+	end   *time.Time // Times of day, on the zero date
+}
+
+// parseCalendarSlot reads and validates the data of a calendar slot
+func parseCalendarSlot(data string) (calendarSlot, error) {
+	var s calendarSlot
+	if err := json.Unmarshal([]byte(data), &s.data); err != nil {
+		return s, errors.New("data must be a JSON object")
+	}
+	d := s.data
+
+	var err error
+	if s.date, err = time.Parse(dailyNoteDateLayout, d.Date); err != nil {
+		return s, errors.New("date must be YYYY-MM-DD")
+	}
+	if s.date.Year() < minCalendarYear || s.date.Year() > maxCalendarYear {
+		return s, fmt.Errorf("date must be from %d to %d", minCalendarYear, maxCalendarYear)
+	}
+	if d.EndDate != "" {
+		last, err := time.Parse(dailyNoteDateLayout, d.EndDate)
+		if err != nil {
+			return s, errors.New("end_date must be YYYY-MM-DD")
+		}
+		if last.Before(s.date) {
+			return s, errors.New("end_date can't be before date")
+		}
+		if last.Year() > maxCalendarYear {
+			return s, fmt.Errorf("end_date must be no later than %d", maxCalendarYear)
+		}
+		s.span = int(last.Sub(s.date).Hours() / 24)
+	}
+
+	if !d.IsAllDay {
+		if d.StartTime != nil && *d.StartTime != "" {
+			t, err := time.Parse(calendarTimeLayout, *d.StartTime)
+			if err != nil {
+				return s, errors.New("start_time must be HH:MM")
+			}
+			s.start = &t
+		}
+		if d.EndTime != nil && *d.EndTime != "" {
+			t, err := time.Parse(calendarTimeLayout, *d.EndTime)
+			if err != nil {
+				return s, errors.New("end_time must be HH:MM")
+			}
+			if s.start == nil {
+				return s, errors.New("end_time needs a start_time")
+			}
+			if s.span == 0 && t.Before(*s.start) {
+				return s, errors.New("end_time is before start_time, set end_date for slots that go past midnight")
+			}
+			s.end = &t
+		}
+	}
+
+	if d.Timezone != "" {
+		if s.loc, err = util.LoadTimezone(d.Timezone); err != nil {
+			return s, err
+		}
+	}
+
+	if d.RRule != "" {
+		rule, err := ical.ParseRecurrence(d.RRule)
+		if err != nil {
+			return s, fmt.Errorf("rrule: %w", err)
+		}
+		s.rule = &rule
+	}
+	if len(d.ExDates) > 0 && s.rule == nil {
+		return s, errors.New("exdates need an rrule")
+	}
+	s.exdates = map[string]bool{}
+	for _, ex := range d.ExDates {
+		if _, err := time.Parse(dailyNoteDateLayout, ex); err != nil {
+			return s, errors.New("exdates must be YYYY-MM-DD dates")
+		}
+		s.exdates[ex] = true
+	}
+
+	return s, nil
+}
+
+// allDay reports whether the slot takes whole days, as do slots without a
+// start time
+func (s calendarSlot) allDay() bool {
+	return s.start == nil
+}
+
+// location is where the slot's times are, loc for floating slots. All-day
+// slots are on the same dates everywhere.
+func (s calendarSlot) location(loc *time.Location) *time.Location {
+	if s.loc != nil && !s.allDay() {
+		return s.loc
+	}
+	return loc
+}
+
+// first is when the slot first starts
+func (s calendarSlot) first(loc *time.Location) time.Time {
+	loc = s.location(loc)
+	if s.allDay() {
+		return time.Date(s.date.Year(), s.date.Month(), s.date.Day(), 0, 0, 0, 0, loc)
+	}
+	return time.Date(s.date.Year(), s.date.Month(), s.date.Day(), s.start.Hour(), s.start.Minute(), 0, 0, loc)
+}
+
+// endOf is when the occurrence that starts at start ends: the end of its last
+// day for all-day slots, and its start if it has no end time and one day
+func (s calendarSlot) endOf(start time.Time) time.Time {
+	y, m, d := start.Date()
+	switch {
+	case s.end != nil:
+		return time.Date(y, m, d+s.span, s.end.Hour(), s.end.Minute(), 0, 0, start.Location())
+	case s.allDay() || s.span > 0:
+		return time.Date(y, m, d+s.span+1, 0, 0, 0, 0, start.Location())
+	default:
+		return start
+	}
+}
+
+// each calls fn with the start and end of every occurrence of the slot in
+// order, leaving out exdates, until fn returns false. Floating slots are
+// placed in loc. Occurrences that end before from may be skipped, a zero from
+// skips none.
+func (s calendarSlot) each(loc *time.Location, from time.Time, fn func(start, end time.Time) bool) {
+	first := s.first(loc)
+	if s.rule == nil {
+		fn(first, s.endOf(first))
+		return
+	}
+
+	// Occurrences that start up to the slot's length before from may still
+	// be going on at from
+	if !from.IsZero() {
+		from = from.AddDate(0, 0, -(s.span + 1))
+	}
+	s.rule.EachFrom(first, from, func(start time.Time) bool {
+		if s.exdates[start.Format(dailyNoteDateLayout)] {
+			return true
+		}
+		return fn(start, s.endOf(start))
+	})
+}
+
+// calendarSlotStart is when a calendar slot starts, placing floating slots in
+// loc. For recurring slots it is the first occurrence that starts after the
+// given time, if there is one.
+func calendarSlotStart(vo model.ViewObject, loc *time.Location, after time.Time) (time.Time, error) {
+	if vo.Type != "calendar_slot" {
+		return time.Time{}, errors.New("not a calendar slot")
+	}
+
+	s, err := parseCalendarSlot(vo.Data)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if s.rule == nil {
+		return s.first(loc), nil
+	}
+
+	var next time.Time
+	s.each(loc, after, func(start, _ time.Time) bool {
+		if start.After(after) {
+			next = start
+			return false
+		}
+		return true
+	})
+	if next.IsZero() {
+		return time.Time{}, errors.New("the slot has no more occurrences")
+	}
+	return next, nil
+}
+
+// occurrence describes an occurrence of a slot in loc
+func (s calendarSlot) occurrence(vo model.ViewObject, start, end time.Time, loc *time.Location) CalendarOccurrence {
+	o := CalendarOccurrence{
+		ObjectID:       vo.ID,
+		Name:           vo.Name,
+		OccurrenceDate: start.Format(dailyNoteDateLayout),
+		IsAllDay:       s.allDay(),
+		Start:          start.Format(time.RFC3339),
+		End:            end.Format(time.RFC3339),
+		Recurring:      s.rule != nil,
+		Color:          s.data.Color,
+	}
+
+	localStart, localEnd := start.In(loc), end.In(loc)
+	o.Date = localStart.Format(dailyNoteDateLayout)
+
+	last := localEnd
+	if s.allDay() {
+		last = localEnd.AddDate(0, 0, -1)
+	} else {
+		startTime := localStart.Format(calendarTimeLayout)
+		o.StartTime = &startTime
+		if s.end != nil {
+			endTime := localEnd.Format(calendarTimeLayout)
+			o.EndTime = &endTime
+		}
+	}
+	if d := last.Format(dailyNoteDateLayout); d > o.Date {
+		o.EndDate = d
+	}
+	return o
+}
+
+// GetCalendarOccurrences lists the occurrences of the slots of a calendar view
+// that overlap the days from the from to the to parameter, repeating
+// recurring slots, in the timezone parameter or else timezoneOffset
+func (h Handler) GetCalendarOccurrences(c echo.Context) error {
+	v, err := h.findCalendarView(c)
+	if err != nil {
+		return err
+	}
+
+	tz := c.QueryParam("timezone")
+	if tz == "" {
+		tz = util.FormatUTCOffset(parseTimezoneOffset(c))
+	}
+	loc, err := util.LoadTimezone(tz)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	from, err := time.ParseInLocation(dailyNoteDateLayout, c.QueryParam("from"), loc)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "from must be YYYY-MM-DD")
+	}
+	to, err := time.ParseInLocation(dailyNoteDateLayout, c.QueryParam("to"), loc)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "to must be YYYY-MM-DD")
+	}
+	if to.Before(from) {
+		return echo.NewHTTPError(http.StatusBadRequest, "to can't be before from")
+	}
+	if to.Sub(from) > maxOccurrenceRangeDays*24*time.Hour {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("The range can be at most %d days", maxOccurrenceRangeDays))
+	}
+	// to is the last day of the range
+	end := to.AddDate(0, 0, 1)
+
+	slots, err := h.findCalendarSlots(v.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	occurrences := []CalendarOccurrence{}
+	for _, vo := range slots {
+		s, err := parseCalendarSlot(vo.Data)
+		if err != nil {
+			continue
+		}
+
+		s.each(loc, from, func(start, stop time.Time) bool {
+			if !start.Before(end) {
+				return false
+			}
+			if stop.After(from) || !start.Before(from) {
+				occurrences = append(occurrences, s.occurrence(vo, start, stop, loc))
+			}
+			return len(occurrences) <= maxOccurrences
+		})
+		if len(occurrences) > maxOccurrences {
+			return echo.NewHTTPError(http.StatusBadRequest, "There are too many occurrences, ask for fewer days")
+		}
+	}
+
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		if occurrences[i].Start != occurrences[j].Start {
+			a, _ := time.Parse(time.RFC3339, occurrences[i].Start)
+			b, _ := time.Parse(time.RFC3339, occurrences[j].Start)
+			return a.Before(b)
+		}
+		return strings.ToLower(occurrences[i].Name) < strings.ToLower(occurrences[j].Name)
+	})
+
+	return c.JSON(http.StatusOK, occurrences)
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/collab"
+	"github.com/collabreef/collabreef/internal/config"
+	"github.com/labstack/echo/v4"
+)
+
+type VerifyCollabTokenRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type VerifyCollabTokenResponse struct {
+	collab.Session
+	ExpiresAt string `json:"expires_at"`
+}
+
+// VerifyCollabToken checks a collab handshake token and returns the session it
+// grants, for services that don't hold COLLAB_SECRET themselves
+func (h *Handler) VerifyCollabToken(c echo.Context) error {
+	req := new(VerifyCollabTokenRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request data",
+		})
+	}
+
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Validation failed: " + err.Error(),
+		})
+	}
+
+	session, expiresAt, err := collab.VerifyToken([]byte(config.C.GetString(config.COLLAB_SECRET)), req.Token)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid or expired token")
+	}
+
+	return c.JSON(http.StatusOK, VerifyCollabTokenResponse{
+		Session:   session,
+		ExpiresAt: expiresAt.UTC().Format(time.RFC3339),
+	})
+}
@@ -0,0 +1,409 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/model"
+	"github.com/collabreef/collabreef/internal/util"
+
+	"github.com/labstack/echo/v4"
+)
+
+type CreateCommentRequest struct {
+	Content  string `json:"content" validate:"required"`
+	BlockID  string `json:"block_id"`
+	ThreadID string `json:"thread_id"` // Replies to the thread this comment started
+}
+
+type UpdateCommentRequest struct {
+	Content string `json:"content" validate:"required"`
+}
+
+type CommentResponse struct {
+	ID            string `json:"id"`
+	WorkspaceID   string `json:"workspace_id"`
+	TargetType    string `json:"target_type"`
+	TargetID      string `json:"target_id"`
+	ViewID        string `json:"view_id,omitempty"`
+	BlockID       string `json:"block_id,omitempty"`
+	ThreadID      string `json:"thread_id,omitempty"`
+	Content       string `json:"content"`
+	Resolved      bool   `json:"resolved"`
+	ResolvedAt    string `json:"resolved_at,omitempty"`
+	ResolvedBy    string `json:"resolved_by,omitempty"`
+	CreatedAt     string `json:"created_at"`
+	CreatedBy     string `json:"created_by"`
+	CreatedByName string `json:"created_by_name"`
+	UpdatedAt     string `json:"updated_at"`
+	UpdatedBy     string `json:"updated_by"`
+}
+
+// CommentThreadResponse is a comment that starts a thread with its replies
+type CommentThreadResponse struct {
+	CommentResponse
+	Replies []CommentResponse `json:"replies"`
+}
+
+// publishComment limits events about comments to who can see their parent
+func (h Handler) publishComment(c echo.Context, eventType string, p targetParent, comment model.Comment) {
+	var data any = comment
+	if eventType == model.EventCommentDeleted {
+		data = map[string]string{"id": comment.ID, "target_type": comment.TargetType, "target_id": comment.TargetID}
+	}
+
+	h.publishEvent(c, model.WorkspaceEvent{
+		Type:        eventType,
+		WorkspaceID: comment.WorkspaceID,
+		Data:        data,
+		Visibility:  p.Visibility,
+		OwnerID:     p.OwnerID,
+	})
+}
+
+// notifyCommentMentions notifies the members mentioned in a comment who
+// weren't in previousContent
+func (h Handler) notifyCommentMentions(actor model.User, p targetParent, comment model.Comment, previousContent string) {
+	if p.Visibility == "private" {
+		return
+	}
+
+	title := p.Title
+	if title == "" {
+		title = "a " + strings.ReplaceAll(p.TargetType, "_", " ")
+	}
+
+	for _, id := range h.newMentions(comment.WorkspaceID, comment.Content, previousContent) {
+		notify(h.db, actor, model.Notification{
+			UserID:      id,
+			WorkspaceID: comment.WorkspaceID,
+			Type:        model.NotificationMention,
+			TargetType:  model.NotificationTargetComment,
+			TargetID:    comment.ID,
+			Message:     actor.Name + " mentioned you in a comment on " + title,
+		})
+	}
+}
+
+func (h Handler) commentResponse(comment model.Comment) CommentResponse {
+	return CommentResponse{
+		ID:            comment.ID,
+		WorkspaceID:   comment.WorkspaceID,
+		TargetType:    comment.TargetType,
+		TargetID:      comment.TargetID,
+		ViewID:        comment.ViewID,
+		BlockID:       comment.BlockID,
+		ThreadID:      comment.ThreadID,
+		Content:       comment.Content,
+		Resolved:      comment.ResolvedAt != "",
+		ResolvedAt:    comment.ResolvedAt,
+		ResolvedBy:    comment.ResolvedBy,
+		CreatedAt:     comment.CreatedAt,
+		CreatedBy:     comment.CreatedBy,
+		CreatedByName: h.getUserNameByID(comment.CreatedBy),
+		UpdatedAt:     comment.UpdatedAt,
+		UpdatedBy:     comment.UpdatedBy,
+	}
+}
+
+func (h Handler) GetNoteComments(c echo.Context) error {
+	return h.getComments(c, model.CommentTargetNote, c.Param("id"), "")
+}
+
+func (h Handler) GetViewObjectComments(c echo.Context) error {
+	return h.getComments(c, model.CommentTargetViewObject, c.Param("id"), c.Param("viewId"))
+}
+
+// getComments returns the threads on a note or view object, oldest first.
+// ?resolved=true or false only returns resolved or open threads.
+func (h Handler) getComments(c echo.Context, targetType string, targetID string, viewID string) error {
+	workspaceId := c.Param("workspaceId")
+	user := c.Get("user").(model.User)
+
+	p, err := h.findTargetParent(workspaceId, targetType, targetID, viewID)
+	if err != nil {
+		return err
+	}
+
+	if !h.canReadTarget(p, user.ID) {
+		return echo.NewHTTPError(http.StatusForbidden, "You do not have permission to see these comments")
+	}
+
+	comments, err := h.db.FindComments(model.CommentFilter{
+		WorkspaceID: workspaceId,
+		TargetType:  p.TargetType,
+		TargetID:    p.TargetID,
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	resolved := c.QueryParam("resolved")
+
+	threads := []CommentThreadResponse{}
+	index := map[string]int{}
+	for _, comment := range comments {
+		if comment.ThreadID != "" {
+			continue
+		}
+		if resolved == "true" && comment.ResolvedAt == "" || resolved == "false" && comment.ResolvedAt != "" {
+			continue
+		}
+		index[comment.ID] = len(threads)
+		threads = append(threads, CommentThreadResponse{
+			CommentResponse: h.commentResponse(comment),
+			Replies:         []CommentResponse{},
+		})
+	}
+	for _, comment := range comments {
+		if i, ok := index[comment.ThreadID]; ok {
+			threads[i].Replies = append(threads[i].Replies, h.commentResponse(comment))
+		}
+	}
+
+	return c.JSON(http.StatusOK, threads)
+}
+
+func (h Handler) CreateNoteComment(c echo.Context) error {
+	return h.createComment(c, model.CommentTargetNote, c.Param("id"), "")
+}
+
+func (h Handler) CreateViewObjectComment(c echo.Context) error {
+	return h.createComment(c, model.CommentTargetViewObject, c.Param("id"), c.Param("viewId"))
+}
+
+func (h Handler) createComment(c echo.Context, targetType string, targetID string, viewID string) error {
+	workspaceId := c.Param("workspaceId")
+
+	var req CreateCommentRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request data"})
+	}
+
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Validation failed: " + err.Error(),
+		})
+	}
+
+	user := c.Get("user").(model.User)
+
+	p, err := h.findTargetParent(workspaceId, targetType, targetID, viewID)
+	if err != nil {
+		return err
+	}
+
+	if !h.canWriteTarget(p, user.ID) {
+		return echo.NewHTTPError(http.StatusForbidden, "You do not have permission to comment here")
+	}
+
+	comment := model.Comment{
+		ID:          util.NewId(),
+		WorkspaceID: p.WorkspaceID,
+		TargetType:  p.TargetType,
+		TargetID:    p.TargetID,
+		ViewID:      p.ViewID,
+		BlockID:     req.BlockID,
+		Content:     req.Content,
+		CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+		CreatedBy:   user.ID,
+		UpdatedAt:   time.Now().UTC().Format(time.RFC3339),
+		UpdatedBy:   user.ID,
+	}
+
+	if req.ThreadID != "" {
+		thread, err := h.db.FindCommentByID(req.ThreadID)
+		if err != nil || thread.TargetType != p.TargetType || thread.TargetID != p.TargetID {
+			return echo.NewHTTPError(http.StatusNotFound, "Thread not found")
+		}
+		// Replies to replies go to the thread they are in
+		if thread.ThreadID != "" {
+			thread, err = h.db.FindCommentByID(thread.ThreadID)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusNotFound, "Thread not found")
+			}
+		}
+		comment.ThreadID = thread.ID
+		comment.BlockID = thread.BlockID
+	}
+
+	if p.TargetType != model.CommentTargetNote {
+		comment.BlockID = ""
+	}
+
+	if err := h.db.CreateComment(comment); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	h.publishComment(c, model.EventCommentCreated, p, comment)
+	h.notifyCommentMentions(user, p, comment, "")
+
+	return c.JSON(http.StatusCreated, h.commentResponse(comment))
+}
+
+// findComment looks up a comment of the workspace and its parent, which the
+// user must be able to see
+func (h Handler) findComment(c echo.Context) (model.Comment, targetParent, error) {
+	workspaceId := c.Param("workspaceId")
+	user := c.Get("user").(model.User)
+
+	comment, err := h.db.FindCommentByID(c.Param("commentId"))
+	if err != nil || comment.WorkspaceID != workspaceId {
+		return model.Comment{}, targetParent{}, echo.NewHTTPError(http.StatusNotFound, "Comment not found")
+	}
+
+	p, err := h.findTargetParent(workspaceId, comment.TargetType, comment.TargetID, comment.ViewID)
+	if err != nil {
+		return model.Comment{}, targetParent{}, err
+	}
+
+	if !h.canReadTarget(p, user.ID) {
+		return model.Comment{}, targetParent{}, echo.NewHTTPError(http.StatusNotFound, "Comment not found")
+	}
+
+	return comment, p, nil
+}
+
+func (h Handler) GetComment(c echo.Context) error {
+	comment, _, err := h.findComment(c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, h.commentResponse(comment))
+}
+
+func (h Handler) UpdateComment(c echo.Context) error {
+	var req UpdateCommentRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request data"})
+	}
+
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Validation failed: " + err.Error(),
+		})
+	}
+
+	comment, p, err := h.findComment(c)
+	if err != nil {
+		return err
+	}
+
+	user := c.Get("user").(model.User)
+
+	if comment.CreatedBy != user.ID {
+		return echo.NewHTTPError(http.StatusForbidden, "Only the author can edit a comment")
+	}
+
+	previousContent := comment.Content
+	comment.Content = req.Content
+	comment.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	comment.UpdatedBy = user.ID
+
+	if err := h.db.UpdateComment(comment); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	h.publishComment(c, model.EventCommentUpdated, p, comment)
+	h.notifyCommentMentions(user, p, comment, previousContent)
+
+	return c.JSON(http.StatusOK, h.commentResponse(comment))
+}
+
+func (h Handler) ResolveComment(c echo.Context) error {
+	return h.setCommentResolved(c, true)
+}
+
+func (h Handler) ReopenComment(c echo.Context) error {
+	return h.setCommentResolved(c, false)
+}
+
+func (h Handler) setCommentResolved(c echo.Context, resolved bool) error {
+	comment, p, err := h.findComment(c)
+	if err != nil {
+		return err
+	}
+
+	user := c.Get("user").(model.User)
+
+	if !h.canWriteTarget(p, user.ID) {
+		return echo.NewHTTPError(http.StatusForbidden, "You do not have permission to comment here")
+	}
+
+	if comment.ThreadID != "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Only threads can be resolved, not replies")
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	if resolved {
+		comment.ResolvedAt = now
+		comment.ResolvedBy = user.ID
+	} else {
+		comment.ResolvedAt = ""
+		comment.ResolvedBy = ""
+	}
+	comment.UpdatedAt = now
+	comment.UpdatedBy = user.ID
+
+	if err := h.db.UpdateComment(comment); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	h.publishComment(c, model.EventCommentUpdated, p, comment)
+
+	return c.JSON(http.StatusOK, h.commentResponse(comment))
+}
+
+// DeleteComment deletes a comment, together with its replies when it starts a
+// thread. Authors can delete their comments, workspace owners and admins any.
+func (h Handler) DeleteComment(c echo.Context) error {
+	comment, p, err := h.findComment(c)
+	if err != nil {
+		return err
+	}
+
+	user := c.Get("user").(model.User)
+
+	if comment.CreatedBy != user.ID && !h.isWorkspaceAdmin(user.ID, comment.WorkspaceID) {
+		return echo.NewHTTPError(http.StatusForbidden, "You do not have permission to delete this comment")
+	}
+
+	if err := h.db.DeleteCommentThread(comment.ID); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	h.publishComment(c, model.EventCommentDeleted, p, comment)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// isWorkspaceAdmin reports whether the user is an owner or admin of the workspace
+func (h Handler) isWorkspaceAdmin(userID string, workspaceID string) bool {
+	members, err := h.db.FindWorkspaceUsers(model.WorkspaceUserFilter{WorkspaceID: workspaceID, UserID: userID})
+	if err != nil || len(members) == 0 {
+		return false
+	}
+	return members[0].Role == model.WorkspaceUserRoleOwner || members[0].Role == model.WorkspaceUserRoleAdmin
+}
+
+// deleteComments removes the comments of a note, view object or view that was
+// deleted
+func (h Handler) deleteComments(f model.CommentFilter) {
+	if err := h.db.DeleteComments(f); err != nil {
+		log.Printf("Failed to delete comments: %v", err)
+	}
+}
+
+// commentCounts returns how many comments each target has, or nil when they
+// can't be counted
+func (h Handler) commentCounts(targetType string, ids []string) map[string]int64 {
+	counts, err := h.db.CountComments(targetType, ids)
+	if err != nil {
+		log.Printf("Failed to count comments: %v", err)
+		return nil
+	}
+	return counts
+}
@@ -0,0 +1,309 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/model"
+	"github.com/collabreef/collabreef/internal/util"
+
+	"github.com/labstack/echo/v4"
+)
+
+const dailyNoteDateLayout = "2006-01-02"
+
+type DailyNoteResponse struct {
+	Date    string          `json:"date"`
+	Created bool            `json:"created"`
+	Note    GetNoteResponse `json:"note"`
+	// Previous and Next are the closest dates with a daily note, if any
+	Previous string `json:"previous"`
+	Next     string `json:"next"`
+}
+
+type DailyNoteEntryResponse struct {
+	Date   string `json:"date"`
+	NoteID string `json:"note_id"`
+}
+
+// parseDailyNoteDate reads a date as YYYY-MM-DD, or today, yesterday or
+// tomorrow on the client's clock
+func parseDailyNoteDate(c echo.Context, value string) (time.Time, error) {
+	today := clientNow(c).Truncate(24 * time.Hour)
+
+	switch value {
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	}
+	return time.Parse(dailyNoteDateLayout, value)
+}
+
+// findDailyNote returns the user's daily note for a date. Entries whose note
+// is gone are dropped, so that a new one can be made.
+func (h Handler) findDailyNote(workspaceID string, userID string, date string) (model.Note, error) {
+	dn, err := h.db.FindDailyNote(workspaceID, userID, date)
+	if err != nil {
+		return model.Note{}, err
+	}
+
+	n, err := h.db.FindNote(model.Note{WorkspaceID: workspaceID, ID: dn.NoteID})
+	if err != nil {
+		h.deleteDailyNotes(dn.NoteID)
+		return model.Note{}, err
+	}
+	return n, nil
+}
+
+// createDailyNote creates the user's note for a day, from the template given
+// by the template parameter if there is one. If another request made the note
+// first, that one is returned and created is false.
+func (h Handler) createDailyNote(c echo.Context, workspaceID string, day time.Time) (n model.Note, created bool, err error) {
+	user := c.Get("user").(model.User)
+	date := day.Format(dailyNoteDateLayout)
+
+	visibility := c.QueryParam("visibility")
+	switch visibility {
+	case "":
+		visibility = "private"
+	case "public", "workspace", "private":
+	default:
+		return n, false, echo.NewHTTPError(http.StatusBadRequest, "Note visibility is invalid")
+	}
+
+	title := date
+	content := ""
+	if templateID := c.QueryParam("template"); templateID != "" {
+		t, err := h.findNoteTemplate(workspaceID, templateID, user.ID)
+		if err != nil {
+			return n, false, err
+		}
+
+		// Placeholders get the note's day, at the time it is on the client
+		now := clientNow(c)
+		at := day.Add(now.Sub(now.Truncate(24 * time.Hour)))
+
+		renderedTitle, renderedContent, err := h.renderNoteTemplate(c, t, at, "", nil)
+		if err != nil {
+			return n, false, err
+		}
+		if renderedTitle != "" {
+			title = renderedTitle
+		}
+		content = renderedContent
+	}
+
+	n = model.Note{
+		WorkspaceID: workspaceID,
+		ID:          util.NewId(),
+		Title:       title,
+		Content:     content,
+		Visibility:  visibility,
+		CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+		CreatedBy:   user.ID,
+		UpdatedAt:   time.Now().UTC().Format(time.RFC3339),
+		UpdatedBy:   user.ID,
+	}
+	dn := model.DailyNote{
+		WorkspaceID: workspaceID,
+		UserID:      user.ID,
+		Date:        date,
+		NoteID:      n.ID,
+		CreatedAt:   n.CreatedAt,
+	}
+
+	tx, err := h.db.Begin(context.Background())
+	if err != nil {
+		return n, false, echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if err := tx.CreateNote(n); err != nil {
+		tx.Rollback()
+		return n, false, echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if err := tx.CreateDailyNote(dn); err != nil {
+		tx.Rollback()
+		// Someone else created it in the meantime
+		if existing, findErr := h.findDailyNote(workspaceID, user.ID, date); findErr == nil {
+			return existing, false, nil
+		}
+		return n, false, echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return n, false, echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	h.publishNote(c, model.EventNoteCreated, n)
+	h.queueMentions(n, user.ID)
+	h.indexTasks(n)
+
+	return n, true, nil
+}
+
+// adjacentDailyNote returns the user's closest daily note before or after a
+// day
+func (h Handler) adjacentDailyNote(workspaceID string, userID string, day time.Time, after bool) (model.DailyNote, bool) {
+	f := model.DailyNoteFilter{
+		WorkspaceID: workspaceID,
+		UserID:      userID,
+		PageSize:    1,
+		PageNumber:  1,
+	}
+	if after {
+		f.From = day.AddDate(0, 0, 1).Format(dailyNoteDateLayout)
+	} else {
+		f.To = day.AddDate(0, 0, -1).Format(dailyNoteDateLayout)
+		f.Descending = true
+	}
+
+	notes, err := h.db.FindDailyNotes(f)
+	if err != nil || len(notes) == 0 {
+		return model.DailyNote{}, false
+	}
+	return notes[0], true
+}
+
+func (h Handler) deleteDailyNotes(noteID string) {
+	if err := h.db.DeleteDailyNotes(noteID); err != nil {
+		log.Printf("Failed to delete daily notes: %v", err)
+	}
+}
+
+// GetDailyNote returns the user's note for a day, creating it when there is
+// none yet
+func (h Handler) GetDailyNote(c echo.Context) error {
+	workspaceId := c.Param("workspaceId")
+	user := c.Get("user").(model.User)
+
+	if !h.isUserWorkspaceMember(user.ID, workspaceId) {
+		return echo.NewHTTPError(http.StatusForbidden, "You are not a member of this workspace")
+	}
+
+	day, err := parseDailyNoteDate(c, c.Param("date"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid date, use YYYY-MM-DD, today, yesterday or tomorrow")
+	}
+	date := day.Format(dailyNoteDateLayout)
+
+	created := false
+	n, err := h.findDailyNote(workspaceId, user.ID, date)
+	if err != nil {
+		n, created, err = h.createDailyNote(c, workspaceId, day)
+		if err != nil {
+			return err
+		}
+	}
+
+	res := DailyNoteResponse{
+		Date:    date,
+		Created: created,
+		Note: GetNoteResponse{
+			ID:         n.ID,
+			Visibility: n.Visibility,
+			Title:      n.Title,
+			Content:    n.Content,
+			CreatedAt:  n.CreatedAt,
+			CreatedBy:  h.getUserNameByID(n.CreatedBy),
+			UpdatedAt:  n.UpdatedAt,
+			UpdatedBy:  h.getUserNameByID(n.UpdatedBy),
+		},
+	}
+	if prev, ok := h.adjacentDailyNote(workspaceId, user.ID, day, false); ok {
+		res.Previous = prev.Date
+	}
+	if next, ok := h.adjacentDailyNote(workspaceId, user.ID, day, true); ok {
+		res.Next = next.Date
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	return c.JSON(status, res)
+}
+
+// GetPreviousDailyNote returns the user's closest daily note before a date
+func (h Handler) GetPreviousDailyNote(c echo.Context) error {
+	return h.getAdjacentDailyNote(c, false)
+}
+
+// GetNextDailyNote returns the user's closest daily note after a date
+func (h Handler) GetNextDailyNote(c echo.Context) error {
+	return h.getAdjacentDailyNote(c, true)
+}
+
+func (h Handler) getAdjacentDailyNote(c echo.Context, after bool) error {
+	workspaceId := c.Param("workspaceId")
+	user := c.Get("user").(model.User)
+
+	if !h.isUserWorkspaceMember(user.ID, workspaceId) {
+		return echo.NewHTTPError(http.StatusForbidden, "You are not a member of this workspace")
+	}
+
+	day, err := parseDailyNoteDate(c, c.Param("date"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid date, use YYYY-MM-DD, today, yesterday or tomorrow")
+	}
+
+	dn, ok := h.adjacentDailyNote(workspaceId, user.ID, day, after)
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, "No daily note found")
+	}
+
+	return c.JSON(http.StatusOK, DailyNoteEntryResponse{Date: dn.Date, NoteID: dn.NoteID})
+}
+
+// GetDailyNoteCalendar lists the days from one date to another that the user
+// has a daily note for. It defaults to the current month on the client's
+// clock and covers at most a year.
+func (h Handler) GetDailyNoteCalendar(c echo.Context) error {
+	workspaceId := c.Param("workspaceId")
+	user := c.Get("user").(model.User)
+
+	if !h.isUserWorkspaceMember(user.ID, workspaceId) {
+		return echo.NewHTTPError(http.StatusForbidden, "You are not a member of this workspace")
+	}
+
+	today := clientNow(c)
+	from := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, -1)
+
+	var err error
+	if v := c.QueryParam("from"); v != "" {
+		if from, err = time.Parse(dailyNoteDateLayout, v); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid from date, use YYYY-MM-DD")
+		}
+	}
+	if v := c.QueryParam("to"); v != "" {
+		if to, err = time.Parse(dailyNoteDateLayout, v); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid to date, use YYYY-MM-DD")
+		}
+	}
+	if to.Before(from) || to.Sub(from) > 366*24*time.Hour {
+		return echo.NewHTTPError(http.StatusBadRequest, "to must be after from and at most a year later")
+	}
+
+	notes, err := h.db.FindDailyNotes(model.DailyNoteFilter{
+		WorkspaceID: workspaceId,
+		UserID:      user.ID,
+		From:        from.Format(dailyNoteDateLayout),
+		To:          to.Format(dailyNoteDateLayout),
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	res := make([]DailyNoteEntryResponse, 0, len(notes))
+	for _, dn := range notes {
+		res = append(res, DailyNoteEntryResponse{Date: dn.Date, NoteID: dn.NoteID})
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
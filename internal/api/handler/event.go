@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/collabreef/collabreef/internal/model"
+	"github.com/collabreef/collabreef/internal/util"
+
+	"github.com/labstack/echo/v4"
+)
+
+// publish announces a change in a workspace to its subscribers. Call it once the
+// change is committed, it never fails the request.
+func (h Handler) publish(c echo.Context, workspaceID string, eventType string, data any) {
+	h.publishEvent(c, model.WorkspaceEvent{
+		Type:        eventType,
+		WorkspaceID: workspaceID,
+		Data:        data,
+	})
+}
+
+func (h Handler) publishEvent(c echo.Context, e model.WorkspaceEvent) {
+	e.ID = util.NewId()
+	e.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	if actor, ok := c.Get("user").(model.User); ok {
+		e.Actor = &model.EventUser{ID: actor.ID, Name: actor.Name}
+	}
+
+	h.events.Publish(e)
+}
+
+// publishNote limits events about private notes to their author
+func (h Handler) publishNote(c echo.Context, eventType string, n model.Note) {
+	var data any = n
+	if eventType == model.EventNoteDeleted {
+		data = map[string]string{"id": n.ID, "title": n.Title}
+	}
+
+	h.publishEvent(c, model.WorkspaceEvent{
+		Type:        eventType,
+		WorkspaceID: n.WorkspaceID,
+		Data:        data,
+		Visibility:  n.Visibility,
+		OwnerID:     n.CreatedBy,
+	})
+}
+
+// publishView limits events about private views to their creator
+func (h Handler) publishView(c echo.Context, eventType string, v model.View) {
+	var data any = v
+	if eventType == model.EventViewDeleted {
+		data = map[string]string{"id": v.ID, "name": v.Name, "type": v.Type}
+	}
+
+	h.publishEvent(c, model.WorkspaceEvent{
+		Type:        eventType,
+		WorkspaceID: v.WorkspaceID,
+		Data:        data,
+		Visibility:  v.Visibility,
+		OwnerID:     v.CreatedBy,
+	})
+}
+
+// publishViewObject follows the visibility of the view the object belongs to
+func (h Handler) publishViewObject(c echo.Context, eventType string, v model.View, vo model.ViewObject) {
+	var data any = vo
+	if eventType == model.EventViewObjectDeleted {
+		data = map[string]string{"id": vo.ID, "view_id": vo.ViewID, "name": vo.Name}
+	}
+
+	h.publishEvent(c, model.WorkspaceEvent{
+		Type:        eventType,
+		WorkspaceID: v.WorkspaceID,
+		Data:        data,
+		Visibility:  v.Visibility,
+		OwnerID:     v.CreatedBy,
+	})
+}
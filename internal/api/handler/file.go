@@ -60,6 +60,8 @@ func (h Handler) Upload(c echo.Context) error {
 		return c.String(http.StatusInternalServerError, "failed to save file record")
 	}
 
+	h.publish(c, workspaceId, model.EventFileUploaded, fileModel)
+
 	return c.JSON(http.StatusOK, echo.Map{
 		"id":            fileModel.ID,
 		"filename":      newFileName,
@@ -111,6 +113,14 @@ func (h Handler) Delete(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete file record")
 	}
 
+	audit(h.db, c, auditEntry{
+		WorkspaceID: workspaceId,
+		Action:      model.AuditActionFileDelete,
+		TargetType:  model.AuditTargetFile,
+		TargetID:    id,
+		Before:      map[string]any{"name": f.Name, "size": f.Size},
+	})
+
 	segments := []string{workspaceId, f.Name}
 
 	err = h.storage.Delete(segments)
@@ -119,6 +129,8 @@ func (h Handler) Delete(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, "failed to delete file")
 	}
 
+	h.publish(c, workspaceId, model.EventFileDeleted, map[string]string{"id": f.ID, "name": f.Name, "original_name": f.OriginalFilename})
+
 	return c.JSON(http.StatusOK, echo.Map{"message": "File deleted"})
 }
 
@@ -222,6 +234,8 @@ func (h Handler) RenameFile(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to rename file")
 	}
 
+	h.publish(c, workspaceId, model.EventFileRenamed, file)
+
 	return c.JSON(http.StatusOK, echo.Map{
 		"id":            file.ID,
 		"name":          file.Name,
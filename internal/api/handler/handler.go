@@ -1,22 +1,76 @@
 package handler
 
 import (
+	"log"
 	"net/url"
 
+	"github.com/collabreef/collabreef/internal/collab"
+	"github.com/collabreef/collabreef/internal/config"
 	"github.com/collabreef/collabreef/internal/db"
+	"github.com/collabreef/collabreef/internal/eventbus"
+	"github.com/collabreef/collabreef/internal/job"
+	"github.com/collabreef/collabreef/internal/mailer"
+	"github.com/collabreef/collabreef/internal/presence"
+	"github.com/collabreef/collabreef/internal/ratelimit"
 	"github.com/collabreef/collabreef/internal/storage"
+	"github.com/collabreef/collabreef/internal/webhook"
 )
 
 type Handler struct {
 	db        db.DB
 	storage   storage.Storage
+	mailer    mailer.Mailer
+	limiter   *ratelimit.Limiter
+	webhooks  *webhook.Dispatcher
+	jobs      *job.Runner
+	events    *eventbus.Bus
 	collabURL *url.URL
+	collab    *collab.Server
+	notes     *collab.NoteSync
+	presence  *presence.Tracker
 }
 
-func NewHandler(r db.DB, s storage.Storage, collabURL *url.URL) *Handler {
-	return &Handler{
+func NewHandler(r db.DB, s storage.Storage, m mailer.Mailer, l *ratelimit.Limiter, wh *webhook.Dispatcher, jobs *job.Runner, bus *eventbus.Bus, collabURL *url.URL, cs *collab.Server) *Handler {
+	var updater collab.Updater = collab.NewRemote(collabURL, []byte(config.C.GetString(config.COLLAB_SECRET)))
+	if cs != nil {
+		updater = cs
+	}
+
+	h := &Handler{
 		db:        r,
 		storage:   s,
+		mailer:    m,
+		limiter:   l,
+		webhooks:  wh,
+		jobs:      jobs,
+		events:    bus,
 		collabURL: collabURL,
+		collab:    cs,
+		notes:     collab.NewNoteSync(r, updater),
+		presence:  presence.New(),
+	}
+
+	if cs != nil {
+		cs.OnNoteStored(h.collabNoteStored)
 	}
+	jobs.Handle(reminderJobType, h.fireReminder)
+	jobs.Handle(taskBackfillJobType, h.backfillTasks)
+	jobs.Handle(mentionsJobType, h.notifyMentions)
+	jobs.Handle(mapLocationSyncJobType, h.syncMapLocations)
+	h.queueTaskBackfill()
+	h.queueMapLocationSync()
+
+	// The Node.js collab service stores notes and view objects without
+	// telling the server
+	if cs == nil {
+		jobs.Handle(noteSyncJobType, h.syncNotes)
+		if err := jobs.Schedule(noteSyncJobType, "* * * * *"); err != nil {
+			log.Printf("Failed to schedule note sync: %v", err)
+		}
+		if err := jobs.Schedule(mapLocationSyncJobType, "* * * * *"); err != nil {
+			log.Printf("Failed to schedule map location sync: %v", err)
+		}
+	}
+
+	return h
 }
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/collabreef/collabreef/internal/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetJobs lists background jobs, newest first, for admins to see what is
+// queued and what failed. They can be filtered by type and status.
+func (h Handler) GetJobs(c echo.Context) error {
+	filter := model.JobFilter{
+		Type:       c.QueryParam("type"),
+		Status:     c.QueryParam("status"),
+		PageSize:   50,
+		PageNumber: 1,
+	}
+
+	switch filter.Status {
+	case "", model.JobStatusPending, model.JobStatusSucceeded, model.JobStatusFailed:
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid status, use pending, succeeded or failed")
+	}
+	if ps := c.QueryParam("pageSize"); ps != "" {
+		if v, err := strconv.Atoi(ps); err == nil && v > 0 && v <= 500 {
+			filter.PageSize = v
+		}
+	}
+	if pn := c.QueryParam("pageNumber"); pn != "" {
+		if v, err := strconv.Atoi(pn); err == nil && v > 0 {
+			filter.PageNumber = v
+		}
+	}
+
+	jobs, err := h.db.FindJobs(filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, jobs)
+}
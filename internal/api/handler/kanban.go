@@ -0,0 +1,672 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/db"
+	"github.com/collabreef/collabreef/internal/model"
+	"github.com/collabreef/collabreef/internal/util"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultKanbanMetricsDays = 30
+	maxKanbanMetricsDays     = 366
+)
+
+// viewObjectNoteCreatedLayout is how time.Time.String writes the creation
+// time of view object notes
+const viewObjectNoteCreatedLayout = "2006-01-02 15:04:05.999999999 -0700 MST"
+
+type MoveKanbanCardRequest struct {
+	ColumnID string `json:"column_id" validate:"required"`
+	// The card goes right after AfterNoteID, or else right before
+	// BeforeNoteID, or else at the bottom of the column
+	AfterNoteID  string `json:"after_note_id"`
+	BeforeNoteID string `json:"before_note_id"`
+}
+
+type KanbanCardResponse struct {
+	ColumnID    string `json:"column_id"`
+	NoteID      string `json:"note_id"`
+	Position    string `json:"position"`
+	CompletedAt string `json:"completed_at,omitempty"`
+	// CheckedTasks is how many tasks the column's rules checked
+	CheckedTasks int `json:"checked_tasks"`
+}
+
+// parseKanbanColumn reads and validates the data of a kanban column, which
+// may be empty
+func parseKanbanColumn(data string) (model.KanbanColumnData, error) {
+	var d model.KanbanColumnData
+	if data == "" {
+		return d, nil
+	}
+	if err := json.Unmarshal([]byte(data), &d); err != nil {
+		return d, errors.New("data must be a JSON object")
+	}
+	if d.WIPLimit < 0 {
+		return d, errors.New("wip_limit can't be negative")
+	}
+	return d, nil
+}
+
+// kanbanColumn is the data of a kanban column, without rules if it is invalid
+func kanbanColumn(vo model.ViewObject) model.KanbanColumnData {
+	d, _ := parseKanbanColumn(vo.Data)
+	return d
+}
+
+// findKanbanColumn returns a column of a kanban view
+func (h Handler) findKanbanColumn(v model.View, id string) (model.ViewObject, error) {
+	vo, err := h.db.FindViewObject(model.ViewObject{ID: id, ViewID: v.ID})
+	if err != nil || vo.ViewID != v.ID || vo.Type != "kanban_column" {
+		return model.ViewObject{}, echo.NewHTTPError(http.StatusNotFound, "Column not found")
+	}
+	return vo, nil
+}
+
+// positionCards gives the cards of a column that have no position yet, which
+// were added before cards had positions, one after the cards before them
+func positionCards(tx db.DB, cards []model.ViewObjectNote) ([]model.ViewObjectNote, error) {
+	prev := ""
+	for i, card := range cards {
+		if card.Position != "" && card.Position > prev {
+			prev = card.Position
+			continue
+		}
+		next := ""
+		for _, later := range cards[i+1:] {
+			if later.Position > prev {
+				next = later.Position
+				break
+			}
+		}
+		key, err := util.KeyBetween(prev, next)
+		if err != nil {
+			return nil, err
+		}
+		cards[i].Position = key
+		if err := tx.MoveNoteToViewObject(card.ViewObjectID, cards[i]); err != nil {
+			return nil, err
+		}
+		prev = key
+	}
+	return cards, nil
+}
+
+// wipLimitError is the error for a column that can't take another card
+func wipLimitError(column model.ViewObject, limit int) error {
+	return echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("%s is at its WIP limit of %d cards", column.Name, limit))
+}
+
+// completedAt is when a card that moved to column was completed, which is
+// now for done columns unless it already was
+func completedAt(column model.ViewObject, previous string) string {
+	if !kanbanColumn(column).Done {
+		return ""
+	}
+	if previous != "" {
+		return previous
+	}
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+func (h Handler) recordKanbanMove(tx db.DB, viewID, noteID, fromColumnID, toColumnID, userID string) error {
+	return tx.CreateKanbanMove(model.KanbanMove{
+		ID:           util.NewId(),
+		ViewID:       viewID,
+		NoteID:       noteID,
+		FromColumnID: fromColumnID,
+		ToColumnID:   toColumnID,
+		MovedAt:      time.Now().UTC().Format(time.RFC3339),
+		MovedBy:      userID,
+	})
+}
+
+// applyColumnRules does what a column does to the cards moved to it and
+// returns how many tasks it checked
+func (h Handler) applyColumnRules(c echo.Context, user model.User, column model.ViewObject, noteID string) int {
+	if !kanbanColumn(column).CheckTasks {
+		return 0
+	}
+	return h.checkNoteTasks(c, user, noteID)
+}
+
+// checkNoteTasks checks every task in a note the user can edit
+func (h Handler) checkNoteTasks(c echo.Context, user model.User, noteID string) int {
+	note, err := h.db.FindNote(model.Note{ID: noteID})
+	if err != nil || !h.canEditNote(user.ID, note) {
+		return 0
+	}
+
+	content := note.Content
+	checked := 0
+	for i, task := range util.TipTapTasks(note.Content) {
+		if task.Checked {
+			continue
+		}
+		updated, _, err := util.TipTapSetTaskChecked(content, i, true)
+		if err != nil {
+			break
+		}
+		content = updated
+		checked++
+	}
+	if checked == 0 {
+		return 0
+	}
+
+	note.Content = content
+	note.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	note.UpdatedBy = user.ID
+	if err := h.db.UpdateNote(note); err != nil {
+		log.Printf("Failed to check tasks of note %s: %v", note.ID, err)
+		return 0
+	}
+
+	h.publishNote(c, model.EventNoteUpdated, note)
+	h.pushNoteToCollab(note, user.ID)
+	h.indexTasks(note)
+
+	return checked
+}
+
+// MoveKanbanCard moves a card, i.e. a note attached to a kanban column, to a
+// place in the same or another column. Only the moved card changes, so moves
+// of different cards don't clobber each other, and a card that was moved
+// from the column in the URL already can't be moved again from there.
+func (h Handler) MoveKanbanCard(c echo.Context) error {
+	user := c.Get("user").(model.User)
+
+	var req MoveKanbanCardRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "column_id is required")
+	}
+
+	v, err := h.db.FindView(model.View{ID: c.Param("viewId"), WorkspaceID: c.Param("workspaceId")})
+	if err != nil || v.WorkspaceID != c.Param("workspaceId") || !h.canSeeView(v, user.ID) {
+		return echo.NewHTTPError(http.StatusNotFound, "View not found")
+	}
+	if v.Type != "kanban" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Only the cards of kanban views can be moved")
+	}
+
+	from, err := h.findKanbanColumn(v, c.Param("id"))
+	if err != nil {
+		return err
+	}
+	to, err := h.findKanbanColumn(v, req.ColumnID)
+	if err != nil {
+		return err
+	}
+	noteID := c.Param("noteId")
+
+	tx, err := h.db.Begin(context.Background())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	found, err := tx.FindViewObjectNotes(model.ViewObjectNoteFilter{ViewObjectID: from.ID, NoteID: noteID})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if len(found) == 0 {
+		return echo.NewHTTPError(http.StatusConflict, "The card is not in "+from.Name+" anymore")
+	}
+	card := found[0]
+
+	cards, err := tx.FindViewObjectNotes(model.ViewObjectNoteFilter{ViewObjectID: to.ID})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if cards, err = positionCards(tx, cards); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	others := make([]model.ViewObjectNote, 0, len(cards))
+	for _, other := range cards {
+		if other.NoteID != noteID {
+			others = append(others, other)
+		} else if from.ID != to.ID {
+			return echo.NewHTTPError(http.StatusConflict, "The note is already in "+to.Name)
+		}
+	}
+	if limit := kanbanColumn(to).WIPLimit; from.ID != to.ID && limit > 0 && len(others) >= limit {
+		return wipLimitError(to, limit)
+	}
+
+	// The cards the moved one goes between
+	i := len(others)
+	neighbor := req.AfterNoteID
+	if neighbor == "" {
+		neighbor = req.BeforeNoteID
+	}
+	if neighbor != "" {
+		i = -1
+		for j, other := range others {
+			if other.NoteID == neighbor {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			return echo.NewHTTPError(http.StatusConflict, "The card to put it next to is not in "+to.Name+" anymore")
+		}
+		if req.AfterNoteID != "" {
+			i++
+		}
+	}
+	prev, next := "", ""
+	if i > 0 {
+		prev = others[i-1].Position
+	}
+	if i < len(others) {
+		next = others[i].Position
+	}
+
+	card.Position, err = util.KeyBetween(prev, next)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	card.ViewObjectID = to.ID
+	card.CompletedAt = completedAt(to, card.CompletedAt)
+
+	if err := tx.MoveNoteToViewObject(from.ID, card); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if from.ID != to.ID {
+		if err := h.recordKanbanMove(tx, v.ID, noteID, from.ID, to.ID, user.ID); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	res := KanbanCardResponse{
+		ColumnID:    to.ID,
+		NoteID:      noteID,
+		Position:    card.Position,
+		CompletedAt: card.CompletedAt,
+	}
+	if from.ID != to.ID {
+		res.CheckedTasks = h.applyColumnRules(c, user, to, noteID)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+type KanbanColumnMetrics struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	WIPLimit int    `json:"wip_limit,omitempty"`
+	Done     bool   `json:"done"`
+	Cards    int    `json:"cards"`
+}
+
+// KanbanFlowDay is how many cards each column held at the end of a day, UTC
+type KanbanFlowDay struct {
+	Date    string         `json:"date"`
+	Columns map[string]int `json:"columns"`
+}
+
+// KanbanCardTimes is how long a card completed in the period took. Lead
+// time runs from when it was added to the board and cycle time from when it
+// first left the column it was added to.
+type KanbanCardTimes struct {
+	NoteID      string  `json:"note_id"`
+	AddedAt     string  `json:"added_at"`
+	StartedAt   string  `json:"started_at"`
+	CompletedAt string  `json:"completed_at"`
+	LeadHours   float64 `json:"lead_hours"`
+	CycleHours  float64 `json:"cycle_hours"`
+}
+
+type KanbanTimeStats struct {
+	Count        int     `json:"count"`
+	AverageHours float64 `json:"average_hours"`
+	MedianHours  float64 `json:"median_hours"`
+	P85Hours     float64 `json:"p85_hours"`
+}
+
+type KanbanMetricsResponse struct {
+	From           string                `json:"from"`
+	To             string                `json:"to"`
+	Columns        []KanbanColumnMetrics `json:"columns"`
+	CumulativeFlow []KanbanFlowDay       `json:"cumulative_flow"`
+	LeadTime       KanbanTimeStats       `json:"lead_time"`
+	CycleTime      KanbanTimeStats       `json:"cycle_time"`
+	Cards          []KanbanCardTimes     `json:"cards"`
+}
+
+// kanbanEvent is a card entering, leaving or moving between columns
+type kanbanEvent struct {
+	at     time.Time
+	noteID string
+	from   string
+	to     string
+}
+
+// GetKanbanMetrics returns the cumulative flow of a kanban view, i.e. how
+// many cards each column held each day, and the lead and cycle times of the
+// cards completed in the period, from (YYYY-MM-DD, default 30 days ago) to
+// (default today)
+func (h Handler) GetKanbanMetrics(c echo.Context) error {
+	user := c.Get("user").(model.User)
+
+	v, err := h.db.FindView(model.View{ID: c.Param("id"), WorkspaceID: c.Param("workspaceId")})
+	if err != nil || v.WorkspaceID != c.Param("workspaceId") || !h.canSeeView(v, user.ID) {
+		return echo.NewHTTPError(http.StatusNotFound, "View not found")
+	}
+	if v.Type != "kanban" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Only kanban views have kanban metrics")
+	}
+
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	if s := c.QueryParam("to"); s != "" {
+		if to, err = time.Parse(dailyNoteDateLayout, s); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "to must be YYYY-MM-DD")
+		}
+	}
+	from := to.AddDate(0, 0, 1-defaultKanbanMetricsDays)
+	if s := c.QueryParam("from"); s != "" {
+		if from, err = time.Parse(dailyNoteDateLayout, s); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "from must be YYYY-MM-DD")
+		}
+	}
+	if from.After(to) || to.Sub(from) >= maxKanbanMetricsDays*24*time.Hour {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("from must be before to and at most %d days apart", maxKanbanMetricsDays))
+	}
+	end := to.AddDate(0, 0, 1)
+
+	columns, err := h.findAllViewObjects(v.ID, "kanban_column")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	columnIDs := make([]string, len(columns))
+	done := map[string]bool{}
+	for i, col := range columns {
+		columnIDs[i] = col.ID
+		done[col.ID] = kanbanColumn(col).Done
+	}
+
+	var cards []model.ViewObjectNote
+	if len(columnIDs) > 0 {
+		if cards, err = h.db.FindViewObjectNotes(model.ViewObjectNoteFilter{ViewObjectIDs: columnIDs}); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+	moves, err := h.db.FindKanbanMoves(model.KanbanMoveFilter{ViewID: v.ID, Until: end.Format(time.RFC3339)})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	events := kanbanEvents(moves, cards)
+
+	res := KanbanMetricsResponse{
+		From:           from.Format(dailyNoteDateLayout),
+		To:             to.Format(dailyNoteDateLayout),
+		Columns:        []KanbanColumnMetrics{},
+		CumulativeFlow: []KanbanFlowDay{},
+		Cards:          []KanbanCardTimes{},
+	}
+
+	current := map[string]int{}
+	for _, card := range cards {
+		current[card.ViewObjectID]++
+	}
+	for _, col := range kanbanColumnOrder(v, columns) {
+		d := kanbanColumn(col)
+		res.Columns = append(res.Columns, KanbanColumnMetrics{
+			ID:       col.ID,
+			Name:     col.Name,
+			WIPLimit: d.WIPLimit,
+			Done:     d.Done,
+			Cards:    current[col.ID],
+		})
+	}
+
+	// Replay the moves to find where the cards were at the end of each day
+	onBoard := map[kanbanCardKey]bool{}
+	next := 0
+	for day := from; day.Before(end); day = day.AddDate(0, 0, 1) {
+		dayEnd := day.AddDate(0, 0, 1)
+		for ; next < len(events) && events[next].at.Before(dayEnd); next++ {
+			e := events[next]
+			delete(onBoard, kanbanCardKey{e.noteID, e.from})
+			if e.to != "" {
+				onBoard[kanbanCardKey{e.noteID, e.to}] = true
+			}
+		}
+		counts := map[string]int{}
+		for _, id := range columnIDs {
+			counts[id] = 0
+		}
+		for card := range onBoard {
+			if _, ok := counts[card.column]; ok {
+				counts[card.column]++
+			}
+		}
+		res.CumulativeFlow = append(res.CumulativeFlow, KanbanFlowDay{Date: day.Format(dailyNoteDateLayout), Columns: counts})
+	}
+
+	var lead, cycle []float64
+	for _, t := range kanbanCardTimes(events, done) {
+		completed, _ := time.Parse(time.RFC3339, t.CompletedAt)
+		if completed.Before(from) || !completed.Before(end) {
+			continue
+		}
+		res.Cards = append(res.Cards, t)
+		lead = append(lead, t.LeadHours)
+		cycle = append(cycle, t.CycleHours)
+	}
+	res.LeadTime = kanbanTimeStats(lead)
+	res.CycleTime = kanbanTimeStats(cycle)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// kanbanEvents turns moves into events, oldest first. Cards added before
+// moves were recorded are taken to have been added when they were attached.
+func kanbanEvents(moves []model.KanbanMove, cards []model.ViewObjectNote) []kanbanEvent {
+	moved := map[string]bool{}
+	var events []kanbanEvent
+	for _, m := range moves {
+		at, err := time.Parse(time.RFC3339, m.MovedAt)
+		if err != nil {
+			continue
+		}
+		moved[m.NoteID] = true
+		events = append(events, kanbanEvent{at: at, noteID: m.NoteID, from: m.FromColumnID, to: m.ToColumnID})
+	}
+	for _, card := range cards {
+		if moved[card.NoteID] {
+			continue
+		}
+		at, _ := time.Parse(viewObjectNoteCreatedLayout, card.CreatedAt)
+		events = append(events, kanbanEvent{at: at.UTC(), noteID: card.NoteID, to: card.ViewObjectID})
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].at.Before(events[j].at)
+	})
+	return events
+}
+
+// kanbanCardKey is a card, which is a note in a column. A note can be a card
+// of several columns.
+type kanbanCardKey struct {
+	noteID string
+	column string
+}
+
+// kanbanCardTimes follows each card through the columns to find when it was
+// added, started and completed. Cards taken off the board or moved out of
+// the done columns start over.
+func kanbanCardTimes(events []kanbanEvent, done map[string]bool) []KanbanCardTimes {
+	type card struct {
+		noteID                    string
+		added, started, completed time.Time
+	}
+	cards := map[kanbanCardKey]*card{}
+	var order []*card
+
+	for _, e := range events {
+		key := kanbanCardKey{e.noteID, e.from}
+		c, ok := cards[key]
+		delete(cards, key)
+		if e.to == "" {
+			continue
+		}
+		if e.from == "" || !ok {
+			c = &card{noteID: e.noteID, added: e.at}
+			order = append(order, c)
+		} else if c.started.IsZero() {
+			c.started = e.at
+		}
+		if !done[e.to] {
+			c.completed = time.Time{}
+		} else if c.completed.IsZero() {
+			c.completed = e.at
+		}
+		cards[kanbanCardKey{e.noteID, e.to}] = c
+	}
+
+	var times []KanbanCardTimes
+	for _, c := range order {
+		if c.completed.IsZero() {
+			continue
+		}
+		started := c.started
+		if started.IsZero() {
+			started = c.completed
+		}
+		times = append(times, KanbanCardTimes{
+			NoteID:      c.noteID,
+			AddedAt:     c.added.Format(time.RFC3339),
+			StartedAt:   started.Format(time.RFC3339),
+			CompletedAt: c.completed.Format(time.RFC3339),
+			LeadHours:   roundHours(c.completed.Sub(c.added)),
+			CycleHours:  roundHours(c.completed.Sub(started)),
+		})
+	}
+	return times
+}
+
+func roundHours(d time.Duration) float64 {
+	return math.Round(d.Hours()*100) / 100
+}
+
+func kanbanTimeStats(hours []float64) KanbanTimeStats {
+	s := KanbanTimeStats{Count: len(hours)}
+	if len(hours) == 0 {
+		return s
+	}
+	sort.Float64s(hours)
+	sum := 0.0
+	for _, h := range hours {
+		sum += h
+	}
+	s.AverageHours = math.Round(sum/float64(len(hours))*100) / 100
+	s.MedianHours = hours[(len(hours)-1)/2]
+	if len(hours)%2 == 0 {
+		s.MedianHours = math.Round((hours[len(hours)/2-1]+hours[len(hours)/2])/2*100) / 100
+	}
+	s.P85Hours = hours[int(math.Ceil(0.85*float64(len(hours))))-1]
+	return s
+}
+
+// kanbanColumnOrder sorts the columns of a kanban view the way the view's
+// data orders them, with the others after them
+func kanbanColumnOrder(v model.View, columns []model.ViewObject) []model.ViewObject {
+	var data struct {
+		Columns []string `json:"columns"`
+	}
+	json.Unmarshal([]byte(v.Data), &data)
+
+	rank := map[string]int{}
+	for i, id := range data.Columns {
+		rank[id] = i
+	}
+	sorted := append([]model.ViewObject(nil), columns...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ri, iok := rank[sorted[i].ID]
+		rj, jok := rank[sorted[j].ID]
+		if iok != jok {
+			return iok
+		}
+		if iok {
+			return ri < rj
+		}
+		return sorted[i].CreatedAt < sorted[j].CreatedAt
+	})
+	return sorted
+}
+
+// addKanbanCard attaches a note to the bottom of a kanban column
+func (h Handler) addKanbanCard(column model.ViewObject, card model.ViewObjectNote) (model.ViewObjectNote, error) {
+	tx, err := h.db.Begin(context.Background())
+	if err != nil {
+		return card, echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	cards, err := tx.FindViewObjectNotes(model.ViewObjectNoteFilter{ViewObjectID: column.ID})
+	if err != nil {
+		return card, echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	for _, other := range cards {
+		if other.NoteID == card.NoteID {
+			return card, echo.NewHTTPError(http.StatusConflict, "The note is already in "+column.Name)
+		}
+	}
+	if limit := kanbanColumn(column).WIPLimit; limit > 0 && len(cards) >= limit {
+		return card, wipLimitError(column, limit)
+	}
+	if cards, err = positionCards(tx, cards); err != nil {
+		return card, echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	last := ""
+	if len(cards) > 0 {
+		last = cards[len(cards)-1].Position
+	}
+	if card.Position, err = util.KeyBetween(last, ""); err != nil {
+		return card, echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	card.CompletedAt = completedAt(column, "")
+
+	if err := tx.AddNoteToViewObject(card); err != nil {
+		return card, echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if err := h.recordKanbanMove(tx, column.ViewID, card.NoteID, "", column.ID, card.CreatedBy); err != nil {
+		return card, echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if err := tx.Commit(); err != nil {
+		return card, echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return card, nil
+}
+
+// removeKanbanCards records that the cards of a column were taken off the
+// board
+func (h Handler) removeKanbanCards(column model.ViewObject, noteIDs []string, userID string) {
+	for _, noteID := range noteIDs {
+		if err := h.recordKanbanMove(h.db, column.ViewID, noteID, column.ID, "", userID); err != nil {
+			log.Printf("Failed to record kanban move: %v", err)
+		}
+	}
+}
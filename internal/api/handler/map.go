@@ -0,0 +1,384 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/geo"
+	"github.com/collabreef/collabreef/internal/model"
+	"github.com/collabreef/collabreef/internal/util"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	maxMapImportSize     = 20 << 20
+	maxMapImportFeatures = 5000
+	maxMapLinePoints     = 20000
+)
+
+// MapImportResult is what became of one feature of an imported file
+type MapImportResult struct {
+	// Index is the position of the feature in the file, counting from 1.
+	// The parts of multi-part features each have a result.
+	Index    int    `json:"index"`
+	Name     string `json:"name"`
+	Status   string `json:"status"` // created, updated, unchanged or invalid
+	Error    string `json:"error,omitempty"`
+	ObjectID string `json:"object_id,omitempty"`
+}
+
+type MapImportResponse struct {
+	Format   geo.Format         `json:"format"`
+	Created  int                `json:"created"`
+	Updated  int                `json:"updated"`
+	Skipped  int                `json:"skipped"`
+	Invalid  int                `json:"invalid"`
+	Features []MapImportResult  `json:"features"`
+	Objects  []model.ViewObject `json:"objects"`
+}
+
+// findMapView returns the map view of the request if the signed-in user can
+// see it
+func (h Handler) findMapView(c echo.Context) (model.View, error) {
+	user := c.Get("user").(model.User)
+
+	v, err := h.db.FindView(model.View{ID: c.Param("id"), WorkspaceID: c.Param("workspaceId")})
+	if err != nil || !h.canSeeView(v, user.ID) {
+		return model.View{}, echo.NewHTTPError(http.StatusNotFound, "View not found")
+	}
+	if v.Type != "map" {
+		return model.View{}, echo.NewHTTPError(http.StatusBadRequest, "Only map views can be exported as maps")
+	}
+	return v, nil
+}
+
+// findMapObjects returns the markers and lines of a map view
+func (h Handler) findMapObjects(viewID string) ([]model.ViewObject, error) {
+	objects, err := h.findAllViewObjects(viewID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var found []model.ViewObject
+	for _, vo := range objects {
+		if vo.Type == "map_marker" || vo.Type == "map_line" {
+			found = append(found, vo)
+		}
+	}
+	return found, nil
+}
+
+// parseMapObject reads and validates the data of a map marker or line
+func parseMapObject(objectType string, data string) (geo.Feature, error) {
+	var f geo.Feature
+
+	switch objectType {
+	case "map_marker":
+		var d model.MapMarkerData
+		if err := json.Unmarshal([]byte(data), &d); err != nil {
+			return f, errors.New("data must be a JSON object with lat and lng")
+		}
+		f.ID, f.Color = d.FeatureID, d.Color
+		f.Points = []geo.Point{{Lat: d.Lat, Lng: d.Lng}}
+	case "map_line":
+		var d model.MapLineData
+		if err := json.Unmarshal([]byte(data), &d); err != nil {
+			return f, errors.New("data must be a JSON object with points")
+		}
+		if len(d.Points) > maxMapLinePoints {
+			return f, fmt.Errorf("a line can have at most %d points", maxMapLinePoints)
+		}
+		f.ID, f.Color, f.Line = d.FeatureID, d.Color, true
+		for _, p := range d.Points {
+			f.Points = append(f.Points, geo.Point{Lat: p.Lat, Lng: p.Lng})
+		}
+	default:
+		return f, errors.New("not a map marker or line")
+	}
+
+	return f, f.Validate()
+}
+
+// mapObjectData is the data of the marker or line of a feature
+func mapObjectData(f geo.Feature) (string, string) {
+	if !f.Line {
+		b, _ := json.Marshal(model.MapMarkerData{
+			Lat:       f.Points[0].Lat,
+			Lng:       f.Points[0].Lng,
+			Color:     f.Color,
+			FeatureID: f.ID,
+		})
+		return "map_marker", string(b)
+	}
+
+	d := model.MapLineData{Color: f.Color, FeatureID: f.ID}
+	for _, p := range f.Points {
+		d.Points = append(d.Points, model.MapPoint{Lat: p.Lat, Lng: p.Lng})
+	}
+	b, _ := json.Marshal(d)
+	return "map_line", string(b)
+}
+
+// mapFeatures turns the markers and lines of a map view into features whose
+// description is made of the notes attached to them that the viewer can see
+func (h Handler) mapFeatures(v model.View, viewerID string) ([]geo.Feature, error) {
+	objects, err := h.findMapObjects(v.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var features []geo.Feature
+	for _, vo := range objects {
+		f, err := parseMapObject(vo.Type, vo.Data)
+		if err != nil {
+			continue
+		}
+		if f.ID == "" {
+			f.ID = vo.ID
+		}
+		f.Name = vo.Name
+		if f.Description, err = h.viewObjectNotesText(vo.ID, viewerID); err != nil {
+			return nil, err
+		}
+		features = append(features, f)
+	}
+	return features, nil
+}
+
+// ExportMapViewGeoJSON downloads a map view as a GeoJSON FeatureCollection
+func (h Handler) ExportMapViewGeoJSON(c echo.Context) error {
+	return h.exportMapView(c, geo.FormatGeoJSON)
+}
+
+// ExportMapViewKML downloads a map view as a KML file
+func (h Handler) ExportMapViewKML(c echo.Context) error {
+	return h.exportMapView(c, geo.FormatKML)
+}
+
+func (h Handler) exportMapView(c echo.Context, format geo.Format) error {
+	v, err := h.findMapView(c)
+	if err != nil {
+		return err
+	}
+	user := c.Get("user").(model.User)
+
+	features, err := h.mapFeatures(v, user.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	var buf bytes.Buffer
+	contentType := "application/geo+json"
+	if format == geo.FormatKML {
+		contentType = "application/vnd.google-earth.kml+xml"
+		err = geo.WriteKML(&buf, v.Name, features)
+	} else {
+		err = geo.WriteGeoJSON(&buf, v.Name, features)
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="map.%s"`, format))
+	return c.Blob(http.StatusOK, contentType, buf.Bytes())
+}
+
+// featureNote is the Markdown of the note an imported feature's description
+// and properties become
+func featureNote(f geo.Feature) string {
+	var b strings.Builder
+	b.WriteString(f.Description)
+	for i, name := range f.PropertyNames() {
+		if i == 0 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "- **%s:** %s\n", name, f.Properties[name])
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// ImportView imports a file into a view: iCalendar files into calendar views
+// and GeoJSON, KML or GPX files into map views
+func (h Handler) ImportView(c echo.Context) error {
+	v, err := h.db.FindView(model.View{ID: c.Param("id"), WorkspaceID: c.Param("workspaceId")})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "View not found")
+	}
+
+	switch v.Type {
+	case "calendar":
+		return h.ImportCalendarView(c)
+	case "map":
+		return h.ImportMapView(c)
+	}
+	return echo.NewHTTPError(http.StatusBadRequest, "Only calendar and map views can be imported into")
+}
+
+// ImportMapView adds the features of a GeoJSON, KML or GPX file to a map
+// view: points become markers, and lines, routes and tracks become lines.
+// Features imported or exported before, going by their ID, are updated
+// instead. The description and other properties of a new feature become a
+// note attached to its object. Each feature is reported on.
+func (h Handler) ImportMapView(c echo.Context) error {
+	v, err := h.findMapView(c)
+	if err != nil {
+		return err
+	}
+	user := c.Get("user").(model.User)
+
+	raw, err := readImportFile(c, maxMapImportSize, "map")
+	if err != nil {
+		return err
+	}
+	features, format, err := geo.Parse(raw)
+	if errors.Is(err, geo.ErrUnknownFormat) {
+		return echo.NewHTTPError(http.StatusBadRequest, "The file is not a GeoJSON, KML or GPX file")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if len(features) > maxMapImportFeatures {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("A map can have at most %d features", maxMapImportFeatures))
+	}
+
+	existing, err := h.findMapObjects(v.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	byID := map[string]model.ViewObject{}
+	for _, vo := range existing {
+		byID[vo.ID] = vo
+	}
+	// Feature IDs go before object IDs
+	for _, vo := range existing {
+		if f, err := parseMapObject(vo.Type, vo.Data); err == nil && f.ID != "" {
+			byID[f.ID] = vo
+		}
+	}
+
+	now := time.Now().UTC()
+	res := MapImportResponse{Format: format, Features: []MapImportResult{}, Objects: []model.ViewObject{}}
+	var created, updated []model.ViewObject
+	var notes []model.Note
+
+	tx, err := h.db.Begin(context.Background())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	for _, f := range features {
+		result := MapImportResult{Index: f.Index, Name: f.Name}
+
+		err := f.Validate()
+		if err == nil && len(f.Points) > maxMapLinePoints {
+			err = fmt.Errorf("a line can have at most %d points", maxMapLinePoints)
+		}
+		if err != nil {
+			result.Status = "invalid"
+			result.Error = err.Error()
+			res.Invalid++
+			res.Features = append(res.Features, result)
+			continue
+		}
+
+		name := strings.TrimSpace(f.Name)
+		if name == "" {
+			name = "Untitled"
+		}
+
+		vo, exists := byID[f.ID]
+		if exists && f.ID != "" {
+			// Exported objects have their own ID, which isn't a feature ID
+			if f.ID == vo.ID {
+				f.ID = ""
+			}
+			if old, err := parseMapObject(vo.Type, vo.Data); err == nil && f.Color == "" {
+				f.Color = old.Color
+			}
+			objectType, data := mapObjectData(f)
+
+			result.ObjectID = vo.ID
+			if vo.Name == name && vo.Type == objectType && vo.Data == data {
+				result.Status = "unchanged"
+				res.Skipped++
+				res.Features = append(res.Features, result)
+				continue
+			}
+			vo.Name = name
+			vo.Type = objectType
+			vo.Data = data
+			vo.UpdatedAt = now.String()
+			vo.UpdatedBy = user.ID
+			if err := tx.UpdateViewObject(vo); err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+			}
+			updated = append(updated, vo)
+			result.Status = "updated"
+			res.Features = append(res.Features, result)
+			continue
+		}
+
+		objectType, data := mapObjectData(f)
+		vo = model.ViewObject{
+			ID:        util.NewId(),
+			ViewID:    v.ID,
+			Name:      name,
+			Type:      objectType,
+			Data:      data,
+			CreatedAt: now.String(),
+			CreatedBy: user.ID,
+			UpdatedAt: now.String(),
+			UpdatedBy: user.ID,
+		}
+		if err := tx.CreateViewObject(vo); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		created = append(created, vo)
+		if f.ID != "" {
+			byID[f.ID] = vo
+		}
+
+		if note := featureNote(f); note != "" {
+			n, err := addViewObjectNote(tx, v, vo, note, user.ID)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+			}
+			notes = append(notes, n)
+		}
+
+		result.ObjectID = vo.ID
+		result.Status = "created"
+		res.Features = append(res.Features, result)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	for _, n := range notes {
+		h.publishNote(c, model.EventNoteCreated, n)
+		h.indexTasks(n)
+	}
+	for _, vo := range created {
+		h.publishViewObject(c, model.EventViewObjectCreated, v, vo)
+		h.indexMapLocation(vo)
+	}
+	for _, vo := range updated {
+		h.publishViewObject(c, model.EventViewObjectUpdated, v, vo)
+		h.indexMapLocation(vo)
+	}
+
+	res.Created = len(created)
+	res.Updated = len(updated)
+	res.Objects = append(append(res.Objects, created...), updated...)
+
+	return c.JSON(http.StatusOK, res)
+}
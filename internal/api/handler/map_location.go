@@ -0,0 +1,328 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/geo"
+	"github.com/collabreef/collabreef/internal/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// markerGeohashPrecision is about 4cm by 2cm at the equator
+	markerGeohashPrecision = 12
+	// maxNearest is the most objects a nearest-neighbour query returns
+	maxNearest = 1000
+	// nearestStartRadius is how far around the point a nearest-neighbour
+	// query without a radius first looks, in meters
+	nearestStartRadius = 1000.0
+
+	mapLocationSyncJobType = "map_location_sync"
+	// Objects indexed by the location sync per query
+	mapLocationBatchSize = 200
+)
+
+// indexMapLocation saves where a map marker or line is. Other objects, and
+// markers and lines with invalid data, have no location.
+func (h Handler) indexMapLocation(vo model.ViewObject) {
+	f, err := parseMapObject(vo.Type, vo.Data)
+	if err != nil {
+		h.deleteMapLocation(vo.ID)
+		return
+	}
+
+	b := geo.BoundsOf(f.Points)
+	center := b.Center()
+	hash := geo.Geohash(center, markerGeohashPrecision)
+	if f.Line {
+		hash = geo.CommonGeohash(f.Points, markerGeohashPrecision)
+	}
+
+	err = h.db.SaveMapLocation(model.MapLocation{
+		ViewObjectID:    vo.ID,
+		ViewID:          vo.ViewID,
+		Lat:             center.Lat,
+		Lng:             center.Lng,
+		MinLat:          b.MinLat,
+		MinLng:          b.MinLng,
+		MaxLat:          b.MaxLat,
+		MaxLng:          b.MaxLng,
+		Geohash:         hash,
+		ObjectUpdatedAt: vo.UpdatedAt,
+	})
+	if err != nil {
+		log.Printf("Failed to index location of view object %s: %v", vo.ID, err)
+	}
+}
+
+func (h Handler) deleteMapLocation(viewObjectID string) {
+	if err := h.db.DeleteMapLocation(viewObjectID); err != nil {
+		log.Printf("Failed to delete map location: %v", err)
+	}
+}
+
+// syncMapLocations runs the job that indexes the markers and lines that
+// changed without the server knowing, i.e. through the Node.js collab service,
+// or are from before locations were indexed, a batch at a time, and forgets
+// those that were deleted
+func (h Handler) syncMapLocations(ctx context.Context, j model.Job) error {
+	if err := h.db.DeleteStaleMapLocations(); err != nil {
+		return err
+	}
+
+	afterID := ""
+	for {
+		objects, err := h.db.FindMapObjectsWithStaleLocations(afterID, mapLocationBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, vo := range objects {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			h.indexMapLocation(vo)
+			afterID = vo.ID
+		}
+		if len(objects) < mapLocationBatchSize {
+			return nil
+		}
+	}
+}
+
+// queueMapLocationSync queues the location sync to run once the job runner
+// starts, to index the objects from before locations were
+func (h Handler) queueMapLocationSync() {
+	if err := h.jobs.Cancel(mapLocationSyncJobType); err != nil {
+		log.Printf("Failed to queue the map location sync: %v", err)
+		return
+	}
+	if _, err := h.jobs.Enqueue(mapLocationSyncJobType, mapLocationSyncJobType, "", time.Time{}); err != nil {
+		log.Printf("Failed to queue the map location sync: %v", err)
+	}
+}
+
+// mapQuery is a spatial query on the objects of a map view
+type mapQuery struct {
+	bbox    *geo.Bounds
+	near    *geo.Point
+	radius  float64
+	nearest int
+}
+
+// parseMapQuery reads the bbox, lat, lng, radius and nearest query
+// parameters. ok is false if there are none.
+func parseMapQuery(c echo.Context) (q mapQuery, ok bool, err error) {
+	if s := c.QueryParam("bbox"); s != "" {
+		values, err := parseFloats(s, 4)
+		if err != nil {
+			return q, false, echo.NewHTTPError(http.StatusBadRequest, "bbox must be west,south,east,north")
+		}
+		b := geo.Bounds{MinLng: values[0], MinLat: values[1], MaxLng: values[2], MaxLat: values[3]}
+		if (geo.Point{Lat: b.MinLat, Lng: b.MinLng}).Validate() != nil ||
+			(geo.Point{Lat: b.MaxLat, Lng: b.MaxLng}).Validate() != nil || b.MinLat > b.MaxLat {
+			return q, false, echo.NewHTTPError(http.StatusBadRequest, "bbox must be west,south,east,north")
+		}
+		q.bbox = &b
+	}
+
+	lat, lng := c.QueryParam("lat"), c.QueryParam("lng")
+	if lat != "" || lng != "" {
+		values, err := parseFloats(lat+","+lng, 2)
+		p := geo.Point{}
+		if err == nil {
+			p = geo.Point{Lat: values[0], Lng: values[1]}
+			err = p.Validate()
+		}
+		if err != nil {
+			return q, false, echo.NewHTTPError(http.StatusBadRequest, "lat and lng must both be valid coordinates")
+		}
+		q.near = &p
+	}
+
+	if s := c.QueryParam("radius"); s != "" {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || v <= 0 || math.IsInf(v, 0) {
+			return q, false, echo.NewHTTPError(http.StatusBadRequest, "radius must be a positive number of meters")
+		}
+		q.radius = v
+	}
+	if s := c.QueryParam("nearest"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v <= 0 || v > maxNearest {
+			return q, false, echo.NewHTTPError(http.StatusBadRequest, "nearest must be between 1 and "+strconv.Itoa(maxNearest))
+		}
+		q.nearest = v
+	}
+	if q.near == nil && (q.radius > 0 || q.nearest > 0) {
+		return q, false, echo.NewHTTPError(http.StatusBadRequest, "radius and nearest need lat and lng")
+	}
+
+	return q, q.bbox != nil || q.near != nil, nil
+}
+
+func parseFloats(s string, n int) ([]float64, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != n {
+		return nil, strconv.ErrSyntax
+	}
+	values := make([]float64, n)
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || math.IsNaN(v) {
+			return nil, strconv.ErrSyntax
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// mapQueryResult is an object found by a spatial query, with its distance in
+// meters from the query's point if it has one
+type mapQueryResult struct {
+	object   model.ViewObject
+	distance *float64
+}
+
+// queryMapObjects finds the markers and lines of a map view that match a
+// spatial query, nearest first if the query has a point and newest first if
+// not. objectType narrows them to markers or lines.
+func (h Handler) queryMapObjects(viewID string, objectType string, q mapQuery) ([]mapQueryResult, error) {
+	if q.nearest == 0 || q.radius > 0 || q.bbox != nil {
+		search := geo.Bounds{MinLat: -90, MinLng: -180, MaxLat: 90, MaxLng: 180}
+		switch {
+		case q.near != nil && q.radius > 0:
+			search = geo.CircleBounds(*q.near, q.radius)
+		case q.bbox != nil:
+			search = *q.bbox
+		}
+		return h.findMapObjectsIn(viewID, objectType, search, q)
+	}
+
+	// Look further and further around the point until enough are found
+	for radius := nearestStartRadius; ; radius *= 4 {
+		radius = math.Min(radius, geo.MaxDistance)
+		found, err := h.findMapObjectsIn(viewID, objectType, geo.CircleBounds(*q.near, radius), mapQuery{near: q.near, radius: radius, nearest: q.nearest})
+		if err != nil || len(found) >= q.nearest || radius == geo.MaxDistance {
+			return found, err
+		}
+	}
+}
+
+// findMapObjectsIn finds the objects whose indexed bounds overlap search,
+// then keeps those that match the query exactly
+func (h Handler) findMapObjectsIn(viewID string, objectType string, search geo.Bounds, q mapQuery) ([]mapQueryResult, error) {
+	locations, err := h.db.FindMapLocations(model.MapLocationFilter{
+		ViewID: viewID,
+		MinLat: search.MinLat,
+		MinLng: search.MinLng,
+		MaxLat: search.MaxLat,
+		MaxLng: search.MaxLng,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(locations))
+	for i, l := range locations {
+		ids[i] = l.ViewObjectID
+	}
+
+	results := []mapQueryResult{}
+	for start := 0; start < len(ids); start += calendarBatchSize {
+		batch := ids[start:min(start+calendarBatchSize, len(ids))]
+		objects, err := h.db.FindViewObjects(model.ViewObjectFilter{
+			ViewID:     viewID,
+			ObjectType: objectType,
+			ObjectIDs:  batch,
+			PageSize:   len(batch),
+			PageNumber: 1,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, vo := range objects {
+			f, err := parseMapObject(vo.Type, vo.Data)
+			if err != nil {
+				continue
+			}
+			if q.bbox != nil && !q.bbox.Intersects(f.Points, f.Line) {
+				continue
+			}
+			r := mapQueryResult{object: vo}
+			if q.near != nil {
+				d := geo.DistanceTo(*q.near, f.Points, f.Line)
+				if q.radius > 0 && d > q.radius {
+					continue
+				}
+				r.distance = &d
+			}
+			results = append(results, r)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].distance != nil {
+			return *results[i].distance < *results[j].distance
+		}
+		return results[i].object.CreatedAt > results[j].object.CreatedAt
+	})
+	if q.nearest > 0 && len(results) > q.nearest {
+		results = results[:q.nearest]
+	}
+	return results, nil
+}
+
+// findViewObjects returns a page of the objects of a view. Those of map views
+// can be found by position with the bbox, lat, lng, radius and nearest query
+// parameters, in which case their distances from lat and lng are returned too.
+func (h Handler) findViewObjects(c echo.Context, view model.View, filter model.ViewObjectFilter) ([]model.ViewObject, map[string]float64, error) {
+	q, spatial, err := parseMapQuery(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	if spatial && view.Type != "map" {
+		return nil, nil, echo.NewHTTPError(http.StatusBadRequest, "Only the objects of map views can be found by position")
+	}
+
+	if !spatial {
+		objects, err := h.db.FindViewObjects(filter)
+		if err != nil {
+			return nil, nil, echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return objects, nil, nil
+	}
+
+	results, err := h.queryMapObjects(view.ID, filter.ObjectType, q)
+	if err != nil {
+		return nil, nil, echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	start := min((filter.PageNumber-1)*filter.PageSize, len(results))
+	results = results[start:min(start+filter.PageSize, len(results))]
+
+	objects := make([]model.ViewObject, len(results))
+	distances := map[string]float64{}
+	for i, r := range results {
+		objects[i] = r.object
+		if r.distance != nil {
+			distances[r.object.ID] = *r.distance
+		}
+	}
+	return objects, distances, nil
+}
+
+func distancePtr(distances map[string]float64, id string) *float64 {
+	if d, ok := distances[id]; ok {
+		return &d
+	}
+	return nil
+}
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"context"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -16,6 +18,8 @@ type CreateNoteRequest struct {
 	Visibility string `json:"visibility"  validate:"required"`
 	Title      string `json:"title"`
 	Content    string `json:"content"`
+	// Values fill the prompts of the template given by ?template=
+	Values map[string]string `json:"values"`
 }
 
 type UpdateNoteRequest struct {
@@ -34,6 +38,8 @@ type GetNoteResponse struct {
 	CreatedBy  string   `json:"created_by"`
 	UpdatedAt  string   `json:"updated_at"`
 	UpdatedBy  string   `json:"updated_by"`
+	// CommentCount is the number of comments, including replies
+	CommentCount int64 `json:"comment_count"`
 }
 
 // Helper function to get username by user ID
@@ -95,18 +101,26 @@ func (h Handler) GetPublicNotes(c echo.Context) error {
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
+
+	ids := make([]string, len(notes))
+	for i, b := range notes {
+		ids[i] = b.ID
+	}
+	counts := h.commentCounts(model.CommentTargetNote, ids)
+
 	res := make([]GetNoteResponse, 0)
 
 	for _, b := range notes {
 		res = append(res, GetNoteResponse{
-			ID:         b.ID,
-			Visibility: b.Visibility,
-			Title:      b.Title,
-			Content:    b.Content,
-			CreatedAt:  b.CreatedAt,
-			CreatedBy:  h.getUserNameByID(b.CreatedBy),
-			UpdatedAt:  b.UpdatedAt,
-			UpdatedBy:  h.getUserNameByID(b.UpdatedBy),
+			ID:           b.ID,
+			Visibility:   b.Visibility,
+			Title:        b.Title,
+			Content:      b.Content,
+			CreatedAt:    b.CreatedAt,
+			CreatedBy:    h.getUserNameByID(b.CreatedBy),
+			UpdatedAt:    b.UpdatedAt,
+			UpdatedBy:    h.getUserNameByID(b.UpdatedBy),
+			CommentCount: counts[b.ID],
 		})
 	}
 
@@ -194,18 +208,25 @@ func (h Handler) GetNotes(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	ids := make([]string, len(notes))
+	for i, b := range notes {
+		ids[i] = b.ID
+	}
+	counts := h.commentCounts(model.CommentTargetNote, ids)
+
 	res := make([]GetNoteResponse, 0)
 
 	for _, b := range notes {
 		res = append(res, GetNoteResponse{
-			ID:         b.ID,
-			Visibility: b.Visibility,
-			Title:      b.Title,
-			Content:    b.Content,
-			CreatedAt:  b.CreatedAt,
-			CreatedBy:  h.getUserNameByID(b.CreatedBy),
-			UpdatedAt:  b.UpdatedAt,
-			UpdatedBy:  h.getUserNameByID(b.UpdatedBy),
+			ID:           b.ID,
+			Visibility:   b.Visibility,
+			Title:        b.Title,
+			Content:      b.Content,
+			CreatedAt:    b.CreatedAt,
+			CreatedBy:    h.getUserNameByID(b.CreatedBy),
+			UpdatedAt:    b.UpdatedAt,
+			UpdatedBy:    h.getUserNameByID(b.UpdatedBy),
+			CommentCount: counts[b.ID],
 		})
 	}
 
@@ -287,10 +308,27 @@ func (h Handler) CreateNote(c echo.Context) error {
 		content = tiptapJSON
 	}
 
+	// A template fills in the title and content the request leaves empty
+	title := req.Title
+	if templateID := c.QueryParam("template"); templateID != "" {
+		t, err := h.findNoteTemplate(workspaceId, templateID, user.ID)
+		if err != nil {
+			return err
+		}
+		renderedTitle, renderedContent, err := h.renderNoteTemplate(c, t, clientNow(c), req.Title, req.Values)
+		if err != nil {
+			return err
+		}
+		title = renderedTitle
+		if req.Content == "" {
+			content = renderedContent
+		}
+	}
+
 	n.WorkspaceID = workspaceId
 	n.ID = util.NewId()
 	n.Visibility = req.Visibility
-	n.Title = req.Title
+	n.Title = title
 	n.Content = content
 	n.CreatedAt = time.Now().UTC().Format(time.RFC3339)
 	n.CreatedBy = user.ID
@@ -303,6 +341,10 @@ func (h Handler) CreateNote(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
+	h.publishNote(c, model.EventNoteCreated, n)
+	h.queueMentions(n, user.ID)
+	h.indexTasks(n)
+
 	return c.JSON(http.StatusCreated, n)
 }
 
@@ -330,6 +372,29 @@ func (h Handler) DeleteNote(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	if err := h.notes.Delete(existingNote.ID); err != nil {
+		log.Printf("Failed to delete collaborative document of note %s: %v", existingNote.ID, err)
+	}
+
+	h.deleteComments(model.CommentFilter{TargetType: model.CommentTargetNote, TargetID: existingNote.ID})
+	h.deleteDailyNotes(existingNote.ID)
+	h.deleteTasks(existingNote.ID)
+	h.deleteMentionIndex(existingNote.ID)
+	h.deleteReminders(model.ReminderFilter{TargetType: model.ReminderTargetNote, TargetID: existingNote.ID})
+	if err := h.db.DeleteTableRows(model.TableRowFilter{NoteID: existingNote.ID}); err != nil {
+		log.Printf("Failed to delete table rows of note %s: %v", existingNote.ID, err)
+	}
+
+	audit(h.db, c, auditEntry{
+		WorkspaceID: existingNote.WorkspaceID,
+		Action:      model.AuditActionNoteDelete,
+		TargetType:  model.AuditTargetNote,
+		TargetID:    existingNote.ID,
+		Before:      map[string]string{"title": existingNote.Title, "visibility": existingNote.Visibility},
+	})
+
+	h.publishNote(c, model.EventNoteDeleted, existingNote)
+
 	return c.NoContent(http.StatusNoContent)
 }
 
@@ -359,10 +424,13 @@ func (h Handler) UpdateNote(c echo.Context) error {
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
+	if existingNote.WorkspaceID != workspaceId {
+		return echo.NewHTTPError(http.StatusNotFound, "Note not found")
+	}
 
 	user := c.Get("user").(model.User)
 
-	if existingNote.CreatedBy != user.ID {
+	if !h.canEditNote(user.ID, existingNote) {
 		return echo.NewHTTPError(http.StatusUnauthorized)
 	}
 
@@ -395,6 +463,11 @@ func (h Handler) UpdateNote(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
+	h.publishNote(c, model.EventNoteUpdated, n)
+	h.pushNoteToCollab(n, user.ID)
+	h.queueMentions(n, user.ID)
+	h.indexTasks(n)
+
 	return c.JSON(http.StatusOK, existingNote)
 }
 
@@ -447,5 +520,36 @@ func (h Handler) UpdateNoteVisibility(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
+	audit(h.db, c, auditEntry{
+		WorkspaceID: workspaceId,
+		Action:      model.AuditActionNoteVisibilityUpdate,
+		TargetType:  model.AuditTargetNote,
+		TargetID:    n.ID,
+		Before:      map[string]string{"visibility": existingNote.Visibility},
+		After:       map[string]string{"visibility": n.Visibility},
+	})
+
+	h.publishNote(c, model.EventNoteUpdated, n)
+
 	return c.JSON(http.StatusOK, n)
 }
+
+// collabNoteStored follows up on a note the built-in collab server stored,
+// like saving it through the API would
+func (h Handler) collabNoteStored(before model.Note, after model.Note) {
+	h.queueMentions(after, after.UpdatedBy)
+	h.indexTasks(after)
+}
+
+// pushNoteToCollab writes a REST change into the note's collaborative document
+// in the background, so open editors pick it up instead of saving over it
+func (h Handler) pushNoteToCollab(n model.Note, userID string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+
+		if err := h.notes.Push(ctx, n, userID); err != nil {
+			log.Printf("Failed to push note %s to the collab service: %v", n.ID, err)
+		}
+	}()
+}
@@ -0,0 +1,312 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/model"
+	"github.com/collabreef/collabreef/internal/util"
+
+	"github.com/labstack/echo/v4"
+)
+
+type NoteTemplateRequest struct {
+	Name        string                     `json:"name" validate:"required"`
+	Description string                     `json:"description"`
+	Title       string                     `json:"title"`
+	Format      string                     `json:"format"`
+	Content     string                     `json:"content"`
+	Prompts     []model.NoteTemplatePrompt `json:"prompts" validate:"dive"`
+}
+
+type NoteTemplateResponse struct {
+	ID          string                     `json:"id"`
+	WorkspaceID string                     `json:"workspace_id"`
+	Name        string                     `json:"name"`
+	Description string                     `json:"description"`
+	Title       string                     `json:"title"`
+	Format      string                     `json:"format"`
+	Content     string                     `json:"content"`
+	Prompts     []model.NoteTemplatePrompt `json:"prompts"`
+	CreatedAt   string                     `json:"created_at"`
+	CreatedBy   string                     `json:"created_by"`
+	UpdatedAt   string                     `json:"updated_at"`
+	UpdatedBy   string                     `json:"updated_by"`
+}
+
+type RenderNoteTemplateRequest struct {
+	Title  string            `json:"title"`
+	Values map[string]string `json:"values"`
+}
+
+type RenderNoteTemplateResponse struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+func noteTemplatePrompts(t model.NoteTemplate) []model.NoteTemplatePrompt {
+	prompts := []model.NoteTemplatePrompt{}
+	if t.Prompts != "" {
+		json.Unmarshal([]byte(t.Prompts), &prompts)
+	}
+	return prompts
+}
+
+func (h Handler) noteTemplateResponse(t model.NoteTemplate) NoteTemplateResponse {
+	return NoteTemplateResponse{
+		ID:          t.ID,
+		WorkspaceID: t.WorkspaceID,
+		Name:        t.Name,
+		Description: t.Description,
+		Title:       t.Title,
+		Format:      t.Format,
+		Content:     t.Content,
+		Prompts:     noteTemplatePrompts(t),
+		CreatedAt:   t.CreatedAt,
+		CreatedBy:   h.getUserNameByID(t.CreatedBy),
+		UpdatedAt:   t.UpdatedAt,
+		UpdatedBy:   h.getUserNameByID(t.UpdatedBy),
+	}
+}
+
+// findNoteTemplate looks up a template of the workspace, which the user must
+// be a member of
+func (h Handler) findNoteTemplate(workspaceID string, id string, userID string) (model.NoteTemplate, error) {
+	if !h.isUserWorkspaceMember(userID, workspaceID) {
+		return model.NoteTemplate{}, echo.NewHTTPError(http.StatusForbidden, "You are not a member of this workspace")
+	}
+
+	t, err := h.db.FindNoteTemplateByID(id)
+	if err != nil || t.WorkspaceID != workspaceID {
+		return model.NoteTemplate{}, echo.NewHTTPError(http.StatusNotFound, "Template not found")
+	}
+	return t, nil
+}
+
+// renderNoteTemplate fills in a template's title and content, with now giving
+// the date and time placeholders. Prompts take their values from values, or
+// their defaults.
+func (h Handler) renderNoteTemplate(c echo.Context, t model.NoteTemplate, now time.Time, title string, values map[string]string) (string, string, error) {
+	user := c.Get("user").(model.User)
+
+	vars := map[string]string{}
+	var missing []string
+	for _, p := range noteTemplatePrompts(t) {
+		v, ok := values[p.Name]
+		if !ok || v == "" {
+			v = p.Default
+		}
+		if v == "" && p.Required {
+			missing = append(missing, p.Name)
+		}
+		vars[p.Name] = v
+	}
+	if len(missing) > 0 {
+		return "", "", echo.NewHTTPError(http.StatusBadRequest, "Missing values for: "+strings.Join(missing, ", "))
+	}
+
+	// Built-in placeholders win over prompts of the same name
+	vars["date"] = now.Format("2006-01-02")
+	vars["time"] = now.Format("15:04")
+	vars["datetime"] = now.Format("2006-01-02 15:04")
+	vars["weekday"] = now.Weekday().String()
+	vars["user"] = user.Name
+	if w, err := h.db.FindWorkspaceByID(t.WorkspaceID); err == nil {
+		vars["workspace"] = w.Name
+	}
+
+	if title == "" {
+		title = util.ReplacePlaceholders(t.Title, vars)
+	}
+	vars["title"] = title
+
+	var content string
+	var err error
+	switch t.Format {
+	case model.NoteTemplateFormatMarkdown:
+		content, err = util.MarkdownToTipTap(util.ReplacePlaceholders(t.Content, vars))
+	default:
+		content, err = util.TipTapReplacePlaceholders(t.Content, vars)
+	}
+	if err != nil {
+		return "", "", echo.NewHTTPError(http.StatusInternalServerError, "Failed to render template: "+err.Error())
+	}
+
+	return title, content, nil
+}
+
+func (h Handler) GetNoteTemplates(c echo.Context) error {
+	workspaceId := c.Param("workspaceId")
+	user := c.Get("user").(model.User)
+
+	if !h.isUserWorkspaceMember(user.ID, workspaceId) {
+		return echo.NewHTTPError(http.StatusForbidden, "You are not a member of this workspace")
+	}
+
+	templates, err := h.db.FindNoteTemplates(model.NoteTemplateFilter{WorkspaceID: workspaceId})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	res := []NoteTemplateResponse{}
+	for _, t := range templates {
+		res = append(res, h.noteTemplateResponse(t))
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h Handler) GetNoteTemplate(c echo.Context) error {
+	user := c.Get("user").(model.User)
+
+	t, err := h.findNoteTemplate(c.Param("workspaceId"), c.Param("id"), user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, h.noteTemplateResponse(t))
+}
+
+// bindNoteTemplate reads and checks a template from the request into t
+func bindNoteTemplate(c echo.Context, t *model.NoteTemplate) error {
+	var req NoteTemplateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request data"})
+	}
+
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Validation failed: " + err.Error(),
+		})
+	}
+
+	if req.Format == "" {
+		req.Format = model.NoteTemplateFormatTipTap
+	}
+	if !model.IsValidNoteTemplateFormat(req.Format) {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid format, use tiptap or markdown")
+	}
+	if req.Format == model.NoteTemplateFormatTipTap && req.Content != "" && !json.Valid([]byte(req.Content)) {
+		return echo.NewHTTPError(http.StatusBadRequest, "content must be TipTap JSON")
+	}
+	if req.Content == "" && req.Format == model.NoteTemplateFormatTipTap {
+		req.Content = `{"type":"doc","content":[{"type":"paragraph"}]}`
+	}
+
+	if req.Prompts == nil {
+		req.Prompts = []model.NoteTemplatePrompt{}
+	}
+	prompts, err := json.Marshal(req.Prompts)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	t.Name = req.Name
+	t.Description = req.Description
+	t.Title = req.Title
+	t.Format = req.Format
+	t.Content = req.Content
+	t.Prompts = string(prompts)
+	return nil
+}
+
+func (h Handler) CreateNoteTemplate(c echo.Context) error {
+	workspaceId := c.Param("workspaceId")
+	user := c.Get("user").(model.User)
+
+	if !h.isUserWorkspaceMember(user.ID, workspaceId) {
+		return echo.NewHTTPError(http.StatusForbidden, "You are not a member of this workspace")
+	}
+
+	t := model.NoteTemplate{
+		ID:          util.NewId(),
+		WorkspaceID: workspaceId,
+		CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+		CreatedBy:   user.ID,
+		UpdatedAt:   time.Now().UTC().Format(time.RFC3339),
+		UpdatedBy:   user.ID,
+	}
+	// Bind errors have been written to the response already
+	if err := bindNoteTemplate(c, &t); err != nil || c.Response().Committed {
+		return err
+	}
+
+	if err := h.db.CreateNoteTemplate(t); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, h.noteTemplateResponse(t))
+}
+
+// UpdateNoteTemplate lets the template's creator and workspace owners and
+// admins change it
+func (h Handler) UpdateNoteTemplate(c echo.Context) error {
+	workspaceId := c.Param("workspaceId")
+	user := c.Get("user").(model.User)
+
+	t, err := h.findNoteTemplate(workspaceId, c.Param("id"), user.ID)
+	if err != nil {
+		return err
+	}
+
+	if t.CreatedBy != user.ID && !h.isWorkspaceAdmin(user.ID, workspaceId) {
+		return echo.NewHTTPError(http.StatusForbidden, "You do not have permission to change this template")
+	}
+
+	if err := bindNoteTemplate(c, &t); err != nil || c.Response().Committed {
+		return err
+	}
+	t.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	t.UpdatedBy = user.ID
+
+	if err := h.db.UpdateNoteTemplate(t); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, h.noteTemplateResponse(t))
+}
+
+func (h Handler) DeleteNoteTemplate(c echo.Context) error {
+	workspaceId := c.Param("workspaceId")
+	user := c.Get("user").(model.User)
+
+	t, err := h.findNoteTemplate(workspaceId, c.Param("id"), user.ID)
+	if err != nil {
+		return err
+	}
+
+	if t.CreatedBy != user.ID && !h.isWorkspaceAdmin(user.ID, workspaceId) {
+		return echo.NewHTTPError(http.StatusForbidden, "You do not have permission to delete this template")
+	}
+
+	if err := h.db.DeleteNoteTemplate(t.ID); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RenderNoteTemplate returns the title and content a note created from the
+// template would get, without creating it, e.g. to pre-fill a form
+func (h Handler) RenderNoteTemplate(c echo.Context) error {
+	user := c.Get("user").(model.User)
+
+	t, err := h.findNoteTemplate(c.Param("workspaceId"), c.Param("id"), user.ID)
+	if err != nil {
+		return err
+	}
+
+	var req RenderNoteTemplateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request data"})
+	}
+
+	title, content, err := h.renderNoteTemplate(c, t, clientNow(c), req.Title, req.Values)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, RenderNoteTemplateResponse{Title: title, Content: content})
+}
@@ -5,9 +5,18 @@ import (
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	"github.com/collabreef/collabreef/internal/collab"
 	"github.com/collabreef/collabreef/internal/model"
+	"github.com/collabreef/collabreef/internal/presence"
 )
 
+// canEditNote reports whether a user may change the title and content of a
+// note, over REST and in the collaborative editor alike: only its creator may.
+// Anyone else who can see it, e.g. a member of its workspace, may only read it.
+func (h *Handler) canEditNote(userID string, note model.Note) bool {
+	return note.CreatedBy == userID
+}
+
 // HandleNoteWebSocket handles WebSocket connections for note collaboration
 func (h *Handler) HandleNoteWebSocket(c echo.Context) error {
 	noteID := c.Param("noteId")
@@ -45,11 +54,80 @@ func (h *Handler) HandleNoteWebSocket(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusForbidden, "You do not have permission to access this note")
 	}
 
-	log.Printf("Note WebSocket proxy: user=%s, noteId=%s", user.ID, noteID)
+	readOnly := !h.canEditNote(user.ID, note)
+
+	log.Printf("Note WebSocket proxy: user=%s, noteId=%s, readOnly=%t", user.ID, noteID, readOnly)
+
+	defer h.presence.Join(
+		presence.Target{Kind: presence.KindNote, ID: note.ID, WorkspaceID: note.WorkspaceID},
+		presence.Connection{UserID: user.ID, UserName: user.Name, ReadOnly: readOnly},
+	)()
+
+	return h.connectCollab(c, collab.Session{
+		UserID:   user.ID,
+		UserName: user.Name,
+		Document: collab.NoteDocumentName(note.ID),
+		ReadOnly: readOnly,
+	})
+}
+
+// HandlePublicNoteWebSocket handles WebSocket connections for public notes (read-only)
+func (h *Handler) HandlePublicNoteWebSocket(c echo.Context) error {
+	noteID := c.Param("noteId")
+
+	if noteID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Note ID is required")
+	}
+
+	note, err := h.db.FindNote(model.Note{ID: noteID})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Note not found")
+	}
+
+	// Get user from context (may be nil for unauthenticated users)
+	var user *model.User
+	if u := c.Get("user"); u != nil {
+		if uu, ok := u.(model.User); ok {
+			user = &uu
+		}
+	}
+
+	// Same visibility rules as GetPublicNote
+	isVisible := false
+	switch note.Visibility {
+	case "public":
+		isVisible = true
+	case "workspace":
+		isVisible = user != nil && h.isUserWorkspaceMember(user.ID, note.WorkspaceID)
+	case "private":
+		isVisible = user != nil && note.CreatedBy == user.ID
+	}
+
+	if !isVisible {
+		return echo.NewHTTPError(http.StatusForbidden, "You do not have permission to access this note")
+	}
+
+	userID := "anonymous"
+	userName := "Anonymous"
+	viewer := presence.Connection{ReadOnly: true}
+	if user != nil {
+		userID = user.ID
+		userName = user.Name
+		viewer.UserID = user.ID
+		viewer.UserName = user.Name
+	}
+
+	log.Printf("Public Note WebSocket proxy: user=%s, noteId=%s", userID, noteID)
+
+	defer h.presence.Join(
+		presence.Target{Kind: presence.KindNote, ID: note.ID, WorkspaceID: note.WorkspaceID},
+		viewer,
+	)()
 
-	return h.proxyToCollab(c, map[string]string{
-		"X-User-ID":   user.ID,
-		"X-User-Name": user.Name,
-		"X-Read-Only": "false",
+	return h.connectCollab(c, collab.Session{
+		UserID:   userID,
+		UserName: userName,
+		Document: collab.NoteDocumentName(note.ID),
+		ReadOnly: true,
 	})
 }
@@ -0,0 +1,386 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/collabreef/collabreef/internal/db"
+	"github.com/collabreef/collabreef/internal/model"
+	"github.com/collabreef/collabreef/internal/util"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+const mentionsJobType = "mentions"
+
+type GetNotificationsResponse struct {
+	Notifications []model.Notification `json:"notifications"`
+	Total         int64                `json:"total"`
+	Unread        int64                `json:"unread"`
+}
+
+type UpdateNotificationPreferencesRequest struct {
+	Types       map[string]bool `json:"types"`
+	EmailDigest bool            `json:"email_digest"`
+}
+
+// notify records a notification unless the recipient caused it or turned
+// notifications of its type off. Pass the transaction the change is made in so
+// it's only kept if the change is committed. Failing to record it is logged but
+// never fails the request.
+func notify(r db.DB, actor model.User, n model.Notification) {
+	n.ActorID = actor.ID
+	n.ActorName = actor.Name
+	if n.UserID == "" || n.UserID == n.ActorID {
+		return
+	}
+
+	recipient, err := r.FindUserByID(n.UserID)
+	if err != nil || recipient.Disabled || !recipient.NotificationPreferences().Wants(n.Type) {
+		return
+	}
+
+	n.ID = util.NewId()
+	n.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	if err := r.CreateNotification(n); err != nil {
+		log.Printf("Failed to record %s notification for %s: %v", n.Type, n.UserID, err)
+	}
+}
+
+// mentionsJob is the payload of the job that notifies the mentions in a note
+type mentionsJob struct {
+	NoteID  string `json:"note_id"`
+	ActorID string `json:"actor_id"`
+}
+
+func mentionsJobKey(noteID string) string {
+	return "mentions:" + noteID
+}
+
+// queueMentions notifies the members a change by actorID mentioned in a note
+// in the background, since finding them reads every member of the workspace.
+// A change queued before that hasn't run yet is notified together with it.
+func (h Handler) queueMentions(note model.Note, actorID string) {
+	payload, err := json.Marshal(mentionsJob{NoteID: note.ID, ActorID: actorID})
+	if err != nil {
+		return
+	}
+	if err := h.jobs.Cancel(mentionsJobKey(note.ID)); err != nil {
+		log.Printf("Failed to queue mentions of note %s: %v", note.ID, err)
+		return
+	}
+	if _, err := h.jobs.Enqueue(mentionsJobType, mentionsJobKey(note.ID), string(payload), time.Time{}); err != nil {
+		log.Printf("Failed to queue mentions of note %s: %v", note.ID, err)
+	}
+}
+
+// notifyMentions runs the job of a changed note. It notifies the members of
+// the note's workspace who are mentioned in it but weren't when it was last
+// indexed, and indexes its mentions again. Private notes mention nobody,
+// since nobody else can read them.
+func (h Handler) notifyMentions(ctx context.Context, j model.Job) error {
+	var payload mentionsJob
+	if err := json.Unmarshal([]byte(j.Payload), &payload); err != nil {
+		return nil
+	}
+
+	note, err := h.db.FindNote(model.Note{ID: payload.NoteID})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Notes without an index are new, so all of their mentions are new
+	previous, err := h.db.FindMentionIndex(note.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		previous = model.MentionIndex{Known: true}
+	} else if err != nil {
+		return err
+	}
+
+	var ids []string
+	for id := range mentionedUsers(note.Content, h.workspaceMembers(note.WorkspaceID)) {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	actor, err := h.db.FindUserByID(payload.ActorID)
+	if previous.Known && note.Visibility != "private" && err == nil {
+		title := note.Title
+		if title == "" {
+			title = "a note"
+		}
+
+		for _, id := range ids {
+			if strings.Contains(previous.UserIDs, ","+id+",") {
+				continue
+			}
+			notify(h.db, actor, model.Notification{
+				UserID:      id,
+				WorkspaceID: note.WorkspaceID,
+				Type:        model.NotificationMention,
+				TargetType:  model.NotificationTargetNote,
+				TargetID:    note.ID,
+				Message:     actor.Name + " mentioned you in " + title,
+			})
+		}
+	}
+
+	index := model.MentionIndex{NoteID: note.ID, WorkspaceID: note.WorkspaceID, Known: true}
+	if len(ids) > 0 {
+		index.UserIDs = "," + strings.Join(ids, ",") + ","
+	}
+	return h.db.SaveMentionIndex(index)
+}
+
+func (h Handler) deleteMentionIndex(noteID string) {
+	if err := h.db.DeleteMentionIndex(noteID); err != nil {
+		log.Printf("Failed to delete mentions of note %s: %v", noteID, err)
+	}
+}
+
+// newMentions returns the IDs of the workspace's members who are mentioned in
+// content but weren't in previousContent. Members are mentioned with mention
+// nodes or by writing @ and their name.
+func (h Handler) newMentions(workspaceID string, content string, previousContent string) []string {
+	users := h.workspaceMembers(workspaceID)
+	if len(users) == 0 {
+		return nil
+	}
+
+	before := mentionedUsers(previousContent, users)
+
+	var ids []string
+	for id := range mentionedUsers(content, users) {
+		if !before[id] {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// workspaceMembers returns the users who are members of a workspace
+func (h Handler) workspaceMembers(workspaceID string) []model.User {
+	members, err := h.db.FindWorkspaceUsers(model.WorkspaceUserFilter{WorkspaceID: workspaceID})
+	if err != nil {
+		log.Printf("Failed to find members of workspace %s: %v", workspaceID, err)
+		return nil
+	}
+
+	var users []model.User
+	for _, m := range members {
+		if u, err := h.db.FindUserByID(m.UserID); err == nil {
+			users = append(users, u)
+		}
+	}
+	return users
+}
+
+// mentionedUsers returns the IDs of the users mentioned in a note's content
+func mentionedUsers(content string, users []model.User) map[string]bool {
+	mentioned := map[string]bool{}
+	if content == "" {
+		return mentioned
+	}
+
+	text, ids := util.TipTapText(content)
+	for _, u := range users {
+		for _, id := range ids {
+			if id == u.ID {
+				mentioned[u.ID] = true
+			}
+		}
+		if u.Name != "" && containsMention(text, u.Name) {
+			mentioned[u.ID] = true
+		}
+	}
+	return mentioned
+}
+
+// containsMention reports whether text has @name as a word of its own, which
+// rules out e.g. email addresses
+func containsMention(text string, name string) bool {
+	lower := strings.ToLower(text)
+	mention := "@" + strings.ToLower(name)
+
+	for i := 0; ; {
+		j := strings.Index(lower[i:], mention)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(mention)
+
+		before, _ := utf8.DecodeLastRuneInString(lower[:start])
+		after, _ := utf8.DecodeRuneInString(lower[end:])
+		if (start == 0 || !isWordRune(before)) && (end == len(lower) || !isWordRune(after)) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func (h Handler) GetNotifications(c echo.Context) error {
+	user := c.Get("user").(model.User)
+
+	filter := model.NotificationFilter{
+		UserID:     user.ID,
+		Unread:     c.QueryParam("unread") == "true",
+		PageSize:   50,
+		PageNumber: 1,
+	}
+
+	if ps := c.QueryParam("pageSize"); ps != "" {
+		if v, err := strconv.Atoi(ps); err == nil && v > 0 && v <= 500 {
+			filter.PageSize = v
+		}
+	}
+	if pn := c.QueryParam("pageNumber"); pn != "" {
+		if v, err := strconv.Atoi(pn); err == nil && v > 0 {
+			filter.PageNumber = v
+		}
+	}
+
+	notifications, err := h.db.FindNotifications(filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	total, err := h.db.CountNotifications(filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	unread, err := h.db.CountNotifications(model.NotificationFilter{UserID: user.ID, Unread: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if notifications == nil {
+		notifications = []model.Notification{}
+	}
+
+	return c.JSON(http.StatusOK, GetNotificationsResponse{
+		Notifications: notifications,
+		Total:         total,
+		Unread:        unread,
+	})
+}
+
+func (h Handler) GetUnreadNotificationCount(c echo.Context) error {
+	user := c.Get("user").(model.User)
+
+	count, err := h.db.CountNotifications(model.NotificationFilter{UserID: user.ID, Unread: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, map[string]int64{"count": count})
+}
+
+func (h Handler) MarkNotificationRead(c echo.Context) error {
+	id := c.Param("id")
+	if id == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "notification id is required")
+	}
+
+	user := c.Get("user").(model.User)
+
+	// Other users' notifications look like they don't exist
+	n, err := h.db.FindNotificationByID(id)
+	if err != nil || n.UserID != user.ID {
+		return echo.NewHTTPError(http.StatusNotFound, "Notification not found")
+	}
+
+	if _, err := h.db.MarkNotificationsRead(user.ID, []string{id}, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h Handler) MarkAllNotificationsRead(c echo.Context) error {
+	user := c.Get("user").(model.User)
+
+	n, err := h.db.MarkNotificationsRead(user.ID, nil, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, map[string]int{"count": n})
+}
+
+func (h Handler) GetNotificationPreferences(c echo.Context) error {
+	user := c.Get("user").(model.User)
+
+	u, err := h.db.FindUserByID(user.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, notificationPreferencesResponse(u.NotificationPreferences()))
+}
+
+func (h Handler) UpdateNotificationPreferences(c echo.Context) error {
+	var req UpdateNotificationPreferencesRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request data"})
+	}
+
+	for t := range req.Types {
+		if !model.IsValidNotificationType(t) {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid notification type: "+t)
+		}
+	}
+
+	user := c.Get("user").(model.User)
+
+	u, err := h.db.FindUserByID(user.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	prefs := model.NotificationPreferences{Types: req.Types, EmailDigest: req.EmailDigest}
+	if err := u.SetNotificationPreferences(prefs); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	u.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	u.UpdatedBy = user.ID
+
+	if err := h.db.UpdateUser(u); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, notificationPreferencesResponse(prefs))
+}
+
+// notificationPreferencesResponse lists every notification type, so clients
+// see the ones that are on because they aren't set
+func notificationPreferencesResponse(p model.NotificationPreferences) model.NotificationPreferences {
+	types := map[string]bool{}
+	for _, t := range []string{
+		model.NotificationMention,
+		model.NotificationMemberAdded,
+		model.NotificationRoleChanged,
+		model.NotificationNoteAttached,
+	} {
+		types[t] = p.Wants(t)
+	}
+	return model.NotificationPreferences{Types: types, EmailDigest: p.EmailDigest}
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/collabreef/collabreef/internal/model"
+	"github.com/collabreef/collabreef/internal/presence"
+	"github.com/labstack/echo/v4"
+)
+
+// GetWorkspacePresence returns the notes and views of a workspace that someone
+// has open, leaving out other users' private ones. Only members can see who is
+// active in a workspace.
+func (h Handler) GetWorkspacePresence(c echo.Context) error {
+	workspaceId := c.Param("workspaceId")
+	user := c.Get("user").(model.User)
+
+	if !h.isUserWorkspaceMember(user.ID, workspaceId) {
+		return echo.NewHTTPError(http.StatusForbidden, "You are not a member of this workspace")
+	}
+
+	res := []presence.Presence{}
+	for _, p := range h.presence.Workspace(workspaceId) {
+		if h.isPresenceTargetVisible(p.Type, p.ID, workspaceId, user.ID) {
+			res = append(res, p)
+		}
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h Handler) GetNotePresence(c echo.Context) error {
+	return h.getPresence(c, presence.KindNote)
+}
+
+func (h Handler) GetViewPresence(c echo.Context) error {
+	return h.getPresence(c, presence.KindView)
+}
+
+func (h Handler) getPresence(c echo.Context, kind string) error {
+	workspaceId := c.Param("workspaceId")
+	id := c.Param("id")
+	user := c.Get("user").(model.User)
+
+	if !h.canSeePresence(kind, id, workspaceId, user.ID) {
+		return echo.NewHTTPError(http.StatusNotFound, "Not found")
+	}
+
+	return c.JSON(http.StatusOK, h.presence.Get(presence.Target{Kind: kind, ID: id, WorkspaceID: workspaceId}))
+}
+
+// canSeePresence reports whether the user is a member of the workspace and the
+// note or view belongs to it and is visible to them
+func (h Handler) canSeePresence(kind string, id string, workspaceID string, userID string) bool {
+	return h.isUserWorkspaceMember(userID, workspaceID) && h.isPresenceTargetVisible(kind, id, workspaceID, userID)
+}
+
+// isPresenceTargetVisible reports whether a note or view belongs to the
+// workspace and isn't another user's private one
+func (h Handler) isPresenceTargetVisible(kind string, id string, workspaceID string, userID string) bool {
+	var owner, visibility, workspace string
+
+	switch kind {
+	case presence.KindNote:
+		n, err := h.db.FindNote(model.Note{ID: id})
+		if err != nil {
+			return false
+		}
+		owner, visibility, workspace = n.CreatedBy, n.Visibility, n.WorkspaceID
+	case presence.KindView:
+		v, err := h.db.FindView(model.View{ID: id})
+		if err != nil {
+			return false
+		}
+		owner, visibility, workspace = v.CreatedBy, v.Visibility, v.WorkspaceID
+	default:
+		return false
+	}
+
+	return workspace == workspaceID && (visibility != "private" || owner == userID)
+}
@@ -0,0 +1,573 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/model"
+	"github.com/collabreef/collabreef/internal/util"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+const (
+	reminderJobType = "reminder"
+
+	// Reminders relative to a calendar slot are at most four weeks ahead of it
+	maxReminderMinutesBefore = 4 * 7 * 24 * 60
+)
+
+type ReminderRequest struct {
+	// RemindAt is an RFC 3339 time, or a local YYYY-MM-DDTHH:MM in Timezone
+	RemindAt string `json:"remind_at"`
+	// MinutesBefore reminds that long before a calendar slot starts instead,
+	// and follows the slot when it is moved
+	MinutesBefore *int   `json:"minutes_before"`
+	Timezone      string `json:"timezone"`
+	Channel       string `json:"channel"`
+	Message       string `json:"message"`
+}
+
+type ReminderResponse struct {
+	model.Reminder
+	// RemindAtLocal is RemindAt in the reminder's timezone
+	RemindAtLocal string `json:"remind_at_local"`
+	TargetTitle   string `json:"target_title"`
+}
+
+// reminderJob is the payload of the job that fires a reminder. A job for a
+// time the reminder was since moved from does nothing.
+type reminderJob struct {
+	ReminderID string `json:"reminder_id"`
+	RemindAt   string `json:"remind_at"`
+}
+
+func reminderJobKey(id string) string {
+	return "reminder:" + id
+}
+
+func reminderResponse(r model.Reminder, title string) ReminderResponse {
+	res := ReminderResponse{Reminder: r, RemindAtLocal: r.RemindAt, TargetTitle: title}
+
+	if at, err := time.Parse(time.RFC3339, r.RemindAt); err == nil {
+		if loc, err := util.LoadTimezone(r.Timezone); err == nil {
+			res.RemindAtLocal = at.In(loc).Format(time.RFC3339)
+		}
+	}
+	return res
+}
+
+// reminderTime works out when a reminder is due from a request, in UTC
+func (h Handler) reminderTime(p targetParent, req ReminderRequest, loc *time.Location) (time.Time, error) {
+	if req.MinutesBefore != nil {
+		if *req.MinutesBefore < 0 || *req.MinutesBefore > maxReminderMinutesBefore {
+			return time.Time{}, echo.NewHTTPError(http.StatusBadRequest, "minutes_before must be between 0 and "+strconv.Itoa(maxReminderMinutesBefore))
+		}
+
+		vo, err := h.db.FindViewObject(model.ViewObject{ID: p.TargetID, ViewID: p.ViewID})
+		if err != nil || p.TargetType != model.ReminderTargetViewObject {
+			return time.Time{}, echo.NewHTTPError(http.StatusBadRequest, "minutes_before needs a calendar slot with a date")
+		}
+		// Recurring slots remind of the next occurrence there is still time for
+		before := time.Duration(*req.MinutesBefore) * time.Minute
+		start, err := calendarSlotStart(vo, loc, time.Now().Add(before))
+		if err != nil {
+			return time.Time{}, echo.NewHTTPError(http.StatusBadRequest, "minutes_before needs a calendar slot with a date")
+		}
+		return start.Add(-before).UTC(), nil
+	}
+
+	if req.RemindAt == "" {
+		return time.Time{}, echo.NewHTTPError(http.StatusBadRequest, "remind_at or minutes_before is required")
+	}
+	if at, err := time.Parse(time.RFC3339, req.RemindAt); err == nil {
+		return at.UTC(), nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04", "2006-01-02T15:04:05"} {
+		if at, err := time.ParseInLocation(layout, req.RemindAt, loc); err == nil {
+			return at.UTC(), nil
+		}
+	}
+	return time.Time{}, echo.NewHTTPError(http.StatusBadRequest, "Invalid remind_at, use RFC 3339 or YYYY-MM-DDTHH:MM")
+}
+
+// applyReminderRequest validates a request and sets the reminder's time,
+// timezone, channel and message from it
+func (h Handler) applyReminderRequest(c echo.Context, p targetParent, req ReminderRequest, r *model.Reminder) error {
+	r.Timezone = req.Timezone
+	if r.Timezone == "" {
+		r.Timezone = util.FormatUTCOffset(parseTimezoneOffset(c))
+	}
+	loc, err := util.LoadTimezone(r.Timezone)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	r.Channel = req.Channel
+	switch r.Channel {
+	case "":
+		r.Channel = model.ReminderChannelNotification
+	case model.ReminderChannelNotification:
+	case model.ReminderChannelWebhook:
+		// Events about private notes and views never reach webhooks
+		if p.Visibility == "private" {
+			return echo.NewHTTPError(http.StatusBadRequest, "Reminders on private items can't be sent to webhooks")
+		}
+		if h.webhooks == nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Webhooks are disabled")
+		}
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid channel, use notification or webhook")
+	}
+
+	if len(req.Message) > 1000 {
+		return echo.NewHTTPError(http.StatusBadRequest, "Message is too long")
+	}
+	r.Message = req.Message
+
+	at, err := h.reminderTime(p, req, loc)
+	if err != nil {
+		return err
+	}
+	if !at.After(time.Now()) {
+		return echo.NewHTTPError(http.StatusBadRequest, "The reminder time has already passed")
+	}
+	r.RemindAt = at.Format(time.RFC3339)
+	r.MinutesBefore = req.MinutesBefore
+
+	return nil
+}
+
+// scheduleReminder queues the job that fires a reminder, replacing the one
+// for an earlier time
+func (h Handler) scheduleReminder(r model.Reminder) error {
+	if err := h.jobs.Cancel(reminderJobKey(r.ID)); err != nil {
+		return err
+	}
+	return h.enqueueReminder(r)
+}
+
+func (h Handler) enqueueReminder(r model.Reminder) error {
+	at, err := time.Parse(time.RFC3339, r.RemindAt)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(reminderJob{ReminderID: r.ID, RemindAt: r.RemindAt})
+	if err != nil {
+		return err
+	}
+
+	_, err = h.jobs.Enqueue(reminderJobType, reminderJobKey(r.ID), string(payload), at)
+	return err
+}
+
+// rescheduleReminders moves the pending reminders that are relative to a
+// calendar slot along with it. Those that would be due already are dropped
+// from the queue.
+func (h Handler) rescheduleReminders(vo model.ViewObject) {
+	reminders, err := h.db.FindReminders(model.ReminderFilter{
+		TargetType: model.ReminderTargetViewObject,
+		TargetID:   vo.ID,
+		Pending:    true,
+	})
+	if err != nil {
+		log.Printf("Failed to find reminders of %s: %v", vo.ID, err)
+		return
+	}
+
+	for _, r := range reminders {
+		if r.MinutesBefore == nil {
+			continue
+		}
+
+		loc, err := util.LoadTimezone(r.Timezone)
+		if err != nil {
+			continue
+		}
+		before := time.Duration(*r.MinutesBefore) * time.Minute
+		start, err := calendarSlotStart(vo, loc, time.Now().Add(before))
+		if err != nil {
+			h.jobs.Cancel(reminderJobKey(r.ID))
+			continue
+		}
+
+		at := start.Add(-before).UTC()
+		if at.Format(time.RFC3339) == r.RemindAt {
+			continue
+		}
+		r.RemindAt = at.Format(time.RFC3339)
+		r.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+
+		if err := h.db.UpdateReminder(r); err != nil {
+			log.Printf("Failed to move reminder %s: %v", r.ID, err)
+			continue
+		}
+		if at.After(time.Now()) {
+			err = h.scheduleReminder(r)
+		} else {
+			err = h.jobs.Cancel(reminderJobKey(r.ID))
+		}
+		if err != nil {
+			log.Printf("Failed to reschedule reminder %s: %v", r.ID, err)
+		}
+	}
+}
+
+// deleteReminders removes the reminders on a note, view object or view that
+// was deleted, with their jobs
+func (h Handler) deleteReminders(f model.ReminderFilter) {
+	f.Pending = true
+	reminders, err := h.db.FindReminders(f)
+	if err != nil {
+		log.Printf("Failed to find reminders: %v", err)
+	}
+	for _, r := range reminders {
+		h.jobs.Cancel(reminderJobKey(r.ID))
+	}
+
+	f.Pending = false
+	if err := h.db.DeleteReminders(f); err != nil {
+		log.Printf("Failed to delete reminders: %v", err)
+	}
+}
+
+// fireReminder runs the job of a reminder. Reminders that were deleted or
+// moved, and those on things the user can no longer see, are skipped.
+func (h Handler) fireReminder(ctx context.Context, j model.Job) error {
+	var payload reminderJob
+	if err := json.Unmarshal([]byte(j.Payload), &payload); err != nil {
+		return nil
+	}
+
+	r, err := h.db.FindReminderByID(payload.ReminderID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if r.FiredAt != "" || r.RemindAt != payload.RemindAt {
+		return nil
+	}
+
+	p, err := h.findTargetParent(r.WorkspaceID, r.TargetType, r.TargetID, r.ViewID)
+	if err != nil {
+		return nil
+	}
+	user, err := h.db.FindUserByID(r.UserID)
+	if err != nil || user.Disabled || !h.canWriteTarget(p, user.ID) {
+		return nil
+	}
+
+	title := p.Title
+	if title == "" {
+		title = "a " + strings.ReplaceAll(p.TargetType, "_", " ")
+	}
+	message := "Reminder: " + title
+	if r.Message != "" {
+		message += ": " + r.Message
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	switch r.Channel {
+	case model.ReminderChannelWebhook:
+		fired, err := h.db.MarkReminderFired(r.ID, r.RemindAt, now)
+		if err != nil || !fired {
+			return err
+		}
+		r.FiredAt = now
+
+		h.events.Publish(model.WorkspaceEvent{
+			ID:          util.NewId(),
+			Type:        model.EventReminderDue,
+			WorkspaceID: r.WorkspaceID,
+			Actor:       &model.EventUser{ID: user.ID, Name: user.Name},
+			Data:        reminderResponse(r, p.Title),
+			CreatedAt:   now,
+			Visibility:  p.Visibility,
+			OwnerID:     p.OwnerID,
+		})
+		h.repeatReminder(r)
+		return nil
+	}
+
+	// The notification is only kept along with marking the reminder fired, so
+	// a retry doesn't notify twice
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	fired, err := tx.MarkReminderFired(r.ID, r.RemindAt, now)
+	if err != nil || !fired {
+		return err
+	}
+
+	if user.NotificationPreferences().Wants(model.NotificationReminder) {
+		targetType := model.NotificationTargetNote
+		if r.TargetType == model.ReminderTargetViewObject {
+			targetType = model.NotificationTargetViewObject
+		}
+
+		err := tx.CreateNotification(model.Notification{
+			ID:          util.NewId(),
+			UserID:      user.ID,
+			WorkspaceID: r.WorkspaceID,
+			Type:        model.NotificationReminder,
+			TargetType:  targetType,
+			TargetID:    r.TargetID,
+			Message:     message,
+			CreatedAt:   now,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	h.repeatReminder(r)
+	return nil
+}
+
+// repeatReminder sets a reminder on a recurring calendar slot again after it
+// fired, for the slot's next occurrence
+func (h Handler) repeatReminder(r model.Reminder) {
+	if r.MinutesBefore == nil || r.TargetType != model.ReminderTargetViewObject {
+		return
+	}
+	vo, err := h.db.FindViewObject(model.ViewObject{ID: r.TargetID, ViewID: r.ViewID})
+	if err != nil {
+		return
+	}
+	if s, err := parseCalendarSlot(vo.Data); err != nil || s.rule == nil {
+		return
+	}
+	loc, err := util.LoadTimezone(r.Timezone)
+	if err != nil {
+		return
+	}
+
+	// The occurrence after the one just reminded of, or after now if the
+	// reminder fired late
+	after, _ := time.Parse(time.RFC3339, r.RemindAt)
+	if now := time.Now(); now.After(after) {
+		after = now
+	}
+	before := time.Duration(*r.MinutesBefore) * time.Minute
+	start, err := calendarSlotStart(vo, loc, after.Add(before))
+	if err != nil {
+		return
+	}
+
+	r.RemindAt = start.Add(-before).UTC().Format(time.RFC3339)
+	r.FiredAt = ""
+	r.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	if err := h.db.UpdateReminder(r); err != nil {
+		log.Printf("Failed to repeat reminder %s: %v", r.ID, err)
+		return
+	}
+	// The job running now is the reminder's only one, so there's none to cancel
+	if err := h.enqueueReminder(r); err != nil {
+		log.Printf("Failed to repeat reminder %s: %v", r.ID, err)
+	}
+}
+
+// findReminder returns one of the signed-in user's reminders in the workspace
+func (h Handler) findReminder(c echo.Context) (model.Reminder, error) {
+	user := c.Get("user").(model.User)
+
+	r, err := h.db.FindReminderByID(c.Param("reminderId"))
+	if err != nil || r.WorkspaceID != c.Param("workspaceId") || r.UserID != user.ID {
+		return model.Reminder{}, echo.NewHTTPError(http.StatusNotFound, "Reminder not found")
+	}
+	return r, nil
+}
+
+// GetReminders lists the signed-in user's reminders in a workspace, soonest
+// first. pending=true leaves out those that fired, and target_type and
+// target_id limit them to one note or view object.
+func (h Handler) GetReminders(c echo.Context) error {
+	workspaceId := c.Param("workspaceId")
+	user := c.Get("user").(model.User)
+
+	if !h.isUserWorkspaceMember(user.ID, workspaceId) {
+		return echo.NewHTTPError(http.StatusForbidden, "You are not a member of this workspace")
+	}
+
+	filter := model.ReminderFilter{
+		WorkspaceID: workspaceId,
+		UserID:      user.ID,
+		TargetType:  c.QueryParam("target_type"),
+		TargetID:    c.QueryParam("target_id"),
+		Pending:     c.QueryParam("pending") == "true",
+		PageSize:    100,
+		PageNumber:  1,
+	}
+	if ps := c.QueryParam("pageSize"); ps != "" {
+		if v, err := strconv.Atoi(ps); err == nil && v > 0 && v <= 500 {
+			filter.PageSize = v
+		}
+	}
+	if pn := c.QueryParam("pageNumber"); pn != "" {
+		if v, err := strconv.Atoi(pn); err == nil && v > 0 {
+			filter.PageNumber = v
+		}
+	}
+
+	reminders, err := h.db.FindReminders(filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	res := make([]ReminderResponse, 0, len(reminders))
+	for _, r := range reminders {
+		title := ""
+		if p, err := h.findTargetParent(r.WorkspaceID, r.TargetType, r.TargetID, r.ViewID); err == nil {
+			title = p.Title
+		}
+		res = append(res, reminderResponse(r, title))
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h Handler) CreateNoteReminder(c echo.Context) error {
+	return h.createReminder(c, model.ReminderTargetNote, c.Param("id"), "")
+}
+
+func (h Handler) CreateViewObjectReminder(c echo.Context) error {
+	return h.createReminder(c, model.ReminderTargetViewObject, c.Param("id"), c.Param("viewId"))
+}
+
+// createReminder sets a reminder for the signed-in user. Members may set them
+// on the notes and view objects of their workspace they can see.
+func (h Handler) createReminder(c echo.Context, targetType string, targetID string, viewID string) error {
+	workspaceId := c.Param("workspaceId")
+	user := c.Get("user").(model.User)
+
+	var req ReminderRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request data"})
+	}
+
+	p, err := h.findTargetParent(workspaceId, targetType, targetID, viewID)
+	if err != nil {
+		return err
+	}
+	if !h.canWriteTarget(p, user.ID) {
+		return echo.NewHTTPError(http.StatusForbidden, "You do not have permission to set reminders here")
+	}
+
+	r := model.Reminder{
+		ID:          util.NewId(),
+		WorkspaceID: p.WorkspaceID,
+		UserID:      user.ID,
+		TargetType:  p.TargetType,
+		TargetID:    p.TargetID,
+		ViewID:      p.ViewID,
+		CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+		UpdatedAt:   time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := h.applyReminderRequest(c, p, req, &r); err != nil {
+		return err
+	}
+
+	if err := h.db.CreateReminder(r); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if err := h.scheduleReminder(r); err != nil {
+		h.db.DeleteReminder(r.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, reminderResponse(r, p.Title))
+}
+
+func (h Handler) GetReminder(c echo.Context) error {
+	r, err := h.findReminder(c)
+	if err != nil {
+		return err
+	}
+
+	title := ""
+	if p, err := h.findTargetParent(r.WorkspaceID, r.TargetType, r.TargetID, r.ViewID); err == nil {
+		title = p.Title
+	}
+
+	return c.JSON(http.StatusOK, reminderResponse(r, title))
+}
+
+// UpdateReminder changes a reminder's time, channel or message. A reminder
+// that fired is set again for its new time.
+func (h Handler) UpdateReminder(c echo.Context) error {
+	user := c.Get("user").(model.User)
+
+	r, err := h.findReminder(c)
+	if err != nil {
+		return err
+	}
+
+	var req ReminderRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request data"})
+	}
+
+	p, err := h.findTargetParent(r.WorkspaceID, r.TargetType, r.TargetID, r.ViewID)
+	if err != nil {
+		return err
+	}
+	if !h.canWriteTarget(p, user.ID) {
+		return echo.NewHTTPError(http.StatusForbidden, "You do not have permission to set reminders here")
+	}
+
+	// What the request leaves out stays as it was, apart from the message
+	if req.RemindAt == "" && req.MinutesBefore == nil {
+		req.RemindAt, req.MinutesBefore = r.RemindAt, r.MinutesBefore
+	}
+	if req.Timezone == "" {
+		req.Timezone = r.Timezone
+	}
+	if req.Channel == "" {
+		req.Channel = r.Channel
+	}
+	if err := h.applyReminderRequest(c, p, req, &r); err != nil {
+		return err
+	}
+	r.FiredAt = ""
+	r.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	if err := h.db.UpdateReminder(r); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if err := h.scheduleReminder(r); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, reminderResponse(r, p.Title))
+}
+
+func (h Handler) DeleteReminder(c echo.Context) error {
+	r, err := h.findReminder(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.jobs.Cancel(reminderJobKey(r.ID)); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if err := h.db.DeleteReminder(r.ID); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -13,6 +13,23 @@ type NoteCountByDateResponse struct {
 	Count int    `json:"count"`
 }
 
+// parseTimezoneOffset reads the timezoneOffset parameter, the client's offset
+// from UTC in minutes, e.g. 480 for UTC+8. It defaults to 0 for UTC.
+func parseTimezoneOffset(c echo.Context) int {
+	if tz := c.QueryParam("timezoneOffset"); tz != "" {
+		if v, err := strconv.Atoi(tz); err == nil && v >= -720 && v <= 840 {
+			return v
+		}
+	}
+	return 0
+}
+
+// clientNow is the current time on the client's clock, going by the
+// timezoneOffset parameter. Its location is still UTC.
+func clientNow(c echo.Context) time.Time {
+	return time.Now().UTC().Add(time.Duration(parseTimezoneOffset(c)) * time.Minute)
+}
+
 // GetNoteCountsByDate returns the number of notes created per date
 func (h Handler) GetNoteCountsByDate(c echo.Context) error {
 	workspaceId := c.Param("workspaceId")
@@ -28,13 +45,7 @@ func (h Handler) GetNoteCountsByDate(c echo.Context) error {
 		}
 	}
 
-	// Parse timezone offset parameter (in minutes, default to 0 for UTC)
-	timezoneOffset := 0
-	if tz := c.QueryParam("timezoneOffset"); tz != "" {
-		if v, err := strconv.Atoi(tz); err == nil && v >= -720 && v <= 840 {
-			timezoneOffset = v
-		}
-	}
+	timezoneOffset := parseTimezoneOffset(c)
 
 	// Calculate start date
 	startDate := time.Now().UTC().AddDate(0, 0, -days)
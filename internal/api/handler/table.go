@@ -0,0 +1,863 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	maxTableProperties   = 100
+	maxTableTextLength   = 10000
+	maxTableListLength   = 100
+	defaultTablePageSize = 100
+	maxTablePageSize     = 500
+)
+
+// Properties every row has besides those of the view's schema, which can be
+// filtered and sorted by too
+const (
+	tableTitle     = "title"
+	tableCreatedAt = "created_at"
+	tableUpdatedAt = "updated_at"
+)
+
+type CreateTableRowRequest struct {
+	NoteID string                     `json:"note_id" validate:"required"`
+	Values map[string]json.RawMessage `json:"values"`
+}
+
+// UpdateTableRowRequest changes the values of a row's properties. Those left
+// out are kept and those set to null are cleared.
+type UpdateTableRowRequest struct {
+	Values map[string]json.RawMessage `json:"values"`
+}
+
+type TableRowResponse struct {
+	NoteID     string         `json:"note_id"`
+	Title      string         `json:"title"`
+	Visibility string         `json:"visibility"`
+	Values     map[string]any `json:"values"`
+	CreatedAt  string         `json:"created_at"`
+	CreatedBy  string         `json:"created_by"`
+	UpdatedAt  string         `json:"updated_at"`
+	UpdatedBy  string         `json:"updated_by"`
+}
+
+type TableRowsResponse struct {
+	// Total is how many rows match the filters, on all pages
+	Total int                `json:"total"`
+	Rows  []TableRowResponse `json:"rows"`
+}
+
+// TableGroup is the rows with one value of the property they are grouped
+// by. Value is null for the rows without a value, and rows with several
+// values of multi-select and relation properties are in a group for each.
+type TableGroup struct {
+	Value any                `json:"value"`
+	Count int                `json:"count"`
+	Rows  []TableRowResponse `json:"rows"`
+}
+
+type TableGroupsResponse struct {
+	Total  int          `json:"total"`
+	Groups []TableGroup `json:"groups"`
+}
+
+// parseTableSchema reads and validates the data of a table view, which may
+// be empty
+func parseTableSchema(data string) (model.TableViewData, error) {
+	var d model.TableViewData
+	if data == "" {
+		return d, nil
+	}
+	if err := json.Unmarshal([]byte(data), &d); err != nil {
+		return d, errors.New("data must be a JSON object with properties")
+	}
+	if len(d.Properties) > maxTableProperties {
+		return d, fmt.Errorf("a table can have at most %d properties", maxTableProperties)
+	}
+
+	ids := map[string]bool{}
+	for _, p := range d.Properties {
+		switch {
+		case p.ID == "":
+			return d, errors.New("every property needs an id")
+		case p.ID == tableTitle || p.ID == tableCreatedAt || p.ID == tableUpdatedAt:
+			return d, fmt.Errorf("%q can't be the id of a property", p.ID)
+		case ids[p.ID]:
+			return d, fmt.Errorf("there are several properties with the id %q", p.ID)
+		case strings.TrimSpace(p.Name) == "":
+			return d, fmt.Errorf("property %q needs a name", p.ID)
+		}
+		ids[p.ID] = true
+
+		switch p.Type {
+		case model.TablePropertySelect, model.TablePropertyMultiSelect:
+			if len(p.Options) == 0 {
+				return d, fmt.Errorf("%s needs options", p.Name)
+			}
+			names := map[string]bool{}
+			for _, o := range p.Options {
+				if o.Name == "" || names[o.Name] {
+					return d, fmt.Errorf("the options of %s need unique names", p.Name)
+				}
+				names[o.Name] = true
+			}
+		case model.TablePropertyText, model.TablePropertyNumber, model.TablePropertyDate,
+			model.TablePropertyCheckbox, model.TablePropertyUser, model.TablePropertyRelation:
+			if len(p.Options) > 0 {
+				return d, fmt.Errorf("%s can't have options, only select and multi-select properties can", p.Name)
+			}
+		default:
+			return d, fmt.Errorf("%s has an unknown type %q", p.Name, p.Type)
+		}
+	}
+	return d, nil
+}
+
+func optionIndex(p model.TableProperty, name string) int {
+	for i, o := range p.Options {
+		if o.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// tableValue reads the value of a property from JSON. A nil value with no
+// error means no value.
+func tableValue(p model.TableProperty, raw json.RawMessage) (any, error) {
+	if string(raw) == "null" {
+		return nil, nil
+	}
+
+	switch p.Type {
+	case model.TablePropertyText:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, fmt.Errorf("%s must be text", p.Name)
+		}
+		if len(s) > maxTableTextLength {
+			return nil, fmt.Errorf("%s can be at most %d characters long", p.Name, maxTableTextLength)
+		}
+		if s == "" {
+			return nil, nil
+		}
+		return s, nil
+	case model.TablePropertyNumber:
+		var f float64
+		if err := json.Unmarshal(raw, &f); err != nil {
+			return nil, fmt.Errorf("%s must be a number", p.Name)
+		}
+		return f, nil
+	case model.TablePropertySelect:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil || optionIndex(p, s) < 0 {
+			return nil, fmt.Errorf("%s must be one of its options", p.Name)
+		}
+		return s, nil
+	case model.TablePropertyMultiSelect, model.TablePropertyRelation:
+		var list []string
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return nil, fmt.Errorf("%s must be a list", p.Name)
+		}
+		if len(list) > maxTableListLength {
+			return nil, fmt.Errorf("%s can have at most %d values", p.Name, maxTableListLength)
+		}
+		seen := map[string]bool{}
+		values := []string{}
+		for _, s := range list {
+			if p.Type == model.TablePropertyMultiSelect && optionIndex(p, s) < 0 {
+				return nil, fmt.Errorf("%s must be a list of its options", p.Name)
+			}
+			if s != "" && !seen[s] {
+				seen[s] = true
+				values = append(values, s)
+			}
+		}
+		if len(values) == 0 {
+			return nil, nil
+		}
+		return values, nil
+	case model.TablePropertyDate:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, fmt.Errorf("%s must be a date", p.Name)
+		}
+		if _, err := time.Parse(dailyNoteDateLayout, s); err != nil {
+			return nil, fmt.Errorf("%s must be a date as YYYY-MM-DD", p.Name)
+		}
+		return s, nil
+	case model.TablePropertyCheckbox:
+		var b bool
+		if err := json.Unmarshal(raw, &b); err != nil {
+			return nil, fmt.Errorf("%s must be true or false", p.Name)
+		}
+		return b, nil
+	case model.TablePropertyUser:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, fmt.Errorf("%s must be a user ID", p.Name)
+		}
+		if s == "" {
+			return nil, nil
+		}
+		return s, nil
+	}
+	return nil, fmt.Errorf("%s has an unknown type", p.Name)
+}
+
+// checkTableReferences checks that the users and notes a value refers to are
+// in the workspace of the view
+func (h Handler) checkTableReferences(v model.View, p model.TableProperty, value any) error {
+	switch p.Type {
+	case model.TablePropertyUser:
+		if !h.isUserWorkspaceMember(value.(string), v.WorkspaceID) {
+			return fmt.Errorf("%s must be a member of the workspace", p.Name)
+		}
+	case model.TablePropertyRelation:
+		for _, id := range value.([]string) {
+			n, err := h.db.FindNote(model.Note{ID: id})
+			if err != nil || n.WorkspaceID != v.WorkspaceID {
+				return fmt.Errorf("%s must be notes of the workspace", p.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// rowValues reads the stored values of a row, leaving out those of
+// properties that were removed from the schema or no longer fit it
+func rowValues(schema model.TableViewData, data string) map[string]any {
+	var raw map[string]json.RawMessage
+	json.Unmarshal([]byte(data), &raw)
+
+	values := map[string]any{}
+	for _, p := range schema.Properties {
+		if r, ok := raw[p.ID]; ok {
+			if value, err := tableValue(p, r); err == nil && value != nil {
+				values[p.ID] = value
+			}
+		}
+	}
+	return values
+}
+
+// setRowValues validates changes to the values of a row and applies them
+func (h Handler) setRowValues(v model.View, schema model.TableViewData, values map[string]any, changes map[string]json.RawMessage) error {
+	properties := map[string]model.TableProperty{}
+	for _, p := range schema.Properties {
+		properties[p.ID] = p
+	}
+
+	for id, raw := range changes {
+		p, ok := properties[id]
+		if !ok {
+			return fmt.Errorf("the table has no property %q", id)
+		}
+		value, err := tableValue(p, raw)
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			delete(values, id)
+			continue
+		}
+		if err := h.checkTableReferences(v, p, value); err != nil {
+			return err
+		}
+		values[id] = value
+	}
+	return nil
+}
+
+// findTableView returns the table view of the request, and its schema, if
+// the signed-in user can see it
+func (h Handler) findTableView(c echo.Context) (model.View, model.TableViewData, error) {
+	user := c.Get("user").(model.User)
+
+	v, err := h.db.FindView(model.View{ID: c.Param("id"), WorkspaceID: c.Param("workspaceId")})
+	if err != nil || v.WorkspaceID != c.Param("workspaceId") || !h.canSeeView(v, user.ID) {
+		return v, model.TableViewData{}, echo.NewHTTPError(http.StatusNotFound, "View not found")
+	}
+	if v.Type != "table" {
+		return v, model.TableViewData{}, echo.NewHTTPError(http.StatusBadRequest, "Only table views have rows")
+	}
+	schema, err := parseTableSchema(v.Data)
+	if err != nil {
+		return v, schema, echo.NewHTTPError(http.StatusInternalServerError, "Invalid table schema: "+err.Error())
+	}
+	return v, schema, nil
+}
+
+func (h Handler) tableRowResponse(row model.TableRow, note model.Note, values map[string]any) TableRowResponse {
+	res := unnamedTableRowResponse(row, note, values)
+	h.nameTableRowUsers([]TableRowResponse{res}, map[string]string{})
+	return res
+}
+
+// unnamedTableRowResponse is a row's response with the IDs of who created and
+// last changed it in place of their names, so that listings only look up the
+// names of the rows they return
+func unnamedTableRowResponse(row model.TableRow, note model.Note, values map[string]any) TableRowResponse {
+	return TableRowResponse{
+		NoteID:     row.NoteID,
+		Title:      note.Title,
+		Visibility: note.Visibility,
+		Values:     values,
+		CreatedAt:  row.CreatedAt,
+		CreatedBy:  row.CreatedBy,
+		UpdatedAt:  row.UpdatedAt,
+		UpdatedBy:  row.UpdatedBy,
+	}
+}
+
+// nameTableRowUsers replaces the user IDs of unnamed row responses with the
+// users' names, which names caches by ID
+func (h Handler) nameTableRowUsers(rows []TableRowResponse, names map[string]string) {
+	name := func(id string) string {
+		n, ok := names[id]
+		if !ok {
+			n = h.getUserNameByID(id)
+			names[id] = n
+		}
+		return n
+	}
+	for i := range rows {
+		rows[i].CreatedBy = name(rows[i].CreatedBy)
+		rows[i].UpdatedBy = name(rows[i].UpdatedBy)
+	}
+}
+
+// tableFilter is a filter=property:op[:value] query parameter
+type tableFilter struct {
+	property model.TableProperty
+	op       string
+	text     string
+	number   float64
+}
+
+// tableProperty finds a property of the schema by ID, or one of the
+// properties every row has
+func tableProperty(schema model.TableViewData, id string) (model.TableProperty, bool) {
+	switch id {
+	case tableTitle:
+		return model.TableProperty{ID: id, Name: "Title", Type: model.TablePropertyText}, true
+	case tableCreatedAt, tableUpdatedAt:
+		return model.TableProperty{ID: id, Name: id, Type: model.TablePropertyDate}, true
+	}
+	for _, p := range schema.Properties {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return model.TableProperty{}, false
+}
+
+// tableFilterOps are the filter operators each type of property has
+var tableFilterOps = map[string][]string{
+	model.TablePropertyText:        {"eq", "ne", "contains", "not_contains", "empty", "not_empty"},
+	model.TablePropertyNumber:      {"eq", "ne", "gt", "gte", "lt", "lte", "empty", "not_empty"},
+	model.TablePropertySelect:      {"eq", "ne", "empty", "not_empty"},
+	model.TablePropertyMultiSelect: {"contains", "not_contains", "empty", "not_empty"},
+	model.TablePropertyDate:        {"eq", "ne", "gt", "gte", "lt", "lte", "empty", "not_empty"},
+	model.TablePropertyCheckbox:    {"eq", "ne"},
+	model.TablePropertyUser:        {"eq", "ne", "empty", "not_empty"},
+	model.TablePropertyRelation:    {"contains", "not_contains", "empty", "not_empty"},
+}
+
+func parseTableFilter(schema model.TableViewData, s string, userID string) (tableFilter, error) {
+	parts := strings.SplitN(s, ":", 3)
+	if len(parts) < 2 {
+		return tableFilter{}, fmt.Errorf("filter %q must be property:operator:value", s)
+	}
+	p, ok := tableProperty(schema, parts[0])
+	if !ok {
+		return tableFilter{}, fmt.Errorf("the table has no property %q", parts[0])
+	}
+	f := tableFilter{property: p, op: parts[1]}
+
+	known := false
+	for _, op := range tableFilterOps[p.Type] {
+		known = known || op == f.op
+	}
+	if !known {
+		return f, fmt.Errorf("%s can be filtered with %s", p.Name, strings.Join(tableFilterOps[p.Type], ", "))
+	}
+	if f.op == "empty" || f.op == "not_empty" {
+		return f, nil
+	}
+	if len(parts) < 3 {
+		return f, fmt.Errorf("filter %q needs a value", s)
+	}
+	f.text = parts[2]
+
+	switch p.Type {
+	case model.TablePropertyNumber:
+		n, err := strconv.ParseFloat(f.text, 64)
+		if err != nil {
+			return f, fmt.Errorf("%s must be filtered by a number", p.Name)
+		}
+		f.number = n
+	case model.TablePropertyDate:
+		if _, err := time.Parse(dailyNoteDateLayout, f.text); err != nil {
+			return f, fmt.Errorf("%s must be filtered by a date as YYYY-MM-DD", p.Name)
+		}
+	case model.TablePropertyCheckbox:
+		if f.text != "true" && f.text != "false" {
+			return f, fmt.Errorf("%s must be filtered by true or false", p.Name)
+		}
+	case model.TablePropertyUser:
+		if f.text == "me" {
+			f.text = userID
+		}
+	case model.TablePropertyText:
+		f.text = strings.ToLower(f.text)
+	}
+	return f, nil
+}
+
+// tableRowValue is the value of a property of a row, nil if it has none.
+// Unchecked checkboxes are false.
+func tableRowValue(row TableRowResponse, p model.TableProperty) any {
+	switch p.ID {
+	case tableTitle:
+		if row.Title == "" {
+			return nil
+		}
+		return row.Title
+	case tableCreatedAt:
+		return dateOf(row.CreatedAt)
+	case tableUpdatedAt:
+		return dateOf(row.UpdatedAt)
+	}
+	value, ok := row.Values[p.ID]
+	if !ok && p.Type == model.TablePropertyCheckbox {
+		return false
+	}
+	if !ok {
+		return nil
+	}
+	return value
+}
+
+// dateOf is the UTC date of an RFC3339 timestamp or one written by
+// time.Time.String
+func dateOf(timestamp string) any {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		if t, err = time.Parse(viewObjectNoteCreatedLayout, timestamp); err != nil {
+			return nil
+		}
+	}
+	return t.UTC().Format(dailyNoteDateLayout)
+}
+
+func (f tableFilter) matches(row TableRowResponse) bool {
+	value := tableRowValue(row, f.property)
+	switch f.op {
+	case "empty":
+		return value == nil
+	case "not_empty":
+		return value != nil
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return f.op == "ne" || f.op == "not_contains"
+	case bool:
+		return (strconv.FormatBool(v) == f.text) == (f.op == "eq")
+	case float64:
+		return compareOp(f.op, compareFloats(v, f.number))
+	case []string:
+		found := false
+		for _, s := range v {
+			found = found || s == f.text
+		}
+		return found == (f.op == "contains")
+	case string:
+		switch f.property.Type {
+		case model.TablePropertyText:
+			lower := strings.ToLower(v)
+			if f.op == "contains" || f.op == "not_contains" {
+				return strings.Contains(lower, f.text) == (f.op == "contains")
+			}
+			return compareOp(f.op, strings.Compare(lower, f.text))
+		default:
+			return compareOp(f.op, strings.Compare(v, f.text))
+		}
+	}
+	return false
+}
+
+func compareOp(op string, cmp int) bool {
+	switch op {
+	case "eq":
+		return cmp == 0
+	case "ne":
+		return cmp != 0
+	case "gt":
+		return cmp > 0
+	case "gte":
+		return cmp >= 0
+	case "lt":
+		return cmp < 0
+	case "lte":
+		return cmp <= 0
+	}
+	return false
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareTableValues orders two values of a property: select options in
+// the order of the schema, users by name and text regardless of case
+func (h Handler) compareTableValues(p model.TableProperty, a, b any, names map[string]string) int {
+	switch a := a.(type) {
+	case float64:
+		return compareFloats(a, b.(float64))
+	case bool:
+		bb := b.(bool)
+		switch {
+		case a == bb:
+			return 0
+		case !a:
+			return -1
+		}
+		return 1
+	case []string:
+		bb := b.([]string)
+		element := p
+		if p.Type == model.TablePropertyMultiSelect {
+			element.Type = model.TablePropertySelect
+		} else {
+			element.Type = model.TablePropertyText
+		}
+		for i := 0; i < len(a) && i < len(bb); i++ {
+			if cmp := h.compareTableValues(element, a[i], bb[i], names); cmp != 0 {
+				return cmp
+			}
+		}
+		return len(a) - len(bb)
+	case string:
+		bb := b.(string)
+		switch p.Type {
+		case model.TablePropertySelect:
+			return optionIndex(p, a) - optionIndex(p, bb)
+		case model.TablePropertyUser:
+			for _, id := range []string{a, bb} {
+				if _, ok := names[id]; !ok {
+					names[id] = h.getUserNameByID(id)
+				}
+			}
+			return strings.Compare(strings.ToLower(names[a]), strings.ToLower(names[bb]))
+		case model.TablePropertyText:
+			return strings.Compare(strings.ToLower(a), strings.ToLower(bb))
+		}
+		return strings.Compare(a, bb)
+	}
+	return 0
+}
+
+// GetTableRows lists the rows of a table view whose notes the user can see.
+// They can be filtered with filter=property:operator:value parameters,
+// sorted with sort=property,-property and grouped with group_by=property;
+// title, created_at and updated_at can be used as properties too. Rows are
+// in the order they were added unless sorted, and paged, within each group
+// if grouped.
+func (h Handler) GetTableRows(c echo.Context) error {
+	user := c.Get("user").(model.User)
+
+	v, schema, err := h.findTableView(c)
+	if err != nil {
+		return err
+	}
+
+	pageSize := defaultTablePageSize
+	pageNumber := 1
+	if ps := c.QueryParam("pageSize"); ps != "" {
+		if v, err := strconv.Atoi(ps); err == nil && v > 0 && v <= maxTablePageSize {
+			pageSize = v
+		}
+	}
+	if pn := c.QueryParam("pageNumber"); pn != "" {
+		if v, err := strconv.Atoi(pn); err == nil && v > 0 {
+			pageNumber = v
+		}
+	}
+	page := func(rows []TableRowResponse) []TableRowResponse {
+		start := min((pageNumber-1)*pageSize, len(rows))
+		end := min(start+pageSize, len(rows))
+		return rows[start:end]
+	}
+
+	var filters []tableFilter
+	for _, s := range c.QueryParams()["filter"] {
+		f, err := parseTableFilter(schema, s, user.ID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		filters = append(filters, f)
+	}
+
+	type sortKey struct {
+		property model.TableProperty
+		desc     bool
+	}
+	var sorts []sortKey
+	if s := c.QueryParam("sort"); s != "" {
+		for _, id := range strings.Split(s, ",") {
+			desc := strings.HasPrefix(id, "-")
+			p, ok := tableProperty(schema, strings.TrimPrefix(id, "-"))
+			if !ok {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("the table has no property %q", strings.TrimPrefix(id, "-")))
+			}
+			sorts = append(sorts, sortKey{p, desc})
+		}
+	}
+
+	var groupBy *model.TableProperty
+	if s := c.QueryParam("group_by"); s != "" {
+		p, ok := tableProperty(schema, s)
+		if !ok {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("the table has no property %q", s))
+		}
+		groupBy = &p
+	}
+
+	rows, err := h.db.FindTableRows(v.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	notes, err := h.db.FindTableRowNotes(v.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	byID := map[string]model.Note{}
+	for _, n := range notes {
+		byID[n.ID] = n
+	}
+
+	matching := []TableRowResponse{}
+	for _, row := range rows {
+		n, ok := byID[row.NoteID]
+		// Nobody else may read private notes, not even their titles
+		if !ok || (n.Visibility == "private" && n.CreatedBy != user.ID) {
+			continue
+		}
+		res := unnamedTableRowResponse(row, n, rowValues(schema, row.Values))
+		keep := true
+		for _, f := range filters {
+			keep = keep && f.matches(res)
+		}
+		if keep {
+			matching = append(matching, res)
+		}
+	}
+
+	// Rows without a value go last either way
+	names := map[string]string{}
+	sort.SliceStable(matching, func(i, j int) bool {
+		for _, s := range sorts {
+			a, b := tableRowValue(matching[i], s.property), tableRowValue(matching[j], s.property)
+			if a == nil || b == nil {
+				if (a == nil) != (b == nil) {
+					return b == nil
+				}
+				continue
+			}
+			cmp := h.compareTableValues(s.property, a, b, names)
+			if s.desc {
+				cmp = -cmp
+			}
+			if cmp != 0 {
+				return cmp < 0
+			}
+		}
+		return false
+	})
+
+	if groupBy != nil {
+		groups := h.groupTableRows(*groupBy, matching, names)
+		for i := range groups {
+			groups[i].Rows = page(groups[i].Rows)
+			h.nameTableRowUsers(groups[i].Rows, names)
+		}
+		return c.JSON(http.StatusOK, TableGroupsResponse{Total: len(matching), Groups: groups})
+	}
+
+	paged := page(matching)
+	h.nameTableRowUsers(paged, names)
+	return c.JSON(http.StatusOK, TableRowsResponse{Total: len(matching), Rows: paged})
+}
+
+// groupTableRows groups sorted rows by a property. Groups of select
+// properties follow the order of the options, the others are sorted by
+// value, and the group of rows without a value is last.
+func (h Handler) groupTableRows(p model.TableProperty, rows []TableRowResponse, names map[string]string) []TableGroup {
+	element := p
+	switch p.Type {
+	case model.TablePropertyMultiSelect:
+		element.Type = model.TablePropertySelect
+	case model.TablePropertyRelation:
+		element.Type = model.TablePropertyText
+	}
+
+	var groups []TableGroup
+	index := map[string]int{}
+	add := func(value any, row TableRowResponse) {
+		key := fmt.Sprintf("%T:%v", value, value)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, TableGroup{Value: value, Rows: []TableRowResponse{}})
+		}
+		groups[i].Rows = append(groups[i].Rows, row)
+		groups[i].Count++
+	}
+
+	for _, row := range rows {
+		switch value := tableRowValue(row, p).(type) {
+		case []string:
+			for _, s := range value {
+				add(s, row)
+			}
+		default:
+			add(value, row)
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i].Value, groups[j].Value
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return h.compareTableValues(element, a, b, names) < 0
+	})
+	if groups == nil {
+		groups = []TableGroup{}
+	}
+	return groups
+}
+
+// AddTableRow adds a note the user can see to a table view, with values for
+// its properties
+func (h Handler) AddTableRow(c echo.Context) error {
+	user := c.Get("user").(model.User)
+
+	var req CreateTableRowRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "note_id is required")
+	}
+
+	v, schema, err := h.findTableView(c)
+	if err != nil {
+		return err
+	}
+
+	note, err := h.db.FindNote(model.Note{ID: req.NoteID})
+	if err != nil || note.WorkspaceID != v.WorkspaceID || (note.Visibility == "private" && note.CreatedBy != user.ID) {
+		return echo.NewHTTPError(http.StatusNotFound, "Note not found")
+	}
+	if _, err := h.db.FindTableRow(v.ID, note.ID); err == nil {
+		return echo.NewHTTPError(http.StatusConflict, "The note is already in the table")
+	}
+
+	values := map[string]any{}
+	if err := h.setRowValues(v, schema, values, req.Values); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	data, _ := json.Marshal(values)
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	row := model.TableRow{
+		ViewID:    v.ID,
+		NoteID:    note.ID,
+		Values:    string(data),
+		CreatedAt: now,
+		CreatedBy: user.ID,
+		UpdatedAt: now,
+		UpdatedBy: user.ID,
+	}
+	if err := h.db.CreateTableRow(row); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, h.tableRowResponse(row, note, values))
+}
+
+// UpdateTableRow changes the values of the properties of a row
+func (h Handler) UpdateTableRow(c echo.Context) error {
+	user := c.Get("user").(model.User)
+
+	var req UpdateTableRowRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	v, schema, err := h.findTableView(c)
+	if err != nil {
+		return err
+	}
+
+	row, err := h.db.FindTableRow(v.ID, c.Param("noteId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Row not found")
+	}
+	note, err := h.db.FindNote(model.Note{ID: row.NoteID})
+	if err != nil || (note.Visibility == "private" && note.CreatedBy != user.ID) {
+		return echo.NewHTTPError(http.StatusNotFound, "Row not found")
+	}
+
+	values := rowValues(schema, row.Values)
+	if err := h.setRowValues(v, schema, values, req.Values); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	data, _ := json.Marshal(values)
+
+	row.Values = string(data)
+	row.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	row.UpdatedBy = user.ID
+	if err := h.db.UpdateTableRow(row); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, h.tableRowResponse(row, note, values))
+}
+
+// RemoveTableRow takes a note out of a table view, leaving the note as it is
+func (h Handler) RemoveTableRow(c echo.Context) error {
+	v, _, err := h.findTableView(c)
+	if err != nil {
+		return err
+	}
+	if _, err := h.db.FindTableRow(v.ID, c.Param("noteId")); err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Row not found")
+	}
+	if err := h.db.DeleteTableRows(model.TableRowFilter{ViewID: v.ID, NoteID: c.Param("noteId")}); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
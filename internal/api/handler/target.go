@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/collabreef/collabreef/internal/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+// targetParent is the note or view object comments and reminders are attached
+// to, with the visibility they inherit
+type targetParent struct {
+	TargetType  string
+	TargetID    string
+	ViewID      string
+	WorkspaceID string
+	Title       string
+	Visibility  string
+	OwnerID     string
+}
+
+// findTargetParent looks up the note or view object a comment or reminder
+// belongs to, which must be in the workspace
+func (h Handler) findTargetParent(workspaceID string, targetType string, targetID string, viewID string) (targetParent, error) {
+	switch targetType {
+	case model.CommentTargetNote:
+		n, err := h.db.FindNote(model.Note{ID: targetID})
+		if err != nil || n.WorkspaceID != workspaceID {
+			return targetParent{}, echo.NewHTTPError(http.StatusNotFound, "Note not found")
+		}
+		return targetParent{
+			TargetType:  targetType,
+			TargetID:    n.ID,
+			WorkspaceID: n.WorkspaceID,
+			Title:       n.Title,
+			Visibility:  n.Visibility,
+			OwnerID:     n.CreatedBy,
+		}, nil
+	case model.CommentTargetViewObject:
+		v, err := h.db.FindView(model.View{ID: viewID})
+		if err != nil || v.WorkspaceID != workspaceID {
+			return targetParent{}, echo.NewHTTPError(http.StatusNotFound, "View not found")
+		}
+		vo, err := h.db.FindViewObject(model.ViewObject{ID: targetID, ViewID: viewID})
+		if err != nil || vo.ViewID != v.ID {
+			return targetParent{}, echo.NewHTTPError(http.StatusNotFound, "View object not found")
+		}
+		return targetParent{
+			TargetType:  targetType,
+			TargetID:    vo.ID,
+			ViewID:      v.ID,
+			WorkspaceID: v.WorkspaceID,
+			Title:       vo.Name,
+			Visibility:  v.Visibility,
+			OwnerID:     v.CreatedBy,
+		}, nil
+	}
+	return targetParent{}, echo.NewHTTPError(http.StatusBadRequest, "Invalid target")
+}
+
+// canReadTarget reports whether the user can see the parent and so what is
+// attached to it: anyone if it's public, its creator if it's private and members of
+// its workspace otherwise
+func (h Handler) canReadTarget(p targetParent, userID string) bool {
+	switch p.Visibility {
+	case "public":
+		return true
+	case "private":
+		return p.OwnerID == userID
+	}
+	return h.isUserWorkspaceMember(userID, p.WorkspaceID)
+}
+
+// canWriteTarget reports whether the user may comment on the parent or set
+// reminders on it: members of the workspace who can see it
+func (h Handler) canWriteTarget(p targetParent, userID string) bool {
+	if p.Visibility == "private" {
+		return p.OwnerID == userID
+	}
+	return h.isUserWorkspaceMember(userID, p.WorkspaceID)
+}
@@ -0,0 +1,355 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/model"
+	"github.com/collabreef/collabreef/internal/util"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	taskBackfillJobType = "task_backfill"
+	noteSyncJobType     = "note_sync"
+
+	// Notes indexed by the task backfill and the note sync per query
+	taskBackfillBatchSize = 200
+)
+
+type UpdateTaskRequest struct {
+	// Checked sets the task's state; leaving it out toggles it
+	Checked *bool `json:"checked"`
+}
+
+type TaskResponse struct {
+	ID           string   `json:"id"`
+	NoteID       string   `json:"note_id"`
+	NoteTitle    string   `json:"note_title"`
+	Position     int      `json:"position"`
+	Text         string   `json:"text"`
+	Checked      bool     `json:"checked"`
+	DueDate      string   `json:"due_date"`
+	AssigneeID   string   `json:"assignee_id"`
+	AssigneeName string   `json:"assignee_name"`
+	Tags         []string `json:"tags"`
+	UpdatedAt    string   `json:"updated_at"`
+}
+
+func (h Handler) taskResponse(t model.Task, noteTitle string) TaskResponse {
+	tags := []string{}
+	for _, tag := range strings.Split(t.Tags, ",") {
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	res := TaskResponse{
+		ID:         t.ID,
+		NoteID:     t.NoteID,
+		NoteTitle:  noteTitle,
+		Position:   t.Position,
+		Text:       t.Text,
+		Checked:    t.Checked,
+		DueDate:    t.DueDate,
+		AssigneeID: t.AssigneeID,
+		Tags:       tags,
+		UpdatedAt:  t.UpdatedAt,
+	}
+	if t.AssigneeID != "" {
+		res.AssigneeName = h.getUserNameByID(t.AssigneeID)
+	}
+	return res
+}
+
+// taskAssignee picks who a task is for: the first member mentioned with a
+// mention node, or else the first one mentioned as @name
+func taskAssignee(task util.TipTapTask, members []model.User) string {
+	for _, id := range task.Mentions {
+		for _, u := range members {
+			if u.ID == id {
+				return id
+			}
+		}
+	}
+	for _, u := range members {
+		if u.Name != "" && containsMention(task.Text, u.Name) {
+			return u.ID
+		}
+	}
+	return ""
+}
+
+// indexTasks replaces the indexed tasks of a note with the task items in its
+// content. Tasks keep their IDs as long as their text stays the same. Failing
+// to index is logged but never fails the request.
+func (h Handler) indexTasks(n model.Note) {
+	items := util.TipTapTasks(n.Content)
+
+	existing, err := h.db.FindTasks(model.TaskFilter{WorkspaceID: n.WorkspaceID, NoteID: n.ID})
+	if err != nil {
+		log.Printf("Failed to find tasks of note %s: %v", n.ID, err)
+		return
+	}
+	byText := map[string][]model.Task{}
+	for _, t := range existing {
+		byText[t.Text] = append(byText[t.Text], t)
+	}
+
+	var members []model.User
+	if len(items) > 0 {
+		members = h.workspaceMembers(n.WorkspaceID)
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	tasks := make([]model.Task, 0, len(items))
+	for i, item := range items {
+		t := model.Task{
+			ID:          util.NewId(),
+			WorkspaceID: n.WorkspaceID,
+			NoteID:      n.ID,
+			Position:    i,
+			Text:        item.Text,
+			Checked:     item.Checked,
+			DueDate:     item.DueDate,
+			AssigneeID:  taskAssignee(item, members),
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		if len(item.Tags) > 0 {
+			t.Tags = "," + strings.Join(item.Tags, ",") + ","
+		}
+		if same := byText[item.Text]; len(same) > 0 {
+			t.ID = same[0].ID
+			t.CreatedAt = same[0].CreatedAt
+			if same[0].Checked == t.Checked && same[0].Position == t.Position {
+				t.UpdatedAt = same[0].UpdatedAt
+			}
+			byText[item.Text] = same[1:]
+		}
+		tasks = append(tasks, t)
+	}
+
+	tx, err := h.db.Begin(context.Background())
+	if err != nil {
+		log.Printf("Failed to index tasks of note %s: %v", n.ID, err)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := tx.DeleteTasks(n.ID); err != nil {
+		log.Printf("Failed to index tasks of note %s: %v", n.ID, err)
+		return
+	}
+	if err := tx.CreateTasks(tasks); err != nil {
+		log.Printf("Failed to index tasks of note %s: %v", n.ID, err)
+		return
+	}
+	if err := tx.SaveTaskIndex(model.TaskIndex{NoteID: n.ID, WorkspaceID: n.WorkspaceID, NoteUpdatedAt: n.UpdatedAt}); err != nil {
+		log.Printf("Failed to index tasks of note %s: %v", n.ID, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Failed to index tasks of note %s: %v", n.ID, err)
+	}
+}
+
+// syncNotes runs the job that follows up on the notes that changed without
+// the server knowing, i.e. through the Node.js collab service, since they were
+// last indexed, a batch at a time: their tasks are indexed again and the
+// members they mention anew notified
+func (h Handler) syncNotes(ctx context.Context, j model.Job) error {
+	afterID := ""
+	for {
+		notes, err := h.db.FindNotesWithStaleTasks(afterID, taskBackfillBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, n := range notes {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			h.queueMentions(n, n.UpdatedBy)
+			h.indexTasks(n)
+			afterID = n.ID
+		}
+		if len(notes) < taskBackfillBatchSize {
+			return nil
+		}
+	}
+}
+
+// backfillTasks runs the job that indexes the notes whose tasks never were,
+// i.e. those from before tasks were indexed, a batch at a time
+func (h Handler) backfillTasks(ctx context.Context, j model.Job) error {
+	afterID := ""
+	for {
+		notes, err := h.db.FindNotesWithoutTaskIndex(afterID, taskBackfillBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, n := range notes {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			h.indexTasks(n)
+			afterID = n.ID
+		}
+		if len(notes) < taskBackfillBatchSize {
+			return nil
+		}
+	}
+}
+
+// queueTaskBackfill queues the task backfill to run once the job runner starts.
+// It finds nothing to do once every note was indexed.
+func (h Handler) queueTaskBackfill() {
+	if err := h.jobs.Cancel(taskBackfillJobType); err != nil {
+		log.Printf("Failed to queue the task backfill: %v", err)
+		return
+	}
+	if _, err := h.jobs.Enqueue(taskBackfillJobType, taskBackfillJobType, "", time.Time{}); err != nil {
+		log.Printf("Failed to queue the task backfill: %v", err)
+	}
+}
+
+func (h Handler) deleteTasks(noteID string) {
+	if err := h.db.DeleteTasks(noteID); err != nil {
+		log.Printf("Failed to delete tasks: %v", err)
+	}
+}
+
+// GetTasks lists the tasks in the notes of a workspace the user can see. They
+// can be filtered by status (open or done), assignee (a user ID or me), due
+// date and tag.
+func (h Handler) GetTasks(c echo.Context) error {
+	workspaceId := c.Param("workspaceId")
+	user := c.Get("user").(model.User)
+
+	if !h.isUserWorkspaceMember(user.ID, workspaceId) {
+		return echo.NewHTTPError(http.StatusForbidden, "You are not a member of this workspace")
+	}
+
+	filter := model.TaskFilter{
+		WorkspaceID: workspaceId,
+		ViewerID:    user.ID,
+		NoteID:      c.QueryParam("note_id"),
+		Status:      c.QueryParam("status"),
+		AssigneeID:  c.QueryParam("assignee"),
+		DueFrom:     c.QueryParam("due_from"),
+		DueTo:       c.QueryParam("due_to"),
+		Tag:         strings.ToLower(strings.TrimPrefix(c.QueryParam("tag"), "#")),
+		PageSize:    100,
+		PageNumber:  1,
+	}
+
+	if filter.Status != "" && filter.Status != model.TaskStatusOpen && filter.Status != model.TaskStatusDone {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid status, use open or done")
+	}
+	if filter.AssigneeID == "me" {
+		filter.AssigneeID = user.ID
+	}
+	for _, d := range []string{filter.DueFrom, filter.DueTo} {
+		if _, err := time.Parse(dailyNoteDateLayout, d); d != "" && err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid due date, use YYYY-MM-DD")
+		}
+	}
+	if ps := c.QueryParam("pageSize"); ps != "" {
+		if v, err := strconv.Atoi(ps); err == nil && v > 0 && v <= 500 {
+			filter.PageSize = v
+		}
+	}
+	if pn := c.QueryParam("pageNumber"); pn != "" {
+		if v, err := strconv.Atoi(pn); err == nil && v > 0 {
+			filter.PageNumber = v
+		}
+	}
+
+	tasks, err := h.db.FindTasks(filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	titles := map[string]string{}
+	res := make([]TaskResponse, 0, len(tasks))
+	for _, t := range tasks {
+		title, ok := titles[t.NoteID]
+		if !ok {
+			if n, err := h.db.FindNote(model.Note{ID: t.NoteID}); err == nil {
+				title = n.Title
+			}
+			titles[t.NoteID] = title
+		}
+		res = append(res, h.taskResponse(t, title))
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// UpdateTask checks or unchecks a task by changing the task item in its note,
+// the same way editing the note would. Whoever can edit the note may do so.
+func (h Handler) UpdateTask(c echo.Context) error {
+	workspaceId := c.Param("workspaceId")
+	user := c.Get("user").(model.User)
+
+	var req UpdateTaskRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request data"})
+	}
+
+	task, err := h.db.FindTaskByID(c.Param("id"))
+	if err != nil || task.WorkspaceID != workspaceId {
+		return echo.NewHTTPError(http.StatusNotFound, "Task not found")
+	}
+
+	note, err := h.db.FindNote(model.Note{ID: task.NoteID})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Task not found")
+	}
+	if !h.canEditNote(user.ID, note) {
+		return echo.NewHTTPError(http.StatusForbidden, "You do not have permission to change this task")
+	}
+
+	checked := !task.Checked
+	if req.Checked != nil {
+		checked = *req.Checked
+	}
+
+	content, item, err := util.TipTapSetTaskChecked(note.Content, task.Position, checked)
+	if err != nil && !errors.Is(err, util.ErrTaskNotFound) {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if err != nil || item.Text != task.Text {
+		// The note was changed since its tasks were indexed
+		h.indexTasks(note)
+		return echo.NewHTTPError(http.StatusConflict, "The note has changed, reload its tasks")
+	}
+
+	if item.Checked != checked {
+		note.Content = content
+		note.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+		note.UpdatedBy = user.ID
+
+		if err := h.db.UpdateNote(note); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		h.publishNote(c, model.EventNoteUpdated, note)
+		h.pushNoteToCollab(note, user.ID)
+		h.queueMentions(note, user.ID)
+	}
+	h.indexTasks(note)
+
+	updated, err := h.db.FindTaskByID(task.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, h.taskResponse(updated, note.Title))
+}
@@ -0,0 +1,567 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+const maxTimelineDependencies = 100
+
+type RescheduleTimelineItemRequest struct {
+	Date string `json:"date" validate:"required"`
+	// EndDate is the new last day, by default the item keeps its duration
+	EndDate string `json:"end_date"`
+	// Shift moves every item that depends on this one by as many days as
+	// it moved, instead of only those that would start before it ends
+	Shift bool `json:"shift"`
+}
+
+type RescheduleTimelineItemResponse struct {
+	// Items are the items whose dates changed, this one first
+	Items []model.ViewObject `json:"items"`
+}
+
+type TimelineItemSchedule struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Date      string   `json:"date"`
+	EndDate   string   `json:"end_date"`
+	Duration  int      `json:"duration"` // Days
+	Progress  int      `json:"progress"`
+	Color     string   `json:"color,omitempty"`
+	DependsOn []string `json:"depends_on"`
+	// LatestEndDate is the last day the item can end on without delaying the
+	// end of the timeline, and Slack how many days that is after it ends.
+	// Slack is negative if the item ends after a dependent item starts.
+	// Neither is known if dependencies form a cycle.
+	LatestEndDate string `json:"latest_end_date,omitempty"`
+	Slack         *int   `json:"slack"`
+	Critical      bool   `json:"critical"`
+}
+
+// TimelineConflict is a dependency whose successor starts before its
+// predecessor ends
+type TimelineConflict struct {
+	Predecessor string `json:"predecessor"`
+	Successor   string `json:"successor"`
+}
+
+type TimelineResponse struct {
+	Date    string `json:"date,omitempty"`     // First day of the timeline
+	EndDate string `json:"end_date,omitempty"` // Last day of the timeline
+	// Progress is the progress of the items weighted by their durations
+	Progress float64                `json:"progress"`
+	Items    []TimelineItemSchedule `json:"items"`
+	// CriticalPath are the items without slack, by date
+	CriticalPath []string           `json:"critical_path"`
+	Conflicts    []TimelineConflict `json:"conflicts"`
+	// Cycle are the items whose dependencies form a cycle, each one
+	// depending on the one before and the first on the last
+	Cycle []string `json:"cycle,omitempty"`
+}
+
+// timelineItem is a timeline item with validated data, and its first and
+// last days counted from the Unix epoch
+type timelineItem struct {
+	object model.ViewObject
+	data   model.TimelineItemData
+	start  int
+	end    int
+}
+
+func (t timelineItem) duration() int {
+	return t.end - t.start + 1
+}
+
+func timelineDay(date string) (int, error) {
+	d, err := time.Parse(dailyNoteDateLayout, date)
+	if err != nil {
+		return 0, err
+	}
+	return int(d.Unix() / 86400), nil
+}
+
+func timelineDate(day int) string {
+	return time.Unix(int64(day)*86400, 0).UTC().Format(dailyNoteDateLayout)
+}
+
+// parseTimelineItem reads and validates the data of a timeline item
+func parseTimelineItem(vo model.ViewObject) (timelineItem, error) {
+	t := timelineItem{object: vo}
+	if err := json.Unmarshal([]byte(vo.Data), &t.data); err != nil {
+		return t, errors.New("data must be a JSON object")
+	}
+	d := t.data
+
+	var err error
+	if t.start, err = timelineDay(d.Date); err != nil {
+		return t, errors.New("date must be YYYY-MM-DD")
+	}
+	t.end = t.start
+	if d.EndDate != "" {
+		if t.end, err = timelineDay(d.EndDate); err != nil {
+			return t, errors.New("end_date must be YYYY-MM-DD")
+		}
+		if t.end < t.start {
+			return t, errors.New("end_date can't be before date")
+		}
+	}
+
+	if d.Progress < 0 || d.Progress > 100 {
+		return t, errors.New("progress must be between 0 and 100")
+	}
+
+	if len(d.DependsOn) > maxTimelineDependencies {
+		return t, fmt.Errorf("an item can depend on at most %d others", maxTimelineDependencies)
+	}
+	seen := map[string]bool{}
+	for _, id := range d.DependsOn {
+		switch {
+		case id == "" || seen[id]:
+			return t, errors.New("depends_on must be a list of different item IDs")
+		case id == vo.ID:
+			return t, errors.New("an item can't depend on itself")
+		}
+		seen[id] = true
+	}
+	return t, nil
+}
+
+// findTimelineItems returns the valid items of a timeline view by date.
+// Items with invalid data, which the collab service may have stored, are
+// left out.
+func (h Handler) findTimelineItems(viewID string) ([]timelineItem, error) {
+	objects, err := h.findAllViewObjects(viewID, "timeline_item")
+	if err != nil {
+		return nil, err
+	}
+
+	items := []timelineItem{}
+	for _, vo := range objects {
+		if t, err := parseTimelineItem(vo); err == nil {
+			items = append(items, t)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].start != items[j].start {
+			return items[i].start < items[j].start
+		}
+		return items[i].object.ID < items[j].object.ID
+	})
+	return items, nil
+}
+
+// sortTimeline orders items so that each comes after the items it depends
+// on, ignoring dependencies on items that aren't there. If dependencies
+// form a cycle, it returns the IDs of the items in it instead.
+func sortTimeline(items []timelineItem) ([]timelineItem, []string) {
+	index := map[string]int{}
+	for i, t := range items {
+		index[t.object.ID] = i
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(items))
+	order := make([]timelineItem, 0, len(items))
+	var path []string
+	var cycle []string
+
+	var visit func(i int) bool
+	visit = func(i int) bool {
+		state[i] = visiting
+		path = append(path, items[i].object.ID)
+		for _, id := range items[i].data.DependsOn {
+			j, ok := index[id]
+			if !ok {
+				continue
+			}
+			if state[j] == visiting {
+				// Each item on the path depends on the next, so the cycle
+				// is the rest of the path from j, reversed
+				for k := len(path) - 1; k >= 0; k-- {
+					cycle = append(cycle, path[k])
+					if path[k] == id {
+						break
+					}
+				}
+				return false
+			}
+			if state[j] == unvisited && !visit(j) {
+				return false
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = visited
+		order = append(order, items[i])
+		return true
+	}
+
+	for i := range items {
+		if state[i] == unvisited && !visit(i) {
+			return nil, cycle
+		}
+	}
+	return order, nil
+}
+
+// timelineDependents returns the IDs of the items that depend on each item
+func timelineDependents(items []timelineItem) map[string][]string {
+	dependents := map[string][]string{}
+	for _, t := range items {
+		for _, id := range t.data.DependsOn {
+			dependents[id] = append(dependents[id], t.object.ID)
+		}
+	}
+	return dependents
+}
+
+// cyclePath names the items of a cycle, as in A → B → A
+func cyclePath(items []timelineItem, cycle []string) string {
+	names := map[string]string{}
+	for _, t := range items {
+		names[t.object.ID] = t.object.Name
+		if names[t.object.ID] == "" {
+			names[t.object.ID] = t.object.ID
+		}
+	}
+	path := make([]string, 0, len(cycle)+1)
+	for _, id := range cycle {
+		path = append(path, names[id])
+	}
+	path = append(path, names[cycle[0]])
+	return strings.Join(path, " → ")
+}
+
+// validateTimelineItem checks that a timeline item depends only on other
+// items of its view and that its dependencies don't form a cycle
+func (h Handler) validateTimelineItem(vo model.ViewObject) error {
+	item, err := parseTimelineItem(vo)
+	if err != nil {
+		return err
+	}
+
+	items, err := h.findTimelineItems(vo.ViewID)
+	if err != nil {
+		return err
+	}
+	ids := map[string]bool{}
+	others := []timelineItem{item}
+	for _, t := range items {
+		ids[t.object.ID] = true
+		if t.object.ID != vo.ID {
+			others = append(others, t)
+		}
+	}
+	for _, id := range item.data.DependsOn {
+		if !ids[id] {
+			return fmt.Errorf("%s isn't an item of this timeline", id)
+		}
+	}
+
+	if _, cycle := sortTimeline(others); cycle != nil {
+		return fmt.Errorf("dependencies would form a cycle: %s", cyclePath(others, cycle))
+	}
+	return nil
+}
+
+// GetTimeline returns the schedule of the items of a timeline view: where
+// their dependencies conflict, how many days each can slip, and the
+// critical path of those that can't slip without delaying the end
+func (h Handler) GetTimeline(c echo.Context) error {
+	user := c.Get("user").(model.User)
+
+	v, err := h.db.FindView(model.View{ID: c.Param("id"), WorkspaceID: c.Param("workspaceId")})
+	if err != nil || v.WorkspaceID != c.Param("workspaceId") || !h.canSeeView(v, user.ID) {
+		return echo.NewHTTPError(http.StatusNotFound, "View not found")
+	}
+	if v.Type != "timeline" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Only timeline views have a schedule")
+	}
+
+	items, err := h.findTimelineItems(v.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	res := TimelineResponse{
+		Items:        make([]TimelineItemSchedule, len(items)),
+		CriticalPath: []string{},
+		Conflicts:    []TimelineConflict{},
+	}
+	if len(items) == 0 {
+		return c.JSON(http.StatusOK, res)
+	}
+
+	byID := map[string]timelineItem{}
+	first, last := items[0].start, items[0].end
+	done, total := 0, 0
+	for _, t := range items {
+		byID[t.object.ID] = t
+		first, last = min(first, t.start), max(last, t.end)
+		done += t.data.Progress * t.duration()
+		total += t.duration()
+	}
+	res.Date, res.EndDate = timelineDate(first), timelineDate(last)
+	res.Progress = float64(done) / float64(total)
+
+	for _, t := range items {
+		for _, id := range t.data.DependsOn {
+			if p, ok := byID[id]; ok && t.start <= p.end {
+				res.Conflicts = append(res.Conflicts, TimelineConflict{Predecessor: id, Successor: t.object.ID})
+			}
+		}
+	}
+
+	// Go backwards from the end of the timeline to find the latest each
+	// item can end so that every item it precedes can still start on time
+	order, cycle := sortTimeline(items)
+	latestEnd := map[string]int{}
+	if cycle == nil {
+		dependents := timelineDependents(items)
+		for i := len(order) - 1; i >= 0; i-- {
+			t := order[i]
+			end := last
+			for _, id := range dependents[t.object.ID] {
+				s := byID[id]
+				end = min(end, latestEnd[id]-s.duration())
+			}
+			latestEnd[t.object.ID] = end
+		}
+	}
+	res.Cycle = cycle
+
+	for i, t := range items {
+		s := TimelineItemSchedule{
+			ID:        t.object.ID,
+			Name:      t.object.Name,
+			Date:      timelineDate(t.start),
+			EndDate:   timelineDate(t.end),
+			Duration:  t.duration(),
+			Progress:  t.data.Progress,
+			Color:     t.data.Color,
+			DependsOn: t.data.DependsOn,
+		}
+		if s.DependsOn == nil {
+			s.DependsOn = []string{}
+		}
+		if end, ok := latestEnd[t.object.ID]; ok {
+			slack := end - t.end
+			s.LatestEndDate = timelineDate(end)
+			s.Slack = &slack
+			s.Critical = slack <= 0
+			if s.Critical {
+				res.CriticalPath = append(res.CriticalPath, t.object.ID)
+			}
+		}
+		res.Items[i] = s
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// setTimelineDates changes the dates in the data of a timeline item, keeping
+// the rest as it is
+func setTimelineDates(data string, start, end int) string {
+	var fields map[string]any
+	json.Unmarshal([]byte(data), &fields)
+	if fields == nil {
+		fields = map[string]any{}
+	}
+	fields["date"] = timelineDate(start)
+	if end == start {
+		delete(fields, "end_date")
+	} else {
+		fields["end_date"] = timelineDate(end)
+	}
+	b, _ := json.Marshal(fields)
+	return string(b)
+}
+
+// RescheduleTimelineItem moves a timeline item to new dates and pushes the
+// items that depend on it, directly or not, so that none starts before the
+// items it depends on end
+func (h Handler) RescheduleTimelineItem(c echo.Context) error {
+	user := c.Get("user").(model.User)
+
+	var req RescheduleTimelineItemRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "date is required")
+	}
+	start, err := timelineDay(req.Date)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "date must be YYYY-MM-DD")
+	}
+
+	view, err := h.db.FindView(model.View{ID: c.Param("viewId"), WorkspaceID: c.Param("workspaceId")})
+	if err != nil || view.WorkspaceID != c.Param("workspaceId") || !h.canSeeView(view, user.ID) {
+		return echo.NewHTTPError(http.StatusNotFound, "View not found")
+	}
+	if view.Type != "timeline" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Only the items of timeline views can be rescheduled")
+	}
+
+	items, err := h.findTimelineItems(view.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	byID := map[string]*timelineItem{}
+	for i := range items {
+		byID[items[i].object.ID] = &items[i]
+	}
+	item, ok := byID[c.Param("id")]
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, "Timeline item not found")
+	}
+
+	end := start + item.duration() - 1
+	if req.EndDate != "" {
+		if end, err = timelineDay(req.EndDate); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "end_date must be YYYY-MM-DD")
+		}
+		if end < start {
+			return echo.NewHTTPError(http.StatusBadRequest, "end_date can't be before date")
+		}
+	}
+
+	order, cycle := sortTimeline(items)
+	if cycle != nil {
+		return echo.NewHTTPError(http.StatusConflict, "Dependencies form a cycle: "+cyclePath(items, cycle))
+	}
+
+	// The items downstream of the one that moves
+	dependents := timelineDependents(items)
+	downstream := map[string]bool{}
+	queue := []string{item.object.ID}
+	for len(queue) > 0 {
+		for _, id := range dependents[queue[0]] {
+			if !downstream[id] {
+				downstream[id] = true
+				queue = append(queue, id)
+			}
+		}
+		queue = queue[1:]
+	}
+
+	original := map[string][2]int{}
+	for _, t := range items {
+		original[t.object.ID] = [2]int{t.start, t.end}
+	}
+
+	shift := start - item.start
+	item.start, item.end = start, end
+	for _, t := range order {
+		d := byID[t.object.ID]
+		if !downstream[d.object.ID] {
+			continue
+		}
+		if req.Shift {
+			d.start += shift
+			d.end += shift
+		}
+		earliest := d.start
+		for _, id := range d.data.DependsOn {
+			if p, ok := byID[id]; ok {
+				earliest = max(earliest, p.end+1)
+			}
+		}
+		d.end += earliest - d.start
+		d.start = earliest
+	}
+
+	changed := []*timelineItem{item}
+	for _, t := range order {
+		d := byID[t.object.ID]
+		if d != item && original[d.object.ID] != [2]int{d.start, d.end} {
+			changed = append(changed, d)
+		}
+	}
+
+	tx, err := h.db.Begin(context.Background())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	res := RescheduleTimelineItemResponse{Items: []model.ViewObject{}}
+	now := time.Now().UTC().String()
+	for _, d := range changed {
+		vo := d.object
+		vo.Data = setTimelineDates(vo.Data, d.start, d.end)
+		vo.UpdatedAt = now
+		vo.UpdatedBy = user.ID
+		if err := tx.UpdateViewObject(vo); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		res.Items = append(res.Items, vo)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	for _, vo := range res.Items {
+		h.publishViewObject(c, model.EventViewObjectUpdated, view, vo)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// removeTimelineDependency drops a deleted timeline item from the
+// dependencies of the items that depended on it
+func (h Handler) removeTimelineDependency(c echo.Context, view model.View, id string, userID string) {
+	objects, err := h.findAllViewObjects(view.ID, "timeline_item")
+	if err != nil {
+		log.Printf("Failed to find timeline items of view %s: %v", view.ID, err)
+		return
+	}
+
+	for _, vo := range objects {
+		var fields map[string]any
+		if json.Unmarshal([]byte(vo.Data), &fields) != nil {
+			continue
+		}
+		deps, _ := fields["depends_on"].([]any)
+		kept := []any{}
+		for _, dep := range deps {
+			if dep != id {
+				kept = append(kept, dep)
+			}
+		}
+		if len(kept) == len(deps) {
+			continue
+		}
+		if len(kept) == 0 {
+			delete(fields, "depends_on")
+		} else {
+			fields["depends_on"] = kept
+		}
+
+		b, _ := json.Marshal(fields)
+		vo.Data = string(b)
+		vo.UpdatedAt = time.Now().UTC().String()
+		vo.UpdatedBy = userID
+		if err := h.db.UpdateViewObject(vo); err != nil {
+			log.Printf("Failed to remove dependency of timeline item %s: %v", vo.ID, err)
+			continue
+		}
+		h.publishViewObject(c, model.EventViewObjectUpdated, view, vo)
+	}
+}
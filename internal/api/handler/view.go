@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"log"
 	"net/http"
 	"strconv"
 	"time"
@@ -139,9 +140,15 @@ func (h Handler) CreateView(c echo.Context) error {
 
 	// Validate view type
 	switch req.Type {
-	case "map", "calendar", "kanban", "whiteboard", "spreadsheet":
+	case "map", "calendar", "kanban", "whiteboard", "spreadsheet", "table", "timeline":
 	default:
-		return echo.NewHTTPError(http.StatusBadRequest, "View type must be 'map', 'calendar', 'kanban', 'whiteboard', or 'spreadsheet'")
+		return echo.NewHTTPError(http.StatusBadRequest, "View type must be 'map', 'calendar', 'kanban', 'whiteboard', 'spreadsheet', 'table', or 'timeline'")
+	}
+
+	if req.Type == "table" {
+		if _, err := parseTableSchema(req.Data); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid table schema: "+err.Error())
+		}
 	}
 
 	user := c.Get("user").(model.User)
@@ -178,6 +185,8 @@ func (h Handler) CreateView(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
+	h.publishView(c, model.EventViewCreated, v)
+
 	return c.JSON(http.StatusCreated, v)
 }
 
@@ -212,9 +221,9 @@ func (h Handler) UpdateView(c echo.Context) error {
 	// Validate view type if provided
 	if req.Type != "" {
 		switch req.Type {
-		case "map", "calendar", "kanban", "whiteboard", "spreadsheet":
+		case "map", "calendar", "kanban", "whiteboard", "spreadsheet", "table", "timeline":
 		default:
-			return echo.NewHTTPError(http.StatusBadRequest, "View type must be 'map', 'calendar', 'kanban', 'whiteboard', or 'spreadsheet'")
+			return echo.NewHTTPError(http.StatusBadRequest, "View type must be 'map', 'calendar', 'kanban', 'whiteboard', 'spreadsheet', 'table', or 'timeline'")
 		}
 	}
 
@@ -255,12 +264,20 @@ func (h Handler) UpdateView(c echo.Context) error {
 	// Note: Data can be explicitly set to empty string to clear it
 	// So we don't check if it's empty here
 
+	if v.Type == "table" {
+		if _, err := parseTableSchema(v.Data); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid table schema: "+err.Error())
+		}
+	}
+
 	err = h.db.UpdateView(v)
 
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
+	h.publishView(c, model.EventViewUpdated, v)
+
 	return c.JSON(http.StatusOK, v)
 }
 
@@ -273,7 +290,7 @@ func (h Handler) DeleteView(c echo.Context) error {
 
 	view := model.View{WorkspaceID: workspaceId, ID: id}
 
-	_, err := h.db.FindView(view)
+	existingView, err := h.db.FindView(view)
 
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
@@ -283,6 +300,28 @@ func (h Handler) DeleteView(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	h.deleteComments(model.CommentFilter{ViewID: existingView.ID})
+	h.deleteReminders(model.ReminderFilter{ViewID: existingView.ID})
+	if err := h.db.DeleteCalendarFeeds(model.CalendarFeedFilter{ViewID: existingView.ID}); err != nil {
+		log.Printf("Failed to delete calendar feeds: %v", err)
+	}
+	if err := h.db.DeleteKanbanMoves(existingView.ID); err != nil {
+		log.Printf("Failed to delete kanban moves: %v", err)
+	}
+	if err := h.db.DeleteTableRows(model.TableRowFilter{ViewID: existingView.ID}); err != nil {
+		log.Printf("Failed to delete table rows: %v", err)
+	}
+
+	audit(h.db, c, auditEntry{
+		WorkspaceID: workspaceId,
+		Action:      model.AuditActionViewDelete,
+		TargetType:  model.AuditTargetView,
+		TargetID:    id,
+		Before:      map[string]string{"name": existingView.Name, "type": existingView.Type, "visibility": existingView.Visibility},
+	})
+
+	h.publishView(c, model.EventViewDeleted, existingView)
+
 	return c.NoContent(http.StatusNoContent)
 }
 
@@ -477,5 +516,16 @@ func (h Handler) UpdateViewVisibility(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
+	audit(h.db, c, auditEntry{
+		WorkspaceID: workspaceId,
+		Action:      model.AuditActionViewVisibilityUpdate,
+		TargetType:  model.AuditTargetView,
+		TargetID:    v.ID,
+		Before:      map[string]string{"visibility": existingView.Visibility},
+		After:       map[string]string{"visibility": v.Visibility},
+	})
+
+	h.publishView(c, model.EventViewUpdated, v)
+
 	return c.JSON(http.StatusOK, v)
 }
\ No newline at end of file
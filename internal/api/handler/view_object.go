@@ -33,6 +33,11 @@ type GetViewObjectResponse struct {
 	CreatedBy string `json:"created_by"`
 	UpdatedAt string `json:"updated_at"`
 	UpdatedBy string `json:"updated_by"`
+	// CommentCount is the number of comments, including replies
+	CommentCount int64 `json:"comment_count"`
+	// Distance is how far the map object is from the lat and lng of a
+	// spatial query, in meters
+	Distance *float64 `json:"distance,omitempty"`
 }
 
 func (h Handler) GetViewObjects(c echo.Context) error {
@@ -67,24 +72,32 @@ func (h Handler) GetViewObjects(c echo.Context) error {
 		PageNumber: pageNumber,
 	}
 
-	viewObjects, err := h.db.FindViewObjects(filter)
+	viewObjects, distances, err := h.findViewObjects(c, view, filter)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
+	}
+
+	ids := make([]string, len(viewObjects))
+	for i, vo := range viewObjects {
+		ids[i] = vo.ID
 	}
+	counts := h.commentCounts(model.CommentTargetViewObject, ids)
 
 	res := []GetViewObjectResponse{}
 
 	for _, vo := range viewObjects {
 		res = append(res, GetViewObjectResponse{
-			ID:        vo.ID,
-			ViewID:    vo.ViewID,
-			Name:      vo.Name,
-			Type:      vo.Type,
-			Data:      vo.Data,
-			CreatedAt: vo.CreatedAt,
-			CreatedBy: h.getUserNameByID(vo.CreatedBy),
-			UpdatedAt: vo.UpdatedAt,
-			UpdatedBy: h.getUserNameByID(vo.UpdatedBy),
+			ID:           vo.ID,
+			ViewID:       vo.ViewID,
+			Name:         vo.Name,
+			Type:         vo.Type,
+			Data:         vo.Data,
+			CreatedAt:    vo.CreatedAt,
+			CreatedBy:    h.getUserNameByID(vo.CreatedBy),
+			UpdatedAt:    vo.UpdatedAt,
+			UpdatedBy:    h.getUserNameByID(vo.UpdatedBy),
+			CommentCount: counts[vo.ID],
+			Distance:     distancePtr(distances, vo.ID),
 		})
 	}
 
@@ -168,12 +181,31 @@ func (h Handler) CreateViewObject(c echo.Context) error {
 	if view.Type == "calendar" && req.Type != "calendar_slot" {
 		return echo.NewHTTPError(http.StatusBadRequest, "Object type must be 'calendar_slot' for calendar views")
 	}
-	if view.Type == "map" && req.Type != "map_marker" {
-		return echo.NewHTTPError(http.StatusBadRequest, "Object type must be 'map_marker' for map views")
+	if view.Type == "map" && req.Type != "map_marker" && req.Type != "map_line" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Object type must be 'map_marker' or 'map_line' for map views")
 	}
 	if view.Type == "kanban" && req.Type != "kanban_column" {
 		return echo.NewHTTPError(http.StatusBadRequest, "Object type must be 'kanban_column' for kanban views")
 	}
+	if view.Type == "timeline" && req.Type != "timeline_item" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Object type must be 'timeline_item' for timeline views")
+	}
+
+	if req.Type == "calendar_slot" {
+		if _, err := parseCalendarSlot(req.Data); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid calendar slot: "+err.Error())
+		}
+	}
+	if req.Type == "map_line" {
+		if _, err := parseMapObject(req.Type, req.Data); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid map line: "+err.Error())
+		}
+	}
+	if req.Type == "kanban_column" {
+		if _, err := parseKanbanColumn(req.Data); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid kanban column: "+err.Error())
+		}
+	}
 
 	user := c.Get("user").(model.User)
 
@@ -189,12 +221,24 @@ func (h Handler) CreateViewObject(c echo.Context) error {
 		UpdatedBy: user.ID,
 	}
 
+	if vo.Type == "timeline_item" {
+		if err := h.validateTimelineItem(vo); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid timeline item: "+err.Error())
+		}
+	}
+
 	err = h.db.CreateViewObject(vo)
 
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
+	if view.Type == "map" {
+		h.indexMapLocation(vo)
+	}
+
+	h.publishViewObject(c, model.EventViewObjectCreated, view, vo)
+
 	return c.JSON(http.StatusCreated, vo)
 }
 
@@ -242,12 +286,15 @@ func (h Handler) UpdateViewObject(c echo.Context) error {
 	if req.Type != "" && view.Type == "calendar" && req.Type != "calendar_slot" {
 		return echo.NewHTTPError(http.StatusBadRequest, "Object type must be 'calendar_slot' for calendar views")
 	}
-	if req.Type != "" && view.Type == "map" && req.Type != "map_marker" {
-		return echo.NewHTTPError(http.StatusBadRequest, "Object type must be 'map_marker' for map views")
+	if req.Type != "" && view.Type == "map" && req.Type != "map_marker" && req.Type != "map_line" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Object type must be 'map_marker' or 'map_line' for map views")
 	}
 	if req.Type != "" && view.Type == "kanban" && req.Type != "kanban_column" {
 		return echo.NewHTTPError(http.StatusBadRequest, "Object type must be 'kanban_column' for kanban views")
 	}
+	if req.Type != "" && view.Type == "timeline" && req.Type != "timeline_item" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Object type must be 'timeline_item' for timeline views")
+	}
 
 	user := c.Get("user").(model.User)
 
@@ -274,12 +321,41 @@ func (h Handler) UpdateViewObject(c echo.Context) error {
 		vo.Data = existingViewObject.Data
 	}
 
+	// Slots stored before they were validated can still be renamed
+	if vo.Type == "calendar_slot" && (req.Data != "" || req.Type != "") {
+		if _, err := parseCalendarSlot(vo.Data); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid calendar slot: "+err.Error())
+		}
+	}
+	if vo.Type == "map_line" && (req.Data != "" || req.Type != "") {
+		if _, err := parseMapObject(vo.Type, vo.Data); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid map line: "+err.Error())
+		}
+	}
+	if vo.Type == "kanban_column" && (req.Data != "" || req.Type != "") {
+		if _, err := parseKanbanColumn(vo.Data); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid kanban column: "+err.Error())
+		}
+	}
+	if vo.Type == "timeline_item" && (req.Data != "" || req.Type != "") {
+		if err := h.validateTimelineItem(vo); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid timeline item: "+err.Error())
+		}
+	}
+
 	err = h.db.UpdateViewObject(vo)
 
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
+	if view.Type == "map" {
+		h.indexMapLocation(vo)
+	}
+
+	h.publishViewObject(c, model.EventViewObjectUpdated, view, vo)
+	h.rescheduleReminders(vo)
+
 	return c.JSON(http.StatusOK, vo)
 }
 
@@ -293,23 +369,53 @@ func (h Handler) DeleteViewObject(c echo.Context) error {
 	}
 
 	// Verify view exists and belongs to workspace
-	_, err := h.db.FindView(model.View{ID: viewId, WorkspaceID: workspaceId})
+	view, err := h.db.FindView(model.View{ID: viewId, WorkspaceID: workspaceId})
 	if err != nil {
 		return echo.NewHTTPError(http.StatusNotFound, "View not found")
 	}
 
 	viewObject := model.ViewObject{ID: id, ViewID: viewId}
 
-	_, err = h.db.FindViewObject(viewObject)
+	existingViewObject, err := h.db.FindViewObject(viewObject)
 
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
+	var cards []string
+	if existingViewObject.Type == "kanban_column" {
+		links, _ := h.db.FindViewObjectNotes(model.ViewObjectNoteFilter{ViewObjectID: existingViewObject.ID})
+		for _, l := range links {
+			cards = append(cards, l.NoteID)
+		}
+	}
+
 	if err := h.db.DeleteViewObject(viewObject); err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	h.removeKanbanCards(existingViewObject, cards, c.Get("user").(model.User).ID)
+	if existingViewObject.Type == "timeline_item" {
+		h.removeTimelineDependency(c, view, existingViewObject.ID, c.Get("user").(model.User).ID)
+	}
+
+	h.deleteComments(model.CommentFilter{TargetType: model.CommentTargetViewObject, TargetID: existingViewObject.ID})
+	h.deleteReminders(model.ReminderFilter{TargetType: model.ReminderTargetViewObject, TargetID: existingViewObject.ID})
+
+	audit(h.db, c, auditEntry{
+		WorkspaceID: workspaceId,
+		Action:      model.AuditActionViewObjectDelete,
+		TargetType:  model.AuditTargetViewObject,
+		TargetID:    id,
+		Before:      map[string]string{"view_id": viewId, "name": existingViewObject.Name, "type": existingViewObject.Type},
+	})
+
+	if view.Type == "map" {
+		h.deleteMapLocation(existingViewObject.ID)
+	}
+
+	h.publishViewObject(c, model.EventViewObjectDeleted, view, existingViewObject)
+
 	return c.NoContent(http.StatusNoContent)
 }
 
@@ -375,24 +481,32 @@ func (h Handler) GetPublicViewObjects(c echo.Context) error {
 		PageNumber: pageNumber,
 	}
 
-	viewObjects, err := h.db.FindViewObjects(filter)
+	viewObjects, distances, err := h.findViewObjects(c, view, filter)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
+	}
+
+	ids := make([]string, len(viewObjects))
+	for i, vo := range viewObjects {
+		ids[i] = vo.ID
 	}
+	counts := h.commentCounts(model.CommentTargetViewObject, ids)
 
 	res := []GetViewObjectResponse{}
 
 	for _, vo := range viewObjects {
 		res = append(res, GetViewObjectResponse{
-			ID:        vo.ID,
-			ViewID:    vo.ViewID,
-			Name:      vo.Name,
-			Type:      vo.Type,
-			Data:      vo.Data,
-			CreatedAt: vo.CreatedAt,
-			CreatedBy: h.getUserNameByID(vo.CreatedBy),
-			UpdatedAt: vo.UpdatedAt,
-			UpdatedBy: h.getUserNameByID(vo.UpdatedBy),
+			ID:           vo.ID,
+			ViewID:       vo.ViewID,
+			Name:         vo.Name,
+			Type:         vo.Type,
+			Data:         vo.Data,
+			CreatedAt:    vo.CreatedAt,
+			CreatedBy:    h.getUserNameByID(vo.CreatedBy),
+			UpdatedAt:    vo.UpdatedAt,
+			UpdatedBy:    h.getUserNameByID(vo.UpdatedBy),
+			CommentCount: counts[vo.ID],
+			Distance:     distancePtr(distances, vo.ID),
 		})
 	}
 
@@ -17,6 +17,9 @@ type ViewObjectNoteResponse struct {
 	NoteID       string `json:"note_id"`
 	CreatedAt    string `json:"created_at"`
 	CreatedBy    string `json:"created_by"`
+	// Position and CompletedAt are those of kanban cards
+	Position    string `json:"position,omitempty"`
+	CompletedAt string `json:"completed_at,omitempty"`
 }
 
 // GetNotesForViewObject returns all notes associated with a view object
@@ -93,7 +96,7 @@ func (h Handler) AddNoteToViewObject(c echo.Context) error {
 	}
 
 	// Verify view object exists and belongs to view
-	_, err = h.db.FindViewObject(model.ViewObject{ID: viewObjectId, ViewID: viewId})
+	viewObject, err := h.db.FindViewObject(model.ViewObject{ID: viewObjectId, ViewID: viewId})
 	if err != nil {
 		return echo.NewHTTPError(http.StatusNotFound, "View object not found")
 	}
@@ -113,16 +116,36 @@ func (h Handler) AddNoteToViewObject(c echo.Context) error {
 		CreatedBy:    user.ID,
 	}
 
-	err = h.db.AddNoteToViewObject(viewObjectNote)
-	if err != nil {
+	if viewObject.Type == "kanban_column" {
+		if viewObjectNote, err = h.addKanbanCard(viewObject, viewObjectNote); err != nil {
+			return err
+		}
+		h.applyColumnRules(c, user, viewObject, note.ID)
+	} else if err := h.db.AddNoteToViewObject(viewObjectNote); err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	// Nobody else may read private notes, not even their titles
+	title := note.Title
+	if title == "" || note.Visibility == "private" {
+		title = "a note"
+	}
+	notify(h.db, user, model.Notification{
+		UserID:      viewObject.CreatedBy,
+		WorkspaceID: workspaceId,
+		Type:        model.NotificationNoteAttached,
+		TargetType:  model.NotificationTargetViewObject,
+		TargetID:    viewObject.ID,
+		Message:     user.Name + " attached " + title + " to " + viewObject.Name,
+	})
+
 	return c.JSON(http.StatusCreated, ViewObjectNoteResponse{
 		ViewObjectID: viewObjectNote.ViewObjectID,
 		NoteID:       viewObjectNote.NoteID,
 		CreatedAt:    viewObjectNote.CreatedAt,
 		CreatedBy:    h.getUserNameByID(viewObjectNote.CreatedBy),
+		Position:     viewObjectNote.Position,
+		CompletedAt:  viewObjectNote.CompletedAt,
 	})
 }
 
@@ -156,7 +179,7 @@ func (h Handler) RemoveNoteFromViewObject(c echo.Context) error {
 	}
 
 	// Verify view object exists and belongs to view
-	_, err = h.db.FindViewObject(model.ViewObject{ID: viewObjectId, ViewID: viewId})
+	viewObject, err := h.db.FindViewObject(model.ViewObject{ID: viewObjectId, ViewID: viewId})
 	if err != nil {
 		return echo.NewHTTPError(http.StatusNotFound, "View object not found")
 	}
@@ -166,11 +189,20 @@ func (h Handler) RemoveNoteFromViewObject(c echo.Context) error {
 		NoteID:       noteId,
 	}
 
+	var removed []string
+	if viewObject.Type == "kanban_column" {
+		if cards, err := h.db.FindViewObjectNotes(model.ViewObjectNoteFilter{ViewObjectID: viewObjectId, NoteID: noteId}); err == nil && len(cards) > 0 {
+			removed = []string{noteId}
+		}
+	}
+
 	err = h.db.RemoveNoteFromViewObject(viewObjectNote)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	h.removeKanbanCards(viewObject, removed, c.Get("user").(model.User).ID)
+
 	return c.NoContent(http.StatusNoContent)
 }
 
@@ -0,0 +1,449 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/config"
+	"github.com/collabreef/collabreef/internal/model"
+	"github.com/collabreef/collabreef/internal/util"
+
+	"github.com/labstack/echo/v4"
+)
+
+const webhookSecretPrefix = "whsec_"
+
+type CreateWebhookRequest struct {
+	Name    string   `json:"name" validate:"required"`
+	URL     string   `json:"url" validate:"required"`
+	Events  []string `json:"events"`
+	Enabled *bool    `json:"enabled"`
+}
+
+type UpdateWebhookRequest struct {
+	Name    string   `json:"name" validate:"required"`
+	URL     string   `json:"url" validate:"required"`
+	Events  []string `json:"events"`
+	Enabled bool     `json:"enabled"`
+}
+
+type WebhookResponse struct {
+	ID          string   `json:"id"`
+	WorkspaceID string   `json:"workspace_id"`
+	Name        string   `json:"name"`
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Enabled     bool     `json:"enabled"`
+	CreatedAt   string   `json:"created_at"`
+	CreatedBy   string   `json:"created_by"`
+	UpdatedAt   string   `json:"updated_at"`
+	UpdatedBy   string   `json:"updated_by"`
+}
+
+type WebhookSecretResponse struct {
+	WebhookResponse
+	Secret string `json:"secret"`
+}
+
+func toWebhookResponse(w model.Webhook) WebhookResponse {
+	return WebhookResponse{
+		ID:          w.ID,
+		WorkspaceID: w.WorkspaceID,
+		Name:        w.Name,
+		URL:         w.URL,
+		Events:      strings.Split(w.Events, ","),
+		Enabled:     w.Enabled,
+		CreatedAt:   w.CreatedAt,
+		CreatedBy:   w.CreatedBy,
+		UpdatedAt:   w.UpdatedAt,
+		UpdatedBy:   w.UpdatedBy,
+	}
+}
+
+// webhookEvents validates an event filter and joins it for storage, an empty
+// filter subscribes to everything
+func webhookEvents(events []string) (string, error) {
+	if len(events) == 0 {
+		return "*", nil
+	}
+
+	seen := map[string]struct{}{}
+	var filters []string
+
+	for _, e := range events {
+		e = strings.TrimSpace(e)
+		if !model.IsValidWebhookEventFilter(e) {
+			return "", echo.NewHTTPError(http.StatusBadRequest, "Invalid event: "+e)
+		}
+		if _, ok := seen[e]; ok {
+			continue
+		}
+		seen[e] = struct{}{}
+		filters = append(filters, e)
+	}
+
+	return strings.Join(filters, ","), nil
+}
+
+// newWebhookSecret returns a signing secret and its encrypted form for storage
+func newWebhookSecret() (string, string, error) {
+	token, _, err := util.GenerateToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	secret := webhookSecretPrefix + token
+
+	encrypted, err := util.Encrypt(secret, config.C.GetString(config.APP_SECRET))
+	if err != nil {
+		return "", "", err
+	}
+
+	return secret, encrypted, nil
+}
+
+// checkWebhookAccess allows workspace owners and admins through, webhooks can
+// leak every change in the workspace so members can't manage them
+func (h Handler) checkWebhookAccess(c echo.Context, workspaceId string) error {
+	if h.webhooks == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Webhooks are disabled")
+	}
+
+	user := c.Get("user").(model.User)
+
+	members, err := h.db.FindWorkspaceUsers(model.WorkspaceUserFilter{
+		WorkspaceID: workspaceId,
+		UserID:      user.ID,
+	})
+	if err != nil || len(members) == 0 {
+		return echo.NewHTTPError(http.StatusForbidden, "You are not a member of this workspace")
+	}
+
+	if members[0].Role != model.WorkspaceUserRoleOwner && members[0].Role != model.WorkspaceUserRoleAdmin {
+		return echo.NewHTTPError(http.StatusForbidden, "Only workspace owner or admin can manage webhooks")
+	}
+
+	return nil
+}
+
+// findWorkspaceWebhook loads a webhook after checking access to its workspace
+func (h Handler) findWorkspaceWebhook(c echo.Context) (model.Webhook, error) {
+	workspaceId := c.Param("workspaceId")
+	id := c.Param("id")
+	if workspaceId == "" || id == "" {
+		return model.Webhook{}, echo.NewHTTPError(http.StatusBadRequest, "workspace id and webhook id are required")
+	}
+
+	if err := h.checkWebhookAccess(c, workspaceId); err != nil {
+		return model.Webhook{}, err
+	}
+
+	w, err := h.db.FindWebhookByID(id)
+	if err != nil || w.WorkspaceID != workspaceId {
+		return model.Webhook{}, echo.NewHTTPError(http.StatusNotFound, "Webhook not found")
+	}
+
+	return w, nil
+}
+
+func (h Handler) GetWebhooks(c echo.Context) error {
+	workspaceId := c.Param("workspaceId")
+	if workspaceId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "workspace id is required")
+	}
+
+	if err := h.checkWebhookAccess(c, workspaceId); err != nil {
+		return err
+	}
+
+	hooks, err := h.db.FindWebhooks(model.WebhookFilter{WorkspaceID: workspaceId})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	res := make([]WebhookResponse, 0, len(hooks))
+	for _, w := range hooks {
+		res = append(res, toWebhookResponse(w))
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h Handler) GetWebhook(c echo.Context) error {
+	w, err := h.findWorkspaceWebhook(c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, toWebhookResponse(w))
+}
+
+func (h Handler) CreateWebhook(c echo.Context) error {
+	workspaceId := c.Param("workspaceId")
+	if workspaceId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "workspace id is required")
+	}
+
+	var req CreateWebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Validation failed: " + err.Error(),
+		})
+	}
+
+	if err := h.checkWebhookAccess(c, workspaceId); err != nil {
+		return err
+	}
+
+	events, err := webhookEvents(req.Events)
+	if err != nil {
+		return err
+	}
+
+	if err := h.webhooks.CheckURL(req.URL); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid url: "+err.Error())
+	}
+
+	secret, encrypted, err := newWebhookSecret()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to generate webhook secret")
+	}
+
+	user := c.Get("user").(model.User)
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	w := model.Webhook{
+		ID:          util.NewId(),
+		WorkspaceID: workspaceId,
+		Name:        req.Name,
+		URL:         req.URL,
+		Secret:      encrypted,
+		Events:      events,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+		CreatedAt:   now,
+		CreatedBy:   user.ID,
+		UpdatedAt:   now,
+		UpdatedBy:   user.ID,
+	}
+
+	if err := h.db.CreateWebhook(w); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	audit(h.db, c, auditEntry{
+		WorkspaceID: workspaceId,
+		Action:      model.AuditActionWebhookCreate,
+		TargetType:  model.AuditTargetWebhook,
+		TargetID:    w.ID,
+		After:       toWebhookResponse(w),
+	})
+
+	// The secret is only returned here and when it is rotated
+	return c.JSON(http.StatusCreated, WebhookSecretResponse{
+		WebhookResponse: toWebhookResponse(w),
+		Secret:          secret,
+	})
+}
+
+func (h Handler) UpdateWebhook(c echo.Context) error {
+	var req UpdateWebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Validation failed: " + err.Error(),
+		})
+	}
+
+	w, err := h.findWorkspaceWebhook(c)
+	if err != nil {
+		return err
+	}
+
+	events, err := webhookEvents(req.Events)
+	if err != nil {
+		return err
+	}
+
+	if err := h.webhooks.CheckURL(req.URL); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid url: "+err.Error())
+	}
+
+	before := toWebhookResponse(w)
+
+	w.Name = req.Name
+	w.URL = req.URL
+	w.Events = events
+	w.Enabled = req.Enabled
+	w.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	w.UpdatedBy = c.Get("user").(model.User).ID
+
+	if err := h.db.UpdateWebhook(w); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	audit(h.db, c, auditEntry{
+		WorkspaceID: w.WorkspaceID,
+		Action:      model.AuditActionWebhookUpdate,
+		TargetType:  model.AuditTargetWebhook,
+		TargetID:    w.ID,
+		Before:      before,
+		After:       toWebhookResponse(w),
+	})
+
+	return c.JSON(http.StatusOK, toWebhookResponse(w))
+}
+
+func (h Handler) RotateWebhookSecret(c echo.Context) error {
+	w, err := h.findWorkspaceWebhook(c)
+	if err != nil {
+		return err
+	}
+
+	secret, encrypted, err := newWebhookSecret()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to generate webhook secret")
+	}
+
+	w.Secret = encrypted
+	w.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	w.UpdatedBy = c.Get("user").(model.User).ID
+
+	if err := h.db.UpdateWebhook(w); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	audit(h.db, c, auditEntry{
+		WorkspaceID: w.WorkspaceID,
+		Action:      model.AuditActionWebhookSecretRotate,
+		TargetType:  model.AuditTargetWebhook,
+		TargetID:    w.ID,
+	})
+
+	return c.JSON(http.StatusOK, WebhookSecretResponse{
+		WebhookResponse: toWebhookResponse(w),
+		Secret:          secret,
+	})
+}
+
+func (h Handler) DeleteWebhook(c echo.Context) error {
+	w, err := h.findWorkspaceWebhook(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.db.DeleteWebhook(w.ID); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	audit(h.db, c, auditEntry{
+		WorkspaceID: w.WorkspaceID,
+		Action:      model.AuditActionWebhookDelete,
+		TargetType:  model.AuditTargetWebhook,
+		TargetID:    w.ID,
+		Before:      toWebhookResponse(w),
+	})
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// PingWebhook queues a ping event so receivers can be tested without waiting
+// for a real change
+func (h Handler) PingWebhook(c echo.Context) error {
+	w, err := h.findWorkspaceWebhook(c)
+	if err != nil {
+		return err
+	}
+
+	if !w.Enabled {
+		return echo.NewHTTPError(http.StatusConflict, "Webhook is disabled")
+	}
+
+	user := c.Get("user").(model.User)
+
+	delivery, err := h.webhooks.Ping(w, &model.EventUser{ID: user.ID, Name: user.Name})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusAccepted, delivery)
+}
+
+func (h Handler) GetWebhookDeliveries(c echo.Context) error {
+	w, err := h.findWorkspaceWebhook(c)
+	if err != nil {
+		return err
+	}
+
+	pageSize := 20
+	pageNumber := 1
+	if ps := c.QueryParam("pageSize"); ps != "" {
+		if v, err := strconv.Atoi(ps); err == nil && v > 0 {
+			pageSize = v
+		}
+	}
+	if pn := c.QueryParam("pageNumber"); pn != "" {
+		if v, err := strconv.Atoi(pn); err == nil && v > 0 {
+			pageNumber = v
+		}
+	}
+
+	deliveries, err := h.db.FindWebhookDeliveries(model.WebhookDeliveryFilter{
+		WebhookID:  w.ID,
+		Status:     c.QueryParam("status"),
+		PageSize:   pageSize,
+		PageNumber: pageNumber,
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, deliveries)
+}
+
+func (h Handler) GetWebhookDelivery(c echo.Context) error {
+	w, err := h.findWorkspaceWebhook(c)
+	if err != nil {
+		return err
+	}
+
+	delivery, err := h.db.FindWebhookDeliveryByID(c.Param("deliveryId"))
+	if err != nil || delivery.WebhookID != w.ID {
+		return echo.NewHTTPError(http.StatusNotFound, "Delivery not found")
+	}
+
+	return c.JSON(http.StatusOK, delivery)
+}
+
+// RedeliverWebhookDelivery replays the payload of an earlier delivery, signed
+// with the current secret
+func (h Handler) RedeliverWebhookDelivery(c echo.Context) error {
+	w, err := h.findWorkspaceWebhook(c)
+	if err != nil {
+		return err
+	}
+
+	previous, err := h.db.FindWebhookDeliveryByID(c.Param("deliveryId"))
+	if err != nil || previous.WebhookID != w.ID {
+		return echo.NewHTTPError(http.StatusNotFound, "Delivery not found")
+	}
+
+	if !w.Enabled {
+		return echo.NewHTTPError(http.StatusConflict, "Webhook is disabled")
+	}
+
+	delivery, err := h.webhooks.Redeliver(w, previous)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusAccepted, delivery)
+}
@@ -6,9 +6,28 @@ import (
 	"net/http/httputil"
 
 	"github.com/labstack/echo/v4"
+	"github.com/collabreef/collabreef/internal/collab"
+	"github.com/collabreef/collabreef/internal/config"
 	"github.com/collabreef/collabreef/internal/model"
+	"github.com/collabreef/collabreef/internal/presence"
 )
 
+// connectCollab hands a WebSocket connection to the in-process collab server,
+// or proxies it to the collab service with a token granting the session
+func (h *Handler) connectCollab(c echo.Context, session collab.Session) error {
+	if h.collab != nil {
+		h.collab.ServeWebSocket(c.Response(), c.Request(), session)
+		return nil
+	}
+
+	token, err := collab.SignToken([]byte(config.C.GetString(config.COLLAB_SECRET)), session, collab.TokenTTL)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to sign collab token")
+	}
+
+	return h.proxyToCollab(c, map[string]string{collab.TokenHeader: token})
+}
+
 // proxyToCollab reverse-proxies the request to the collab service with custom headers
 func (h *Handler) proxyToCollab(c echo.Context, headers map[string]string) error {
 	proxy := httputil.NewSingleHostReverseProxy(h.collabURL)
@@ -47,12 +66,15 @@ func (h *Handler) HandleViewWebSocket(c echo.Context) error {
 
 	log.Printf("WebSocket proxy: user=%s, viewId=%s, type=%s", user.ID, viewID, view.Type)
 
-	// Reverse proxy to collab service with user info headers
-	return h.proxyToCollab(c, map[string]string{
-		"X-User-ID":   user.ID,
-		"X-User-Name": user.Name,
-		"X-View-Type": view.Type,
-		"X-Read-Only": "false",
+	defer h.presence.Join(
+		presence.Target{Kind: presence.KindView, ID: view.ID, WorkspaceID: view.WorkspaceID},
+		presence.Connection{UserID: user.ID, UserName: user.Name},
+	)()
+
+	return h.connectCollab(c, collab.Session{
+		UserID:    user.ID,
+		UserName:  user.Name,
+		Document:  collab.ViewDocumentName(view.Type, view.ID),
 	})
 }
 
@@ -95,17 +117,25 @@ func (h *Handler) HandlePublicViewWebSocket(c echo.Context) error {
 
 	userID := "anonymous"
 	userName := "Anonymous"
+	viewer := presence.Connection{ReadOnly: true}
 	if user != nil {
 		userID = user.ID
 		userName = user.Name
+		viewer.UserID = user.ID
+		viewer.UserName = user.Name
 	}
 
 	log.Printf("Public WebSocket proxy: user=%s, viewId=%s, type=%s", userID, viewID, view.Type)
 
-	return h.proxyToCollab(c, map[string]string{
-		"X-User-ID":   userID,
-		"X-User-Name": userName,
-		"X-View-Type": view.Type,
-		"X-Read-Only": "true",
+	defer h.presence.Join(
+		presence.Target{Kind: presence.KindView, ID: view.ID, WorkspaceID: view.WorkspaceID},
+		viewer,
+	)()
+
+	return h.connectCollab(c, collab.Session{
+		UserID:    userID,
+		UserName:  userName,
+		ReadOnly:  true,
+		Document:  collab.ViewDocumentName(view.Type, view.ID),
 	})
 }
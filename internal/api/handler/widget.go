@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"encoding/json"
 	"net/http"
 	"strconv"
 	"time"
@@ -167,6 +168,26 @@ func (h Handler) GetWidgetPath(c echo.Context) error {
 	return c.JSON(http.StatusOK, path)
 }
 
+// checkWidgetConfig makes sure what a widget's config refers to is in the
+// workspace. Other settings are left to the widget.
+func (h Handler) checkWidgetConfig(workspaceID string, widgetType string, config string) error {
+	if config == "" || widgetType != string(model.WidgetTypeNoteForm) {
+		return nil
+	}
+
+	var cfg model.NoteFormWidgetConfig
+	if err := json.Unmarshal([]byte(config), &cfg); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid widget config")
+	}
+	if cfg.TemplateID != "" {
+		t, err := h.db.FindNoteTemplateByID(cfg.TemplateID)
+		if err != nil || t.WorkspaceID != workspaceID {
+			return echo.NewHTTPError(http.StatusBadRequest, "Template not found")
+		}
+	}
+	return nil
+}
+
 func (h Handler) CreateWidget(c echo.Context) error {
 	workspaceId := c.Param("workspaceId")
 	if workspaceId == "" {
@@ -205,6 +226,10 @@ func (h Handler) CreateWidget(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid widget type")
 	}
 
+	if err := h.checkWidgetConfig(workspaceId, req.Type, req.Config); err != nil {
+		return err
+	}
+
 	var w model.Widget
 	user := c.Get("user").(model.User)
 
@@ -225,6 +250,8 @@ func (h Handler) CreateWidget(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
+	h.publish(c, workspaceId, model.EventWidgetCreated, w)
+
 	return c.JSON(http.StatusCreated, w)
 }
 
@@ -278,6 +305,10 @@ func (h Handler) UpdateWidget(c echo.Context) error {
 	if w.ParentID == "" {
 		w.ParentID = existingWidget.ParentID
 	}
+
+	if err := h.checkWidgetConfig(workspaceId, w.Type, req.Config); err != nil {
+		return err
+	}
 	w.CreatedAt = existingWidget.CreatedAt
 	w.CreatedBy = existingWidget.CreatedBy
 	w.UpdatedAt = time.Now().UTC().String()
@@ -289,6 +320,8 @@ func (h Handler) UpdateWidget(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
+	h.publish(c, workspaceId, model.EventWidgetUpdated, w)
+
 	return c.JSON(http.StatusOK, w)
 }
 
@@ -301,7 +334,7 @@ func (h Handler) DeleteWidget(c echo.Context) error {
 	widget := model.Widget{WorkspaceID: workspaceId, ID: id}
 
 	// Verify widget exists
-	_, err := h.db.FindWidget(widget)
+	existingWidget, err := h.db.FindWidget(widget)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
@@ -311,5 +344,15 @@ func (h Handler) DeleteWidget(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	audit(h.db, c, auditEntry{
+		WorkspaceID: workspaceId,
+		Action:      model.AuditActionWidgetDelete,
+		TargetType:  model.AuditTargetWidget,
+		TargetID:    id,
+		Before:      map[string]string{"type": existingWidget.Type},
+	})
+
+	h.publish(c, workspaceId, model.EventWidgetDeleted, map[string]string{"id": id, "type": existingWidget.Type})
+
 	return c.NoContent(http.StatusNoContent)
 }
@@ -165,10 +165,23 @@ func (h Handler) DeleteWorkspace(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusForbidden, "Only the workspace owner can delete the workspace.")
 	}
 
+	workspace, err := db.FindWorkspaceByID(id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
 	if err := db.DeleteWorkspace(id); err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	audit(db, c, auditEntry{
+		WorkspaceID: id,
+		Action:      model.AuditActionWorkspaceDelete,
+		TargetType:  model.AuditTargetWorkspace,
+		TargetID:    id,
+		Before:      map[string]any{"name": workspace.Name, "members": len(users)},
+	})
+
 	if err := db.Commit(); err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
@@ -222,6 +235,11 @@ func (h Handler) UpdateWorkspace(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusForbidden, "Only the workspace owner can update the workspace.")
 	}
 
+	existingWorkspace, err := db.FindWorkspaceByID(id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
 	workspace := model.Workspace{
 		ID:        id,
 		Name:      req.Name,
@@ -233,6 +251,15 @@ func (h Handler) UpdateWorkspace(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	audit(db, c, auditEntry{
+		WorkspaceID: id,
+		Action:      model.AuditActionWorkspaceUpdate,
+		TargetType:  model.AuditTargetWorkspace,
+		TargetID:    id,
+		Before:      map[string]string{"name": existingWorkspace.Name},
+		After:       map[string]string{"name": workspace.Name},
+	})
+
 	if err := db.Commit(); err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
@@ -345,13 +372,32 @@ func (h Handler) InviteMember(c echo.Context) error {
 	}
 
 	// Find user by email
-	users, err := db.FindUsers(model.UserFilter{NameOrEmail: req.Email})
+	users, err := db.FindUsers(model.UserFilter{Email: req.Email})
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	// No account yet: send a pending invitation, accepted once the invitee verifies
+	// the address they sign up with or follows the link in the email
 	if len(users) == 0 {
-		return echo.NewHTTPError(http.StatusNotFound, "User not found with this email")
+		invitation, err := h.inviteByEmail(db, workspaceId, req.Email, req.Role, currentUser)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		audit(db, c, auditEntry{
+			WorkspaceID: workspaceId,
+			Action:      model.AuditActionInvitationCreate,
+			TargetType:  model.AuditTargetWorkspaceInvitation,
+			TargetID:    invitation.ID,
+			After:       map[string]string{"email": invitation.Email, "role": invitation.Role},
+		})
+
+		if err := db.Commit(); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		return c.JSON(http.StatusAccepted, invitation)
 	}
 
 	invitedUser := users[0]
@@ -382,18 +428,41 @@ func (h Handler) InviteMember(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	audit(db, c, auditEntry{
+		WorkspaceID: workspaceId,
+		Action:      model.AuditActionMemberAdd,
+		TargetType:  model.AuditTargetWorkspaceMember,
+		TargetID:    invitedUser.ID,
+		After:       map[string]string{"email": invitedUser.Email, "role": workspaceUser.Role},
+	})
+
+	if workspace, err := db.FindWorkspaceByID(workspaceId); err == nil {
+		notify(db, currentUser, model.Notification{
+			UserID:      invitedUser.ID,
+			WorkspaceID: workspaceId,
+			Type:        model.NotificationMemberAdded,
+			TargetType:  model.NotificationTargetWorkspace,
+			TargetID:    workspaceId,
+			Message:     currentUser.Name + " added you to " + workspace.Name + " as " + workspaceUser.Role,
+		})
+	}
+
 	if err := db.Commit(); err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	return c.JSON(http.StatusCreated, WorkspaceMemberResponse{
+	member := WorkspaceMemberResponse{
 		WorkspaceID: workspaceUser.WorkspaceID,
 		UserID:      workspaceUser.UserID,
 		UserName:    invitedUser.Name,
 		UserEmail:   invitedUser.Email,
 		Role:        workspaceUser.Role,
 		CreatedAt:   workspaceUser.CreatedAt,
-	})
+	}
+
+	h.publish(c, workspaceId, model.EventMemberAdded, member)
+
+	return c.JSON(http.StatusCreated, member)
 }
 
 func (h Handler) UpdateMemberRole(c echo.Context) error {
@@ -463,6 +532,7 @@ func (h Handler) UpdateMemberRole(c echo.Context) error {
 	}
 
 	// Update role
+	previousRole := targetMember.Role
 	targetMember.Role = req.Role
 	targetMember.UpdatedBy = currentUser.ID
 	targetMember.UpdatedAt = time.Now().UTC().String()
@@ -471,6 +541,28 @@ func (h Handler) UpdateMemberRole(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	audit(db, c, auditEntry{
+		WorkspaceID: workspaceId,
+		Action:      model.AuditActionMemberRoleUpdate,
+		TargetType:  model.AuditTargetWorkspaceMember,
+		TargetID:    userId,
+		Before:      map[string]string{"role": previousRole},
+		After:       map[string]string{"role": targetMember.Role},
+	})
+
+	if previousRole != targetMember.Role {
+		if workspace, err := db.FindWorkspaceByID(workspaceId); err == nil {
+			notify(db, currentUser, model.Notification{
+				UserID:      userId,
+				WorkspaceID: workspaceId,
+				Type:        model.NotificationRoleChanged,
+				TargetType:  model.NotificationTargetWorkspace,
+				TargetID:    workspaceId,
+				Message:     currentUser.Name + " changed your role in " + workspace.Name + " to " + targetMember.Role,
+			})
+		}
+	}
+
 	// Get updated user info
 	user, err := db.FindUserByID(userId)
 	if err != nil {
@@ -481,14 +573,18 @@ func (h Handler) UpdateMemberRole(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	return c.JSON(http.StatusOK, WorkspaceMemberResponse{
+	member := WorkspaceMemberResponse{
 		WorkspaceID: targetMember.WorkspaceID,
 		UserID:      targetMember.UserID,
 		UserName:    user.Name,
 		UserEmail:   user.Email,
 		Role:        targetMember.Role,
 		CreatedAt:   targetMember.CreatedAt,
-	})
+	}
+
+	h.publish(c, workspaceId, model.EventMemberUpdated, member)
+
+	return c.JSON(http.StatusOK, member)
 }
 
 func (h Handler) RemoveMember(c echo.Context) error {
@@ -551,9 +647,19 @@ func (h Handler) RemoveMember(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	audit(db, c, auditEntry{
+		WorkspaceID: workspaceId,
+		Action:      model.AuditActionMemberRemove,
+		TargetType:  model.AuditTargetWorkspaceMember,
+		TargetID:    userId,
+		Before:      map[string]string{"role": targetMember.Role},
+	})
+
 	if err := db.Commit(); err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	h.publish(c, workspaceId, model.EventMemberRemoved, map[string]string{"user_id": userId, "role": targetMember.Role})
+
 	return c.NoContent(http.StatusNoContent)
 }
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/eventbus"
+	"github.com/collabreef/collabreef/internal/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+// sseHeartbeat keeps idle streams from being closed by proxies
+const sseHeartbeat = 25 * time.Second
+
+// eventReset tells the client it missed events and should reload its data
+const eventReset = "reset"
+
+// StreamWorkspaceEvents sends the workspace's changes as Server-Sent Events.
+// Clients that reconnect with Last-Event-ID get the events they missed, or a
+// reset event when those are no longer in the log.
+func (h Handler) StreamWorkspaceEvents(c echo.Context) error {
+	workspaceId := c.Param("workspaceId")
+	if workspaceId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "workspace id is required")
+	}
+
+	user := c.Get("user").(model.User)
+
+	if !h.isUserWorkspaceMember(user.ID, workspaceId) {
+		return echo.NewHTTPError(http.StatusForbidden, "You are not a member of this workspace")
+	}
+
+	// EventSource can't set headers on the first connection, so allow a query param too
+	lastEventID := c.Request().Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.QueryParam("lastEventId")
+	}
+
+	sub, backlog, complete := h.events.Subscribe(workspaceId, lastEventID)
+	defer h.events.Unsubscribe(sub)
+
+	resp := c.Response()
+	resp.Header().Set(echo.HeaderContentType, "text/event-stream")
+	resp.Header().Set(echo.HeaderCacheControl, "no-cache")
+	resp.Header().Set(echo.HeaderConnection, "keep-alive")
+	resp.Header().Set("X-Accel-Buffering", "no")
+	resp.WriteHeader(http.StatusOK)
+
+	if !complete {
+		fmt.Fprintf(resp, "event: %s\ndata: {}\n\n", eventReset)
+	}
+
+	for _, ev := range backlog {
+		if err := writeWorkspaceEvent(resp, ev, user.ID); err != nil {
+			return nil
+		}
+	}
+	resp.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	ctx := c.Request().Context()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(resp, ": ping\n\n"); err != nil {
+				return nil
+			}
+			resp.Flush()
+
+		case ev, ok := <-sub.C:
+			// The subscription was dropped for falling behind, the client
+			// reconnects with Last-Event-ID and catches up from the log
+			if !ok {
+				return nil
+			}
+
+			if err := writeWorkspaceEvent(resp, ev, user.ID); err != nil {
+				return nil
+			}
+			resp.Flush()
+
+			if isRemovalOf(ev, user.ID) {
+				return nil
+			}
+		}
+	}
+}
+
+func writeWorkspaceEvent(resp *echo.Response, ev eventbus.Event, userID string) error {
+	if !ev.VisibleTo(userID) {
+		return nil
+	}
+
+	data, err := json.Marshal(ev.WorkspaceEvent)
+	if err != nil {
+		return nil
+	}
+
+	_, err = fmt.Fprintf(resp, "id: %s\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, data)
+	return err
+}
+
+// isRemovalOf reports whether the event removes the user from the workspace,
+// which ends their stream
+func isRemovalOf(ev eventbus.Event, userID string) bool {
+	if ev.Type != model.EventMemberRemoved {
+		return false
+	}
+
+	data, ok := ev.Data.(map[string]string)
+	return ok && data["user_id"] == userID
+}
@@ -0,0 +1,310 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/db"
+	"github.com/collabreef/collabreef/internal/mailer"
+	"github.com/collabreef/collabreef/internal/model"
+	"github.com/collabreef/collabreef/internal/util"
+
+	"github.com/labstack/echo/v4"
+)
+
+const workspaceInvitationTTL = 7 * 24 * time.Hour
+
+type AcceptWorkspaceInvitationRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+func isInvitationExpired(i model.WorkspaceInvitation) bool {
+	expiresAt, err := time.Parse(time.RFC3339, i.ExpiresAt)
+	return err != nil || time.Now().UTC().After(expiresAt)
+}
+
+// inviteByEmail creates a pending invitation for an address that has no account yet
+// and emails the invitee a link to accept it
+func (h Handler) inviteByEmail(tx db.DB, workspaceID string, email string, role string, inviter model.User) (model.WorkspaceInvitation, error) {
+	// Addresses are stored in lower case and looked up regardless of case
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	// Re-inviting the same address replaces the previous invitation
+	existing, err := tx.FindWorkspaceInvitations(model.WorkspaceInvitationFilter{
+		WorkspaceID: workspaceID,
+		Email:       email,
+		Pending:     true,
+	})
+	if err != nil {
+		return model.WorkspaceInvitation{}, err
+	}
+
+	for _, i := range existing {
+		if err := tx.DeleteWorkspaceInvitation(i.ID); err != nil {
+			return model.WorkspaceInvitation{}, err
+		}
+	}
+
+	workspace, err := tx.FindWorkspaceByID(workspaceID)
+	if err != nil {
+		return model.WorkspaceInvitation{}, err
+	}
+
+	token, hash, err := util.GenerateToken()
+	if err != nil {
+		return model.WorkspaceInvitation{}, err
+	}
+
+	now := time.Now().UTC()
+
+	invitation := model.WorkspaceInvitation{
+		ID:          util.NewId(),
+		WorkspaceID: workspaceID,
+		Email:       email,
+		Role:        role,
+		TokenHash:   hash,
+		ExpiresAt:   now.Add(workspaceInvitationTTL).Format(time.RFC3339),
+		CreatedAt:   now.Format(time.RFC3339),
+		CreatedBy:   inviter.ID,
+	}
+
+	if err := tx.CreateWorkspaceInvitation(invitation); err != nil {
+		return model.WorkspaceInvitation{}, err
+	}
+
+	h.sendMail(mailer.WorkspaceInvitationMessage(email, inviter.Name, workspace.Name, appLink("/invitations/accept", token)))
+
+	return invitation, nil
+}
+
+// acceptInvitation adds the user to the invited workspace (unless already a member)
+// and marks the invitation as accepted. It reports whether the user joined.
+func acceptInvitation(tx db.DB, invitation model.WorkspaceInvitation, user model.User) (bool, error) {
+	members, err := tx.FindWorkspaceUsers(model.WorkspaceUserFilter{
+		WorkspaceID: invitation.WorkspaceID,
+		UserID:      user.ID,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now().UTC()
+
+	if len(members) == 0 {
+		err := tx.CreateWorkspaceUser(model.WorkspaceUser{
+			WorkspaceID: invitation.WorkspaceID,
+			UserID:      user.ID,
+			Role:        invitation.Role,
+			CreatedAt:   now.String(),
+			CreatedBy:   invitation.CreatedBy,
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+
+	invitation.AcceptedAt = now.Format(time.RFC3339)
+	invitation.AcceptedBy = user.ID
+
+	return len(members) == 0, tx.UpdateWorkspaceInvitation(invitation)
+}
+
+// acceptPendingInvitations joins the user to every workspace their email address
+// has an outstanding invitation for, and returns the invitations that made them
+// a member
+func acceptPendingInvitations(tx db.DB, user model.User) ([]model.WorkspaceInvitation, error) {
+	invitations, err := tx.FindWorkspaceInvitations(model.WorkspaceInvitationFilter{
+		Email:   user.Email,
+		Pending: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var joined []model.WorkspaceInvitation
+	for _, i := range invitations {
+		if isInvitationExpired(i) {
+			continue
+		}
+		ok, err := acceptInvitation(tx, i, user)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			joined = append(joined, i)
+		}
+	}
+
+	return joined, nil
+}
+
+// hasPendingInvitation reports whether an email address has an unexpired invitation
+func hasPendingInvitation(tx db.DB, email string) (bool, error) {
+	invitations, err := tx.FindWorkspaceInvitations(model.WorkspaceInvitationFilter{
+		Email:   email,
+		Pending: true,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	for _, i := range invitations {
+		if !isInvitationExpired(i) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (h Handler) GetWorkspaceInvitations(c echo.Context) error {
+	workspaceId := c.Param("workspaceId")
+	if workspaceId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "workspace id is required")
+	}
+
+	currentUser := c.Get("user").(model.User)
+
+	currentMember, err := h.db.FindWorkspaceUsers(model.WorkspaceUserFilter{
+		WorkspaceID: workspaceId,
+		UserID:      currentUser.ID,
+	})
+	if err != nil || len(currentMember) == 0 {
+		return echo.NewHTTPError(http.StatusForbidden, "You are not a member of this workspace")
+	}
+
+	if currentMember[0].Role != model.WorkspaceUserRoleOwner && currentMember[0].Role != model.WorkspaceUserRoleAdmin {
+		return echo.NewHTTPError(http.StatusForbidden, "Only workspace owner or admin can view invitations")
+	}
+
+	invitations, err := h.db.FindWorkspaceInvitations(model.WorkspaceInvitationFilter{
+		WorkspaceID: workspaceId,
+		Pending:     true,
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	res := []model.WorkspaceInvitation{}
+	for _, i := range invitations {
+		if !isInvitationExpired(i) {
+			res = append(res, i)
+		}
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h Handler) RevokeWorkspaceInvitation(c echo.Context) error {
+	workspaceId := c.Param("workspaceId")
+	id := c.Param("id")
+	if workspaceId == "" || id == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "workspace id and invitation id are required")
+	}
+
+	currentUser := c.Get("user").(model.User)
+
+	db, err := h.db.Begin(context.Background())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	defer db.Rollback()
+
+	currentMember, err := db.FindWorkspaceUsers(model.WorkspaceUserFilter{
+		WorkspaceID: workspaceId,
+		UserID:      currentUser.ID,
+	})
+	if err != nil || len(currentMember) == 0 {
+		return echo.NewHTTPError(http.StatusForbidden, "You are not a member of this workspace")
+	}
+
+	if currentMember[0].Role != model.WorkspaceUserRoleOwner && currentMember[0].Role != model.WorkspaceUserRoleAdmin {
+		return echo.NewHTTPError(http.StatusForbidden, "Only workspace owner or admin can revoke invitations")
+	}
+
+	invitation, err := db.FindWorkspaceInvitationByID(id)
+	if err != nil || invitation.WorkspaceID != workspaceId {
+		return echo.NewHTTPError(http.StatusNotFound, "Invitation not found")
+	}
+
+	if err := db.DeleteWorkspaceInvitation(id); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	audit(db, c, auditEntry{
+		WorkspaceID: workspaceId,
+		Action:      model.AuditActionInvitationRevoke,
+		TargetType:  model.AuditTargetWorkspaceInvitation,
+		TargetID:    id,
+		Before:      map[string]string{"email": invitation.Email, "role": invitation.Role},
+	})
+
+	if err := db.Commit(); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h Handler) AcceptWorkspaceInvitation(c echo.Context) error {
+	var req AcceptWorkspaceInvitationRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Validation failed: " + err.Error(),
+		})
+	}
+
+	user := c.Get("user").(model.User)
+
+	db, err := h.db.Begin(context.Background())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	defer db.Rollback()
+
+	invitation, err := db.FindWorkspaceInvitationByHash(util.HashToken(req.Token))
+	if err != nil || invitation.AcceptedAt != "" || isInvitationExpired(invitation) {
+		return echo.NewHTTPError(http.StatusBadRequest, "This invitation is invalid or has expired")
+	}
+
+	if !strings.EqualFold(invitation.Email, user.Email) {
+		return echo.NewHTTPError(http.StatusForbidden, "This invitation was sent to a different email address")
+	}
+
+	if _, err := acceptInvitation(db, invitation, user); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	audit(db, c, auditEntry{
+		WorkspaceID: invitation.WorkspaceID,
+		Action:      model.AuditActionMemberJoin,
+		TargetType:  model.AuditTargetWorkspaceMember,
+		TargetID:    user.ID,
+		After:       map[string]string{"role": invitation.Role, "invitation_id": invitation.ID},
+	})
+
+	workspace, err := db.FindWorkspaceByID(invitation.WorkspaceID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if err := db.Commit(); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	h.publish(c, invitation.WorkspaceID, model.EventMemberAdded, WorkspaceMemberResponse{
+		WorkspaceID: invitation.WorkspaceID,
+		UserID:      user.ID,
+		UserName:    user.Name,
+		UserEmail:   user.Email,
+		Role:        invitation.Role,
+	})
+
+	return c.JSON(http.StatusOK, workspace)
+}
@@ -0,0 +1,347 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/db"
+	"github.com/collabreef/collabreef/internal/model"
+	"github.com/collabreef/collabreef/internal/util"
+
+	"github.com/labstack/echo/v4"
+)
+
+const inviteLinkPrefixLength = 8
+
+var (
+	errInviteLinkInvalid        = errors.New("This invite link is invalid or has expired")
+	errInviteLinkDomain         = errors.New("This invite link is restricted to another email domain")
+	errInviteLinkUnverified     = errors.New("Please verify your email address before using this invite link")
+	errInviteLinkExhausted      = errors.New("This invite link has reached its maximum number of uses")
+	errInviteLinkAlreadyAMember = errors.New("You are already a member of this workspace")
+)
+
+type CreateWorkspaceInviteLinkRequest struct {
+	Role          string `json:"role" validate:"required"`
+	ExpiresAt     string `json:"expires_at"`
+	MaxUses       int    `json:"max_uses" validate:"min=0"`
+	AllowedDomain string `json:"allowed_domain"`
+}
+
+type JoinWorkspaceRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+func isInviteLinkUsable(l model.WorkspaceInviteLink) bool {
+	if l.MaxUses > 0 && l.UseCount >= l.MaxUses {
+		return false
+	}
+
+	if l.ExpiresAt == "" {
+		return true
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, l.ExpiresAt)
+	return err == nil && time.Now().UTC().Before(expiresAt)
+}
+
+func emailMatchesDomain(email string, domain string) bool {
+	at := strings.LastIndex(email, "@")
+	return at >= 0 && strings.EqualFold(email[at+1:], domain)
+}
+
+// findInviteLink resolves a plain invite token to a link that can still be used
+// by the given email address
+func findInviteLink(tx db.DB, token string, email string) (model.WorkspaceInviteLink, error) {
+	link, err := tx.FindWorkspaceInviteLinkByHash(util.HashToken(token))
+	if err != nil || !isInviteLinkUsable(link) {
+		return model.WorkspaceInviteLink{}, errInviteLinkInvalid
+	}
+
+	if link.AllowedDomain != "" && !emailMatchesDomain(email, link.AllowedDomain) {
+		return model.WorkspaceInviteLink{}, errInviteLinkDomain
+	}
+
+	return link, nil
+}
+
+// redeemInviteLink adds the user to the link's workspace and counts the use
+func redeemInviteLink(tx db.DB, link model.WorkspaceInviteLink, user model.User) error {
+	// A domain restriction means nothing until the address has been confirmed,
+	// whether or not the server requires verification otherwise
+	if link.AllowedDomain != "" && !user.EmailVerified {
+		return errInviteLinkUnverified
+	}
+
+	members, err := tx.FindWorkspaceUsers(model.WorkspaceUserFilter{
+		WorkspaceID: link.WorkspaceID,
+		UserID:      user.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(members) > 0 {
+		return errInviteLinkAlreadyAMember
+	}
+
+	ok, err := tx.IncrementWorkspaceInviteLinkUses(link.ID)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return errInviteLinkExhausted
+	}
+
+	return tx.CreateWorkspaceUser(model.WorkspaceUser{
+		WorkspaceID: link.WorkspaceID,
+		UserID:      user.ID,
+		Role:        link.Role,
+		CreatedAt:   time.Now().UTC().String(),
+		CreatedBy:   link.CreatedBy,
+	})
+}
+
+func (h Handler) CreateWorkspaceInviteLink(c echo.Context) error {
+	workspaceId := c.Param("workspaceId")
+	if workspaceId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "workspace id is required")
+	}
+
+	var req CreateWorkspaceInviteLinkRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Validation failed: " + err.Error(),
+		})
+	}
+
+	// Ownership can only be transferred explicitly, never through a link
+	if !model.IsValidWorkspaceUserRole(req.Role) || req.Role == model.WorkspaceUserRoleOwner {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid role")
+	}
+
+	var expiresAt string
+	if req.ExpiresAt != "" {
+		parsedTime, err := time.Parse(time.RFC3339, req.ExpiresAt)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid expires_at format, use RFC3339")
+		}
+		if parsedTime.Before(time.Now().UTC()) {
+			return echo.NewHTTPError(http.StatusBadRequest, "expires_at must be in the future")
+		}
+		expiresAt = parsedTime.UTC().Format(time.RFC3339)
+	}
+
+	allowedDomain := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(req.AllowedDomain), "@"))
+	if strings.ContainsAny(allowedDomain, "@ /") {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid allowed_domain")
+	}
+
+	currentUser := c.Get("user").(model.User)
+
+	currentMember, err := h.db.FindWorkspaceUsers(model.WorkspaceUserFilter{
+		WorkspaceID: workspaceId,
+		UserID:      currentUser.ID,
+	})
+	if err != nil || len(currentMember) == 0 {
+		return echo.NewHTTPError(http.StatusForbidden, "You are not a member of this workspace")
+	}
+
+	if currentMember[0].Role != model.WorkspaceUserRoleOwner && currentMember[0].Role != model.WorkspaceUserRoleAdmin {
+		return echo.NewHTTPError(http.StatusForbidden, "Only workspace owner or admin can create invite links")
+	}
+
+	token, hash, err := util.GenerateToken()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to generate invite link")
+	}
+
+	link := model.WorkspaceInviteLink{
+		ID:            util.NewId(),
+		WorkspaceID:   workspaceId,
+		Role:          req.Role,
+		TokenHash:     hash,
+		Prefix:        token[:inviteLinkPrefixLength],
+		AllowedDomain: allowedDomain,
+		MaxUses:       req.MaxUses,
+		ExpiresAt:     expiresAt,
+		CreatedAt:     time.Now().UTC().Format(time.RFC3339),
+		CreatedBy:     currentUser.ID,
+	}
+
+	if err := h.db.CreateWorkspaceInviteLink(link); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	audit(h.db, c, auditEntry{
+		WorkspaceID: workspaceId,
+		Action:      model.AuditActionInviteLinkCreate,
+		TargetType:  model.AuditTargetWorkspaceInviteLink,
+		TargetID:    link.ID,
+		After:       link,
+	})
+
+	// The token is only returned once, the link can't be shown again afterwards
+	return c.JSON(http.StatusCreated, model.WorkspaceInviteLinkCreationResponse{
+		WorkspaceInviteLink: link,
+		Token:               token,
+		URL:                 appLink("/join", token),
+	})
+}
+
+func (h Handler) GetWorkspaceInviteLinks(c echo.Context) error {
+	workspaceId := c.Param("workspaceId")
+	if workspaceId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "workspace id is required")
+	}
+
+	currentUser := c.Get("user").(model.User)
+
+	currentMember, err := h.db.FindWorkspaceUsers(model.WorkspaceUserFilter{
+		WorkspaceID: workspaceId,
+		UserID:      currentUser.ID,
+	})
+	if err != nil || len(currentMember) == 0 {
+		return echo.NewHTTPError(http.StatusForbidden, "You are not a member of this workspace")
+	}
+
+	if currentMember[0].Role != model.WorkspaceUserRoleOwner && currentMember[0].Role != model.WorkspaceUserRoleAdmin {
+		return echo.NewHTTPError(http.StatusForbidden, "Only workspace owner or admin can view invite links")
+	}
+
+	links, err := h.db.FindWorkspaceInviteLinks(model.WorkspaceInviteLinkFilter{
+		WorkspaceID: workspaceId,
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, links)
+}
+
+func (h Handler) RevokeWorkspaceInviteLink(c echo.Context) error {
+	workspaceId := c.Param("workspaceId")
+	id := c.Param("id")
+	if workspaceId == "" || id == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "workspace id and invite link id are required")
+	}
+
+	currentUser := c.Get("user").(model.User)
+
+	db, err := h.db.Begin(context.Background())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	defer db.Rollback()
+
+	currentMember, err := db.FindWorkspaceUsers(model.WorkspaceUserFilter{
+		WorkspaceID: workspaceId,
+		UserID:      currentUser.ID,
+	})
+	if err != nil || len(currentMember) == 0 {
+		return echo.NewHTTPError(http.StatusForbidden, "You are not a member of this workspace")
+	}
+
+	if currentMember[0].Role != model.WorkspaceUserRoleOwner && currentMember[0].Role != model.WorkspaceUserRoleAdmin {
+		return echo.NewHTTPError(http.StatusForbidden, "Only workspace owner or admin can revoke invite links")
+	}
+
+	link, err := db.FindWorkspaceInviteLinkByID(id)
+	if err != nil || link.WorkspaceID != workspaceId {
+		return echo.NewHTTPError(http.StatusNotFound, "Invite link not found")
+	}
+
+	if err := db.DeleteWorkspaceInviteLink(id); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	audit(db, c, auditEntry{
+		WorkspaceID: workspaceId,
+		Action:      model.AuditActionInviteLinkRevoke,
+		TargetType:  model.AuditTargetWorkspaceInviteLink,
+		TargetID:    id,
+		Before:      link,
+	})
+
+	if err := db.Commit(); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h Handler) JoinWorkspace(c echo.Context) error {
+	var req JoinWorkspaceRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Validation failed: " + err.Error(),
+		})
+	}
+
+	user := c.Get("user").(model.User)
+
+	db, err := h.db.Begin(context.Background())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	defer db.Rollback()
+
+	link, err := findInviteLink(db, req.Token, user.Email)
+	if err != nil {
+		if errors.Is(err, errInviteLinkDomain) {
+			return echo.NewHTTPError(http.StatusForbidden, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := redeemInviteLink(db, link, user); err != nil {
+		switch {
+		case errors.Is(err, errInviteLinkAlreadyAMember):
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		case errors.Is(err, errInviteLinkUnverified):
+			return echo.NewHTTPError(http.StatusForbidden, err.Error())
+		case errors.Is(err, errInviteLinkExhausted):
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	audit(db, c, auditEntry{
+		WorkspaceID: link.WorkspaceID,
+		Action:      model.AuditActionMemberJoin,
+		TargetType:  model.AuditTargetWorkspaceMember,
+		TargetID:    user.ID,
+		After:       map[string]string{"role": link.Role, "invite_link_id": link.ID},
+	})
+
+	workspace, err := db.FindWorkspaceByID(link.WorkspaceID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if err := db.Commit(); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	h.publish(c, link.WorkspaceID, model.EventMemberAdded, WorkspaceMemberResponse{
+		WorkspaceID: link.WorkspaceID,
+		UserID:      user.ID,
+		UserName:    user.Name,
+		UserEmail:   user.Email,
+		Role:        link.Role,
+	})
+
+	return c.JSON(http.StatusOK, workspace)
+}
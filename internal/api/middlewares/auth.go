@@ -1,13 +1,18 @@
 package middlewares
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/collabreef/collabreef/internal/api/auth"
+	"github.com/collabreef/collabreef/internal/config"
 	"github.com/collabreef/collabreef/internal/db"
 	"github.com/collabreef/collabreef/internal/model"
+	"github.com/collabreef/collabreef/internal/ratelimit"
 	"github.com/collabreef/collabreef/internal/util"
 
 	"github.com/labstack/echo/v4"
@@ -15,12 +20,14 @@ import (
 )
 
 type AuthMiddleware struct {
-	db db.DB
+	db      db.DB
+	limiter *ratelimit.Limiter
 }
 
-func NewAuthMiddleware(db db.DB) *AuthMiddleware {
+func NewAuthMiddleware(db db.DB, limiter *ratelimit.Limiter) *AuthMiddleware {
 	return &AuthMiddleware{
-		db: db,
+		db:      db,
+		limiter: limiter,
 	}
 }
 
@@ -30,53 +37,11 @@ func (a AuthMiddleware) ParseJWT() echo.MiddlewareFunc {
 			// STEP 1: Check for Authorization header with Bearer token (API Key)
 			authHeader := c.Request().Header.Get("Authorization")
 			if authHeader != "" && strings.HasPrefix(authHeader, "Bearer ") {
-				apiKey := strings.TrimPrefix(authHeader, "Bearer ")
-
-				// Validate API key format
-				if !util.ValidateAPIKeyFormat(apiKey) {
-					return echo.NewHTTPError(http.StatusUnauthorized, "invalid API key format")
-				}
-
-				// Extract prefix for lookup
-				prefix := util.ExtractPrefix(apiKey)
-
-				// Find API key by prefix
-				apiKeyRecord, err := a.db.FindAPIKeyByPrefix(prefix)
-				if err != nil {
-					return echo.NewHTTPError(http.StatusUnauthorized, "invalid API key")
-				}
-
-				// Check expiration
-				if apiKeyRecord.ExpiresAt != "" {
-					expiresAt, err := time.Parse(time.RFC3339, apiKeyRecord.ExpiresAt)
-					if err == nil && time.Now().UTC().After(expiresAt) {
-						return echo.NewHTTPError(http.StatusUnauthorized, "API key expired")
-					}
-				}
-
-				// Verify full key with bcrypt (constant-time comparison)
-				err = bcrypt.CompareHashAndPassword([]byte(apiKeyRecord.KeyHash), []byte(apiKey))
+				user, err := a.apiKeyUser(c, strings.TrimPrefix(authHeader, "Bearer "))
 				if err != nil {
-					return echo.NewHTTPError(http.StatusUnauthorized, "invalid API key")
-				}
-
-				// Load user
-				user, err := a.db.FindUserByID(apiKeyRecord.UserID)
-				if err != nil {
-					return echo.NewHTTPError(http.StatusUnauthorized, "user not found")
-				}
-
-				// Check if user is disabled
-				if user.Disabled {
-					return echo.NewHTTPError(http.StatusUnauthorized, "user account disabled")
+					return err
 				}
 
-				// Update last_used_at asynchronously (don't block request)
-				go func() {
-					apiKeyRecord.LastUsedAt = time.Now().UTC().Format(time.RFC3339)
-					a.db.UpdateAPIKey(apiKeyRecord)
-				}()
-
 				// Set user in context
 				c.Set("user", user)
 				return next(c)
@@ -111,6 +76,118 @@ func (a AuthMiddleware) ParseJWT() echo.MiddlewareFunc {
 	}
 }
 
+// apiKeyUser returns the user an API key belongs to
+func (a AuthMiddleware) apiKeyUser(c echo.Context, apiKey string) (model.User, error) {
+	// Clients that keep presenting bad keys are locked out to stop enumeration
+	failureKey := "apikey:ip:" + c.RealIP()
+	if res, err := a.limiter.Locked(failureKey); err != nil {
+		log.Printf("Rate limiter error: %v", err)
+	} else if !res.Allowed {
+		return model.User{}, TooManyRequests(c, res)
+	}
+
+	// Validate API key format
+	if !util.ValidateAPIKeyFormat(apiKey) {
+		a.recordFailure(failureKey)
+		return model.User{}, echo.NewHTTPError(http.StatusUnauthorized, "invalid API key format")
+	}
+
+	// Extract prefix for lookup
+	prefix := util.ExtractPrefix(apiKey)
+
+	// Find API key by prefix
+	apiKeyRecord, err := a.db.FindAPIKeyByPrefix(prefix)
+	if err != nil {
+		a.recordFailure(failureKey)
+		return model.User{}, echo.NewHTTPError(http.StatusUnauthorized, "invalid API key")
+	}
+
+	// Check expiration
+	if apiKeyRecord.ExpiresAt != "" {
+		expiresAt, err := time.Parse(time.RFC3339, apiKeyRecord.ExpiresAt)
+		if err == nil && time.Now().UTC().After(expiresAt) {
+			return model.User{}, echo.NewHTTPError(http.StatusUnauthorized, "API key expired")
+		}
+	}
+
+	// Verify full key with bcrypt (constant-time comparison)
+	err = bcrypt.CompareHashAndPassword([]byte(apiKeyRecord.KeyHash), []byte(apiKey))
+	if err != nil {
+		a.recordFailure(failureKey)
+		return model.User{}, echo.NewHTTPError(http.StatusUnauthorized, "invalid API key")
+	}
+
+	// Enforce the key's request quota
+	perMinute := apiKeyRecord.RateLimit
+	if perMinute <= 0 {
+		perMinute = config.C.GetInt(config.RATELIMIT_API_KEY_PER_MINUTE)
+	}
+	if res, err := a.limiter.Allow("apikey:"+apiKeyRecord.ID, ratelimit.PerMinute(perMinute)); err != nil {
+		log.Printf("Rate limiter error: %v", err)
+	} else if !res.Allowed {
+		return model.User{}, TooManyRequests(c, res)
+	}
+
+	// Load user
+	user, err := a.db.FindUserByID(apiKeyRecord.UserID)
+	if err != nil {
+		return model.User{}, echo.NewHTTPError(http.StatusUnauthorized, "user not found")
+	}
+
+	// Check if user is disabled
+	if user.Disabled {
+		return model.User{}, echo.NewHTTPError(http.StatusUnauthorized, "user account disabled")
+	}
+
+	// Update last_used_at asynchronously (don't block request)
+	go func() {
+		apiKeyRecord.LastUsedAt = time.Now().UTC().Format(time.RFC3339)
+		a.db.UpdateAPIKey(apiKeyRecord)
+	}()
+
+	return user, nil
+}
+
+// BasicAuth signs users in with an API key as the password of HTTP Basic
+// authentication, for clients such as calendar apps that can't send bearer
+// tokens. The user name must be the key owner's name or email.
+func (a AuthMiddleware) BasicAuth(realm string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			challenge := func(message string) error {
+				c.Response().Header().Set("WWW-Authenticate", `Basic realm="`+realm+`", charset="UTF-8"`)
+				return c.String(http.StatusUnauthorized, message)
+			}
+
+			name, apiKey, ok := c.Request().BasicAuth()
+			if !ok {
+				return challenge("authentication required")
+			}
+
+			user, err := a.apiKeyUser(c, apiKey)
+			if err != nil {
+				var he *echo.HTTPError
+				if errors.As(err, &he) && he.Code == http.StatusUnauthorized {
+					return challenge(fmt.Sprint(he.Message))
+				}
+				return err
+			}
+			if !strings.EqualFold(name, user.Name) && !strings.EqualFold(name, user.Email) {
+				return challenge("the user name doesn't match the API key")
+			}
+
+			c.Set("user", user)
+			return next(c)
+		}
+	}
+}
+
+func (a AuthMiddleware) recordFailure(key string) {
+	if _, err := a.limiter.Fail(key); err != nil {
+		log.Printf("Rate limiter error: %v", err)
+	}
+}
+
 func (a AuthMiddleware) CheckJWT() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) (returnErr error) {
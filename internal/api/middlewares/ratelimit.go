@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/collabreef/collabreef/internal/ratelimit"
+
+	"github.com/labstack/echo/v4"
+)
+
+type RateLimitMiddleware struct {
+	limiter *ratelimit.Limiter
+}
+
+func NewRateLimitMiddleware(limiter *ratelimit.Limiter) *RateLimitMiddleware {
+	return &RateLimitMiddleware{
+		limiter: limiter,
+	}
+}
+
+// PerIP throttles requests per client IP address, sharing one bucket per name
+func (r RateLimitMiddleware) PerIP(name string, limit ratelimit.Limit) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			res, err := r.limiter.Allow(name+":ip:"+c.RealIP(), limit)
+			if err != nil {
+				// Fail open, an unavailable store shouldn't take the API down with it
+				log.Printf("Rate limiter error: %v", err)
+				return next(c)
+			}
+
+			if !res.Allowed {
+				return TooManyRequests(c, res)
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// TooManyRequests rejects a request that hit a rate limit or lockout
+func TooManyRequests(c echo.Context, res ratelimit.Result) error {
+	c.Response().Header().Set("Retry-After", strconv.Itoa(res.RetryAfterSeconds()))
+	return echo.NewHTTPError(http.StatusTooManyRequests, "too many requests, please try again later")
+}
@@ -18,5 +18,7 @@ func RegisterAdmin(api *echo.Group, h handler.Handler, authMiddleware middleware
 	g.PUT("/users/:id/disable", h.DisableUser)
 	g.PUT("/users/:id/enable", h.EnableUser)
 	g.DELETE("/users/:id", h.DeleteUser)
-
+	g.GET("/audit-events", h.GetAuditEvents)
+	g.GET("/audit-events/export", h.ExportAuditEvents)
+	g.GET("/jobs", h.GetJobs)
 }
@@ -2,13 +2,22 @@ package route
 
 import (
 	"github.com/collabreef/collabreef/internal/api/handler"
+	"github.com/collabreef/collabreef/internal/api/middlewares"
+	"github.com/collabreef/collabreef/internal/config"
+	"github.com/collabreef/collabreef/internal/ratelimit"
 
 	"github.com/labstack/echo/v4"
 )
 
-func RegisterAuth(g *echo.Group, h handler.Handler) {
-	g.POST("/signin", h.SignIn)
+func RegisterAuth(g *echo.Group, h handler.Handler, rateLimit middlewares.RateLimitMiddleware) {
+	perIP := ratelimit.PerMinute(config.C.GetInt(config.RATELIMIT_AUTH_IP_PER_MINUTE))
+
+	g.POST("/signin", h.SignIn, rateLimit.PerIP("signin", perIP))
 	g.GET("/signout", h.SignOut)
-	g.POST("/signup", h.SignUp)
+	g.POST("/signup", h.SignUp, rateLimit.PerIP("signup", perIP))
 	g.GET("/me", h.GetUserInfo)
+	g.POST("/password/forgot", h.ForgotPassword, rateLimit.PerIP("password", perIP))
+	g.POST("/password/reset", h.ResetPassword, rateLimit.PerIP("password", perIP))
+	g.POST("/email/verify", h.VerifyEmail, rateLimit.PerIP("email", perIP))
+	g.POST("/email/verify/resend", h.ResendVerificationEmail, rateLimit.PerIP("email", perIP))
 }
@@ -0,0 +1,34 @@
+package route
+
+import (
+	"net/http"
+
+	"github.com/collabreef/collabreef/internal/api/handler"
+	"github.com/collabreef/collabreef/internal/api/middlewares"
+
+	"github.com/labstack/echo/v4"
+)
+
+// calDAVMethods are the methods calendar apps use, the handlers turn away
+// those they don't support
+var calDAVMethods = []string{
+	http.MethodOptions, http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete,
+	"PROPFIND", "PROPPATCH", "REPORT", "MKCOL", "MKCALENDAR", "MOVE", "COPY",
+}
+
+func RegisterCalDAV(e *echo.Echo, h handler.Handler, auth middlewares.AuthMiddleware) {
+	e.Match([]string{http.MethodGet, "PROPFIND"}, "/.well-known/caldav", h.CalDAVWellKnown)
+
+	// Calendar apps sign in with HTTP Basic, using an API key as the password
+	dav := e.Group("/dav", auth.BasicAuth("Collabreef"))
+
+	dav.Match(calDAVMethods, "", h.CalDAVRoot)
+	dav.Match(calDAVMethods, "/", h.CalDAVRoot)
+	dav.Match(calDAVMethods, "/principals/:userId", h.CalDAVPrincipal)
+	dav.Match(calDAVMethods, "/principals/:userId/", h.CalDAVPrincipal)
+	dav.Match(calDAVMethods, "/calendars", h.CalDAVHome)
+	dav.Match(calDAVMethods, "/calendars/", h.CalDAVHome)
+	dav.Match(calDAVMethods, "/calendars/:viewId", h.CalDAVCalendar)
+	dav.Match(calDAVMethods, "/calendars/:viewId/", h.CalDAVCalendar)
+	dav.Match(calDAVMethods, "/calendars/:viewId/:object", h.CalDAVObject)
+}
@@ -0,0 +1,19 @@
+package route
+
+import (
+	"github.com/collabreef/collabreef/internal/api/handler"
+	"github.com/collabreef/collabreef/internal/api/middlewares"
+	"github.com/collabreef/collabreef/internal/config"
+	"github.com/collabreef/collabreef/internal/ratelimit"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterCollab(api *echo.Group, h handler.Handler, rateLimit middlewares.RateLimitMiddleware) {
+	perIP := ratelimit.PerMinute(config.C.GetInt(config.RATELIMIT_AUTH_IP_PER_MINUTE))
+
+	g := api.Group("/collab")
+
+	// The token is the credential, so no session is needed
+	g.POST("/verify", h.VerifyCollabToken, rateLimit.PerIP("collab", perIP))
+}
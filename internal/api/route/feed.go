@@ -0,0 +1,14 @@
+package route
+
+import (
+	"github.com/collabreef/collabreef/internal/api/handler"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterFeed(api *echo.Group, h handler.Handler) {
+	g := api.Group("/feeds")
+
+	// Calendar apps can't sign in, the token in the URL is the credential
+	g.GET("/calendar/:file", h.GetCalendarFeedICS)
+}
@@ -0,0 +1,17 @@
+package route
+
+import (
+	"github.com/collabreef/collabreef/internal/api/handler"
+	"github.com/collabreef/collabreef/internal/api/middlewares"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterInvitation(api *echo.Group, h handler.Handler, authMiddleware middlewares.AuthMiddleware) {
+	g := api.Group("/invitations")
+	g.Use(authMiddleware.CheckJWT())
+	g.Use(authMiddleware.ParseJWT())
+
+	g.POST("/accept", h.AcceptWorkspaceInvitation)
+	g.POST("/join", h.JoinWorkspace)
+}
@@ -0,0 +1,20 @@
+package route
+
+import (
+	"github.com/collabreef/collabreef/internal/api/handler"
+	"github.com/collabreef/collabreef/internal/api/middlewares"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterNotification(api *echo.Group, h handler.Handler, authMiddleware middlewares.AuthMiddleware) {
+	g := api.Group("/notifications")
+	g.Use(authMiddleware.CheckJWT())
+	g.Use(authMiddleware.ParseJWT())
+	g.GET("", h.GetNotifications)
+	g.GET("/unread-count", h.GetUnreadNotificationCount)
+	g.POST("/read-all", h.MarkAllNotificationsRead)
+	g.POST("/:id/read", h.MarkNotificationRead)
+	g.GET("/preferences", h.GetNotificationPreferences)
+	g.PUT("/preferences", h.UpdateNotificationPreferences)
+}
@@ -20,9 +20,10 @@ func RegisterWebSocket(e *echo.Echo, h handler.Handler, auth middlewares.AuthMid
 	// WebSocket endpoint for note collaboration
 	ws.GET("/notes/:noteId", h.HandleNoteWebSocket)
 
-	// Public WebSocket endpoint for read-only access to public views
+	// Public WebSocket endpoints for read-only access to public views and notes
 	// Uses ParseJWT middleware which allows unauthenticated access (optional auth)
 	wsPublic := e.Group("/ws/public")
 	wsPublic.Use(auth.ParseJWT())
 	wsPublic.GET("/views/:viewId", h.HandlePublicViewWebSocket)
+	wsPublic.GET("/notes/:noteId", h.HandlePublicNoteWebSocket)
 }
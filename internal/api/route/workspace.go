@@ -31,6 +31,9 @@ func RegisterWorkspace(api *echo.Group, h handler.Handler, authMiddleware middle
 	g.DELETE("/:workspaceId/notes/:id", h.DeleteNote)
 	g.PATCH("/:workspaceId/notes/:id/visibility/:visibility", h.UpdateNoteVisibility)
 	g.GET("/:workspaceId/notes/:noteId/view-objects", h.GetViewObjectsForNote)
+	g.GET("/:workspaceId/notes/:id/presence", h.GetNotePresence)
+	g.GET("/:workspaceId/notes/:id/comments", h.GetNoteComments)
+	g.POST("/:workspaceId/notes/:id/comments", h.CreateNoteComment)
 
 	g.GET("/:workspaceId/files/:id", h.Download)
 	g.GET("/:workspaceId/files", h.List)
@@ -44,6 +47,21 @@ func RegisterWorkspace(api *echo.Group, h handler.Handler, authMiddleware middle
 	g.PUT("/:workspaceId/views/:id", h.UpdateView)
 	g.DELETE("/:workspaceId/views/:id", h.DeleteView)
 	g.PATCH("/:workspaceId/views/:id/visibility/:visibility", h.UpdateViewVisibility)
+	g.GET("/:workspaceId/views/:id/presence", h.GetViewPresence)
+
+	// Imports of calendar and map files, depending on the view type
+	g.POST("/:workspaceId/views/:id/import", h.ImportView)
+
+	// Calendar occurrences, feeds and export
+	g.GET("/:workspaceId/views/:id/occurrences", h.GetCalendarOccurrences)
+	g.GET("/:workspaceId/views/:id/export.ics", h.ExportCalendarView)
+	g.GET("/:workspaceId/views/:id/feed", h.GetCalendarFeed)
+	g.POST("/:workspaceId/views/:id/feed", h.CreateCalendarFeed)
+	g.DELETE("/:workspaceId/views/:id/feed", h.DeleteCalendarFeed)
+
+	// Map export
+	g.GET("/:workspaceId/views/:id/export.geojson", h.ExportMapViewGeoJSON)
+	g.GET("/:workspaceId/views/:id/export.kml", h.ExportMapViewKML)
 
 	g.GET("/:workspaceId/views/:viewId/objects", h.GetViewObjects)
 	g.POST("/:workspaceId/views/:viewId/objects", h.CreateViewObject)
@@ -56,6 +74,57 @@ func RegisterWorkspace(api *echo.Group, h handler.Handler, authMiddleware middle
 	g.POST("/:workspaceId/views/:viewId/objects/:id/notes", h.AddNoteToViewObject)
 	g.DELETE("/:workspaceId/views/:viewId/objects/:id/notes/:noteId", h.RemoveNoteFromViewObject)
 
+	// Kanban cards, which are the notes of kanban columns, and metrics
+	g.POST("/:workspaceId/views/:viewId/objects/:id/notes/:noteId/move", h.MoveKanbanCard)
+	g.GET("/:workspaceId/views/:id/kanban/metrics", h.GetKanbanMetrics)
+
+	// Rows of table views, which are notes with values for the view's properties
+	g.GET("/:workspaceId/views/:id/rows", h.GetTableRows)
+	g.POST("/:workspaceId/views/:id/rows", h.AddTableRow)
+	g.PATCH("/:workspaceId/views/:id/rows/:noteId", h.UpdateTableRow)
+	g.DELETE("/:workspaceId/views/:id/rows/:noteId", h.RemoveTableRow)
+
+	// Timeline items, which are scheduled with finish-to-start dependencies
+	g.GET("/:workspaceId/views/:id/timeline", h.GetTimeline)
+	g.POST("/:workspaceId/views/:viewId/objects/:id/reschedule", h.RescheduleTimelineItem)
+
+	// View object comments
+	g.GET("/:workspaceId/views/:viewId/objects/:id/comments", h.GetViewObjectComments)
+	g.POST("/:workspaceId/views/:viewId/objects/:id/comments", h.CreateViewObjectComment)
+
+	// Comments
+	g.GET("/:workspaceId/comments/:commentId", h.GetComment)
+	g.PUT("/:workspaceId/comments/:commentId", h.UpdateComment)
+	g.DELETE("/:workspaceId/comments/:commentId", h.DeleteComment)
+	g.POST("/:workspaceId/comments/:commentId/resolve", h.ResolveComment)
+	g.POST("/:workspaceId/comments/:commentId/reopen", h.ReopenComment)
+
+	// Note templates
+	g.GET("/:workspaceId/templates", h.GetNoteTemplates)
+	g.POST("/:workspaceId/templates", h.CreateNoteTemplate)
+	g.GET("/:workspaceId/templates/:id", h.GetNoteTemplate)
+	g.PUT("/:workspaceId/templates/:id", h.UpdateNoteTemplate)
+	g.DELETE("/:workspaceId/templates/:id", h.DeleteNoteTemplate)
+	g.POST("/:workspaceId/templates/:id/render", h.RenderNoteTemplate)
+
+	// Daily notes
+	g.GET("/:workspaceId/daily", h.GetDailyNoteCalendar)
+	g.GET("/:workspaceId/daily/:date", h.GetDailyNote)
+	g.GET("/:workspaceId/daily/:date/previous", h.GetPreviousDailyNote)
+	g.GET("/:workspaceId/daily/:date/next", h.GetNextDailyNote)
+
+	// Tasks
+	g.GET("/:workspaceId/tasks", h.GetTasks)
+	g.PATCH("/:workspaceId/tasks/:id", h.UpdateTask)
+
+	// Reminders
+	g.GET("/:workspaceId/reminders", h.GetReminders)
+	g.POST("/:workspaceId/notes/:id/reminders", h.CreateNoteReminder)
+	g.POST("/:workspaceId/views/:viewId/objects/:id/reminders", h.CreateViewObjectReminder)
+	g.GET("/:workspaceId/reminders/:reminderId", h.GetReminder)
+	g.PUT("/:workspaceId/reminders/:reminderId", h.UpdateReminder)
+	g.DELETE("/:workspaceId/reminders/:reminderId", h.DeleteReminder)
+
 	// Widgets
 	g.GET("/:workspaceId/widgets", h.GetWidgets)
 	g.POST("/:workspaceId/widgets", h.CreateWidget)
@@ -72,4 +141,35 @@ func RegisterWorkspace(api *echo.Group, h handler.Handler, authMiddleware middle
 	g.POST("/:workspaceId/members", h.InviteMember)
 	g.PATCH("/:workspaceId/members/:userId/role", h.UpdateMemberRole)
 	g.DELETE("/:workspaceId/members/:userId", h.RemoveMember)
+
+	// Workspace Invitations
+	g.GET("/:workspaceId/invitations", h.GetWorkspaceInvitations)
+	g.DELETE("/:workspaceId/invitations/:id", h.RevokeWorkspaceInvitation)
+
+	// Workspace Invite Links
+	g.POST("/:workspaceId/invite-links", h.CreateWorkspaceInviteLink)
+	g.GET("/:workspaceId/invite-links", h.GetWorkspaceInviteLinks)
+	g.DELETE("/:workspaceId/invite-links/:id", h.RevokeWorkspaceInviteLink)
+
+	// Workspace Audit Log
+	g.GET("/:workspaceId/audit-events", h.GetWorkspaceAuditEvents)
+	g.GET("/:workspaceId/audit-events/export", h.ExportWorkspaceAuditEvents)
+
+	// Change feed (Server-Sent Events)
+	g.GET("/:workspaceId/events", h.StreamWorkspaceEvents)
+
+	// Who has notes and views open
+	g.GET("/:workspaceId/presence", h.GetWorkspacePresence)
+
+	// Webhooks
+	g.GET("/:workspaceId/webhooks", h.GetWebhooks)
+	g.POST("/:workspaceId/webhooks", h.CreateWebhook)
+	g.GET("/:workspaceId/webhooks/:id", h.GetWebhook)
+	g.PUT("/:workspaceId/webhooks/:id", h.UpdateWebhook)
+	g.DELETE("/:workspaceId/webhooks/:id", h.DeleteWebhook)
+	g.POST("/:workspaceId/webhooks/:id/secret", h.RotateWebhookSecret)
+	g.POST("/:workspaceId/webhooks/:id/ping", h.PingWebhook)
+	g.GET("/:workspaceId/webhooks/:id/deliveries", h.GetWebhookDeliveries)
+	g.GET("/:workspaceId/webhooks/:id/deliveries/:deliveryId", h.GetWebhookDelivery)
+	g.POST("/:workspaceId/webhooks/:id/deliveries/:deliveryId/redeliver", h.RedeliverWebhookDelivery)
 }
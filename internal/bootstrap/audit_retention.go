@@ -0,0 +1,36 @@
+package bootstrap
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/config"
+	"github.com/collabreef/collabreef/internal/db"
+	"github.com/collabreef/collabreef/internal/job"
+	"github.com/collabreef/collabreef/internal/model"
+)
+
+// scheduleAuditRetention deletes audit events older than AUDIT_RETENTION_DAYS
+// every day. A retention of 0 keeps events forever.
+func scheduleAuditRetention(runner *job.Runner, r db.DB) error {
+	days := config.C.GetInt(config.AUDIT_RETENTION_DAYS)
+	if days <= 0 {
+		return nil
+	}
+
+	runner.Handle("audit_retention", func(ctx context.Context, j model.Job) error {
+		cutoff := time.Now().UTC().AddDate(0, 0, -days).Format(time.RFC3339)
+
+		n, err := r.DeleteAuditEventsBefore(cutoff)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			log.Printf("Purged %d audit events older than %d days", n, days)
+		}
+		return nil
+	})
+
+	return runner.Schedule("audit_retention", "@daily")
+}
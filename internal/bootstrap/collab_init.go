@@ -0,0 +1,24 @@
+package bootstrap
+
+import (
+	"fmt"
+
+	"github.com/collabreef/collabreef/internal/collab"
+	"github.com/collabreef/collabreef/internal/config"
+	"github.com/collabreef/collabreef/internal/db"
+)
+
+// NewCollabServer returns the in-process collab server, or nil when editors
+// are proxied to the collab service
+func NewCollabServer(r db.DB) (*collab.Server, error) {
+	mode := config.C.GetString(config.COLLAB_MODE)
+
+	switch mode {
+	case "proxy":
+		return nil, nil
+	case "native":
+		return collab.NewServer(r), nil
+	}
+
+	return nil, fmt.Errorf("unsupported collab mode: %s", mode)
+}
@@ -0,0 +1,28 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/collabreef/collabreef/internal/config"
+	"github.com/collabreef/collabreef/internal/db"
+	"github.com/collabreef/collabreef/internal/job"
+	"github.com/collabreef/collabreef/internal/mailer"
+)
+
+// NewJobRunner returns the background job runner with the server's recurring
+// jobs scheduled. Other jobs register their handlers before it is started.
+func NewJobRunner(r db.DB, m mailer.Mailer) (*job.Runner, error) {
+	runner := job.NewRunner(r, job.Options{
+		MaxAttempts: config.C.GetInt(config.JOB_MAX_ATTEMPTS),
+		Retention:   time.Duration(config.C.GetInt(config.JOB_RETENTION_DAYS)) * 24 * time.Hour,
+	})
+
+	if err := scheduleAuditRetention(runner, r); err != nil {
+		return nil, err
+	}
+	if err := scheduleNotificationDigest(runner, r, m); err != nil {
+		return nil, err
+	}
+
+	return runner, nil
+}
@@ -0,0 +1,35 @@
+package bootstrap
+
+import (
+	"fmt"
+
+	"github.com/collabreef/collabreef/internal/config"
+	"github.com/collabreef/collabreef/internal/mailer"
+	"github.com/collabreef/collabreef/internal/mailer/filemailer"
+	"github.com/collabreef/collabreef/internal/mailer/logmailer"
+	"github.com/collabreef/collabreef/internal/mailer/smtpmailer"
+)
+
+func NewMailer() (mailer.Mailer, error) {
+	driver := config.C.GetString(config.MAIL_DRIVER)
+	from := config.C.GetString(config.MAIL_FROM)
+
+	switch driver {
+	case "log":
+		return logmailer.NewLogMailer(from), nil
+	case "file":
+		return filemailer.NewFileMailer(config.C.GetString(config.MAIL_FILE_DIR), from), nil
+	case "smtp":
+		smtpConfig := smtpmailer.SMTPConfig{
+			Host:     config.C.GetString(config.SMTP_HOST),
+			Port:     config.C.GetInt(config.SMTP_PORT),
+			Username: config.C.GetString(config.SMTP_USERNAME),
+			Password: config.C.GetString(config.SMTP_PASSWORD),
+			From:     from,
+			UseTLS:   config.C.GetBool(config.SMTP_USE_TLS),
+		}
+		return smtpmailer.NewSMTPMailer(smtpConfig)
+	}
+
+	return nil, fmt.Errorf("unsupported mail driver: %s", driver)
+}
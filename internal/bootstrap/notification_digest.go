@@ -0,0 +1,85 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/config"
+	"github.com/collabreef/collabreef/internal/db"
+	"github.com/collabreef/collabreef/internal/job"
+	"github.com/collabreef/collabreef/internal/mailer"
+	"github.com/collabreef/collabreef/internal/model"
+)
+
+// scheduleNotificationDigest emails users who asked for a daily digest their
+// unread notifications, every day at NOTIFICATION_DIGEST_HOUR (UTC). An hour
+// outside 0-23 sends none.
+func scheduleNotificationDigest(runner *job.Runner, r db.DB, m mailer.Mailer) error {
+	hour := config.C.GetInt(config.NOTIFICATION_DIGEST_HOUR)
+	if hour < 0 || hour > 23 || m == nil {
+		return nil
+	}
+
+	runner.Handle("notification_digest", func(ctx context.Context, j model.Job) error {
+		return sendNotificationDigests(r, m)
+	})
+
+	return runner.Schedule("notification_digest", fmt.Sprintf("0 %d * * *", hour))
+}
+
+func sendNotificationDigests(r db.DB, m mailer.Mailer) error {
+	notifications, err := r.FindNotifications(model.NotificationFilter{Unread: true, Unemailed: true})
+	if err != nil {
+		return err
+	}
+
+	byUser := map[string][]model.Notification{}
+	for _, n := range notifications {
+		byUser[n.UserID] = append(byUser[n.UserID], n)
+	}
+
+	link := strings.TrimRight(config.C.GetString(config.APP_BASE_URL), "/") + "/"
+	now := time.Now().UTC().Format(time.RFC3339)
+	sent := 0
+
+	for userID, list := range byUser {
+		ids := make([]string, len(list))
+		messages := make([]string, len(list))
+		// Oldest first, the way they happened
+		for i, n := range list {
+			ids[len(list)-1-i] = n.ID
+			messages[len(list)-1-i] = n.Message
+		}
+
+		// Claiming them first keeps other instances from sending them again.
+		// Users without a digest get theirs marked too, so turning it on
+		// doesn't send everything from before.
+		claimed, err := r.MarkNotificationsEmailed(ids, now)
+		if err != nil {
+			log.Printf("Failed to mark notifications of %s emailed: %v", userID, err)
+			continue
+		}
+		if claimed == 0 {
+			continue
+		}
+
+		user, err := r.FindUserByID(userID)
+		if err != nil || user.Disabled || user.Email == "" || !user.NotificationPreferences().EmailDigest {
+			continue
+		}
+		if err := m.Send(mailer.NotificationDigestMessage(user.Email, user.Name, messages, link)); err != nil {
+			log.Printf("Failed to send notification digest to %s: %v", user.Email, err)
+			continue
+		}
+		sent++
+	}
+
+	if sent > 0 {
+		log.Printf("Sent %d notification digests", sent)
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package bootstrap
+
+import (
+	"fmt"
+
+	"github.com/collabreef/collabreef/internal/config"
+	"github.com/collabreef/collabreef/internal/ratelimit"
+	"github.com/collabreef/collabreef/internal/ratelimit/memorystore"
+	"github.com/collabreef/collabreef/internal/ratelimit/postgresstore"
+)
+
+// NewLimiter returns nil when rate limiting is disabled, which allows every request
+func NewLimiter() (*ratelimit.Limiter, error) {
+	if !config.C.GetBool(config.RATELIMIT_ENABLED) {
+		return nil, nil
+	}
+
+	lockout := ratelimit.Lockout{
+		Threshold: config.C.GetInt(config.RATELIMIT_LOCKOUT_THRESHOLD),
+		Base:      config.C.GetDuration(config.RATELIMIT_LOCKOUT_BASE),
+		Max:       config.C.GetDuration(config.RATELIMIT_LOCKOUT_MAX),
+	}
+
+	storeType := config.C.GetString(config.RATELIMIT_STORE)
+	switch storeType {
+	case "memory":
+		return ratelimit.NewLimiter(memorystore.NewMemoryStore(), lockout), nil
+	case "postgres":
+		if config.C.GetString(config.DB_DRIVER) != "postgres" {
+			return nil, fmt.Errorf("rate limit store postgres requires the postgres database driver")
+		}
+		store, err := postgresstore.NewPostgresStore(config.C.GetString(config.DB_DSN))
+		if err != nil {
+			return nil, err
+		}
+		return ratelimit.NewLimiter(store, lockout), nil
+	}
+
+	return nil, fmt.Errorf("unsupported rate limit store: %s", storeType)
+}
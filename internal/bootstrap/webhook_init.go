@@ -0,0 +1,38 @@
+package bootstrap
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/config"
+	"github.com/collabreef/collabreef/internal/db"
+	"github.com/collabreef/collabreef/internal/urlfetcher"
+	"github.com/collabreef/collabreef/internal/webhook"
+)
+
+// NewWebhookDispatcher returns nil when webhooks are disabled, which drops every event
+func NewWebhookDispatcher(r db.DB) (*webhook.Dispatcher, error) {
+	if !config.C.GetBool(config.WEBHOOK_ENABLED) {
+		return nil, nil
+	}
+
+	var entries []string
+	for _, entry := range strings.Split(config.C.GetString(config.WEBHOOK_ALLOWLIST), ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+
+	allow, err := urlfetcher.ParseAllowlist(entries)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WEBHOOK_ALLOWLIST: %w", err)
+	}
+
+	return webhook.NewDispatcher(r, webhook.Options{
+		SecretKey:   config.C.GetString(config.APP_SECRET),
+		Allow:       allow,
+		MaxAttempts: config.C.GetInt(config.WEBHOOK_MAX_ATTEMPTS),
+		Retention:   time.Duration(config.C.GetInt(config.WEBHOOK_RETENTION_DAYS)) * 24 * time.Hour,
+	}), nil
+}
@@ -0,0 +1,194 @@
+package collab
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/yjs"
+)
+
+// document is a collaborative document held by the Server. mu guards all of
+// its fields except name and server.
+type document struct {
+	name   string
+	server *Server
+	mu     sync.Mutex
+
+	doc  *yjs.Doc
+	refs int
+	// conns maps each connection that has the document open to the
+	// awareness clients it announced and their clocks
+	conns     map[*connection]map[uint64]int
+	awareness map[uint64]awarenessState
+
+	// Unsaved changes, the user who made the last of them and since when the
+	// document is dirty
+	dirty      bool
+	updatedBy  string
+	dirtySince time.Time
+	timer      *time.Timer
+}
+
+// awarenessState is a client's presence, e.g. its cursor and user name, as
+// the JSON the client sent
+type awarenessState struct {
+	clock int
+	state string
+}
+
+func newDocument(s *Server, name string) *document {
+	return &document{
+		name:      name,
+		server:    s,
+		doc:       yjs.NewDoc(),
+		conns:     map[*connection]map[uint64]int{},
+		awareness: map[uint64]awarenessState{},
+	}
+}
+
+// broadcast sends a message to every connection other than except
+func (d *document) broadcast(data []byte, except *connection) {
+	for c := range d.conns {
+		if c != except {
+			c.send(data)
+		}
+	}
+}
+
+// apply applies an update from a connection and passes it on to the others
+func (d *document) apply(from *connection, update []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.doc.ApplyUpdate(update); err != nil {
+		return err
+	}
+
+	d.broadcast(syncMessage(d.name, syncUpdate, update), from)
+	d.changed(from.session.UserID)
+	return nil
+}
+
+// changed marks the document dirty and schedules storing it
+func (d *document) changed(userID string) {
+	now := time.Now()
+	if !d.dirty {
+		d.dirty = true
+		d.dirtySince = now
+	}
+	d.updatedBy = userID
+
+	delay := storeDelay
+	if max := d.dirtySince.Add(storeMaxDelay).Sub(now); max < delay {
+		delay = max
+	}
+
+	d.stopTimer()
+	d.timer = time.AfterFunc(delay, func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		d.store()
+	})
+}
+
+func (d *document) stopTimer() {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+}
+
+// store saves the document if it has unsaved changes
+func (d *document) store() {
+	if !d.dirty {
+		return
+	}
+	d.stopTimer()
+
+	if err := d.server.persist(d); err != nil {
+		// Stays dirty, so it's tried again with the next change or when the
+		// last editor leaves
+		log.Printf("Collab: failed to store %s: %v", d.name, err)
+		return
+	}
+	d.dirty = false
+}
+
+// updateAwareness applies an awareness update from a connection and passes it
+// on to every connection, including the sender, which counts it as traffic
+func (d *document) updateAwareness(from *connection, update []byte) error {
+	dec := yjs.NewDecoder(update)
+	n, err := dec.ReadVarUint()
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	clients := d.conns[from]
+	if clients == nil {
+		return nil
+	}
+	for i := uint64(0); i < n; i++ {
+		client, err := dec.ReadVarUint()
+		if err != nil {
+			return err
+		}
+		clock, err := dec.ReadVarUint()
+		if err != nil {
+			return err
+		}
+		state, err := dec.ReadVarString()
+		if err != nil {
+			return err
+		}
+
+		if state == "null" {
+			delete(d.awareness, client)
+			delete(clients, client)
+			continue
+		}
+		d.awareness[client] = awarenessState{clock: int(clock), state: state}
+		clients[client] = int(clock)
+	}
+
+	d.broadcast(awarenessMessage(d.name, update), nil)
+	return nil
+}
+
+// removeAwareness tells the others that a connection's clients are gone
+func (d *document) removeAwareness(c *connection) {
+	clients := d.conns[c]
+	if len(clients) == 0 {
+		return
+	}
+
+	e := yjs.NewEncoder()
+	e.WriteVarUint(uint64(len(clients)))
+	for client, clock := range clients {
+		if s, ok := d.awareness[client]; ok {
+			clock = s.clock
+		}
+		delete(d.awareness, client)
+
+		e.WriteVarUint(client)
+		e.WriteVarUint(uint64(clock + 1))
+		e.WriteVarString("null")
+	}
+
+	d.broadcast(awarenessMessage(d.name, e.Bytes()), c)
+}
+
+// encodeAwareness encodes the presence of everyone who has the document open
+func (d *document) encodeAwareness() []byte {
+	e := yjs.NewEncoder()
+	e.WriteVarUint(uint64(len(d.awareness)))
+	for client, s := range d.awareness {
+		e.WriteVarUint(client)
+		e.WriteVarUint(uint64(s.clock))
+		e.WriteVarString(s.state)
+	}
+	return e.Bytes()
+}
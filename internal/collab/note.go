@@ -0,0 +1,76 @@
+package collab
+
+import (
+	"unicode/utf16"
+
+	"github.com/collabreef/collabreef/internal/yjs"
+)
+
+// The note editor keeps the TipTap JSON in a text and the title in a map,
+// see web/src/hooks/use-note-collab.ts
+const (
+	noteContent = "content"
+	noteMeta    = "meta"
+	noteTitle   = "title"
+)
+
+// NoteDocumentName returns the name of a note's collaborative document
+func NoteDocumentName(noteID string) string {
+	return prefixNote + noteID
+}
+
+// ReadNote returns the title and content held by a note document. hasTitle is
+// false when no title was ever set in it.
+func ReadNote(doc *yjs.Doc) (title string, content string, hasTitle bool) {
+	content = doc.Get(noteContent).String()
+
+	if v, ok := doc.Get(noteMeta).Get(noteTitle); ok {
+		title, hasTitle = v.(string)
+	}
+
+	return title, content, hasTitle
+}
+
+// SetNote changes a note document to the given title and content. Only the
+// part of the text that differs is replaced, so cursors of people editing the
+// note elsewhere stay where they are. It reports whether anything changed.
+func SetNote(doc *yjs.Doc, title string, content string) bool {
+	changed := false
+
+	text := doc.Get(noteContent)
+	if current := text.String(); current != content {
+		before, after := utf16.Encode([]rune(current)), utf16.Encode([]rune(content))
+
+		prefix := 0
+		for prefix < len(before) && prefix < len(after) && before[prefix] == after[prefix] {
+			prefix++
+		}
+		suffix := 0
+		for suffix < len(before)-prefix && suffix < len(after)-prefix && before[len(before)-1-suffix] == after[len(after)-1-suffix] {
+			suffix++
+		}
+
+		// Don't cut through a surrogate pair
+		if prefix > 0 && isHighSurrogate(before[prefix-1]) {
+			prefix--
+		}
+		if suffix > 0 && isLowSurrogate(before[len(before)-suffix]) {
+			suffix--
+		}
+
+		text.Delete(prefix, len(before)-prefix-suffix)
+		text.Insert(prefix, string(utf16.Decode(after[prefix:len(after)-suffix])))
+		changed = true
+	}
+
+	meta := doc.Get(noteMeta)
+	if v, ok := meta.Get(noteTitle); !ok || v != title {
+		meta.Set(noteTitle, title)
+		changed = true
+	}
+
+	return changed
+}
+
+func isHighSurrogate(u uint16) bool { return u >= 0xd800 && u <= 0xdbff }
+func isLowSurrogate(u uint16) bool  { return u >= 0xdc00 && u <= 0xdfff }
@@ -0,0 +1,94 @@
+package collab
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/collabreef/collabreef/internal/yjs"
+)
+
+func TestSetNote(t *testing.T) {
+	for _, tt := range []struct {
+		name, before, after string
+	}{
+		{"insert", "hello world", "hello, world"},
+		{"delete", "hello, world", "hello world"},
+		{"replace all", "abc", "xyz"},
+		{"from empty", "", "abc"},
+		{"to empty", "abc", ""},
+		// 😀 is D83D DE00 and 😃 D83D DE03, they share the high surrogate
+		{"shared high surrogate", "a😀b", "a😃b"},
+		{"only a shared high surrogate", "😀", "😃"},
+		// 😀 and 𐘀 (D801 DE00) share the low surrogate
+		{"shared low surrogate", "😀", "𐘀"},
+		{"emoji after emoji", "😀😀", "😀😃😀"},
+		{"remove emoji", "x😀y", "xy"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := yjs.NewDoc()
+			SetNote(doc, "Title", tt.before)
+			base := doc.EncodeStateAsUpdate(nil)
+			before := doc.StateVector()
+
+			if changed := SetNote(doc, "Title", tt.after); changed != (tt.before != tt.after) {
+				t.Errorf("SetNote() = %v", changed)
+			}
+			title, content, hasTitle := ReadNote(doc)
+			if content != tt.after || title != "Title" || !hasTitle {
+				t.Fatalf("ReadNote() = %q, %q, %v, want %q", title, content, hasTitle, tt.after)
+			}
+			if !utf8.ValidString(content) || strings.ContainsRune(content, utf8.RuneError) {
+				t.Errorf("content %q has broken characters", content)
+			}
+
+			// Another editor that had the note before gets the same text
+			peer := yjs.NewDoc()
+			if err := peer.ApplyUpdate(base); err != nil {
+				t.Fatal(err)
+			}
+			if err := peer.ApplyUpdate(doc.EncodeStateAsUpdate(before)); err != nil {
+				t.Fatal(err)
+			}
+			if _, content, _ := ReadNote(peer); content != tt.after {
+				t.Errorf("peer content = %q, want %q", content, tt.after)
+			}
+		})
+	}
+}
+
+func TestSetNoteKeepsUnchangedText(t *testing.T) {
+	doc := yjs.NewDoc()
+	SetNote(doc, "Title", "hello world")
+	before := doc.StateVector()
+
+	SetNote(doc, "Title", "hello, world")
+
+	// Only "," is inserted before " world", so the update has no deletions,
+	// i.e. it ends with an empty delete set
+	update := doc.EncodeStateAsUpdate(before)
+	if update[len(update)-1] != 0 {
+		t.Errorf("update %v deletes text", update)
+	}
+	if !strings.Contains(string(update), "\x01,") {
+		t.Errorf("update %v doesn't insert \",\"", update)
+	}
+	if strings.Contains(string(update), "world") || strings.Contains(string(update), "hello") {
+		t.Errorf("update %v inserts unchanged text", update)
+	}
+}
+
+func TestSetNoteTitle(t *testing.T) {
+	doc := yjs.NewDoc()
+	if _, _, hasTitle := ReadNote(doc); hasTitle {
+		t.Error("empty document has a title")
+	}
+	SetNote(doc, "One", "")
+	if SetNote(doc, "One", "") {
+		t.Error("SetNote() reports a change for the same note")
+	}
+	SetNote(doc, "Two", "")
+	if title, _, _ := ReadNote(doc); title != "Two" {
+		t.Errorf("title = %q, want Two", title)
+	}
+}
@@ -0,0 +1,185 @@
+package collab
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/db"
+	"github.com/collabreef/collabreef/internal/model"
+	"github.com/collabreef/collabreef/internal/yjs"
+	"gorm.io/gorm"
+)
+
+// Direction tells Reconcile which side wins when a note and its document differ
+type Direction string
+
+const (
+	// PreferNewest keeps whichever side was changed last
+	PreferNewest   Direction = "newest"
+	PreferDocument Direction = "document"
+	PreferNote     Direction = "note"
+)
+
+func IsValidDirection(d Direction) bool {
+	switch d {
+	case PreferNewest, PreferDocument, PreferNote:
+		return true
+	}
+	return false
+}
+
+// Drift is how a note and its document compare
+type Drift int
+
+const (
+	NoDocument Drift = iota
+	InSync
+	NoteBehind
+	DocumentBehind
+)
+
+// Updater changes a document the way an editor would. Remote does so through
+// the collab service, Server in process.
+type Updater interface {
+	Update(ctx context.Context, document string, userID string, edit func(doc *yjs.Doc) bool) error
+}
+
+// NoteSync keeps notes and their collaborative documents in step
+type NoteSync struct {
+	db      db.DB
+	updater Updater
+	locks   [64]sync.Mutex
+}
+
+func NewNoteSync(r db.DB, u Updater) *NoteSync {
+	return &NoteSync{db: r, updater: u}
+}
+
+// lock serializes changes to the same document
+func (s *NoteSync) lock(document string) func() {
+	h := fnv.New32a()
+	h.Write([]byte(document))
+	l := &s.locks[h.Sum32()%uint32(len(s.locks))]
+	l.Lock()
+	return l.Unlock
+}
+
+// Push writes a note's title and content into its document, so editors that
+// have it open see the change and it isn't overwritten when they save. When
+// the collab service can't be reached, the stored state is changed instead.
+func (s *NoteSync) Push(ctx context.Context, note model.Note, userID string) error {
+	document := NoteDocumentName(note.ID)
+	defer s.lock(document)()
+
+	edit := func(doc *yjs.Doc) bool {
+		return SetNote(doc, note.Title, note.Content)
+	}
+
+	err := s.updater.Update(ctx, document, userID, edit)
+	if !errors.Is(err, ErrUnavailable) {
+		return err
+	}
+
+	log.Printf("Collab service unreachable, updating stored state of %s: %v", document, err)
+
+	stored, err := s.db.FindYjsDocument(document)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// The document is created from the note when it is first opened
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	doc := yjs.NewDoc()
+	if err := doc.ApplyUpdate(stored.Data); err != nil {
+		return err
+	}
+	if !edit(doc) {
+		return nil
+	}
+
+	return s.db.SaveYjsDocument(model.YjsDocument{
+		Name:      document,
+		Data:      doc.EncodeStateAsUpdate(nil),
+		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// Delete removes a note's stored document
+func (s *NoteSync) Delete(noteID string) error {
+	return s.db.DeleteYjsDocument(NoteDocumentName(noteID))
+}
+
+// Reconcile compares a note with its stored document and, unless dryRun is
+// set, copies the winning side over the other. The returned drift is the state
+// found before repairing.
+func (s *NoteSync) Reconcile(ctx context.Context, note model.Note, prefer Direction, dryRun bool) (Drift, error) {
+	document := NoteDocumentName(note.ID)
+
+	stored, err := s.db.FindYjsDocument(document)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return NoDocument, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	doc := yjs.NewDoc()
+	if err := doc.ApplyUpdate(stored.Data); err != nil {
+		return 0, err
+	}
+
+	title, content, hasTitle := ReadNote(doc)
+	if !hasTitle {
+		title = note.Title
+	}
+	if title == note.Title && content == note.Content {
+		return InSync, nil
+	}
+
+	drift := NoteBehind
+	switch prefer {
+	case PreferNote:
+		drift = DocumentBehind
+	case PreferNewest:
+		if newer(note.UpdatedAt, stored.UpdatedAt) {
+			drift = DocumentBehind
+		}
+	}
+
+	if dryRun {
+		return drift, nil
+	}
+
+	if drift == DocumentBehind {
+		return drift, s.Push(ctx, note, note.UpdatedBy)
+	}
+
+	note.Title = title
+	note.Content = content
+	note.UpdatedAt = stored.UpdatedAt
+	if t, err := time.Parse(time.RFC3339, stored.UpdatedAt); err == nil {
+		note.UpdatedAt = t.UTC().Format(time.RFC3339)
+	}
+
+	return drift, s.db.UpdateNote(note)
+}
+
+// newer reports whether timestamp a is later than b. Timestamps that can't be
+// parsed count as oldest.
+func newer(a, b string) bool {
+	ta, errA := time.Parse(time.RFC3339, a)
+	tb, errB := time.Parse(time.RFC3339, b)
+	if errA != nil {
+		return false
+	}
+	if errB != nil {
+		return true
+	}
+	return ta.After(tb)
+}
@@ -0,0 +1,325 @@
+package collab
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/model"
+	"github.com/collabreef/collabreef/internal/yjs"
+	"gorm.io/gorm"
+)
+
+// Prefixes of document names, followed by the ID of the note or view, and the
+// shared types the editors use in them
+const (
+	prefixNote        = "note:"
+	prefixWhiteboard  = "whiteboard:"
+	prefixSpreadsheet = "spreadsheet:"
+	prefixView        = "view:"
+
+	whiteboardCanvas  = "canvas-objects"
+	whiteboardObjects = "view-objects"
+	spreadsheetSheets = "spreadsheet"
+	spreadsheetOps    = "ops"
+)
+
+// ViewDocumentName returns the name of a view's collaborative document, which
+// depends on the kind of view
+func ViewDocumentName(viewType string, viewID string) string {
+	switch viewType {
+	case "whiteboard":
+		return prefixWhiteboard + viewID
+	case "spreadsheet":
+		return prefixSpreadsheet + viewID
+	}
+	return prefixView + viewID
+}
+
+// load fills a new document from its stored state, or when there is none,
+// from the note or view it belongs to
+func (d *document) load() {
+	stored, err := d.server.db.FindYjsDocument(d.name)
+	if err == nil {
+		if err := d.doc.ApplyUpdate(stored.Data); err != nil {
+			log.Printf("Collab: failed to load %s: %v", d.name, err)
+			return
+		}
+
+		// Spreadsheets forward operations through this array; they're
+		// applied to the sheets already and only matter while editing
+		if ops := d.doc.Get(spreadsheetOps); ops.Len() > 0 {
+			ops.Delete(0, ops.Len())
+		}
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Collab: failed to load %s: %v", d.name, err)
+		return
+	}
+
+	kind, id, _ := strings.Cut(d.name, ":")
+	switch kind + ":" {
+	case prefixNote:
+		err = d.server.initNote(d.doc, id)
+	case prefixWhiteboard:
+		err = d.server.initWhiteboard(d.doc, id)
+	case prefixSpreadsheet:
+		err = d.server.initSpreadsheet(d.doc, id)
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Collab: failed to initialize %s: %v", d.name, err)
+	}
+}
+
+func (s *Server) initNote(doc *yjs.Doc, noteID string) error {
+	note, err := s.db.FindNote(model.Note{ID: noteID})
+	if err != nil {
+		return err
+	}
+
+	doc.Get(noteContent).Insert(0, note.Content)
+	doc.Get(noteMeta).Set(noteTitle, note.Title)
+	return nil
+}
+
+func (s *Server) initWhiteboard(doc *yjs.Doc, viewID string) error {
+	view, err := s.db.FindView(model.View{ID: viewID})
+	if err != nil {
+		return err
+	}
+
+	canvas := doc.Get(whiteboardCanvas)
+	var objects map[string]any
+	if err := json.Unmarshal([]byte(view.Data), &objects); err == nil {
+		for key, value := range objects {
+			canvas.Set(key, value)
+		}
+	}
+
+	rows, err := s.db.FindViewObjects(model.ViewObjectFilter{ViewID: viewID, PageSize: -1, PageNumber: 1})
+	if err != nil {
+		return err
+	}
+	viewObjects := doc.Get(whiteboardObjects)
+	for _, o := range rows {
+		viewObjects.Set(o.ID, map[string]any{
+			"id":         o.ID,
+			"type":       o.Type,
+			"name":       o.Name,
+			"data":       o.Data,
+			"created_by": o.CreatedBy,
+			"updated_by": o.UpdatedBy,
+			"created_at": o.CreatedAt,
+			"updated_at": o.UpdatedAt,
+		})
+	}
+	return nil
+}
+
+func (s *Server) initSpreadsheet(doc *yjs.Doc, viewID string) error {
+	view, err := s.db.FindView(model.View{ID: viewID})
+	if err != nil {
+		return err
+	}
+
+	var parsed any
+	if err := json.Unmarshal([]byte(view.Data), &parsed); err != nil {
+		return nil
+	}
+
+	sheets := doc.Get(spreadsheetSheets)
+	switch v := parsed.(type) {
+	case []any:
+		for _, sheet := range v {
+			if m, ok := sheet.(map[string]any); ok {
+				if id, ok := m["id"].(string); ok && id != "" {
+					sheets.Set(id, m)
+				}
+			}
+		}
+	case map[string]any:
+		for key, value := range v {
+			sheets.Set(key, value)
+		}
+	}
+	return nil
+}
+
+// persist saves a document's state and copies its content to the note or
+// view it belongs to
+func (s *Server) persist(d *document) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	err := s.db.SaveYjsDocument(model.YjsDocument{
+		Name:      d.name,
+		Data:      d.doc.EncodeStateAsUpdate(nil),
+		UpdatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	kind, id, _ := strings.Cut(d.name, ":")
+	switch kind + ":" {
+	case prefixNote:
+		err = s.persistNote(d, id, now)
+	case prefixWhiteboard:
+		err = s.persistWhiteboard(d.doc, id, now)
+	case prefixSpreadsheet:
+		err = s.persistSpreadsheet(d.doc, id, now)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (s *Server) persistNote(d *document, noteID string, now string) error {
+	note, err := s.db.FindNote(model.Note{ID: noteID})
+	if err != nil {
+		return err
+	}
+
+	before := note
+	title, content, hasTitle := ReadNote(d.doc)
+	if hasTitle {
+		note.Title = title
+	}
+	note.Content = content
+	note.UpdatedAt = now
+	note.UpdatedBy = d.updatedBy
+
+	if err := s.db.UpdateNote(note); err != nil {
+		return err
+	}
+	if s.noteStored != nil {
+		s.noteStored(before, note)
+	}
+	return nil
+}
+
+func (s *Server) persistWhiteboard(doc *yjs.Doc, viewID string, now string) error {
+	if _, err := s.db.FindView(model.View{ID: viewID}); err != nil {
+		return err
+	}
+
+	canvas := doc.Get(whiteboardCanvas)
+	objects := map[string]any{}
+	for _, key := range canvas.Keys() {
+		objects[key], _ = canvas.Get(key)
+	}
+	data, err := json.Marshal(objects)
+	if err != nil {
+		return err
+	}
+	if err := s.db.UpdateView(model.View{ID: viewID, Data: string(data), UpdatedAt: now}); err != nil {
+		return err
+	}
+
+	rows, err := s.db.FindViewObjects(model.ViewObjectFilter{ViewID: viewID, PageSize: -1, PageNumber: 1})
+	if err != nil {
+		return err
+	}
+
+	viewObjects := doc.Get(whiteboardObjects)
+	stored := map[string]bool{}
+	for _, o := range rows {
+		stored[o.ID] = true
+		if _, ok := viewObjects.Get(o.ID); !ok {
+			if err := s.db.DeleteViewObject(model.ViewObject{ID: o.ID}); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, id := range viewObjects.Keys() {
+		value, _ := viewObjects.Get(id)
+		fields, _ := value.(map[string]any)
+
+		o := model.ViewObject{
+			ID:        id,
+			ViewID:    viewID,
+			Name:      stringField(fields, "name", ""),
+			Type:      stringField(fields, "type", ""),
+			Data:      dataField(fields["data"]),
+			UpdatedBy: stringField(fields, "updated_by", "system"),
+			UpdatedAt: now,
+		}
+		if stored[id] {
+			err = s.db.UpdateViewObject(o)
+		} else {
+			o.CreatedBy = stringField(fields, "created_by", "system")
+			o.CreatedAt = stringField(fields, "created_at", now)
+			err = s.db.CreateViewObject(o)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) persistSpreadsheet(doc *yjs.Doc, viewID string, now string) error {
+	if _, err := s.db.FindView(model.View{ID: viewID}); err != nil {
+		return err
+	}
+
+	sheets := doc.Get(spreadsheetSheets)
+	list := []any{}
+	for _, key := range sheets.Keys() {
+		// Keys starting with an underscore hold editor state, not sheets
+		if !strings.HasPrefix(key, "_") {
+			value, _ := sheets.Get(key)
+			list = append(list, value)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return sheetOrder(list[i]) < sheetOrder(list[j])
+	})
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	return s.db.UpdateView(model.View{ID: viewID, Data: string(data), UpdatedAt: now})
+}
+
+func stringField(fields map[string]any, key string, fallback string) string {
+	if s, ok := fields[key].(string); ok && s != "" {
+		return s
+	}
+	return fallback
+}
+
+// dataField returns a view object's data, which the editors keep either as a
+// JSON string or as the decoded value
+func dataField(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	if v == nil {
+		v = map[string]any{}
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "{}"
+	}
+	return string(b)
+}
+
+func sheetOrder(sheet any) float64 {
+	m, _ := sheet.(map[string]any)
+	switch order := m["order"].(type) {
+	case float64:
+		return order
+	case int:
+		return float64(order)
+	case int64:
+		return float64(order)
+	}
+	return 0
+}
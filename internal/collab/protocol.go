@@ -0,0 +1,113 @@
+// Package collab works with the collaborative documents the editors share: it
+// speaks the Hocuspocus protocol, either to the collab service or as a server
+// in place of it, and maps documents to the notes and views they belong to.
+package collab
+
+import (
+	"github.com/collabreef/collabreef/internal/yjs"
+)
+
+// Hocuspocus message types. Every message starts with the document name,
+// followed by the type and its payload.
+const (
+	messageSync           = 0
+	messageAwareness      = 1
+	messageAuth           = 2
+	messageQueryAwareness = 3
+	messageSyncReply      = 4
+	messageStateless      = 5
+	messageClose          = 7
+	messageSyncStatus     = 8
+)
+
+// y-sync steps within a sync message
+const (
+	syncStep1  = 0
+	syncStep2  = 1
+	syncUpdate = 2
+)
+
+// Auth message subtypes
+const (
+	authToken            = 0
+	authPermissionDenied = 1
+	authAuthenticated    = 2
+)
+
+// message is a decoded Hocuspocus message
+type message struct {
+	document string
+	kind     uint64
+	body     *yjs.Decoder
+}
+
+func readMessage(data []byte) (message, error) {
+	dec := yjs.NewDecoder(data)
+
+	document, err := dec.ReadVarString()
+	if err != nil {
+		return message{}, err
+	}
+	kind, err := dec.ReadVarUint()
+	if err != nil {
+		return message{}, err
+	}
+
+	return message{document: document, kind: kind, body: dec}, nil
+}
+
+func newMessage(document string, kind uint64) *yjs.Encoder {
+	e := yjs.NewEncoder()
+	e.WriteVarString(document)
+	e.WriteVarUint(kind)
+	return e
+}
+
+func authMessage(document string, token string) []byte {
+	e := newMessage(document, messageAuth)
+	e.WriteVarUint(authToken)
+	e.WriteVarString(token)
+	return e.Bytes()
+}
+
+func syncMessage(document string, step uint64, payload []byte) []byte {
+	e := newMessage(document, messageSync)
+	e.WriteVarUint(step)
+	e.WriteVarBytes(payload)
+	return e.Bytes()
+}
+
+func authenticatedMessage(document string, readOnly bool) []byte {
+	scope := "read-write"
+	if readOnly {
+		scope = "readonly"
+	}
+
+	e := newMessage(document, messageAuth)
+	e.WriteVarUint(authAuthenticated)
+	e.WriteVarString(scope)
+	return e.Bytes()
+}
+
+func permissionDeniedMessage(document string, reason string) []byte {
+	e := newMessage(document, messageAuth)
+	e.WriteVarUint(authPermissionDenied)
+	e.WriteVarString(reason)
+	return e.Bytes()
+}
+
+func syncStatusMessage(document string, saved bool) []byte {
+	e := newMessage(document, messageSyncStatus)
+	if saved {
+		e.WriteVarUint(1)
+	} else {
+		e.WriteVarUint(0)
+	}
+	return e.Bytes()
+}
+
+func awarenessMessage(document string, update []byte) []byte {
+	e := newMessage(document, messageAwareness)
+	e.WriteVarBytes(update)
+	return e.Bytes()
+}
@@ -0,0 +1,160 @@
+package collab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/yjs"
+	"golang.org/x/net/websocket"
+)
+
+// ErrUnavailable is returned when the collab service can't be reached
+var ErrUnavailable = errors.New("collab service unavailable")
+
+// maxMessageBytes bounds what the collab service may send in one message
+const maxMessageBytes = 64 << 20
+
+// Remote edits documents held by the collab service. It connects like an
+// editor does, so changes reach everyone who has the document open and the
+// service stores them as usual.
+type Remote struct {
+	url    *url.URL
+	secret []byte
+}
+
+func NewRemote(collabURL *url.URL, secret []byte) *Remote {
+	return &Remote{url: collabURL, secret: secret}
+}
+
+// Update loads a document from the collab service, lets edit change it and
+// sends the changes back. edit reports whether it changed anything.
+func (r *Remote) Update(ctx context.Context, document string, userID string, edit func(doc *yjs.Doc) bool) error {
+	ws, err := r.dial(ctx, Session{UserID: userID, UserName: "Collabreef", Document: document})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer ws.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		ws.SetDeadline(deadline)
+	}
+
+	doc := yjs.NewDoc()
+
+	if err := send(ws, authMessage(document, "")); err != nil {
+		return err
+	}
+	if err := send(ws, syncMessage(document, syncStep1, doc.EncodeStateVector())); err != nil {
+		return err
+	}
+	if err := waitForState(ws, document, doc); err != nil {
+		return err
+	}
+
+	sv := doc.StateVector()
+	if !edit(doc) {
+		return nil
+	}
+
+	if err := send(ws, syncMessage(document, syncUpdate, doc.EncodeStateAsUpdate(sv))); err != nil {
+		return err
+	}
+
+	// Messages are handled in order, so the answer to another sync step 1
+	// means the update has been applied
+	if err := send(ws, syncMessage(document, syncStep1, doc.EncodeStateVector())); err != nil {
+		return err
+	}
+	return waitForState(ws, document, doc)
+}
+
+func (r *Remote) dial(ctx context.Context, session Session) (*websocket.Conn, error) {
+	token, err := SignToken(r.secret, session, TokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	u := *r.url
+	switch u.Scheme {
+	case "https", "wss":
+		u.Scheme = "wss"
+	default:
+		u.Scheme = "ws"
+	}
+	u.Path = "/"
+
+	config, err := websocket.NewConfig(u.String(), r.url.String())
+	if err != nil {
+		return nil, err
+	}
+	config.Header = http.Header{}
+	config.Header.Set(TokenHeader, token)
+
+	dialCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	ws, err := config.DialContext(dialCtx)
+	if err != nil {
+		return nil, err
+	}
+	ws.MaxPayloadBytes = maxMessageBytes
+
+	return ws, nil
+}
+
+func send(ws *websocket.Conn, data []byte) error {
+	return websocket.Message.Send(ws, data)
+}
+
+// waitForState reads messages until the document's state arrives in a sync
+// step 2 and applies it. Live updates arriving meanwhile are applied too.
+func waitForState(ws *websocket.Conn, document string, doc *yjs.Doc) error {
+	for {
+		var data []byte
+		if err := websocket.Message.Receive(ws, &data); err != nil {
+			return err
+		}
+
+		msg, err := readMessage(data)
+		if err != nil {
+			return err
+		}
+		if msg.document != document {
+			continue
+		}
+
+		switch msg.kind {
+		case messageAuth:
+			if kind, _ := msg.body.ReadVarUint(); kind == authPermissionDenied {
+				reason, _ := msg.body.ReadVarString()
+				return fmt.Errorf("collab service denied access to %s: %s", document, reason)
+			}
+
+		case messageClose:
+			return fmt.Errorf("collab service closed %s", document)
+
+		case messageSync:
+			step, err := msg.body.ReadVarUint()
+			if err != nil {
+				return err
+			}
+			if step == syncStep1 {
+				continue
+			}
+			update, err := msg.body.ReadVarBytes()
+			if err != nil {
+				return err
+			}
+			if err := doc.ApplyUpdate(update); err != nil {
+				return err
+			}
+			if step == syncStep2 {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,404 @@
+package collab
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/db"
+	"github.com/collabreef/collabreef/internal/model"
+	"github.com/collabreef/collabreef/internal/yjs"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// storeDelay is how long a document has to be quiet before it is stored,
+	// storeMaxDelay how long it may go unstored while being edited
+	storeDelay    = 2 * time.Second
+	storeMaxDelay = 10 * time.Second
+
+	// keepAliveInterval must stay below the provider's 30 second reconnect
+	// timeout, which a connection without traffic runs into
+	keepAliveInterval = 15 * time.Second
+
+	// sendQueueSize bounds the messages waiting for a slow client before it is
+	// disconnected
+	sendQueueSize = 256
+)
+
+// Session is what a connection may do. The web server decides it when the
+// connection is upgraded, after authenticating the user.
+type Session struct {
+	UserID   string `json:"user_id"`
+	UserName string `json:"user_name"`
+	// Document is the name of the only document the connection may open
+	Document string `json:"document"`
+	ReadOnly bool   `json:"read_only"`
+}
+
+// Server holds collaborative documents in memory and syncs them with editors
+// over the Hocuspocus protocol, so no separate collab service is needed.
+// Documents are loaded from and stored to the database like the collab
+// service does.
+type Server struct {
+	db   db.DB
+	mu   sync.Mutex
+	docs map[string]*document
+
+	noteStored func(before model.Note, after model.Note)
+}
+
+func NewServer(r db.DB) *Server {
+	return &Server{db: r, docs: map[string]*document{}}
+}
+
+// OnNoteStored sets a function that is called with a note before and after
+// editors' changes are copied to it. Set it before serving connections.
+func (s *Server) OnNoteStored(f func(before model.Note, after model.Note)) {
+	s.noteStored = f
+}
+
+// ServeWebSocket upgrades the request and serves the connection until it is
+// closed
+func (s *Server) ServeWebSocket(w http.ResponseWriter, r *http.Request, session Session) {
+	ws := websocket.Server{
+		// Access was checked before the request was handed over; like the
+		// collab service, the origin isn't restricted
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			ws.MaxPayloadBytes = maxMessageBytes
+			s.serve(ws, session)
+		},
+	}
+	ws.ServeHTTP(w, r)
+}
+
+// Update lets edit change a document like an editor would: people who have it
+// open receive the change and it is stored as usual. edit reports whether it
+// changed anything.
+func (s *Server) Update(ctx context.Context, document string, userID string, edit func(doc *yjs.Doc) bool) error {
+	d := s.acquire(document)
+	defer s.release(d)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	sv := d.doc.StateVector()
+	if !edit(d.doc) {
+		return nil
+	}
+
+	d.broadcast(syncMessage(document, syncUpdate, d.doc.EncodeStateAsUpdate(sv)), nil)
+	d.changed(userID)
+	return nil
+}
+
+// Close stores the documents that have unsaved changes. Connections are left
+// to the shutdown of the process.
+func (s *Server) Close() {
+	s.mu.Lock()
+	docs := make([]*document, 0, len(s.docs))
+	for _, d := range s.docs {
+		docs = append(docs, d)
+	}
+	s.mu.Unlock()
+
+	for _, d := range docs {
+		d.mu.Lock()
+		d.store()
+		d.mu.Unlock()
+	}
+}
+
+// acquire returns a document, loading it when nobody has it open yet. Every
+// acquire must be followed by a release.
+func (s *Server) acquire(name string) *document {
+	s.mu.Lock()
+
+	d := s.docs[name]
+	if d != nil {
+		d.mu.Lock()
+		d.refs++
+		d.mu.Unlock()
+		s.mu.Unlock()
+		return d
+	}
+
+	d = newDocument(s, name)
+	d.refs = 1
+	d.mu.Lock()
+	s.docs[name] = d
+	s.mu.Unlock()
+
+	// Others wait on the document's lock until it is loaded
+	d.load()
+	d.mu.Unlock()
+
+	return d
+}
+
+// release stores and unloads a document once nobody has it open anymore
+func (s *Server) release(d *document) {
+	d.mu.Lock()
+	d.refs--
+	if d.refs == 0 {
+		d.store()
+	}
+	d.mu.Unlock()
+
+	s.mu.Lock()
+	d.mu.Lock()
+	if d.refs == 0 && s.docs[d.name] == d {
+		delete(s.docs, d.name)
+		d.stopTimer()
+	}
+	d.mu.Unlock()
+	s.mu.Unlock()
+}
+
+// connection is one editor's websocket, which may have several documents open
+type connection struct {
+	server  *Server
+	ws      *websocket.Conn
+	session Session
+	out     chan []byte
+	done    chan struct{}
+	once    sync.Once
+
+	mu   sync.Mutex
+	docs map[string]*document // open documents by name
+}
+
+func (s *Server) serve(ws *websocket.Conn, session Session) {
+	c := &connection{
+		server:  s,
+		ws:      ws,
+		session: session,
+		out:     make(chan []byte, sendQueueSize),
+		done:    make(chan struct{}),
+		docs:    map[string]*document{},
+	}
+
+	go c.write()
+	defer func() {
+		c.close()
+		for _, d := range c.documents() {
+			c.leave(d)
+		}
+	}()
+
+	for {
+		var data []byte
+		if err := websocket.Message.Receive(ws, &data); err != nil {
+			return
+		}
+
+		msg, err := readMessage(data)
+		if err != nil {
+			log.Printf("Collab: dropping connection of user %s: %v", session.UserID, err)
+			return
+		}
+		if err := c.handle(msg); err != nil {
+			log.Printf("Collab: dropping connection of user %s on %s: %v", session.UserID, msg.document, err)
+			return
+		}
+	}
+}
+
+// send queues a message for the client. A client that doesn't keep up is
+// disconnected rather than slowing down everyone else.
+func (c *connection) send(data []byte) {
+	select {
+	case c.out <- data:
+	case <-c.done:
+	default:
+		c.close()
+	}
+}
+
+func (c *connection) close() {
+	c.once.Do(func() {
+		close(c.done)
+		c.ws.Close()
+	})
+}
+
+// documents returns the documents the connection has open
+func (c *connection) documents() []*document {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	docs := make([]*document, 0, len(c.docs))
+	for _, d := range c.docs {
+		docs = append(docs, d)
+	}
+	return docs
+}
+
+func (c *connection) write() {
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case data := <-c.out:
+			if err := websocket.Message.Send(c.ws, data); err != nil {
+				c.close()
+				return
+			}
+		case <-keepAlive.C:
+			// An empty awareness update is the lightest message the
+			// provider counts as traffic
+			for _, d := range c.documents() {
+				if err := websocket.Message.Send(c.ws, awarenessMessage(d.name, []byte{0})); err != nil {
+					c.close()
+					return
+				}
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *connection) handle(msg message) error {
+	if msg.kind == messageAuth {
+		return c.authenticate(msg)
+	}
+
+	// Everything else needs the document to be opened first
+	c.mu.Lock()
+	d := c.docs[msg.document]
+	c.mu.Unlock()
+	if d == nil {
+		return nil
+	}
+
+	switch msg.kind {
+	case messageSync, messageSyncReply:
+		return c.sync(d, msg)
+
+	case messageAwareness:
+		update, err := msg.body.ReadVarBytes()
+		if err != nil {
+			return err
+		}
+		return d.updateAwareness(c, update)
+
+	case messageQueryAwareness:
+		d.mu.Lock()
+		update := d.encodeAwareness()
+		d.mu.Unlock()
+		c.send(awarenessMessage(d.name, update))
+
+	case messageClose:
+		c.leave(d)
+	}
+
+	return nil
+}
+
+// authenticate opens a document for the connection. The user was
+// authenticated when the connection was upgraded, so the token is ignored and
+// only the document is checked against the session.
+func (c *connection) authenticate(msg message) error {
+	if kind, err := msg.body.ReadVarUint(); err != nil || kind != authToken {
+		return err
+	}
+
+	if msg.document != c.session.Document {
+		c.send(permissionDeniedMessage(msg.document, "forbidden"))
+		return nil
+	}
+
+	c.mu.Lock()
+	d := c.docs[msg.document]
+	c.mu.Unlock()
+	if d != nil {
+		c.send(authenticatedMessage(msg.document, c.session.ReadOnly))
+		return nil
+	}
+
+	d = c.server.acquire(msg.document)
+	c.mu.Lock()
+	c.docs[msg.document] = d
+	c.mu.Unlock()
+
+	d.mu.Lock()
+	d.conns[c] = map[uint64]int{}
+	awareness := d.encodeAwareness()
+	hasAwareness := len(d.awareness) > 0
+	d.mu.Unlock()
+
+	c.send(authenticatedMessage(msg.document, c.session.ReadOnly))
+	if hasAwareness {
+		c.send(awarenessMessage(msg.document, awareness))
+	}
+	return nil
+}
+
+func (c *connection) sync(d *document, msg message) error {
+	step, err := msg.body.ReadVarUint()
+	if err != nil {
+		return err
+	}
+	payload, err := msg.body.ReadVarBytes()
+	if err != nil {
+		return err
+	}
+
+	switch step {
+	case syncStep1:
+		sv, err := yjs.DecodeStateVector(payload)
+		if err != nil {
+			return err
+		}
+
+		d.mu.Lock()
+		update := d.doc.EncodeStateAsUpdate(sv)
+		own := d.doc.EncodeStateVector()
+		d.mu.Unlock()
+
+		c.send(syncMessage(d.name, syncStep2, update))
+		if msg.kind == messageSync {
+			// Ask for what the client has and we don't, e.g. offline edits
+			c.send(syncMessage(d.name, syncStep1, own))
+		}
+
+	case syncStep2, syncUpdate:
+		if c.session.ReadOnly {
+			// Read-only clients may finish the initial sync, but their
+			// changes are dropped
+			if step == syncUpdate {
+				c.send(syncStatusMessage(d.name, false))
+			}
+			return nil
+		}
+
+		if err := d.apply(c, payload); err != nil {
+			return err
+		}
+		if step == syncUpdate {
+			c.send(syncStatusMessage(d.name, true))
+		}
+	}
+
+	return nil
+}
+
+// leave closes a document for the connection, removing its users from the
+// document's awareness
+func (c *connection) leave(d *document) {
+	c.mu.Lock()
+	delete(c.docs, d.name)
+	c.mu.Unlock()
+
+	d.mu.Lock()
+	d.removeAwareness(c)
+	delete(d.conns, c)
+	d.mu.Unlock()
+
+	c.server.release(d)
+}
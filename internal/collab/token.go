@@ -0,0 +1,66 @@
+package collab
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TokenTTL is how long a handshake token can be used to open a connection.
+// It's only checked when connecting, so it can be short.
+const TokenTTL = time.Minute
+
+// TokenHeader carries the token on the request that opens the connection
+const TokenHeader = "X-Collab-Token"
+
+// tokenAudience keeps handshake tokens apart from other JWTs
+const tokenAudience = "collab"
+
+var ErrInvalidToken = errors.New("invalid collab token")
+
+// SignToken creates the token the web server hands the collab service with a
+// connection, so the service can trust who connects to which document. It is
+// an HS256 JWT signed with the shared COLLAB_SECRET.
+func SignToken(secret []byte, s Session, ttl time.Duration) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"aud":       tokenAudience,
+		"sub":       s.UserID,
+		"name":      s.UserName,
+		"doc":       s.Document,
+		"read_only": s.ReadOnly,
+		"exp":       time.Now().Add(ttl).Unix(),
+	})
+	return token.SignedString(secret)
+}
+
+// VerifyToken checks a token's signature and expiry and returns the session
+// it grants
+func VerifyToken(secret []byte, tokenString string) (Session, time.Time, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return secret, nil
+	}, jwt.WithAudience(tokenAudience), jwt.WithExpirationRequired())
+	if err != nil || !token.Valid {
+		return Session{}, time.Time{}, ErrInvalidToken
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	s := Session{}
+	s.UserID, _ = claims["sub"].(string)
+	s.UserName, _ = claims["name"].(string)
+	s.Document, _ = claims["doc"].(string)
+	s.ReadOnly, _ = claims["read_only"].(bool)
+	if s.UserID == "" || s.Document == "" {
+		return Session{}, time.Time{}, ErrInvalidToken
+	}
+
+	exp, err := claims.GetExpirationTime()
+	if err != nil {
+		return Session{}, time.Time{}, ErrInvalidToken
+	}
+	return s, exp.Time, nil
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"errors"
+
 	"github.com/spf13/viper"
 )
 
@@ -50,6 +52,37 @@ const (
 	APP_DISABLE_SIGNUP      = "app_disable_signup"
 	APP_SECRET              = "app_secret"
 	COLLAB_URL              = "collab_url"
+	COLLAB_MODE             = "collab_mode"
+	COLLAB_SECRET           = "collab_secret"
+	APP_BASE_URL            = "app_base_url"
+	APP_REQUIRE_EMAIL_VERIFICATION = "app_require_email_verification"
+	MAIL_DRIVER             = "mail_driver"
+	MAIL_FROM               = "mail_from"
+	MAIL_FILE_DIR           = "mail_file_dir"
+	SMTP_HOST               = "smtp_host"
+	SMTP_PORT               = "smtp_port"
+	SMTP_USERNAME           = "smtp_username"
+	SMTP_PASSWORD           = "smtp_password"
+	SMTP_USE_TLS            = "smtp_use_tls"
+	RATELIMIT_ENABLED       = "ratelimit_enabled"
+	RATELIMIT_STORE         = "ratelimit_store"
+	RATELIMIT_TRUST_PROXY   = "ratelimit_trust_proxy"
+	RATELIMIT_AUTH_IP_PER_MINUTE = "ratelimit_auth_ip_per_minute"
+	RATELIMIT_SIGNIN_ACCOUNT_PER_MINUTE = "ratelimit_signin_account_per_minute"
+	RATELIMIT_LOCKOUT_THRESHOLD = "ratelimit_lockout_threshold"
+	RATELIMIT_LOCKOUT_BASE  = "ratelimit_lockout_base"
+	RATELIMIT_LOCKOUT_MAX   = "ratelimit_lockout_max"
+	RATELIMIT_API_KEY_PER_MINUTE = "ratelimit_api_key_per_minute"
+	RATELIMIT_API_KEY_MAX_PER_MINUTE = "ratelimit_api_key_max_per_minute"
+	AUDIT_RETENTION_DAYS    = "audit_retention_days"
+	WEBHOOK_ENABLED         = "webhook_enabled"
+	WEBHOOK_ALLOWLIST       = "webhook_allowlist"
+	WEBHOOK_MAX_ATTEMPTS    = "webhook_max_attempts"
+	WEBHOOK_RETENTION_DAYS  = "webhook_retention_days"
+	EVENTS_LOG_SIZE         = "events_log_size"
+	NOTIFICATION_DIGEST_HOUR = "notification_digest_hour"
+	JOB_MAX_ATTEMPTS        = "job_max_attempts"
+	JOB_RETENTION_DAYS      = "job_retention_days"
 )
 
 func Init() {
@@ -68,6 +101,57 @@ func Init() {
 	C.SetDefault(APP_DISABLE_SIGNUP, false)
 	C.SetDefault(APP_SECRET, "default_secret")
 	C.SetDefault(COLLAB_URL, "http://127.0.0.1:3000")
+	C.SetDefault(COLLAB_MODE, "proxy")
+	C.SetDefault(APP_BASE_URL, "http://localhost:8080")
+	C.SetDefault(APP_REQUIRE_EMAIL_VERIFICATION, false)
+	C.SetDefault(MAIL_DRIVER, "log")
+	C.SetDefault(MAIL_FROM, "CollabReef <noreply@localhost>")
+	C.SetDefault(MAIL_FILE_DIR, "./bin/mail/")
+	C.SetDefault(SMTP_HOST, "")
+	C.SetDefault(SMTP_PORT, 587)
+	C.SetDefault(SMTP_USERNAME, "")
+	C.SetDefault(SMTP_PASSWORD, "")
+	C.SetDefault(SMTP_USE_TLS, false)
+	C.SetDefault(RATELIMIT_ENABLED, true)
+	C.SetDefault(RATELIMIT_STORE, "memory")
+	C.SetDefault(RATELIMIT_TRUST_PROXY, false)
+	C.SetDefault(RATELIMIT_AUTH_IP_PER_MINUTE, 20)
+	C.SetDefault(RATELIMIT_SIGNIN_ACCOUNT_PER_MINUTE, 10)
+	C.SetDefault(RATELIMIT_LOCKOUT_THRESHOLD, 5)
+	C.SetDefault(RATELIMIT_LOCKOUT_BASE, "1m")
+	C.SetDefault(RATELIMIT_LOCKOUT_MAX, "1h")
+	C.SetDefault(RATELIMIT_API_KEY_PER_MINUTE, 120)
+	C.SetDefault(RATELIMIT_API_KEY_MAX_PER_MINUTE, 600)
+	C.SetDefault(AUDIT_RETENTION_DAYS, 365)
+	C.SetDefault(WEBHOOK_ENABLED, true)
+	C.SetDefault(WEBHOOK_ALLOWLIST, "")
+	C.SetDefault(WEBHOOK_MAX_ATTEMPTS, 8)
+	C.SetDefault(WEBHOOK_RETENTION_DAYS, 30)
+	C.SetDefault(EVENTS_LOG_SIZE, 1000)
+	C.SetDefault(NOTIFICATION_DIGEST_HOUR, 8)
+	C.SetDefault(JOB_MAX_ATTEMPTS, 5)
+	C.SetDefault(JOB_RETENTION_DAYS, 7)
 
 	C.AutomaticEnv()
 }
+
+// defaultCollabSecret was the COLLAB_SECRET default in earlier releases. It is
+// public, so a server still using it is treated like one without a secret.
+const defaultCollabSecret = "default_collab_secret"
+
+// CheckCollabSecret returns an error unless COLLAB_SECRET is set to a value of
+// the operator's own. It signs the handshake tokens the collab service trusts,
+// so it is only needed when editors are proxied to that service.
+func CheckCollabSecret() error {
+	if C.GetString(COLLAB_MODE) == "native" {
+		return nil
+	}
+
+	switch C.GetString(COLLAB_SECRET) {
+	case "":
+		return errors.New("COLLAB_SECRET is not set")
+	case defaultCollabSecret:
+		return errors.New("COLLAB_SECRET must not be the public default")
+	}
+	return nil
+}
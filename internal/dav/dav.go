@@ -0,0 +1,240 @@
+// Package dav reads and writes the XML bodies of WebDAV (RFC 4918) and
+// CalDAV (RFC 4791) requests, as far as calendar views need them
+package dav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	NamespaceDAV            = "DAV:"
+	NamespaceCalDAV         = "urn:ietf:params:xml:ns:caldav"
+	NamespaceCalendarServer = "http://calendarserver.org/ns/"
+	NamespaceApple          = "http://apple.com/ns/ical/"
+
+	timeRangeLayout = "20060102T150405Z"
+)
+
+// Prefixes of the namespaces declared on multistatus responses. Property
+// values may use them.
+var prefixes = map[string]string{
+	NamespaceDAV:            "d",
+	NamespaceCalDAV:         "c",
+	NamespaceCalendarServer: "cs",
+	NamespaceApple:          "ical",
+}
+
+// Name is a property name such as {DAV:}getetag
+func Name(space, local string) xml.Name {
+	return xml.Name{Space: space, Local: local}
+}
+
+// Request is what a PROPFIND, PROPPATCH or REPORT body asks for
+type Request struct {
+	// Type is the root element, e.g. {DAV:}propfind or
+	// {urn:ietf:params:xml:ns:caldav}calendar-multiget
+	Type xml.Name
+	// AllProps is set for allprop and for PROPFIND requests without a body
+	AllProps bool
+	Props    []xml.Name
+	// Hrefs are the resources a calendar-multiget asks for
+	Hrefs []string
+	// Components are the comp-filter names of a calendar-query
+	Components []string
+	// Start and End are the time-range of a calendar-query, zero if it has
+	// none
+	Start, End time.Time
+}
+
+// ParseRequest reads a request body. An empty body asks for all properties.
+func ParseRequest(r io.Reader) (Request, error) {
+	var req Request
+	d := xml.NewDecoder(r)
+
+	var stack []xml.Name
+	inProp := func() bool {
+		return len(stack) > 0 && stack[len(stack)-1] == Name(NamespaceDAV, "prop")
+	}
+
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return req, fmt.Errorf("invalid XML: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if len(stack) == 0 {
+				req.Type = t.Name
+			}
+			switch {
+			case inProp():
+				req.Props = append(req.Props, t.Name)
+			case t.Name == Name(NamespaceDAV, "allprop"):
+				req.AllProps = true
+			case t.Name == Name(NamespaceDAV, "href"):
+				var href string
+				if err := d.DecodeElement(&href, &t); err != nil {
+					return req, fmt.Errorf("invalid XML: %w", err)
+				}
+				req.Hrefs = append(req.Hrefs, strings.TrimSpace(href))
+				continue
+			case t.Name == Name(NamespaceCalDAV, "comp-filter"):
+				req.Components = append(req.Components, strings.ToUpper(attr(t, "name")))
+			case t.Name == Name(NamespaceCalDAV, "time-range"):
+				req.Start, _ = time.Parse(timeRangeLayout, attr(t, "start"))
+				req.End, _ = time.Parse(timeRangeLayout, attr(t, "end"))
+			}
+			stack = append(stack, t.Name)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+
+	if req.Type.Local == "" {
+		req.Type = Name(NamespaceDAV, "propfind")
+		req.AllProps = true
+	}
+	if req.Type == Name(NamespaceDAV, "propfind") && len(req.Props) == 0 {
+		req.AllProps = true
+	}
+	return req, nil
+}
+
+func attr(e xml.StartElement, name string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// Property is a property and its value as XML, which may use the d, c, cs
+// and ical namespace prefixes
+type Property struct {
+	Name  xml.Name
+	Value string
+}
+
+type response struct {
+	href    string
+	status  int
+	found   []Property
+	missing []xml.Name
+}
+
+// Multistatus is a 207 Multi-Status response
+type Multistatus struct {
+	responses []response
+}
+
+// Add adds a resource with the properties it has and those it doesn't
+func (m *Multistatus) Add(href string, found []Property, missing []xml.Name) {
+	m.responses = append(m.responses, response{href: href, found: found, missing: missing})
+}
+
+// AddStatus adds a resource with just a status, e.g. one that wasn't found
+func (m *Multistatus) AddStatus(href string, status int) {
+	m.responses = append(m.responses, response{href: href, status: status})
+}
+
+func (m *Multistatus) Bytes() []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<d:multistatus`)
+	for _, space := range []string{NamespaceDAV, NamespaceCalDAV, NamespaceCalendarServer, NamespaceApple} {
+		fmt.Fprintf(&b, ` xmlns:%s="%s"`, prefixes[space], space)
+	}
+	b.WriteString(">")
+
+	for _, r := range m.responses {
+		b.WriteString("<d:response>")
+		b.WriteString(Href(r.href))
+		if r.status != 0 {
+			writeStatus(&b, r.status)
+		}
+		if len(r.found) > 0 {
+			b.WriteString("<d:propstat><d:prop>")
+			for _, p := range r.found {
+				writeElement(&b, p.Name, p.Value)
+			}
+			b.WriteString("</d:prop>")
+			writeStatus(&b, http.StatusOK)
+			b.WriteString("</d:propstat>")
+		}
+		if len(r.missing) > 0 {
+			b.WriteString("<d:propstat><d:prop>")
+			for _, name := range r.missing {
+				writeElement(&b, name, "")
+			}
+			b.WriteString("</d:prop>")
+			writeStatus(&b, http.StatusNotFound)
+			b.WriteString("</d:propstat>")
+		}
+		b.WriteString("</d:response>")
+	}
+
+	b.WriteString("</d:multistatus>")
+	return b.Bytes()
+}
+
+// Error is the body of a response to a request that failed a precondition,
+// such as {urn:ietf:params:xml:ns:caldav}no-uid-conflict. The content is XML
+// that explains it, e.g. the href of the conflicting resource.
+func Error(condition xml.Name, content string) []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<d:error`)
+	for _, space := range []string{NamespaceDAV, NamespaceCalDAV, NamespaceCalendarServer, NamespaceApple} {
+		fmt.Fprintf(&b, ` xmlns:%s="%s"`, prefixes[space], space)
+	}
+	b.WriteString(">")
+	writeElement(&b, condition, content)
+	b.WriteString("</d:error>")
+	return b.Bytes()
+}
+
+func writeStatus(b *bytes.Buffer, status int) {
+	fmt.Fprintf(b, "<d:status>HTTP/1.1 %d %s</d:status>", status, http.StatusText(status))
+}
+
+// writeElement writes an element, declaring its namespace if it has no prefix
+func writeElement(b *bytes.Buffer, name xml.Name, value string) {
+	tag, decl := name.Local, ""
+	if prefix, ok := prefixes[name.Space]; ok {
+		tag = prefix + ":" + name.Local
+	} else if name.Space != "" {
+		tag = "x:" + name.Local
+		decl = ` xmlns:x="` + Escape(name.Space) + `"`
+	}
+
+	if value == "" {
+		fmt.Fprintf(b, "<%s%s/>", tag, decl)
+		return
+	}
+	fmt.Fprintf(b, "<%s%s>%s</%s>", tag, decl, value, tag)
+}
+
+// Escape escapes text for use in XML
+func Escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// Href writes a DAV:href element
+func Href(href string) string {
+	return "<d:href>" + Escape(href) + "</d:href>"
+}
@@ -18,6 +18,24 @@ type DB interface {
 	ViewObjectNoteRepository
 	WidgetRepository
 	APIKeyRepository
+	UserTokenRepository
+	WorkspaceInvitationRepository
+	WorkspaceInviteLinkRepository
+	AuditEventRepository
+	WebhookRepository
+	WebhookDeliveryRepository
+	NotificationRepository
+	CommentRepository
+	NoteTemplateRepository
+	DailyNoteRepository
+	TaskRepository
+	JobRepository
+	ReminderRepository
+	CalendarFeedRepository
+	MapLocationRepository
+	KanbanMoveRepository
+	TableRowRepository
+	YjsDocumentRepository
 }
 type Uow interface {
 	Begin(ctx context.Context) (DB, error)
@@ -79,6 +97,8 @@ type ViewObjectNoteRepository interface {
 	RemoveNoteFromViewObject(v model.ViewObjectNote) error
 	FindNotesForViewObject(viewObjectID string) ([]model.Note, error)
 	FindViewObjectsForNote(noteID string) ([]model.ViewObject, error)
+	FindViewObjectNotes(f model.ViewObjectNoteFilter) ([]model.ViewObjectNote, error)
+	MoveNoteToViewObject(fromViewObjectID string, v model.ViewObjectNote) error
 }
 type WidgetRepository interface {
 	CreateWidget(w model.Widget) error
@@ -95,3 +115,144 @@ type APIKeyRepository interface {
 	UpdateAPIKey(k model.APIKey) error
 	DeleteAPIKey(id string) error
 }
+type UserTokenRepository interface {
+	CreateUserToken(t model.UserToken) error
+	FindUserTokenByHash(hash string) (model.UserToken, error)
+	UpdateUserToken(t model.UserToken) error
+	DeleteUserTokens(f model.UserTokenFilter) error
+}
+type WorkspaceInvitationRepository interface {
+	CreateWorkspaceInvitation(i model.WorkspaceInvitation) error
+	FindWorkspaceInvitations(f model.WorkspaceInvitationFilter) ([]model.WorkspaceInvitation, error)
+	FindWorkspaceInvitationByID(id string) (model.WorkspaceInvitation, error)
+	FindWorkspaceInvitationByHash(hash string) (model.WorkspaceInvitation, error)
+	UpdateWorkspaceInvitation(i model.WorkspaceInvitation) error
+	DeleteWorkspaceInvitation(id string) error
+}
+type WorkspaceInviteLinkRepository interface {
+	CreateWorkspaceInviteLink(l model.WorkspaceInviteLink) error
+	FindWorkspaceInviteLinks(f model.WorkspaceInviteLinkFilter) ([]model.WorkspaceInviteLink, error)
+	FindWorkspaceInviteLinkByID(id string) (model.WorkspaceInviteLink, error)
+	FindWorkspaceInviteLinkByHash(hash string) (model.WorkspaceInviteLink, error)
+	IncrementWorkspaceInviteLinkUses(id string) (bool, error)
+	DeleteWorkspaceInviteLink(id string) error
+}
+type AuditEventRepository interface {
+	CreateAuditEvent(e model.AuditEvent) error
+	FindAuditEvents(f model.AuditEventFilter) ([]model.AuditEvent, error)
+	CountAuditEvents(f model.AuditEventFilter) (int64, error)
+	DeleteAuditEventsBefore(createdAt string) (int, error)
+}
+type WebhookRepository interface {
+	CreateWebhook(w model.Webhook) error
+	FindWebhooks(f model.WebhookFilter) ([]model.Webhook, error)
+	FindWebhookByID(id string) (model.Webhook, error)
+	UpdateWebhook(w model.Webhook) error
+	DeleteWebhook(id string) error
+}
+type WebhookDeliveryRepository interface {
+	CreateWebhookDelivery(d model.WebhookDelivery) error
+	FindWebhookDeliveries(f model.WebhookDeliveryFilter) ([]model.WebhookDelivery, error)
+	FindWebhookDeliveryByID(id string) (model.WebhookDelivery, error)
+	FindDueWebhookDeliveries(now string, limit int) ([]model.WebhookDelivery, error)
+	ClaimWebhookDelivery(id string, nextAttemptAt string, leaseUntil string) (bool, error)
+	UpdateWebhookDelivery(d model.WebhookDelivery) error
+	DeleteWebhookDeliveriesBefore(createdAt string) (int, error)
+}
+type NotificationRepository interface {
+	CreateNotification(n model.Notification) error
+	FindNotifications(f model.NotificationFilter) ([]model.Notification, error)
+	FindNotificationByID(id string) (model.Notification, error)
+	CountNotifications(f model.NotificationFilter) (int64, error)
+	MarkNotificationsRead(userID string, ids []string, readAt string) (int, error)
+	MarkNotificationsEmailed(ids []string, emailedAt string) (int, error)
+	FindMentionIndex(noteID string) (model.MentionIndex, error)
+	SaveMentionIndex(i model.MentionIndex) error
+	DeleteMentionIndex(noteID string) error
+}
+type CommentRepository interface {
+	CreateComment(c model.Comment) error
+	FindComments(f model.CommentFilter) ([]model.Comment, error)
+	FindCommentByID(id string) (model.Comment, error)
+	CountComments(targetType string, targetIDs []string) (map[string]int64, error)
+	UpdateComment(c model.Comment) error
+	DeleteComments(f model.CommentFilter) error
+	DeleteCommentThread(id string) error
+}
+type NoteTemplateRepository interface {
+	CreateNoteTemplate(t model.NoteTemplate) error
+	FindNoteTemplates(f model.NoteTemplateFilter) ([]model.NoteTemplate, error)
+	FindNoteTemplateByID(id string) (model.NoteTemplate, error)
+	UpdateNoteTemplate(t model.NoteTemplate) error
+	DeleteNoteTemplate(id string) error
+}
+type DailyNoteRepository interface {
+	CreateDailyNote(d model.DailyNote) error
+	FindDailyNote(workspaceID string, userID string, date string) (model.DailyNote, error)
+	FindDailyNotes(f model.DailyNoteFilter) ([]model.DailyNote, error)
+	DeleteDailyNotes(noteID string) error
+}
+type TaskRepository interface {
+	CreateTasks(tasks []model.Task) error
+	FindTasks(f model.TaskFilter) ([]model.Task, error)
+	FindTaskByID(id string) (model.Task, error)
+	DeleteTasks(noteID string) error
+	SaveTaskIndex(i model.TaskIndex) error
+	FindNotesWithStaleTasks(afterID string, limit int) ([]model.Note, error)
+	FindNotesWithoutTaskIndex(afterID string, limit int) ([]model.Note, error)
+}
+type JobRepository interface {
+	CreateJob(j model.Job) error
+	FindJobs(f model.JobFilter) ([]model.Job, error)
+	FindDueJobs(types []string, now string, limit int) ([]model.Job, error)
+	ClaimJob(id string, runAt string, leaseUntil string) (bool, error)
+	UpdateJob(j model.Job) error
+	DeletePendingJobs(key string) (int, error)
+	DeleteJobsBefore(updatedAt string) (int, error)
+	CreateJobSchedule(js model.JobSchedule) error
+	FindJobSchedule(name string) (model.JobSchedule, error)
+	UpdateJobSchedule(js model.JobSchedule) error
+	ClaimJobSchedule(name string, nextRunAt string, following string, lastRunAt string) (bool, error)
+}
+type ReminderRepository interface {
+	CreateReminder(r model.Reminder) error
+	FindReminders(f model.ReminderFilter) ([]model.Reminder, error)
+	FindReminderByID(id string) (model.Reminder, error)
+	UpdateReminder(r model.Reminder) error
+	MarkReminderFired(id string, remindAt string, firedAt string) (bool, error)
+	DeleteReminder(id string) error
+	DeleteReminders(f model.ReminderFilter) error
+}
+type CalendarFeedRepository interface {
+	CreateCalendarFeed(f model.CalendarFeed) error
+	FindCalendarFeed(viewID string, userID string) (model.CalendarFeed, error)
+	FindCalendarFeedByHash(hash string) (model.CalendarFeed, error)
+	UpdateCalendarFeedLastUsed(id string, lastUsedAt string) error
+	DeleteCalendarFeeds(f model.CalendarFeedFilter) error
+}
+type MapLocationRepository interface {
+	SaveMapLocation(l model.MapLocation) error
+	DeleteMapLocation(viewObjectID string) error
+	FindMapLocations(f model.MapLocationFilter) ([]model.MapLocation, error)
+	FindMapObjectsWithStaleLocations(afterID string, limit int) ([]model.ViewObject, error)
+	DeleteStaleMapLocations() error
+}
+type KanbanMoveRepository interface {
+	CreateKanbanMove(m model.KanbanMove) error
+	FindKanbanMoves(f model.KanbanMoveFilter) ([]model.KanbanMove, error)
+	DeleteKanbanMoves(viewID string) error
+}
+type TableRowRepository interface {
+	CreateTableRow(r model.TableRow) error
+	UpdateTableRow(r model.TableRow) error
+	FindTableRow(viewID string, noteID string) (model.TableRow, error)
+	FindTableRows(viewID string) ([]model.TableRow, error)
+	FindTableRowNotes(viewID string) ([]model.Note, error)
+	DeleteTableRows(f model.TableRowFilter) error
+}
+type YjsDocumentRepository interface {
+	FindYjsDocument(name string) (model.YjsDocument, error)
+	FindYjsDocumentNames(prefix string) ([]string, error)
+	SaveYjsDocument(d model.YjsDocument) error
+	DeleteYjsDocument(name string) error
+}
@@ -0,0 +1,104 @@
+package postgresdb
+
+import (
+	"context"
+	"strings"
+
+	"github.com/collabreef/collabreef/internal/model"
+	"gorm.io/gorm"
+)
+
+func (s PostgresDB) CreateAuditEvent(e model.AuditEvent) error {
+	return gorm.G[model.AuditEvent](s.getDB()).Create(context.Background(), &e)
+}
+
+func (s PostgresDB) FindAuditEvents(f model.AuditEventFilter) ([]model.AuditEvent, error) {
+	var events []model.AuditEvent
+
+	conds, args := auditEventConds(f)
+
+	query := s.getDB().Model(&model.AuditEvent{})
+
+	if len(conds) > 0 {
+		query = query.Where(strings.Join(conds, " AND "), args...)
+	}
+
+	query = query.Order("created_at DESC, id DESC")
+
+	if f.PageSize > 0 {
+		query = query.Offset((f.PageNumber - 1) * f.PageSize).Limit(f.PageSize)
+	}
+
+	err := query.Find(&events).Error
+
+	return events, err
+}
+
+func (s PostgresDB) CountAuditEvents(f model.AuditEventFilter) (int64, error) {
+	var count int64
+
+	conds, args := auditEventConds(f)
+
+	query := s.getDB().Model(&model.AuditEvent{})
+
+	if len(conds) > 0 {
+		query = query.Where(strings.Join(conds, " AND "), args...)
+	}
+
+	err := query.Count(&count).Error
+
+	return count, err
+}
+
+func (s PostgresDB) DeleteAuditEventsBefore(createdAt string) (int, error) {
+	return gorm.G[model.AuditEvent](s.getDB()).
+		Where("created_at < ?", createdAt).
+		Delete(context.Background())
+}
+
+func auditEventConds(f model.AuditEventFilter) ([]string, []interface{}) {
+	var conds []string
+	var args []interface{}
+
+	if f.WorkspaceID != "" {
+		conds = append(conds, "workspace_id = ?")
+		args = append(args, f.WorkspaceID)
+	}
+
+	if f.ActorID != "" {
+		conds = append(conds, "actor_id = ?")
+		args = append(args, f.ActorID)
+	}
+
+	if f.Action != "" {
+		conds = append(conds, "action = ?")
+		args = append(args, f.Action)
+	}
+
+	if f.TargetType != "" {
+		conds = append(conds, "target_type = ?")
+		args = append(args, f.TargetType)
+	}
+
+	if f.TargetID != "" {
+		conds = append(conds, "target_id = ?")
+		args = append(args, f.TargetID)
+	}
+
+	if f.From != "" {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, f.From)
+	}
+
+	if f.To != "" {
+		conds = append(conds, "created_at < ?")
+		args = append(args, f.To)
+	}
+
+	if f.BeforeID != "" {
+		conds = append(conds, "(created_at < ? OR (created_at = ? AND id < ?))")
+		args = append(args, f.BeforeCreatedAt, f.BeforeCreatedAt, f.BeforeID)
+	}
+
+	return conds, args
+}
@@ -0,0 +1,60 @@
+package postgresdb
+
+import (
+	"context"
+	"strings"
+
+	"github.com/collabreef/collabreef/internal/model"
+	"gorm.io/gorm"
+)
+
+func (s PostgresDB) CreateCalendarFeed(f model.CalendarFeed) error {
+	return gorm.G[model.CalendarFeed](s.getDB()).Create(context.Background(), &f)
+}
+
+func (s PostgresDB) FindCalendarFeed(viewID string, userID string) (model.CalendarFeed, error) {
+	return gorm.
+		G[model.CalendarFeed](s.getDB()).
+		Where("view_id = ? AND user_id = ?", viewID, userID).
+		Take(context.Background())
+}
+
+func (s PostgresDB) FindCalendarFeedByHash(hash string) (model.CalendarFeed, error) {
+	return gorm.
+		G[model.CalendarFeed](s.getDB()).
+		Where("token_hash = ?", hash).
+		Take(context.Background())
+}
+
+func (s PostgresDB) UpdateCalendarFeedLastUsed(id string, lastUsedAt string) error {
+	_, err := gorm.G[model.CalendarFeed](s.getDB()).
+		Where("id = ?", id).
+		Update(context.Background(), "last_used_at", lastUsedAt)
+
+	return err
+}
+
+func (s PostgresDB) DeleteCalendarFeeds(f model.CalendarFeedFilter) error {
+	var conds []string
+	var args []interface{}
+
+	if f.ViewID != "" {
+		conds = append(conds, "view_id = ?")
+		args = append(args, f.ViewID)
+	}
+
+	if f.UserID != "" {
+		conds = append(conds, "user_id = ?")
+		args = append(args, f.UserID)
+	}
+
+	if len(conds) == 0 {
+		return nil
+	}
+
+	_, err := gorm.G[model.CalendarFeed](s.getDB()).
+		Where(strings.Join(conds, " AND "), args...).
+		Delete(context.Background())
+
+	return err
+}
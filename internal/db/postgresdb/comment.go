@@ -0,0 +1,123 @@
+package postgresdb
+
+import (
+	"context"
+	"strings"
+
+	"github.com/collabreef/collabreef/internal/model"
+	"gorm.io/gorm"
+)
+
+func (s PostgresDB) CreateComment(c model.Comment) error {
+	return gorm.G[model.Comment](s.getDB()).Create(context.Background(), &c)
+}
+
+func (s PostgresDB) FindComments(f model.CommentFilter) ([]model.Comment, error) {
+	conds, args := commentConds(f)
+
+	return gorm.G[model.Comment](s.getDB()).
+		Where(strings.Join(conds, " AND "), args...).
+		Order("created_at ASC, id ASC").
+		Find(context.Background())
+}
+
+func (s PostgresDB) FindCommentByID(id string) (model.Comment, error) {
+	return gorm.
+		G[model.Comment](s.getDB()).
+		Where("id = ?", id).
+		Take(context.Background())
+}
+
+// CountComments counts the comments on each of the targets, leaving out the
+// ones without comments
+func (s PostgresDB) CountComments(targetType string, targetIDs []string) (map[string]int64, error) {
+	counts := map[string]int64{}
+	if len(targetIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		TargetID string
+		Count    int64
+	}
+
+	err := s.getDB().
+		Model(&model.Comment{}).
+		Select("target_id, COUNT(*) AS count").
+		Where("target_type = ? AND target_id IN ?", targetType, targetIDs).
+		Group("target_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range rows {
+		counts[r.TargetID] = r.Count
+	}
+
+	return counts, nil
+}
+
+func (s PostgresDB) UpdateComment(c model.Comment) error {
+	// Select forces zero values such as an empty resolved_at to be written
+	_, err := gorm.G[model.Comment](s.getDB()).
+		Where("id = ?", c.ID).
+		Select("content", "resolved_at", "resolved_by", "updated_at", "updated_by").
+		Updates(context.Background(), c)
+
+	return err
+}
+
+func (s PostgresDB) DeleteComments(f model.CommentFilter) error {
+	conds, args := commentConds(f)
+	if len(conds) == 0 {
+		return nil
+	}
+
+	_, err := gorm.G[model.Comment](s.getDB()).
+		Where(strings.Join(conds, " AND "), args...).
+		Delete(context.Background())
+
+	return err
+}
+
+// DeleteCommentThread deletes a comment together with its replies
+func (s PostgresDB) DeleteCommentThread(id string) error {
+	_, err := gorm.G[model.Comment](s.getDB()).
+		Where("id = ? OR thread_id = ?", id, id).
+		Delete(context.Background())
+
+	return err
+}
+
+func commentConds(f model.CommentFilter) ([]string, []interface{}) {
+	var conds []string
+	var args []interface{}
+
+	if f.WorkspaceID != "" {
+		conds = append(conds, "workspace_id = ?")
+		args = append(args, f.WorkspaceID)
+	}
+
+	if f.TargetType != "" {
+		conds = append(conds, "target_type = ?")
+		args = append(args, f.TargetType)
+	}
+
+	if f.TargetID != "" {
+		conds = append(conds, "target_id = ?")
+		args = append(args, f.TargetID)
+	}
+
+	if f.ViewID != "" {
+		conds = append(conds, "view_id = ?")
+		args = append(args, f.ViewID)
+	}
+
+	if f.ThreadID != "" {
+		conds = append(conds, "thread_id = ?")
+		args = append(args, f.ThreadID)
+	}
+
+	return conds, args
+}
@@ -0,0 +1,51 @@
+package postgresdb
+
+import (
+	"context"
+
+	"github.com/collabreef/collabreef/internal/model"
+	"gorm.io/gorm"
+)
+
+func (s PostgresDB) CreateDailyNote(d model.DailyNote) error {
+	return gorm.G[model.DailyNote](s.getDB()).Create(context.Background(), &d)
+}
+
+func (s PostgresDB) FindDailyNote(workspaceID string, userID string, date string) (model.DailyNote, error) {
+	return gorm.
+		G[model.DailyNote](s.getDB()).
+		Where("workspace_id = ? AND user_id = ? AND date = ?", workspaceID, userID, date).
+		Take(context.Background())
+}
+
+func (s PostgresDB) FindDailyNotes(f model.DailyNoteFilter) ([]model.DailyNote, error) {
+	query := gorm.G[model.DailyNote](s.getDB()).
+		Where("workspace_id = ? AND user_id = ?", f.WorkspaceID, f.UserID)
+
+	if f.From != "" {
+		query = query.Where("date >= ?", f.From)
+	}
+	if f.To != "" {
+		query = query.Where("date <= ?", f.To)
+	}
+
+	if f.Descending {
+		query = query.Order("date DESC")
+	} else {
+		query = query.Order("date ASC")
+	}
+
+	if f.PageSize > 0 {
+		query = query.Offset((f.PageNumber - 1) * f.PageSize).Limit(f.PageSize)
+	}
+
+	return query.Find(context.Background())
+}
+
+func (s PostgresDB) DeleteDailyNotes(noteID string) error {
+	_, err := gorm.G[model.DailyNote](s.getDB()).
+		Where("note_id = ?", noteID).
+		Delete(context.Background())
+
+	return err
+}
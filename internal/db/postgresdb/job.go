@@ -0,0 +1,120 @@
+package postgresdb
+
+import (
+	"context"
+	"strings"
+
+	"github.com/collabreef/collabreef/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (s PostgresDB) CreateJob(j model.Job) error {
+	return gorm.G[model.Job](s.getDB()).Create(context.Background(), &j)
+}
+
+func (s PostgresDB) FindJobs(f model.JobFilter) ([]model.Job, error) {
+	var conds []string
+	var args []interface{}
+
+	if f.Type != "" {
+		conds = append(conds, "type = ?")
+		args = append(args, f.Type)
+	}
+
+	if f.Key != "" {
+		conds = append(conds, "key = ?")
+		args = append(args, f.Key)
+	}
+
+	if f.Status != "" {
+		conds = append(conds, "status = ?")
+		args = append(args, f.Status)
+	}
+
+	query := gorm.G[model.Job](s.getDB()).
+		Where(strings.Join(conds, " AND "), args...).
+		Order("created_at DESC, id DESC")
+
+	if f.PageSize > 0 {
+		query = query.Offset((f.PageNumber - 1) * f.PageSize).Limit(f.PageSize)
+	}
+
+	return query.Find(context.Background())
+}
+
+// FindDueJobs returns pending jobs of the given types that are due by now
+func (s PostgresDB) FindDueJobs(types []string, now string, limit int) ([]model.Job, error) {
+	return gorm.G[model.Job](s.getDB()).
+		Where("status = ? AND run_at <= ? AND type IN ?", model.JobStatusPending, now, types).
+		Order("run_at ASC").
+		Limit(limit).
+		Find(context.Background())
+}
+
+// ClaimJob pushes a due job's run out to leaseUntil so no other worker picks
+// it up meanwhile. It reports whether this caller won it.
+func (s PostgresDB) ClaimJob(id string, runAt string, leaseUntil string) (bool, error) {
+	res := s.getDB().
+		Model(&model.Job{}).
+		Where("id = ? AND status = ? AND run_at = ?", id, model.JobStatusPending, runAt).
+		Update("run_at", leaseUntil)
+
+	return res.RowsAffected == 1, res.Error
+}
+
+func (s PostgresDB) UpdateJob(j model.Job) error {
+	_, err := gorm.G[model.Job](s.getDB()).
+		Where("id = ?", j.ID).
+		Select("status", "attempts", "run_at", "last_run_at", "error", "updated_at").
+		Updates(context.Background(), j)
+
+	return err
+}
+
+// DeletePendingJobs drops the jobs with a key that haven't run to the end
+func (s PostgresDB) DeletePendingJobs(key string) (int, error) {
+	return gorm.G[model.Job](s.getDB()).
+		Where("key = ? AND status = ?", key, model.JobStatusPending).
+		Delete(context.Background())
+}
+
+func (s PostgresDB) DeleteJobsBefore(updatedAt string) (int, error) {
+	return gorm.G[model.Job](s.getDB()).
+		Where("updated_at < ? AND status <> ?", updatedAt, model.JobStatusPending).
+		Delete(context.Background())
+}
+
+// CreateJobSchedule adds a schedule unless one with its name exists
+func (s PostgresDB) CreateJobSchedule(js model.JobSchedule) error {
+	return s.getDB().
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&js).Error
+}
+
+func (s PostgresDB) FindJobSchedule(name string) (model.JobSchedule, error) {
+	return gorm.
+		G[model.JobSchedule](s.getDB()).
+		Where("name = ?", name).
+		Take(context.Background())
+}
+
+func (s PostgresDB) UpdateJobSchedule(js model.JobSchedule) error {
+	_, err := gorm.G[model.JobSchedule](s.getDB()).
+		Where("name = ?", js.Name).
+		Select("spec", "next_run_at").
+		Updates(context.Background(), js)
+
+	return err
+}
+
+// ClaimJobSchedule moves a schedule from the run due at nextRunAt on to the
+// one after. It reports whether this caller won that run.
+func (s PostgresDB) ClaimJobSchedule(name string, nextRunAt string, following string, lastRunAt string) (bool, error) {
+	res := s.getDB().
+		Model(&model.JobSchedule{}).
+		Where("name = ? AND next_run_at = ?", name, nextRunAt).
+		Updates(map[string]interface{}{"next_run_at": following, "last_run_at": lastRunAt})
+
+	return res.RowsAffected == 1, res.Error
+}
@@ -0,0 +1,76 @@
+package postgresdb
+
+import (
+	"context"
+
+	"github.com/collabreef/collabreef/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SaveMapLocation inserts the location of a map object or replaces it
+func (s PostgresDB) SaveMapLocation(l model.MapLocation) error {
+	return s.getDB().
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "view_object_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"view_id", "lat", "lng", "min_lat", "min_lng", "max_lat", "max_lng", "geohash", "object_updated_at",
+			}),
+		}).
+		Create(&l).Error
+}
+
+func (s PostgresDB) DeleteMapLocation(viewObjectID string) error {
+	_, err := gorm.G[model.MapLocation](s.getDB()).Where("view_object_id = ?", viewObjectID).Delete(context.Background())
+	return err
+}
+
+// FindMapLocations returns the locations whose bounds overlap the filter's
+func (s PostgresDB) FindMapLocations(f model.MapLocationFilter) ([]model.MapLocation, error) {
+	var locations []model.MapLocation
+
+	query := s.getDB().Model(&model.MapLocation{}).
+		Joins("JOIN view_objects ON view_objects.id = map_locations.view_object_id").
+		Where("map_locations.view_id = ?", f.ViewID).
+		Where("map_locations.max_lat >= ? AND map_locations.min_lat <= ?", f.MinLat, f.MaxLat)
+
+	// Boxes that cross the antimeridian are two boxes
+	if f.MinLng <= f.MaxLng {
+		query = query.Where("map_locations.max_lng >= ? AND map_locations.min_lng <= ?", f.MinLng, f.MaxLng)
+	} else {
+		query = query.Where("(map_locations.max_lng >= ? OR map_locations.min_lng <= ?)", f.MinLng, f.MaxLng)
+	}
+
+	err := query.Select("map_locations.*").Find(&locations).Error
+	return locations, err
+}
+
+// FindMapObjectsWithStaleLocations returns up to limit markers and lines, in
+// the order of their IDs and after afterID, that changed since their location
+// was indexed, or never were
+func (s PostgresDB) FindMapObjectsWithStaleLocations(afterID string, limit int) ([]model.ViewObject, error) {
+	var objects []model.ViewObject
+
+	err := s.getDB().Model(&model.ViewObject{}).
+		Joins("LEFT JOIN map_locations ON map_locations.view_object_id = view_objects.id").
+		Where("view_objects.type IN ? AND view_objects.id > ?", []string{"map_marker", "map_line"}, afterID).
+		Where("map_locations.view_object_id IS NULL OR map_locations.object_updated_at <> view_objects.updated_at").
+		Order("view_objects.id").
+		Limit(limit).
+		Select("view_objects.*").
+		Find(&objects).Error
+
+	return objects, err
+}
+
+// DeleteStaleMapLocations deletes the locations of objects that were deleted
+// or are no longer markers or lines
+func (s PostgresDB) DeleteStaleMapLocations() error {
+	objects := s.getDB().Model(&model.ViewObject{}).
+		Select("1").
+		Where("view_objects.id = map_locations.view_object_id AND view_objects.type IN ?", []string{"map_marker", "map_line"})
+
+	return s.getDB().
+		Where("NOT EXISTS (?)", objects).
+		Delete(&model.MapLocation{}).Error
+}
@@ -0,0 +1,132 @@
+package postgresdb
+
+import (
+	"context"
+	"strings"
+
+	"github.com/collabreef/collabreef/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (s PostgresDB) CreateNotification(n model.Notification) error {
+	return gorm.G[model.Notification](s.getDB()).Create(context.Background(), &n)
+}
+
+func (s PostgresDB) FindNotifications(f model.NotificationFilter) ([]model.Notification, error) {
+	var notifications []model.Notification
+
+	conds, args := notificationConds(f)
+
+	query := s.getDB().Model(&model.Notification{})
+
+	if len(conds) > 0 {
+		query = query.Where(strings.Join(conds, " AND "), args...)
+	}
+
+	query = query.Order("created_at DESC, id DESC")
+
+	if f.PageSize > 0 {
+		query = query.Offset((f.PageNumber - 1) * f.PageSize).Limit(f.PageSize)
+	}
+
+	err := query.Find(&notifications).Error
+
+	return notifications, err
+}
+
+func (s PostgresDB) FindNotificationByID(id string) (model.Notification, error) {
+	return gorm.
+		G[model.Notification](s.getDB()).
+		Where("id = ?", id).
+		Take(context.Background())
+}
+
+func (s PostgresDB) CountNotifications(f model.NotificationFilter) (int64, error) {
+	var count int64
+
+	conds, args := notificationConds(f)
+
+	query := s.getDB().Model(&model.Notification{})
+
+	if len(conds) > 0 {
+		query = query.Where(strings.Join(conds, " AND "), args...)
+	}
+
+	err := query.Count(&count).Error
+
+	return count, err
+}
+
+// MarkNotificationsRead marks a user's unread notifications as read, only the
+// given ones unless ids is nil. It returns how many were marked.
+func (s PostgresDB) MarkNotificationsRead(userID string, ids []string, readAt string) (int, error) {
+	query := s.getDB().
+		Model(&model.Notification{}).
+		Where("user_id = ? AND (read_at IS NULL OR read_at = '')", userID)
+
+	if ids != nil {
+		query = query.Where("id IN ?", ids)
+	}
+
+	res := query.Update("read_at", readAt)
+
+	return int(res.RowsAffected), res.Error
+}
+
+// MarkNotificationsEmailed marks notifications as sent in a digest, skipping
+// ones that already are. It returns how many were marked.
+func (s PostgresDB) MarkNotificationsEmailed(ids []string, emailedAt string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	res := s.getDB().
+		Model(&model.Notification{}).
+		Where("id IN ? AND (emailed_at IS NULL OR emailed_at = '')", ids).
+		Update("emailed_at", emailedAt)
+
+	return int(res.RowsAffected), res.Error
+}
+
+func notificationConds(f model.NotificationFilter) ([]string, []interface{}) {
+	var conds []string
+	var args []interface{}
+
+	if f.UserID != "" {
+		conds = append(conds, "user_id = ?")
+		args = append(args, f.UserID)
+	}
+
+	if f.Unread {
+		conds = append(conds, "(read_at IS NULL OR read_at = '')")
+	}
+
+	if f.Unemailed {
+		conds = append(conds, "(emailed_at IS NULL OR emailed_at = '')")
+	}
+
+	return conds, args
+}
+
+func (s PostgresDB) FindMentionIndex(noteID string) (model.MentionIndex, error) {
+	return gorm.
+		G[model.MentionIndex](s.getDB()).
+		Where("note_id = ?", noteID).
+		Take(context.Background())
+}
+
+// SaveMentionIndex inserts the record of a note's mentions or replaces it
+func (s PostgresDB) SaveMentionIndex(i model.MentionIndex) error {
+	return s.getDB().
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "note_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"user_ids", "known"}),
+		}).
+		Create(&i).Error
+}
+
+func (s PostgresDB) DeleteMentionIndex(noteID string) error {
+	_, err := gorm.G[model.MentionIndex](s.getDB()).Where("note_id = ?", noteID).Delete(context.Background())
+	return err
+}
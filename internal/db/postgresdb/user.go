@@ -9,7 +9,7 @@ import (
 )
 
 func (s PostgresDB) CreateUser(u model.User) error {
-	return gorm.G[model.User](s.db).Create(context.Background(), &u)
+	return gorm.G[model.User](s.getDB()).Create(context.Background(), &u)
 }
 
 func (s PostgresDB) FindUsers(f model.UserFilter) ([]model.User, error) {
@@ -24,6 +24,11 @@ func (s PostgresDB) FindUsers(f model.UserFilter) ([]model.User, error) {
 		args = append(args, f.NameOrEmail, f.NameOrEmail)
 	}
 
+	if f.Email != "" {
+		conds = append(conds, "LOWER(email) = ?")
+		args = append(args, strings.ToLower(f.Email))
+	}
+
 	users, err := query.
 		Where(strings.Join(conds, " AND "), args...).
 		Find(context.Background())
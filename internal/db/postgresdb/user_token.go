@@ -0,0 +1,49 @@
+package postgresdb
+
+import (
+	"context"
+	"strings"
+
+	"github.com/collabreef/collabreef/internal/model"
+	"gorm.io/gorm"
+)
+
+func (s PostgresDB) CreateUserToken(t model.UserToken) error {
+	return gorm.G[model.UserToken](s.getDB()).Create(context.Background(), &t)
+}
+
+func (s PostgresDB) FindUserTokenByHash(hash string) (model.UserToken, error) {
+	return gorm.
+		G[model.UserToken](s.getDB()).
+		Where("token_hash = ?", hash).
+		Take(context.Background())
+}
+
+func (s PostgresDB) UpdateUserToken(t model.UserToken) error {
+	_, err := gorm.G[model.UserToken](s.getDB()).
+		Where("id = ?", t.ID).
+		Updates(context.Background(), t)
+
+	return err
+}
+
+func (s PostgresDB) DeleteUserTokens(f model.UserTokenFilter) error {
+	var conds []string
+	var args []interface{}
+
+	if f.UserID != "" {
+		conds = append(conds, "user_id = ?")
+		args = append(args, f.UserID)
+	}
+
+	if f.Type != "" {
+		conds = append(conds, "type = ?")
+		args = append(args, f.Type)
+	}
+
+	_, err := gorm.G[model.UserToken](s.getDB()).
+		Where(strings.Join(conds, " AND "), args...).
+		Delete(context.Background())
+
+	return err
+}
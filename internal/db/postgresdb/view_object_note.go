@@ -6,9 +6,9 @@ import (
 
 func (s PostgresDB) AddNoteToViewObject(v model.ViewObjectNote) error {
 	return s.getDB().Exec(`
-		INSERT INTO view_object_notes (view_object_id, note_id, created_at, created_by)
-		VALUES ($1, $2, $3, $4)
-	`, v.ViewObjectID, v.NoteID, v.CreatedAt, v.CreatedBy).Error
+		INSERT INTO view_object_notes (view_object_id, note_id, created_at, created_by, position, completed_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, v.ViewObjectID, v.NoteID, v.CreatedAt, v.CreatedBy, v.Position, v.CompletedAt).Error
 }
 
 func (s PostgresDB) RemoveNoteFromViewObject(v model.ViewObjectNote) error {
@@ -24,6 +24,7 @@ func (s PostgresDB) FindNotesForViewObject(viewObjectID string) ([]model.Note, e
 		Table("notes").
 		Joins("INNER JOIN view_object_notes ON notes.id = view_object_notes.note_id").
 		Where("view_object_notes.view_object_id = ?", viewObjectID).
+		Order("view_object_notes.position, view_object_notes.created_at").
 		Find(&notes).Error
 
 	return notes, err
@@ -39,3 +40,35 @@ func (s PostgresDB) FindViewObjectsForNote(noteID string) ([]model.ViewObject, e
 
 	return viewObjects, err
 }
+
+// FindViewObjectNotes returns links between view objects and notes, in the
+// order of their positions
+func (s PostgresDB) FindViewObjectNotes(f model.ViewObjectNoteFilter) ([]model.ViewObjectNote, error) {
+	query := s.getDB().Model(&model.ViewObjectNote{})
+
+	if f.ViewObjectID != "" {
+		query = query.Where("view_object_id = ?", f.ViewObjectID)
+	}
+	if len(f.ViewObjectIDs) > 0 {
+		query = query.Where("view_object_id IN ?", f.ViewObjectIDs)
+	}
+	if f.NoteID != "" {
+		query = query.Where("note_id = ?", f.NoteID)
+	}
+
+	var links []model.ViewObjectNote
+	err := query.Order("position, created_at").Find(&links).Error
+	return links, err
+}
+
+// MoveNoteToViewObject moves the link of a note from one view object to
+// another, or the same one, setting its position and completion
+func (s PostgresDB) MoveNoteToViewObject(fromViewObjectID string, v model.ViewObjectNote) error {
+	return s.getDB().Model(&model.ViewObjectNote{}).
+		Where("view_object_id = ? AND note_id = ?", fromViewObjectID, v.NoteID).
+		Updates(map[string]any{
+			"view_object_id": v.ViewObjectID,
+			"position":       v.Position,
+			"completed_at":   v.CompletedAt,
+		}).Error
+}
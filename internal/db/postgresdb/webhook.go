@@ -0,0 +1,128 @@
+package postgresdb
+
+import (
+	"context"
+	"strings"
+
+	"github.com/collabreef/collabreef/internal/model"
+	"gorm.io/gorm"
+)
+
+func (s PostgresDB) CreateWebhook(w model.Webhook) error {
+	return gorm.G[model.Webhook](s.getDB()).Create(context.Background(), &w)
+}
+
+func (s PostgresDB) FindWebhooks(f model.WebhookFilter) ([]model.Webhook, error) {
+	var conds []string
+	var args []interface{}
+
+	if f.WorkspaceID != "" {
+		conds = append(conds, "workspace_id = ?")
+		args = append(args, f.WorkspaceID)
+	}
+
+	if f.Enabled {
+		conds = append(conds, "enabled = ?")
+		args = append(args, true)
+	}
+
+	return gorm.G[model.Webhook](s.getDB()).
+		Where(strings.Join(conds, " AND "), args...).
+		Order("created_at ASC").
+		Find(context.Background())
+}
+
+func (s PostgresDB) FindWebhookByID(id string) (model.Webhook, error) {
+	return gorm.
+		G[model.Webhook](s.getDB()).
+		Where("id = ?", id).
+		Take(context.Background())
+}
+
+func (s PostgresDB) UpdateWebhook(w model.Webhook) error {
+	// Select forces zero values such as enabled = false to be written
+	_, err := gorm.G[model.Webhook](s.getDB()).
+		Where("id = ?", w.ID).
+		Select("name", "url", "secret", "events", "enabled", "updated_at", "updated_by").
+		Updates(context.Background(), w)
+
+	return err
+}
+
+func (s PostgresDB) DeleteWebhook(id string) error {
+	_, err := gorm.G[model.Webhook](s.getDB()).
+		Where("id = ?", id).
+		Delete(context.Background())
+
+	return err
+}
+
+func (s PostgresDB) CreateWebhookDelivery(d model.WebhookDelivery) error {
+	return gorm.G[model.WebhookDelivery](s.getDB()).Create(context.Background(), &d)
+}
+
+func (s PostgresDB) FindWebhookDeliveries(f model.WebhookDeliveryFilter) ([]model.WebhookDelivery, error) {
+	var conds []string
+	var args []interface{}
+
+	if f.WebhookID != "" {
+		conds = append(conds, "webhook_id = ?")
+		args = append(args, f.WebhookID)
+	}
+
+	if f.Status != "" {
+		conds = append(conds, "status = ?")
+		args = append(args, f.Status)
+	}
+
+	query := gorm.G[model.WebhookDelivery](s.getDB()).
+		Where(strings.Join(conds, " AND "), args...).
+		Order("created_at DESC, id DESC")
+
+	if f.PageSize > 0 {
+		query = query.Offset((f.PageNumber - 1) * f.PageSize).Limit(f.PageSize)
+	}
+
+	return query.Find(context.Background())
+}
+
+func (s PostgresDB) FindWebhookDeliveryByID(id string) (model.WebhookDelivery, error) {
+	return gorm.
+		G[model.WebhookDelivery](s.getDB()).
+		Where("id = ?", id).
+		Take(context.Background())
+}
+
+func (s PostgresDB) FindDueWebhookDeliveries(now string, limit int) ([]model.WebhookDelivery, error) {
+	return gorm.G[model.WebhookDelivery](s.getDB()).
+		Where("status = ? AND next_attempt_at <= ?", model.WebhookDeliveryStatusPending, now).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(context.Background())
+}
+
+// ClaimWebhookDelivery pushes a due delivery's next attempt out to leaseUntil so
+// no other worker picks it up meanwhile. It reports whether this caller won it.
+func (s PostgresDB) ClaimWebhookDelivery(id string, nextAttemptAt string, leaseUntil string) (bool, error) {
+	res := s.getDB().
+		Model(&model.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at = ?", id, model.WebhookDeliveryStatusPending, nextAttemptAt).
+		Update("next_attempt_at", leaseUntil)
+
+	return res.RowsAffected == 1, res.Error
+}
+
+func (s PostgresDB) UpdateWebhookDelivery(d model.WebhookDelivery) error {
+	_, err := gorm.G[model.WebhookDelivery](s.getDB()).
+		Where("id = ?", d.ID).
+		Select("status", "attempts", "next_attempt_at", "last_attempt_at", "response_status", "response_body", "error", "duration_ms").
+		Updates(context.Background(), d)
+
+	return err
+}
+
+func (s PostgresDB) DeleteWebhookDeliveriesBefore(createdAt string) (int, error) {
+	return gorm.G[model.WebhookDelivery](s.getDB()).
+		Where("created_at < ? AND status <> ?", createdAt, model.WebhookDeliveryStatusPending).
+		Delete(context.Background())
+}
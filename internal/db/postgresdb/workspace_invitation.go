@@ -0,0 +1,67 @@
+package postgresdb
+
+import (
+	"context"
+	"strings"
+
+	"github.com/collabreef/collabreef/internal/model"
+	"gorm.io/gorm"
+)
+
+func (s PostgresDB) CreateWorkspaceInvitation(i model.WorkspaceInvitation) error {
+	return gorm.G[model.WorkspaceInvitation](s.getDB()).Create(context.Background(), &i)
+}
+
+func (s PostgresDB) FindWorkspaceInvitations(f model.WorkspaceInvitationFilter) ([]model.WorkspaceInvitation, error) {
+	var conds []string
+	var args []interface{}
+
+	if f.WorkspaceID != "" {
+		conds = append(conds, "workspace_id = ?")
+		args = append(args, f.WorkspaceID)
+	}
+
+	if f.Email != "" {
+		conds = append(conds, "LOWER(email) = ?")
+		args = append(args, strings.ToLower(f.Email))
+	}
+
+	if f.Pending {
+		conds = append(conds, "(accepted_at IS NULL OR accepted_at = '')")
+	}
+
+	return gorm.G[model.WorkspaceInvitation](s.getDB()).
+		Where(strings.Join(conds, " AND "), args...).
+		Order("created_at DESC").
+		Find(context.Background())
+}
+
+func (s PostgresDB) FindWorkspaceInvitationByID(id string) (model.WorkspaceInvitation, error) {
+	return gorm.
+		G[model.WorkspaceInvitation](s.getDB()).
+		Where("id = ?", id).
+		Take(context.Background())
+}
+
+func (s PostgresDB) FindWorkspaceInvitationByHash(hash string) (model.WorkspaceInvitation, error) {
+	return gorm.
+		G[model.WorkspaceInvitation](s.getDB()).
+		Where("token_hash = ?", hash).
+		Take(context.Background())
+}
+
+func (s PostgresDB) UpdateWorkspaceInvitation(i model.WorkspaceInvitation) error {
+	_, err := gorm.G[model.WorkspaceInvitation](s.getDB()).
+		Where("id = ?", i.ID).
+		Updates(context.Background(), i)
+
+	return err
+}
+
+func (s PostgresDB) DeleteWorkspaceInvitation(id string) error {
+	_, err := gorm.G[model.WorkspaceInvitation](s.getDB()).
+		Where("id = ?", id).
+		Delete(context.Background())
+
+	return err
+}
@@ -0,0 +1,27 @@
+package sqlitedb
+
+import (
+	"context"
+
+	"github.com/collabreef/collabreef/internal/model"
+	"gorm.io/gorm"
+)
+
+func (s SqliteDB) CreateKanbanMove(m model.KanbanMove) error {
+	return gorm.G[model.KanbanMove](s.getDB()).Create(context.Background(), &m)
+}
+
+// FindKanbanMoves returns the moves of the cards of a kanban view, oldest
+// first
+func (s SqliteDB) FindKanbanMoves(f model.KanbanMoveFilter) ([]model.KanbanMove, error) {
+	query := gorm.G[model.KanbanMove](s.getDB()).Where("view_id = ?", f.ViewID)
+	if f.Until != "" {
+		query = query.Where("moved_at <= ?", f.Until)
+	}
+	return query.Order("moved_at, id").Find(context.Background())
+}
+
+func (s SqliteDB) DeleteKanbanMoves(viewID string) error {
+	_, err := gorm.G[model.KanbanMove](s.getDB()).Where("view_id = ?", viewID).Delete(context.Background())
+	return err
+}
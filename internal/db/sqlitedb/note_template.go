@@ -0,0 +1,44 @@
+package sqlitedb
+
+import (
+	"context"
+
+	"github.com/collabreef/collabreef/internal/model"
+	"gorm.io/gorm"
+)
+
+func (s SqliteDB) CreateNoteTemplate(t model.NoteTemplate) error {
+	return gorm.G[model.NoteTemplate](s.getDB()).Create(context.Background(), &t)
+}
+
+func (s SqliteDB) FindNoteTemplates(f model.NoteTemplateFilter) ([]model.NoteTemplate, error) {
+	return gorm.G[model.NoteTemplate](s.getDB()).
+		Where("workspace_id = ?", f.WorkspaceID).
+		Order("name ASC").
+		Find(context.Background())
+}
+
+func (s SqliteDB) FindNoteTemplateByID(id string) (model.NoteTemplate, error) {
+	return gorm.
+		G[model.NoteTemplate](s.getDB()).
+		Where("id = ?", id).
+		Take(context.Background())
+}
+
+func (s SqliteDB) UpdateNoteTemplate(t model.NoteTemplate) error {
+	// Select forces zero values such as an empty description to be written
+	_, err := gorm.G[model.NoteTemplate](s.getDB()).
+		Where("id = ?", t.ID).
+		Select("name", "description", "title", "format", "content", "prompts", "updated_at", "updated_by").
+		Updates(context.Background(), t)
+
+	return err
+}
+
+func (s SqliteDB) DeleteNoteTemplate(id string) error {
+	_, err := gorm.G[model.NoteTemplate](s.getDB()).
+		Where("id = ?", id).
+		Delete(context.Background())
+
+	return err
+}
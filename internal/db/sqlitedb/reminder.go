@@ -0,0 +1,112 @@
+package sqlitedb
+
+import (
+	"context"
+	"strings"
+
+	"github.com/collabreef/collabreef/internal/model"
+	"gorm.io/gorm"
+)
+
+func (s SqliteDB) CreateReminder(r model.Reminder) error {
+	return gorm.G[model.Reminder](s.getDB()).Create(context.Background(), &r)
+}
+
+func reminderConds(f model.ReminderFilter) ([]string, []interface{}) {
+	var conds []string
+	var args []interface{}
+
+	if f.WorkspaceID != "" {
+		conds = append(conds, "workspace_id = ?")
+		args = append(args, f.WorkspaceID)
+	}
+
+	if f.UserID != "" {
+		conds = append(conds, "user_id = ?")
+		args = append(args, f.UserID)
+	}
+
+	if f.TargetType != "" {
+		conds = append(conds, "target_type = ?")
+		args = append(args, f.TargetType)
+	}
+
+	if f.TargetID != "" {
+		conds = append(conds, "target_id = ?")
+		args = append(args, f.TargetID)
+	}
+
+	if f.ViewID != "" {
+		conds = append(conds, "view_id = ?")
+		args = append(args, f.ViewID)
+	}
+
+	if f.Pending {
+		conds = append(conds, "(fired_at IS NULL OR fired_at = '')")
+	}
+
+	return conds, args
+}
+
+func (s SqliteDB) FindReminders(f model.ReminderFilter) ([]model.Reminder, error) {
+	conds, args := reminderConds(f)
+
+	query := gorm.G[model.Reminder](s.getDB()).
+		Where(strings.Join(conds, " AND "), args...).
+		Order("remind_at ASC, id ASC")
+
+	if f.PageSize > 0 {
+		query = query.Offset((f.PageNumber - 1) * f.PageSize).Limit(f.PageSize)
+	}
+
+	return query.Find(context.Background())
+}
+
+func (s SqliteDB) FindReminderByID(id string) (model.Reminder, error) {
+	return gorm.
+		G[model.Reminder](s.getDB()).
+		Where("id = ?", id).
+		Take(context.Background())
+}
+
+func (s SqliteDB) UpdateReminder(r model.Reminder) error {
+	// Select forces zero values such as a cleared minutes_before to be written
+	_, err := gorm.G[model.Reminder](s.getDB()).
+		Where("id = ?", r.ID).
+		Select("remind_at", "timezone", "minutes_before", "channel", "message", "fired_at", "updated_at").
+		Updates(context.Background(), r)
+
+	return err
+}
+
+// MarkReminderFired sets when a reminder fired, as long as it hasn't yet and
+// is still due at remindAt. It reports whether this caller fired it.
+func (s SqliteDB) MarkReminderFired(id string, remindAt string, firedAt string) (bool, error) {
+	res := s.getDB().
+		Model(&model.Reminder{}).
+		Where("id = ? AND remind_at = ? AND (fired_at IS NULL OR fired_at = '')", id, remindAt).
+		Update("fired_at", firedAt)
+
+	return res.RowsAffected == 1, res.Error
+}
+
+func (s SqliteDB) DeleteReminder(id string) error {
+	_, err := gorm.G[model.Reminder](s.getDB()).
+		Where("id = ?", id).
+		Delete(context.Background())
+
+	return err
+}
+
+func (s SqliteDB) DeleteReminders(f model.ReminderFilter) error {
+	conds, args := reminderConds(f)
+	if len(conds) == 0 {
+		return nil
+	}
+
+	_, err := gorm.G[model.Reminder](s.getDB()).
+		Where(strings.Join(conds, " AND "), args...).
+		Delete(context.Background())
+
+	return err
+}
@@ -0,0 +1,84 @@
+package sqlitedb
+
+import (
+	"context"
+	"strings"
+
+	"github.com/collabreef/collabreef/internal/model"
+	"gorm.io/gorm"
+)
+
+func (s SqliteDB) CreateTableRow(r model.TableRow) error {
+	return gorm.G[model.TableRow](s.getDB()).Create(context.Background(), &r)
+}
+
+func (s SqliteDB) UpdateTableRow(r model.TableRow) error {
+	return s.getDB().Model(&model.TableRow{}).
+		Where("view_id = ? AND note_id = ?", r.ViewID, r.NoteID).
+		Updates(map[string]any{
+			"values":     r.Values,
+			"updated_at": r.UpdatedAt,
+			"updated_by": r.UpdatedBy,
+		}).Error
+}
+
+func (s SqliteDB) FindTableRow(viewID string, noteID string) (model.TableRow, error) {
+	return gorm.
+		G[model.TableRow](s.getDB()).
+		Where("view_id = ? AND note_id = ?", viewID, noteID).
+		Take(context.Background())
+}
+
+// FindTableRows returns the rows of a table view whose notes still exist, in
+// the order they were added
+func (s SqliteDB) FindTableRows(viewID string) ([]model.TableRow, error) {
+	var rows []model.TableRow
+	err := s.getDB().
+		Table("table_rows").
+		Joins("INNER JOIN notes ON notes.id = table_rows.note_id").
+		Where("table_rows.view_id = ?", viewID).
+		Order("table_rows.created_at, table_rows.note_id").
+		Select("table_rows.*").
+		Find(&rows).Error
+
+	return rows, err
+}
+
+// FindTableRowNotes returns the notes of the rows of a table view, without
+// their content
+func (s SqliteDB) FindTableRowNotes(viewID string) ([]model.Note, error) {
+	var notes []model.Note
+	err := s.getDB().
+		Table("notes").
+		Joins("INNER JOIN table_rows ON notes.id = table_rows.note_id").
+		Where("table_rows.view_id = ?", viewID).
+		Select("notes.id, notes.workspace_id, notes.title, notes.visibility, notes.created_by, notes.created_at, notes.updated_by, notes.updated_at").
+		Find(&notes).Error
+
+	return notes, err
+}
+
+func (s SqliteDB) DeleteTableRows(f model.TableRowFilter) error {
+	var conds []string
+	var args []interface{}
+
+	if f.ViewID != "" {
+		conds = append(conds, "view_id = ?")
+		args = append(args, f.ViewID)
+	}
+
+	if f.NoteID != "" {
+		conds = append(conds, "note_id = ?")
+		args = append(args, f.NoteID)
+	}
+
+	if len(conds) == 0 {
+		return nil
+	}
+
+	_, err := gorm.G[model.TableRow](s.getDB()).
+		Where(strings.Join(conds, " AND "), args...).
+		Delete(context.Background())
+
+	return err
+}
@@ -0,0 +1,120 @@
+package sqlitedb
+
+import (
+	"context"
+	"strings"
+
+	"github.com/collabreef/collabreef/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (s SqliteDB) CreateTasks(tasks []model.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	return s.getDB().Create(&tasks).Error
+}
+
+func (s SqliteDB) FindTasks(f model.TaskFilter) ([]model.Task, error) {
+	var tasks []model.Task
+
+	query := s.getDB().Model(&model.Task{}).
+		Joins("JOIN notes ON notes.id = tasks.note_id").
+		Where("tasks.workspace_id = ?", f.WorkspaceID)
+
+	if f.NoteID != "" {
+		query = query.Where("tasks.note_id = ?", f.NoteID)
+	}
+	if f.ViewerID != "" {
+		query = query.Where("(notes.visibility <> 'private' OR notes.created_by = ?)", f.ViewerID)
+	}
+	switch f.Status {
+	case model.TaskStatusOpen:
+		query = query.Where("tasks.checked = ?", false)
+	case model.TaskStatusDone:
+		query = query.Where("tasks.checked = ?", true)
+	}
+	if f.AssigneeID != "" {
+		query = query.Where("tasks.assignee_id = ?", f.AssigneeID)
+	}
+	if f.DueFrom != "" {
+		query = query.Where("tasks.due_date <> '' AND tasks.due_date >= ?", f.DueFrom)
+	}
+	if f.DueTo != "" {
+		query = query.Where("tasks.due_date <> '' AND tasks.due_date <= ?", f.DueTo)
+	}
+	if f.Tag != "" {
+		pattern := "%," + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(f.Tag) + ",%"
+		query = query.Where(`tasks.tags LIKE ? ESCAPE '\'`, pattern)
+	}
+
+	// Tasks with a due date first, soonest first
+	query = query.Order("CASE WHEN tasks.due_date = '' THEN 1 ELSE 0 END, tasks.due_date ASC, notes.created_at DESC, tasks.position ASC")
+
+	if f.PageSize > 0 {
+		query = query.Offset((f.PageNumber - 1) * f.PageSize).Limit(f.PageSize)
+	}
+
+	err := query.Select("tasks.*").Find(&tasks).Error
+	return tasks, err
+}
+
+func (s SqliteDB) FindTaskByID(id string) (model.Task, error) {
+	return gorm.
+		G[model.Task](s.getDB()).
+		Where("id = ?", id).
+		Take(context.Background())
+}
+
+// DeleteTasks deletes a note's tasks and the record of indexing them
+func (s SqliteDB) DeleteTasks(noteID string) error {
+	if _, err := gorm.G[model.Task](s.getDB()).Where("note_id = ?", noteID).Delete(context.Background()); err != nil {
+		return err
+	}
+	_, err := gorm.G[model.TaskIndex](s.getDB()).Where("note_id = ?", noteID).Delete(context.Background())
+	return err
+}
+
+// SaveTaskIndex inserts the record of indexing a note's tasks or replaces it
+func (s SqliteDB) SaveTaskIndex(i model.TaskIndex) error {
+	return s.getDB().
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "note_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"note_updated_at"}),
+		}).
+		Create(&i).Error
+}
+
+// FindNotesWithStaleTasks returns up to limit notes, in the order of their
+// IDs and after afterID, that changed since their tasks were indexed. Notes
+// that never were are left to FindNotesWithoutTaskIndex.
+func (s SqliteDB) FindNotesWithStaleTasks(afterID string, limit int) ([]model.Note, error) {
+	var notes []model.Note
+
+	err := s.getDB().Model(&model.Note{}).
+		Joins("JOIN task_indices ON task_indices.note_id = notes.id").
+		Where("task_indices.note_updated_at <> notes.updated_at AND notes.id > ?", afterID).
+		Order("notes.id").
+		Limit(limit).
+		Select("notes.*").
+		Find(&notes).Error
+
+	return notes, err
+}
+
+// FindNotesWithoutTaskIndex returns up to limit notes, in the order of their
+// IDs and after afterID, whose tasks were never indexed
+func (s SqliteDB) FindNotesWithoutTaskIndex(afterID string, limit int) ([]model.Note, error) {
+	var notes []model.Note
+
+	err := s.getDB().Model(&model.Note{}).
+		Joins("LEFT JOIN task_indices ON task_indices.note_id = notes.id").
+		Where("task_indices.note_id IS NULL AND notes.id > ?", afterID).
+		Order("notes.id").
+		Limit(limit).
+		Select("notes.*").
+		Find(&notes).Error
+
+	return notes, err
+}
@@ -0,0 +1,61 @@
+package sqlitedb
+
+import (
+	"context"
+	"strings"
+
+	"github.com/collabreef/collabreef/internal/model"
+	"gorm.io/gorm"
+)
+
+func (s SqliteDB) CreateWorkspaceInviteLink(l model.WorkspaceInviteLink) error {
+	return gorm.G[model.WorkspaceInviteLink](s.getDB()).Create(context.Background(), &l)
+}
+
+func (s SqliteDB) FindWorkspaceInviteLinks(f model.WorkspaceInviteLinkFilter) ([]model.WorkspaceInviteLink, error) {
+	var conds []string
+	var args []interface{}
+
+	if f.WorkspaceID != "" {
+		conds = append(conds, "workspace_id = ?")
+		args = append(args, f.WorkspaceID)
+	}
+
+	return gorm.G[model.WorkspaceInviteLink](s.getDB()).
+		Where(strings.Join(conds, " AND "), args...).
+		Order("created_at DESC").
+		Find(context.Background())
+}
+
+func (s SqliteDB) FindWorkspaceInviteLinkByID(id string) (model.WorkspaceInviteLink, error) {
+	return gorm.
+		G[model.WorkspaceInviteLink](s.getDB()).
+		Where("id = ?", id).
+		Take(context.Background())
+}
+
+func (s SqliteDB) FindWorkspaceInviteLinkByHash(hash string) (model.WorkspaceInviteLink, error) {
+	return gorm.
+		G[model.WorkspaceInviteLink](s.getDB()).
+		Where("token_hash = ?", hash).
+		Take(context.Background())
+}
+
+// IncrementWorkspaceInviteLinkUses atomically bumps the use count, refusing once
+// max_uses (when set) has been reached. It reports whether a use was recorded.
+func (s SqliteDB) IncrementWorkspaceInviteLinkUses(id string) (bool, error) {
+	res := s.getDB().
+		Model(&model.WorkspaceInviteLink{}).
+		Where("id = ? AND (max_uses = 0 OR use_count < max_uses)", id).
+		Update("use_count", gorm.Expr("use_count + 1"))
+
+	return res.RowsAffected == 1, res.Error
+}
+
+func (s SqliteDB) DeleteWorkspaceInviteLink(id string) error {
+	_, err := gorm.G[model.WorkspaceInviteLink](s.getDB()).
+		Where("id = ?", id).
+		Delete(context.Background())
+
+	return err
+}
@@ -0,0 +1,44 @@
+package sqlitedb
+
+import (
+	"context"
+	"strings"
+
+	"github.com/collabreef/collabreef/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (s SqliteDB) FindYjsDocument(name string) (model.YjsDocument, error) {
+	return gorm.G[model.YjsDocument](s.getDB()).
+		Where("name = ?", name).
+		Take(context.Background())
+}
+
+func (s SqliteDB) FindYjsDocumentNames(prefix string) ([]string, error) {
+	var names []string
+
+	pattern := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(prefix) + "%"
+
+	err := s.getDB().Model(&model.YjsDocument{}).
+		Where(`name LIKE ? ESCAPE '\'`, pattern).
+		Order("name").
+		Pluck("name", &names).Error
+
+	return names, err
+}
+
+// SaveYjsDocument inserts the document or replaces its stored state
+func (s SqliteDB) SaveYjsDocument(d model.YjsDocument) error {
+	return s.getDB().
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "name"}},
+			DoUpdates: clause.AssignmentColumns([]string{"data", "updated_at"}),
+		}).
+		Create(&d).Error
+}
+
+func (s SqliteDB) DeleteYjsDocument(name string) error {
+	_, err := gorm.G[model.YjsDocument](s.getDB()).Where("name = ?", name).Delete(context.Background())
+	return err
+}
@@ -0,0 +1,186 @@
+package eventbus
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/model"
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind before it is
+// dropped. Dropped subscribers reconnect and catch up from the log.
+const subscriberBuffer = 64
+
+// Event is a workspace event with its position in the bus
+type Event struct {
+	ID  string
+	seq uint64
+	model.WorkspaceEvent
+}
+
+// Subscription receives the events of one workspace. C is closed when the
+// subscriber is dropped for falling behind or the bus unsubscribes it.
+type Subscription struct {
+	C           <-chan Event
+	c           chan Event
+	workspaceID string
+}
+
+// Bus fans workspace events out to in-process subscribers and keeps the most
+// recent events of each workspace so reconnecting clients can resume. The log
+// lives in memory: a restart, or events published on another instance, are
+// detected through the ID and reported as a gap.
+type Bus struct {
+	mu        sync.Mutex
+	epoch     string
+	seq       uint64
+	logSize   int
+	logs      map[string]*eventLog
+	subs      map[string]map[*Subscription]struct{}
+	listeners []func(model.WorkspaceEvent)
+}
+
+type eventLog struct {
+	events []Event
+	// evicted is the sequence of the newest event pushed out of the log
+	evicted uint64
+}
+
+func New(logSize int) *Bus {
+	if logSize <= 0 {
+		logSize = 1
+	}
+
+	return &Bus{
+		epoch:   strconv.FormatInt(time.Now().UnixNano(), 36),
+		logSize: logSize,
+		logs:    map[string]*eventLog{},
+		subs:    map[string]map[*Subscription]struct{}{},
+	}
+}
+
+// Listen registers fn to be called with every published event, e.g. to forward
+// them to webhooks. fn runs on the publishing goroutine and must not block.
+func (b *Bus) Listen(fn func(model.WorkspaceEvent)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.listeners = append(b.listeners, fn)
+}
+
+// Publish appends the event to its workspace's log and hands it to every
+// subscriber and listener. A nil bus is a no-op.
+func (b *Bus) Publish(e model.WorkspaceEvent) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+
+	b.seq++
+	ev := Event{ID: b.epoch + "-" + strconv.FormatUint(b.seq, 10), seq: b.seq, WorkspaceEvent: e}
+
+	wl := b.logs[e.WorkspaceID]
+	if wl == nil {
+		wl = &eventLog{}
+		b.logs[e.WorkspaceID] = wl
+	}
+	if len(wl.events) == b.logSize {
+		wl.evicted = wl.events[0].seq
+		wl.events = append(wl.events[:0], wl.events[1:]...)
+	}
+	wl.events = append(wl.events, ev)
+
+	for sub := range b.subs[e.WorkspaceID] {
+		select {
+		case sub.c <- ev:
+		default:
+			b.drop(sub)
+		}
+	}
+
+	listeners := b.listeners
+
+	b.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(e)
+	}
+}
+
+// Subscribe starts receiving a workspace's events. With a lastEventID the
+// events logged after it are returned to be replayed first; complete is false
+// when some of them are no longer available and the client should reload.
+func (b *Bus) Subscribe(workspaceID string, lastEventID string) (sub *Subscription, backlog []Event, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := make(chan Event, subscriberBuffer)
+	sub = &Subscription{C: c, c: c, workspaceID: workspaceID}
+
+	if b.subs[workspaceID] == nil {
+		b.subs[workspaceID] = map[*Subscription]struct{}{}
+	}
+	b.subs[workspaceID][sub] = struct{}{}
+
+	if lastEventID == "" {
+		return sub, nil, true
+	}
+
+	after, ok := b.parseID(lastEventID)
+	if !ok {
+		return sub, nil, false
+	}
+
+	wl := b.logs[workspaceID]
+	if wl == nil {
+		return sub, nil, true
+	}
+
+	for _, ev := range wl.events {
+		if ev.seq > after {
+			backlog = append(backlog, ev)
+		}
+	}
+
+	return sub, backlog, after >= wl.evicted
+}
+
+// Unsubscribe stops a subscription and closes its channel
+func (b *Bus) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.drop(sub)
+}
+
+// drop must be called with the lock held
+func (b *Bus) drop(sub *Subscription) {
+	subs := b.subs[sub.workspaceID]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(b.subs, sub.workspaceID)
+	}
+	close(sub.c)
+}
+
+// parseID returns the sequence of an ID issued by this bus since it started
+func (b *Bus) parseID(id string) (uint64, bool) {
+	epoch, seq, ok := strings.Cut(id, "-")
+	if !ok || epoch != b.epoch {
+		return 0, false
+	}
+
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil || n > b.seq {
+		return 0, false
+	}
+
+	return n, true
+}
@@ -0,0 +1,135 @@
+// Package geo reads and writes the map interchange formats map views
+// support: GeoJSON (RFC 7946), KML 2.2 and GPX 1.1
+package geo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+var ErrUnknownFormat = errors.New("not a GeoJSON, KML or GPX file")
+
+// Format is a map interchange format
+type Format string
+
+const (
+	FormatGeoJSON Format = "geojson"
+	FormatKML     Format = "kml"
+	FormatGPX     Format = "gpx"
+)
+
+// Point is a position in WGS 84 degrees
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+// Validate checks that a point is a position on Earth
+func (p Point) Validate() error {
+	if math.IsNaN(p.Lat) || p.Lat < -90 || p.Lat > 90 {
+		return fmt.Errorf("latitude %v is not between -90 and 90", p.Lat)
+	}
+	if math.IsNaN(p.Lng) || p.Lng < -180 || p.Lng > 180 {
+		return fmt.Errorf("longitude %v is not between -180 and 180", p.Lng)
+	}
+	return nil
+}
+
+// Feature is a point or a line with what describes it. Multi-part features
+// are read as one feature per part.
+type Feature struct {
+	// Index is the position of the feature in the file, counting from 1,
+	// which the parts of a multi-part feature share
+	Index int
+	ID    string
+	Name  string
+	// Description is the feature's description, and Properties the rest
+	// of what the file says about it
+	Description string
+	Properties  map[string]string
+	// Color is the feature's color as #rrggbb, if it has one
+	Color string
+	// Points has one point for points and the points of lines in order
+	Points []Point
+	Line   bool
+	// Err is why the feature can't be used, e.g. a geometry that isn't
+	// supported, in which case it has no points
+	Err error
+}
+
+// Validate checks the points of a feature
+func (f Feature) Validate() error {
+	if f.Err != nil {
+		return f.Err
+	}
+	if f.Line && len(f.Points) < 2 {
+		return errors.New("a line needs at least 2 points")
+	}
+	if !f.Line && len(f.Points) != 1 {
+		return errors.New("a point needs a position")
+	}
+	for _, p := range f.Points {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PropertyNames returns the names of a feature's properties in order
+func (f Feature) PropertyNames() []string {
+	names := make([]string, 0, len(f.Properties))
+	for name := range f.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parse reads a file in any of the formats, telling them apart by their
+// content
+func Parse(data []byte) ([]Feature, Format, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	trimmed := bytes.TrimSpace(data)
+
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		features, err := ParseGeoJSON(data)
+		return features, FormatGeoJSON, err
+	}
+	if bytes.HasPrefix(trimmed, []byte("<")) {
+		switch xmlRoot(data) {
+		case "kml":
+			features, err := ParseKML(data)
+			return features, FormatKML, err
+		case "gpx":
+			features, err := ParseGPX(data)
+			return features, FormatGPX, err
+		}
+	}
+	return nil, "", ErrUnknownFormat
+}
+
+// part numbers the parts of a multi-part feature after the first, so that
+// each has an ID of its own
+func part(f Feature, i int) Feature {
+	if i > 0 && f.ID != "" {
+		f.ID = fmt.Sprintf("%s#%d", f.ID, i+1)
+	}
+	return f
+}
+
+// normalizeColor reads a CSS hex color, returning it as #rrggbb
+func normalizeColor(s string) string {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) != 6 || strings.Trim(strings.ToLower(s), "0123456789abcdef") != "" {
+		return ""
+	}
+	return "#" + strings.ToLower(s)
+}
@@ -0,0 +1,262 @@
+package geo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type geoJSONObject struct {
+	Type        string            `json:"type"`
+	ID          any               `json:"id,omitempty"`
+	Features    []json.RawMessage `json:"features,omitempty"`
+	Geometry    *geoJSONGeometry  `json:"geometry,omitempty"`
+	Properties  map[string]any    `json:"properties,omitempty"`
+	Coordinates json.RawMessage   `json:"coordinates,omitempty"`
+}
+
+type geoJSONGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// ParseGeoJSON reads a FeatureCollection, a Feature or a bare geometry.
+// Points, LineStrings and their multi-part kinds are supported.
+func ParseGeoJSON(data []byte) ([]Feature, error) {
+	var root geoJSONObject
+	if err := decodeJSON(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %w", err)
+	}
+
+	switch root.Type {
+	case "FeatureCollection":
+		var features []Feature
+		for i, raw := range root.Features {
+			var obj geoJSONObject
+			if err := decodeJSON(raw, &obj); err != nil || obj.Type != "Feature" {
+				features = append(features, Feature{Index: i + 1, Err: errors.New("not a GeoJSON Feature")})
+				continue
+			}
+			features = append(features, geoJSONFeature(i+1, obj)...)
+		}
+		return features, nil
+	case "Feature":
+		return geoJSONFeature(1, root), nil
+	case "Point", "MultiPoint", "LineString", "MultiLineString":
+		return geoJSONFeature(1, geoJSONObject{
+			Geometry: &geoJSONGeometry{Type: root.Type, Coordinates: root.Coordinates},
+		}), nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+func decodeJSON(data []byte, v any) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	return d.Decode(v)
+}
+
+// geoJSONFeature reads a Feature, with one Feature for each part of its
+// geometry
+func geoJSONFeature(index int, obj geoJSONObject) []Feature {
+	f := Feature{Index: index, Properties: map[string]string{}}
+	if obj.ID != nil {
+		f.ID = propertyString(obj.ID)
+	}
+	for key, value := range obj.Properties {
+		s := propertyString(value)
+		switch strings.ToLower(key) {
+		case "name", "title":
+			if f.Name == "" {
+				f.Name = s
+				continue
+			}
+		case "description", "desc":
+			if f.Description == "" {
+				f.Description = s
+				continue
+			}
+		// simplestyle-spec colors
+		case "marker-color", "stroke", "color":
+			if c := normalizeColor(s); c != "" && f.Color == "" {
+				f.Color = c
+				continue
+			}
+		}
+		if value != nil {
+			f.Properties[key] = s
+		}
+	}
+
+	if obj.Geometry == nil {
+		f.Err = errors.New("the feature has no geometry")
+		return []Feature{f}
+	}
+
+	var err error
+	var parts [][]Point
+	coords := obj.Geometry.Coordinates
+	switch obj.Geometry.Type {
+	case "Point":
+		var p Point
+		if p, err = geoJSONPosition(coords); err == nil {
+			parts = [][]Point{{p}}
+		}
+	case "MultiPoint":
+		var points []Point
+		if points, err = geoJSONPositions(coords); err == nil {
+			for _, p := range points {
+				parts = append(parts, []Point{p})
+			}
+		}
+	case "LineString":
+		f.Line = true
+		var points []Point
+		if points, err = geoJSONPositions(coords); err == nil {
+			parts = [][]Point{points}
+		}
+	case "MultiLineString":
+		f.Line = true
+		var lines []json.RawMessage
+		if err = json.Unmarshal(coords, &lines); err == nil {
+			for _, line := range lines {
+				points, perr := geoJSONPositions(line)
+				if perr != nil {
+					err = perr
+					break
+				}
+				parts = append(parts, points)
+			}
+		}
+	default:
+		f.Err = fmt.Errorf("%s geometries are not supported", obj.Geometry.Type)
+		return []Feature{f}
+	}
+	if err != nil {
+		f.Err = fmt.Errorf("invalid coordinates: %w", err)
+		return []Feature{f}
+	}
+	if len(parts) == 0 {
+		f.Err = errors.New("the feature has no coordinates")
+		return []Feature{f}
+	}
+
+	features := make([]Feature, 0, len(parts))
+	for i, points := range parts {
+		p := part(f, i)
+		p.Points = points
+		features = append(features, p)
+	}
+	return features
+}
+
+// geoJSONPosition reads a [longitude, latitude] position, which may have an
+// altitude too
+func geoJSONPosition(data json.RawMessage) (Point, error) {
+	var pos []float64
+	if err := json.Unmarshal(data, &pos); err != nil {
+		return Point{}, err
+	}
+	if len(pos) < 2 {
+		return Point{}, errors.New("a position needs a longitude and a latitude")
+	}
+	return Point{Lat: pos[1], Lng: pos[0]}, nil
+}
+
+func geoJSONPositions(data json.RawMessage) ([]Point, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	points := make([]Point, 0, len(raw))
+	for _, r := range raw {
+		p, err := geoJSONPosition(r)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, nil
+}
+
+// propertyString turns a JSON value into text, writing arrays and objects as
+// JSON
+func propertyString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// WriteGeoJSON writes features as a FeatureCollection named name. Colors
+// follow the simplestyle spec.
+func WriteGeoJSON(w io.Writer, name string, features []Feature) error {
+	collection := struct {
+		Type     string              `json:"type"`
+		Name     string              `json:"name,omitempty"`
+		Features []geoJSONFeatureOut `json:"features"`
+	}{Type: "FeatureCollection", Name: name, Features: []geoJSONFeatureOut{}}
+
+	for _, f := range features {
+		props := map[string]any{"name": f.Name}
+		for key, value := range f.Properties {
+			props[key] = value
+		}
+		if f.Description != "" {
+			props["description"] = f.Description
+		}
+
+		g := geoJSONGeometryOut{Type: "Point"}
+		if f.Line {
+			g.Type = "LineString"
+			coords := make([][2]float64, len(f.Points))
+			for i, p := range f.Points {
+				coords[i] = [2]float64{p.Lng, p.Lat}
+			}
+			g.Coordinates = coords
+			if f.Color != "" {
+				props["stroke"] = f.Color
+			}
+		} else if len(f.Points) > 0 {
+			g.Coordinates = [2]float64{f.Points[0].Lng, f.Points[0].Lat}
+			if f.Color != "" {
+				props["marker-color"] = f.Color
+			}
+		}
+
+		collection.Features = append(collection.Features, geoJSONFeatureOut{
+			Type:       "Feature",
+			ID:         f.ID,
+			Geometry:   g,
+			Properties: props,
+		})
+	}
+
+	return json.NewEncoder(w).Encode(collection)
+}
+
+type geoJSONFeatureOut struct {
+	Type       string             `json:"type"`
+	ID         string             `json:"id,omitempty"`
+	Geometry   geoJSONGeometryOut `json:"geometry"`
+	Properties map[string]any     `json:"properties"`
+}
+
+type geoJSONGeometryOut struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"`
+}
@@ -0,0 +1,154 @@
+package geo
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type gpxFile struct {
+	Waypoints []gpxPoint `xml:"wpt"`
+	Routes    []struct {
+		gpxInfo
+		Points []gpxPoint `xml:"rtept"`
+	} `xml:"rte"`
+	Tracks []struct {
+		gpxInfo
+		Segments []struct {
+			Points []gpxPoint `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+}
+
+type gpxInfo struct {
+	Name    string `xml:"name"`
+	Desc    string `xml:"desc"`
+	Comment string `xml:"cmt"`
+	Type    string `xml:"type"`
+	Links   []struct {
+		Href string `xml:"href,attr"`
+	} `xml:"link"`
+}
+
+type gpxPoint struct {
+	gpxInfo
+	Lat       string `xml:"lat,attr"`
+	Lon       string `xml:"lon,attr"`
+	Elevation string `xml:"ele"`
+	Time      string `xml:"time"`
+	Symbol    string `xml:"sym"`
+}
+
+// ParseGPX reads a GPX file. Waypoints become points, and routes and each
+// segment of a track become lines.
+func ParseGPX(data []byte) ([]Feature, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = false
+
+	var file gpxFile
+	if err := d.Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid GPX: %w", err)
+	}
+
+	var features []Feature
+	index := 0
+
+	for _, w := range file.Waypoints {
+		index++
+		f := gpxFeature(index, w.gpxInfo)
+		setProperty(f.Properties, "elevation", w.Elevation)
+		setProperty(f.Properties, "time", w.Time)
+		setProperty(f.Properties, "symbol", w.Symbol)
+
+		p, err := gpxPosition(w)
+		if err != nil {
+			f.Err = fmt.Errorf("invalid coordinates: %w", err)
+		} else {
+			f.Points = []Point{p}
+		}
+		features = append(features, f)
+	}
+
+	for _, r := range file.Routes {
+		index++
+		f := gpxFeature(index, r.gpxInfo)
+		f.Line = true
+		f.Points, f.Err = gpxPositions(r.Points)
+		features = append(features, f)
+	}
+
+	for _, t := range file.Tracks {
+		index++
+		f := gpxFeature(index, t.gpxInfo)
+		f.Line = true
+		if len(t.Segments) == 0 {
+			f.Err = errors.New("the track has no segments")
+			features = append(features, f)
+			continue
+		}
+		for i, seg := range t.Segments {
+			p := f
+			p.Points, p.Err = gpxPositions(seg.Points)
+			if i > 0 {
+				p.Name = fmt.Sprintf("%s (%d)", f.Name, i+1)
+			}
+			features = append(features, p)
+		}
+	}
+
+	return features, nil
+}
+
+func gpxFeature(index int, info gpxInfo) Feature {
+	f := Feature{
+		Index:       index,
+		Name:        strings.TrimSpace(info.Name),
+		Description: strings.TrimSpace(info.Desc),
+		Properties:  map[string]string{},
+	}
+	setProperty(f.Properties, "comment", info.Comment)
+	setProperty(f.Properties, "type", info.Type)
+	if len(info.Links) > 0 {
+		setProperty(f.Properties, "link", info.Links[0].Href)
+	}
+	return f
+}
+
+func setProperty(props map[string]string, key, value string) {
+	if value = strings.TrimSpace(value); value != "" {
+		props[key] = value
+	}
+}
+
+func gpxPosition(p gpxPoint) (Point, error) {
+	return parseLngLat([]string{strings.TrimSpace(p.Lon), strings.TrimSpace(p.Lat)})
+}
+
+func gpxPositions(points []gpxPoint) ([]Point, error) {
+	positions := make([]Point, 0, len(points))
+	for _, p := range points {
+		pos, err := gpxPosition(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid coordinates: %w", err)
+		}
+		positions = append(positions, pos)
+	}
+	return positions, nil
+}
+
+// xmlRoot returns the local name of the root element of an XML document
+func xmlRoot(data []byte) string {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = false
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name.Local
+		}
+	}
+}
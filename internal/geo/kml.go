@@ -0,0 +1,367 @@
+package geo
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const kmlNamespace = "http://www.opengis.net/kml/2.2"
+
+type kmlPlacemark struct {
+	ID           string          `xml:"id,attr"`
+	Name         string          `xml:"name"`
+	Description  string          `xml:"description"`
+	StyleURL     string          `xml:"styleUrl"`
+	Style        *kmlStyle       `xml:"Style"`
+	ExtendedData kmlExtendedData `xml:"ExtendedData"`
+
+	Point         *kmlCoordinates `xml:"Point"`
+	LineString    *kmlCoordinates `xml:"LineString"`
+	Track         *kmlTrack       `xml:"Track"`
+	MultiGeometry *kmlMulti       `xml:"MultiGeometry"`
+	Polygon       *struct{}       `xml:"Polygon"`
+	Model         *struct{}       `xml:"Model"`
+}
+
+type kmlCoordinates struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+// kmlTrack is a gx:Track, which Google Earth records tracks as
+type kmlTrack struct {
+	Coords []string `xml:"coord"`
+}
+
+type kmlMulti struct {
+	Points      []kmlCoordinates `xml:"Point"`
+	LineStrings []kmlCoordinates `xml:"LineString"`
+	Polygons    []struct{}       `xml:"Polygon"`
+}
+
+type kmlStyle struct {
+	ID        string `xml:"id,attr"`
+	IconColor string `xml:"IconStyle>color"`
+	LineColor string `xml:"LineStyle>color"`
+}
+
+type kmlStyleMap struct {
+	ID    string `xml:"id,attr"`
+	Pairs []struct {
+		Key      string `xml:"key"`
+		StyleURL string `xml:"styleUrl"`
+	} `xml:"Pair"`
+}
+
+type kmlExtendedData struct {
+	Data []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value"`
+	} `xml:"Data"`
+	SimpleData []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"SchemaData>SimpleData"`
+}
+
+// ParseKML reads the Placemarks of a KML file, in whichever Document or
+// Folder they are. Points, LineStrings, gx:Tracks and MultiGeometries of
+// them are supported.
+func ParseKML(data []byte) ([]Feature, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = false
+
+	var placemarks []kmlPlacemark
+	styles := map[string]kmlStyle{}
+	styleMaps := map[string]string{}
+
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid KML: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "Placemark":
+			var p kmlPlacemark
+			if err := d.DecodeElement(&p, &start); err != nil {
+				return nil, fmt.Errorf("invalid KML: %w", err)
+			}
+			placemarks = append(placemarks, p)
+		case "Style":
+			var s kmlStyle
+			if err := d.DecodeElement(&s, &start); err != nil {
+				return nil, fmt.Errorf("invalid KML: %w", err)
+			}
+			styles[s.ID] = s
+		case "StyleMap":
+			var m kmlStyleMap
+			if err := d.DecodeElement(&m, &start); err != nil {
+				return nil, fmt.Errorf("invalid KML: %w", err)
+			}
+			for _, pair := range m.Pairs {
+				if pair.Key == "normal" {
+					styleMaps[m.ID] = strings.TrimPrefix(pair.StyleURL, "#")
+				}
+			}
+		}
+	}
+
+	var features []Feature
+	for i, p := range placemarks {
+		features = append(features, kmlFeature(i+1, p, styles, styleMaps)...)
+	}
+	return features, nil
+}
+
+func kmlFeature(index int, p kmlPlacemark, styles map[string]kmlStyle, styleMaps map[string]string) []Feature {
+	f := Feature{
+		Index:       index,
+		ID:          p.ID,
+		Name:        strings.TrimSpace(p.Name),
+		Description: strings.TrimSpace(p.Description),
+		Properties:  map[string]string{},
+	}
+	for _, data := range p.ExtendedData.Data {
+		f.Properties[data.Name] = strings.TrimSpace(data.Value)
+	}
+	for _, data := range p.ExtendedData.SimpleData {
+		f.Properties[data.Name] = strings.TrimSpace(data.Value)
+	}
+
+	style := p.Style
+	if style == nil && strings.HasPrefix(p.StyleURL, "#") {
+		id := strings.TrimPrefix(p.StyleURL, "#")
+		if mapped, ok := styleMaps[id]; ok {
+			id = mapped
+		}
+		if s, ok := styles[id]; ok {
+			style = &s
+		}
+	}
+
+	var parts [][]Point
+	var err error
+	switch {
+	case p.Point != nil:
+		var points []Point
+		if points, err = kmlPoints(p.Point.Coordinates); err == nil && len(points) > 0 {
+			parts = [][]Point{points[:1]}
+		}
+	case p.LineString != nil:
+		f.Line = true
+		var points []Point
+		if points, err = kmlPoints(p.LineString.Coordinates); err == nil {
+			parts = [][]Point{points}
+		}
+	case p.Track != nil:
+		f.Line = true
+		var points []Point
+		for _, coord := range p.Track.Coords {
+			var pt Point
+			if pt, err = parseLngLat(strings.Fields(coord)); err != nil {
+				break
+			}
+			points = append(points, pt)
+		}
+		parts = [][]Point{points}
+	case p.MultiGeometry != nil:
+		m := p.MultiGeometry
+		if len(m.Polygons) > 0 || (len(m.Points) > 0 && len(m.LineStrings) > 0) {
+			f.Err = errors.New("only MultiGeometries of just Points or just LineStrings are supported")
+			return []Feature{f}
+		}
+		f.Line = len(m.LineStrings) > 0
+		for _, g := range append(m.Points, m.LineStrings...) {
+			points, perr := kmlPoints(g.Coordinates)
+			if perr != nil {
+				err = perr
+				break
+			}
+			if !f.Line && len(points) > 0 {
+				points = points[:1]
+			}
+			parts = append(parts, points)
+		}
+	case p.Polygon != nil:
+		f.Err = errors.New("Polygon geometries are not supported")
+		return []Feature{f}
+	case p.Model != nil:
+		f.Err = errors.New("Model geometries are not supported")
+		return []Feature{f}
+	default:
+		f.Err = errors.New("the placemark has no geometry")
+		return []Feature{f}
+	}
+	if err != nil {
+		f.Err = fmt.Errorf("invalid coordinates: %w", err)
+		return []Feature{f}
+	}
+	if len(parts) == 0 {
+		f.Err = errors.New("the placemark has no coordinates")
+		return []Feature{f}
+	}
+
+	if style != nil {
+		if f.Line {
+			f.Color = kmlColor(style.LineColor)
+		} else {
+			f.Color = kmlColor(style.IconColor)
+		}
+	}
+
+	features := make([]Feature, 0, len(parts))
+	for i, points := range parts {
+		p := part(f, i)
+		p.Points = points
+		features = append(features, p)
+	}
+	return features
+}
+
+// kmlPoints reads coordinates, which are longitude,latitude[,altitude]
+// tuples separated by whitespace
+func kmlPoints(s string) ([]Point, error) {
+	var points []Point
+	for _, tuple := range strings.Fields(s) {
+		p, err := parseLngLat(strings.Split(tuple, ","))
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, nil
+}
+
+// parseLngLat reads a longitude and a latitude, ignoring anything after them
+func parseLngLat(values []string) (Point, error) {
+	if len(values) < 2 {
+		return Point{}, errors.New("a coordinate needs a longitude and a latitude")
+	}
+	lng, err := strconv.ParseFloat(values[0], 64)
+	if err != nil {
+		return Point{}, fmt.Errorf("%q is not a number", values[0])
+	}
+	lat, err := strconv.ParseFloat(values[1], 64)
+	if err != nil {
+		return Point{}, fmt.Errorf("%q is not a number", values[1])
+	}
+	return Point{Lat: lat, Lng: lng}, nil
+}
+
+// kmlColor turns a KML color, which is aabbggrr, into #rrggbb
+func kmlColor(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) != 8 {
+		return ""
+	}
+	return normalizeColor(s[6:8] + s[4:6] + s[2:4])
+}
+
+// WriteKML writes features as the Placemarks of a Document named name
+func WriteKML(w io.Writer, name string, features []Feature) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	e := xml.NewEncoder(w)
+	e.Indent("", "  ")
+
+	kml := xml.StartElement{Name: xml.Name{Local: "kml"}, Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: kmlNamespace}}}
+	doc := xml.StartElement{Name: xml.Name{Local: "Document"}}
+	if err := e.EncodeToken(kml); err != nil {
+		return err
+	}
+	if err := e.EncodeToken(doc); err != nil {
+		return err
+	}
+	if err := e.EncodeElement(name, xml.StartElement{Name: xml.Name{Local: "name"}}); err != nil {
+		return err
+	}
+
+	for _, f := range features {
+		p := kmlPlacemarkOut{ID: f.ID, Name: f.Name, Description: f.Description}
+		if len(f.Properties) > 0 {
+			p.Data = &kmlDataListOut{}
+			for _, key := range f.PropertyNames() {
+				p.Data.Data = append(p.Data.Data, kmlDataOut{Name: key, Value: f.Properties[key]})
+			}
+		}
+
+		coords := make([]string, len(f.Points))
+		for i, pt := range f.Points {
+			coords[i] = formatFloat(pt.Lng) + "," + formatFloat(pt.Lat)
+		}
+		color := ""
+		if c := normalizeColor(f.Color); c != "" {
+			color = "ff" + c[5:7] + c[3:5] + c[1:3]
+		}
+		if f.Line {
+			p.LineString = &kmlCoordinates{Coordinates: strings.Join(coords, " ")}
+			if color != "" {
+				p.Style = &kmlStyleOut{LineStyle: &kmlColorOut{Color: color}}
+			}
+		} else {
+			p.Point = &kmlCoordinates{Coordinates: strings.Join(coords, " ")}
+			if color != "" {
+				p.Style = &kmlStyleOut{IconStyle: &kmlColorOut{Color: color}}
+			}
+		}
+
+		if err := e.Encode(p); err != nil {
+			return err
+		}
+	}
+
+	if err := e.EncodeToken(doc.End()); err != nil {
+		return err
+	}
+	if err := e.EncodeToken(kml.End()); err != nil {
+		return err
+	}
+	return e.Flush()
+}
+
+type kmlPlacemarkOut struct {
+	XMLName     xml.Name        `xml:"Placemark"`
+	ID          string          `xml:"id,attr,omitempty"`
+	Name        string          `xml:"name"`
+	Description string          `xml:"description,omitempty"`
+	Style       *kmlStyleOut    `xml:"Style,omitempty"`
+	Data        *kmlDataListOut `xml:"ExtendedData,omitempty"`
+	Point       *kmlCoordinates `xml:"Point,omitempty"`
+	LineString  *kmlCoordinates `xml:"LineString,omitempty"`
+}
+
+type kmlStyleOut struct {
+	IconStyle *kmlColorOut `xml:"IconStyle,omitempty"`
+	LineStyle *kmlColorOut `xml:"LineStyle,omitempty"`
+}
+
+type kmlColorOut struct {
+	Color string `xml:"color"`
+}
+
+type kmlDataListOut struct {
+	Data []kmlDataOut `xml:"Data"`
+}
+
+type kmlDataOut struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,247 @@
+package geo
+
+import (
+	"math"
+	"strings"
+)
+
+// EarthRadius is the mean radius of the Earth in meters
+const EarthRadius = 6371008.8
+
+// MaxDistance is the furthest apart two points on Earth can be, in meters
+const MaxDistance = math.Pi * EarthRadius
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
+
+// Distance is the great-circle distance between two points in meters
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat := lat2 - lat1
+	dLng := radians(b.Lng - a.Lng)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// DistanceTo is the distance in meters from p to the nearest point of a
+// feature. Segments of lines are measured on a plane around p, which is
+// close enough for segments up to a few hundred kilometers.
+func DistanceTo(p Point, points []Point, line bool) float64 {
+	d := math.Inf(1)
+	for i, q := range points {
+		d = math.Min(d, Distance(p, q))
+		if line && i > 0 {
+			d = math.Min(d, segmentDistance(p, points[i-1], q))
+		}
+	}
+	return d
+}
+
+// segmentDistance is the distance from p to the segment from a to b,
+// projecting them onto a plane tangent at p
+func segmentDistance(p, a, b Point) float64 {
+	scale := math.Cos(radians(p.Lat))
+	project := func(q Point) (float64, float64) {
+		dLng := q.Lng - p.Lng
+		// Take the short way around the antimeridian
+		if dLng > 180 {
+			dLng -= 360
+		} else if dLng < -180 {
+			dLng += 360
+		}
+		return radians(dLng) * scale * EarthRadius, radians(q.Lat-p.Lat) * EarthRadius
+	}
+
+	ax, ay := project(a)
+	bx, by := project(b)
+	dx, dy := bx-ax, by-ay
+	if dx == 0 && dy == 0 {
+		return math.Hypot(ax, ay)
+	}
+	t := math.Max(0, math.Min(1, -(ax*dx+ay*dy)/(dx*dx+dy*dy)))
+	return math.Hypot(ax+t*dx, ay+t*dy)
+}
+
+// Bounds is a bounding box. Boxes that cross the antimeridian have a MinLng
+// greater than their MaxLng.
+type Bounds struct {
+	MinLat, MinLng float64
+	MaxLat, MaxLng float64
+}
+
+// BoundsOf is the smallest box around points, not crossing the antimeridian
+func BoundsOf(points []Point) Bounds {
+	b := Bounds{MinLat: 90, MinLng: 180, MaxLat: -90, MaxLng: -180}
+	for _, p := range points {
+		b.MinLat = math.Min(b.MinLat, p.Lat)
+		b.MaxLat = math.Max(b.MaxLat, p.Lat)
+		b.MinLng = math.Min(b.MinLng, p.Lng)
+		b.MaxLng = math.Max(b.MaxLng, p.Lng)
+	}
+	return b
+}
+
+// Center is the middle of a box
+func (b Bounds) Center() Point {
+	lng := (b.MinLng + b.MaxLng) / 2
+	if b.MinLng > b.MaxLng {
+		lng = (b.MinLng + b.MaxLng + 360) / 2
+		if lng > 180 {
+			lng -= 360
+		}
+	}
+	return Point{Lat: (b.MinLat + b.MaxLat) / 2, Lng: lng}
+}
+
+// Split splits a box that crosses the antimeridian in two that don't
+func (b Bounds) Split() []Bounds {
+	if b.MinLng <= b.MaxLng {
+		return []Bounds{b}
+	}
+	east, west := b, b
+	east.MaxLng = 180
+	west.MinLng = -180
+	return []Bounds{east, west}
+}
+
+// Contains reports whether a point is in a box
+func (b Bounds) Contains(p Point) bool {
+	for _, s := range b.Split() {
+		if p.Lat >= s.MinLat && p.Lat <= s.MaxLat && p.Lng >= s.MinLng && p.Lng <= s.MaxLng {
+			return true
+		}
+	}
+	return false
+}
+
+// Intersects reports whether any part of a feature is in a box
+func (b Bounds) Intersects(points []Point, line bool) bool {
+	for i, p := range points {
+		if b.Contains(p) {
+			return true
+		}
+		if line && i > 0 {
+			for _, s := range b.Split() {
+				if s.clips(points[i-1], p) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// clips reports whether the segment from p to q crosses a box that doesn't
+// cross the antimeridian, clipping it as Liang-Barsky does
+func (b Bounds) clips(p, q Point) bool {
+	dx, dy := q.Lng-p.Lng, q.Lat-p.Lat
+	t0, t1 := 0.0, 1.0
+	for _, edge := range [][2]float64{
+		{-dx, p.Lng - b.MinLng},
+		{dx, b.MaxLng - p.Lng},
+		{-dy, p.Lat - b.MinLat},
+		{dy, b.MaxLat - p.Lat},
+	} {
+		d, dist := edge[0], edge[1]
+		if d == 0 {
+			if dist < 0 {
+				return false
+			}
+			continue
+		}
+		t := dist / d
+		if d < 0 {
+			t0 = math.Max(t0, t)
+		} else {
+			t1 = math.Min(t1, t)
+		}
+		if t0 > t1 {
+			return false
+		}
+	}
+	return true
+}
+
+// CircleBounds is a box around the points within radius meters of center.
+// Circles that reach a pole take every longitude.
+func CircleBounds(center Point, radius float64) Bounds {
+	dLat := degrees(radius / EarthRadius)
+	b := Bounds{
+		MinLat: math.Max(-90, center.Lat-dLat),
+		MaxLat: math.Min(90, center.Lat+dLat),
+		MinLng: -180,
+		MaxLng: 180,
+	}
+	if b.MinLat == -90 || b.MaxLat == 90 || radius >= MaxDistance/2 {
+		return b
+	}
+
+	// The widest point of the circle, which is nearer the pole than its center
+	dLng := degrees(math.Asin(math.Min(1, math.Sin(radius/EarthRadius)/math.Cos(radians(center.Lat)))))
+	b.MinLng = center.Lng - dLng
+	b.MaxLng = center.Lng + dLng
+	if b.MinLng < -180 {
+		b.MinLng += 360
+	}
+	if b.MaxLng > 180 {
+		b.MaxLng -= 360
+	}
+	return b
+}
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// Geohash encodes a point as a geohash of precision characters
+func Geohash(p Point, precision int) string {
+	latRange := [2]float64{-90, 90}
+	lngRange := [2]float64{-180, 180}
+
+	var b strings.Builder
+	bit, ch, even := 0, 0, true
+	for b.Len() < precision {
+		r, v := &latRange, p.Lat
+		if even {
+			r, v = &lngRange, p.Lng
+		}
+		mid := (r[0] + r[1]) / 2
+		ch <<= 1
+		if v >= mid {
+			ch |= 1
+			r[0] = mid
+		} else {
+			r[1] = mid
+		}
+		even = !even
+
+		if bit++; bit == 5 {
+			b.WriteByte(geohashAlphabet[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return b.String()
+}
+
+// CommonGeohash is the geohash of the smallest cell holding every point, empty
+// if the points are far apart
+func CommonGeohash(points []Point, precision int) string {
+	var common string
+	for i, p := range points {
+		h := Geohash(p, precision)
+		if i == 0 {
+			common = h
+			continue
+		}
+		n := 0
+		for n < len(common) && common[n] == h[n] {
+			n++
+		}
+		common = common[:n]
+	}
+	return common
+}
@@ -0,0 +1,413 @@
+// Package ical reads and writes the VEVENTs of iCalendar (RFC 5545) data,
+// as far as calendar views need them
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/util"
+)
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405"
+
+	// Lines are folded after this many octets
+	maxLineLength = 75
+)
+
+var ErrNotCalendar = errors.New("not iCalendar data")
+
+// Event is a VEVENT. Times of Floating events are wall clock times meant for
+// whatever timezone the calendar is looked at in, and are in UTC only for
+// lack of one. All-day events end on the day after their last day.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time // Zero if the event has no end
+	AllDay      bool
+	Floating    bool
+	// TZID is the IANA timezone the times are in, if they aren't in UTC
+	TZID  string
+	Stamp time.Time
+	// RRule is the recurrence rule of a recurring event, and ExDates the
+	// starts of the occurrences it skips
+	RRule   string
+	ExDates []time.Time
+	// RecurrenceID is set on events that change one occurrence of a
+	// recurring event, to the start of that occurrence
+	RecurrenceID time.Time
+}
+
+// Writer writes a calendar of events
+type Writer struct {
+	w   *bufio.Writer
+	err error
+	// timezones tells for each TZID used if it has a VTIMEZONE, or if times
+	// in it are written in UTC as it couldn't be described
+	timezones map[string]bool
+}
+
+// NewWriter starts a calendar named name. Close must be called to end it.
+func NewWriter(w io.Writer, name string) *Writer {
+	cw := newWriter(w)
+	cw.line("METHOD:PUBLISH")
+	if name != "" {
+		cw.line("X-WR-CALNAME:" + escapeText(name))
+	}
+	return cw
+}
+
+// NewObjectWriter starts a calendar object as stored on a CalDAV server,
+// which has no METHOD (RFC 4791, section 4.1)
+func NewObjectWriter(w io.Writer) *Writer {
+	return newWriter(w)
+}
+
+func newWriter(w io.Writer) *Writer {
+	cw := &Writer{w: bufio.NewWriter(w), timezones: map[string]bool{}}
+	cw.line("BEGIN:VCALENDAR")
+	cw.line("VERSION:2.0")
+	cw.line("PRODID:-//Collabreef//Calendar//EN")
+	cw.line("CALSCALE:GREGORIAN")
+	return cw
+}
+
+func (cw *Writer) Write(e Event) error {
+	// The VTIMEZONE of a TZID comes before the first event that uses it
+	tzid := e.TZID
+	if tzid != "" && !e.Floating && !e.AllDay {
+		described, seen := cw.timezones[tzid]
+		if !seen {
+			if loc, err := time.LoadLocation(tzid); err == nil {
+				described = cw.writeTimezone(tzid, loc, e.Start)
+			}
+			cw.timezones[tzid] = described
+		}
+		if !described {
+			tzid = ""
+		}
+	}
+
+	cw.line("BEGIN:VEVENT")
+	cw.line("UID:" + escapeText(e.UID))
+	cw.line("DTSTAMP:" + e.Stamp.UTC().Format(dateTimeLayout) + "Z")
+
+	switch {
+	case e.AllDay:
+		cw.line("DTSTART;VALUE=DATE:" + e.Start.Format(dateLayout))
+		end := e.End
+		if end.IsZero() || !end.After(e.Start) {
+			end = e.Start.AddDate(0, 0, 1)
+		}
+		cw.line("DTEND;VALUE=DATE:" + end.Format(dateLayout))
+	default:
+		cw.line("DTSTART" + formatDateTime(e.Start, e.Floating, tzid))
+		if !e.End.IsZero() {
+			cw.line("DTEND" + formatDateTime(e.End, e.Floating, tzid))
+		}
+	}
+
+	if e.RRule != "" {
+		cw.line("RRULE:" + e.RRule)
+	}
+	for _, t := range e.ExDates {
+		if e.AllDay {
+			cw.line("EXDATE;VALUE=DATE:" + t.Format(dateLayout))
+		} else {
+			cw.line("EXDATE" + formatDateTime(t, e.Floating, tzid))
+		}
+	}
+
+	cw.line("SUMMARY:" + escapeText(e.Summary))
+	if e.Description != "" {
+		cw.line("DESCRIPTION:" + escapeText(e.Description))
+	}
+	if e.Location != "" {
+		cw.line("LOCATION:" + escapeText(e.Location))
+	}
+	cw.line("END:VEVENT")
+
+	return cw.err
+}
+
+// Close ends the calendar and flushes it
+func (cw *Writer) Close() error {
+	cw.line("END:VCALENDAR")
+	if cw.err != nil {
+		return cw.err
+	}
+	return cw.w.Flush()
+}
+
+// formatDateTime writes the parameters and value of a DATE-TIME property
+func formatDateTime(t time.Time, floating bool, tzid string) string {
+	switch {
+	case floating:
+		return ":" + t.Format(dateTimeLayout)
+	case tzid != "":
+		if loc, err := time.LoadLocation(tzid); err == nil {
+			return ";TZID=" + tzid + ":" + t.In(loc).Format(dateTimeLayout)
+		}
+	}
+	return ":" + t.UTC().Format(dateTimeLayout) + "Z"
+}
+
+// line writes a content line, folded so no line is longer than 75 octets
+// without splitting UTF-8 characters
+func (cw *Writer) line(s string) {
+	if cw.err != nil {
+		return
+	}
+
+	limit := maxLineLength
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8Start(s[cut]) {
+			cut--
+		}
+		if _, cw.err = cw.w.WriteString(s[:cut] + "\r\n "); cw.err != nil {
+			return
+		}
+		s = s[cut:]
+		// The leading space of continuation lines counts too
+		limit = maxLineLength - 1
+	}
+	_, cw.err = cw.w.WriteString(s + "\r\n")
+}
+
+func utf8Start(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+var textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+func unescapeText(s string) string {
+	return textUnescaper.Replace(s)
+}
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Parse reads the VEVENTs of iCalendar data. Events without a start are
+// skipped.
+func Parse(r io.Reader) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	var current []property
+	inCalendar, inEvent, depth := false, false, 0
+
+	for _, l := range lines {
+		p, ok := parseProperty(l)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VCALENDAR"):
+			inCalendar = true
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VEVENT") && !inEvent:
+			inEvent, depth, current = true, 0, nil
+		case p.name == "BEGIN" && inEvent:
+			// Alarms and the like have properties of their own
+			depth++
+		case p.name == "END" && inEvent && depth > 0:
+			depth--
+		case p.name == "END" && strings.EqualFold(p.value, "VEVENT") && inEvent:
+			inEvent = false
+			if e, ok := eventFrom(current); ok {
+				events = append(events, e)
+			}
+		case inEvent && depth == 0:
+			current = append(current, p)
+		}
+	}
+
+	if !inCalendar {
+		return nil, ErrNotCalendar
+	}
+	return events, nil
+}
+
+// unfold joins folded lines back together
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		l := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(l, " ") || strings.HasPrefix(l, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += l[1:]
+			continue
+		}
+		if l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// parseProperty splits NAME;PARAM=value;...:VALUE, minding quoted parameter
+// values that may contain : and ;
+func parseProperty(l string) (property, bool) {
+	p := property{params: map[string]string{}}
+
+	colon, quoted := -1, false
+	for i := 0; i < len(l) && colon < 0; i++ {
+		switch l[i] {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				colon = i
+			}
+		}
+	}
+	if colon < 0 {
+		return p, false
+	}
+
+	p.value = l[colon+1:]
+	parts := strings.Split(l[:colon], ";")
+	p.name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		if k, v, ok := strings.Cut(param, "="); ok {
+			p.params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+		}
+	}
+	return p, true
+}
+
+func eventFrom(props []property) (Event, bool) {
+	var e Event
+	var duration time.Duration
+	hasStart := false
+
+	for _, p := range props {
+		switch p.name {
+		case "UID":
+			e.UID = unescapeText(p.value)
+		case "SUMMARY":
+			e.Summary = unescapeText(p.value)
+		case "DESCRIPTION":
+			e.Description = unescapeText(p.value)
+		case "LOCATION":
+			e.Location = unescapeText(p.value)
+		case "DTSTAMP":
+			e.Stamp, _, _, _ = parseTime(p)
+		case "DTSTART":
+			start, allDay, floating, err := parseTime(p)
+			if err != nil {
+				return Event{}, false
+			}
+			e.Start, e.AllDay, e.Floating, hasStart = start, allDay, floating, true
+			if !floating && p.params["TZID"] != "" {
+				e.TZID = start.Location().String()
+			}
+		case "DTEND":
+			e.End, _, _, _ = parseTime(p)
+		case "DURATION":
+			duration, _ = ParseDuration(p.value)
+		case "RECURRENCE-ID":
+			e.RecurrenceID, _, _, _ = parseTime(p)
+		case "RRULE":
+			e.RRule = strings.TrimSpace(p.value)
+		case "EXDATE":
+			// EXDATE may list several times
+			for _, v := range strings.Split(p.value, ",") {
+				if t, _, _, err := parseTime(property{name: p.name, params: p.params, value: v}); err == nil {
+					e.ExDates = append(e.ExDates, t)
+				}
+			}
+		}
+	}
+
+	if !hasStart {
+		return Event{}, false
+	}
+	if e.End.IsZero() && duration > 0 {
+		e.End = e.Start.Add(duration)
+	}
+	if e.AllDay && (e.End.IsZero() || !e.End.After(e.Start)) {
+		e.End = e.Start.AddDate(0, 0, 1)
+	}
+
+	return e, true
+}
+
+// parseTime reads a DATE or DATE-TIME value. Times in a TZID that isn't known,
+// e.g. one of Outlook's Windows names, are taken as floating.
+func parseTime(p property) (t time.Time, allDay bool, floating bool, err error) {
+	v := strings.TrimSpace(p.value)
+
+	if strings.EqualFold(p.params["VALUE"], "DATE") || len(v) == len(dateLayout) {
+		t, err = time.Parse(dateLayout, v)
+		return t, true, true, err
+	}
+
+	if strings.HasSuffix(v, "Z") {
+		t, err = time.Parse(dateTimeLayout, strings.TrimSuffix(v, "Z"))
+		return t, false, false, err
+	}
+
+	if tzid := p.params["TZID"]; tzid != "" {
+		if loc, err := util.LoadTimezone(strings.TrimPrefix(tzid, "/")); err == nil {
+			t, err = time.ParseInLocation(dateTimeLayout, v, loc)
+			return t, false, false, err
+		}
+	}
+
+	t, err = time.Parse(dateTimeLayout, v)
+	return t, false, true, err
+}
+
+var durationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// ParseDuration reads an iCalendar duration such as PT1H30M or P1W
+func ParseDuration(s string) (time.Duration, error) {
+	m := durationPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil || s == "P" || strings.HasSuffix(s, "T") {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+2] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(m[i+2])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		d += time.Duration(n) * unit
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
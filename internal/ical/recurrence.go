@@ -0,0 +1,555 @@
+package ical
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// A recurrence that hasn't matched a day for this long never will again,
+// e.g. every February 30th
+const maxRecurrenceGap = 10 * 366 * 24 * time.Hour
+
+var weekdayNames = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// WeekdayNum is a BYDAY value such as MO, or 2TU and -1FR for the second
+// Tuesday and the last Friday of the month or year
+type WeekdayNum struct {
+	Weekday time.Weekday
+	N       int
+}
+
+// Recurrence is an RRULE. Rules repeat at most daily, by day, month day,
+// month and set position; BYWEEKNO, BYYEARDAY and the time of day parts
+// aren't supported.
+type Recurrence struct {
+	Freq       Frequency
+	Interval   int
+	Count      int
+	Until      time.Time // Zero if the rule has no end
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []int
+	BySetPos   []int
+	WeekStart  time.Weekday
+
+	// UNTIL is a date, or a time in UTC, or else a wall clock time
+	untilDate, untilUTC bool
+}
+
+// ParseRecurrence reads an RRULE value such as FREQ=WEEKLY;BYDAY=MO,WE
+func ParseRecurrence(s string) (Recurrence, error) {
+	r := Recurrence{Interval: 1, WeekStart: time.Monday}
+
+	s = strings.TrimSpace(s)
+	if len(s) > 6 && strings.EqualFold(s[:6], "RRULE:") {
+		s = s[6:]
+	}
+	if s == "" {
+		return r, errors.New("recurrence rule is empty")
+	}
+
+	seen := map[string]bool{}
+	for _, part := range strings.Split(s, ";") {
+		k, v, ok := strings.Cut(part, "=")
+		k, v = strings.ToUpper(strings.TrimSpace(k)), strings.ToUpper(strings.TrimSpace(v))
+		if !ok || v == "" {
+			return r, fmt.Errorf("invalid recurrence rule part %q", part)
+		}
+		if seen[k] {
+			return r, fmt.Errorf("%s is given twice", k)
+		}
+		seen[k] = true
+
+		var err error
+		switch k {
+		case "FREQ":
+			switch f := Frequency(v); f {
+			case Daily, Weekly, Monthly, Yearly:
+				r.Freq = f
+			case "SECONDLY", "MINUTELY", "HOURLY":
+				return r, fmt.Errorf("FREQ=%s isn't supported, events repeat at most daily", v)
+			default:
+				return r, fmt.Errorf("invalid FREQ %q", v)
+			}
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(v)
+			if err != nil || r.Interval < 1 {
+				return r, fmt.Errorf("invalid INTERVAL %q", v)
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(v)
+			if err != nil || r.Count < 1 {
+				return r, fmt.Errorf("invalid COUNT %q", v)
+			}
+		case "UNTIL":
+			if err := r.parseUntil(v); err != nil {
+				return r, err
+			}
+		case "BYDAY":
+			for _, d := range strings.Split(v, ",") {
+				wd, err := parseWeekdayNum(d)
+				if err != nil {
+					return r, err
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		case "BYMONTHDAY":
+			if r.ByMonthDay, err = parseNumbers(k, v, 31); err != nil {
+				return r, err
+			}
+		case "BYMONTH":
+			if r.ByMonth, err = parseNumbers(k, v, 12); err != nil {
+				return r, err
+			}
+			for _, m := range r.ByMonth {
+				if m < 0 {
+					return r, fmt.Errorf("invalid BYMONTH %d", m)
+				}
+			}
+		case "BYSETPOS":
+			if r.BySetPos, err = parseNumbers(k, v, 366); err != nil {
+				return r, err
+			}
+		case "WKST":
+			wd, ok := weekdayNames[v]
+			if !ok {
+				return r, fmt.Errorf("invalid WKST %q", v)
+			}
+			r.WeekStart = wd
+		default:
+			return r, fmt.Errorf("%s isn't supported", k)
+		}
+	}
+
+	if r.Freq == "" {
+		return r, errors.New("FREQ is required")
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return r, errors.New("COUNT and UNTIL can't both be given")
+	}
+	for _, wd := range r.ByDay {
+		if wd.N != 0 && r.Freq != Monthly && r.Freq != Yearly {
+			return r, errors.New("numbered BYDAY values need FREQ=MONTHLY or YEARLY")
+		}
+		if wd.N != 0 && r.Freq == Monthly && (wd.N > 5 || wd.N < -5) {
+			return r, fmt.Errorf("a month has no weekday number %d", wd.N)
+		}
+	}
+	if len(r.ByMonthDay) > 0 && r.Freq == Weekly {
+		return r, errors.New("BYMONTHDAY can't be used with FREQ=WEEKLY")
+	}
+	if len(r.BySetPos) > 0 && len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 && len(r.ByMonth) == 0 {
+		return r, errors.New("BYSETPOS needs BYDAY, BYMONTHDAY or BYMONTH")
+	}
+
+	return r, nil
+}
+
+func (r *Recurrence) parseUntil(v string) error {
+	var err error
+	switch {
+	case len(v) == len(dateLayout):
+		r.Until, err = time.Parse(dateLayout, v)
+		r.untilDate = true
+	case strings.HasSuffix(v, "Z"):
+		r.Until, err = time.Parse(dateTimeLayout, strings.TrimSuffix(v, "Z"))
+		r.untilUTC = true
+	default:
+		r.Until, err = time.Parse(dateTimeLayout, v)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid UNTIL %q", v)
+	}
+	return nil
+}
+
+func parseWeekdayNum(s string) (WeekdayNum, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", s)
+	}
+	wd, ok := weekdayNames[s[len(s)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", s)
+	}
+
+	n := 0
+	if prefix := s[:len(s)-2]; prefix != "" {
+		var err error
+		n, err = strconv.Atoi(prefix)
+		if err != nil || n == 0 || n > 53 || n < -53 {
+			return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", s)
+		}
+	}
+	return WeekdayNum{Weekday: wd, N: n}, nil
+}
+
+// parseNumbers reads a list of numbers between -max and max, except 0
+func parseNumbers(name, v string, max int) ([]int, error) {
+	var nums []int
+	for _, s := range strings.Split(v, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || n == 0 || n > max || n < -max {
+			return nil, fmt.Errorf("invalid %s %q", name, s)
+		}
+		nums = append(nums, n)
+	}
+	return nums, nil
+}
+
+// String writes the rule back as an RRULE value
+func (r Recurrence) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	switch {
+	case r.Until.IsZero():
+	case r.untilDate:
+		parts = append(parts, "UNTIL="+r.Until.Format(dateLayout))
+	case r.untilUTC:
+		parts = append(parts, "UNTIL="+r.Until.Format(dateTimeLayout)+"Z")
+	default:
+		parts = append(parts, "UNTIL="+r.Until.Format(dateTimeLayout))
+	}
+	if len(r.ByDay) > 0 {
+		var days []string
+		for _, wd := range r.ByDay {
+			d := strings.ToUpper(wd.Weekday.String()[:2])
+			if wd.N != 0 {
+				d = strconv.Itoa(wd.N) + d
+			}
+			days = append(days, d)
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	for _, p := range []struct {
+		name string
+		nums []int
+	}{{"BYMONTHDAY", r.ByMonthDay}, {"BYMONTH", r.ByMonth}, {"BYSETPOS", r.BySetPos}} {
+		if len(p.nums) == 0 {
+			continue
+		}
+		var s []string
+		for _, n := range p.nums {
+			s = append(s, strconv.Itoa(n))
+		}
+		parts = append(parts, p.name+"="+strings.Join(s, ","))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+strings.ToUpper(r.WeekStart.String()[:2]))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Each calls fn with the start of every occurrence in order, at the wall
+// clock time of start in its location, until fn returns false or the rule
+// ends. start itself is always the first occurrence.
+func (r Recurrence) Each(start time.Time, fn func(time.Time) bool) {
+	r.each(start, 0, fn)
+}
+
+// EachFrom is Each for callers that only need the occurrences from a time on.
+// Rules without COUNT jump ahead to the period before from's, so a start long
+// ago doesn't mean walking every occurrence since; others start at start.
+// Some occurrences before from may still be passed to fn.
+func (r Recurrence) EachFrom(start, from time.Time, fn func(time.Time) bool) {
+	if r.Count > 0 || !from.After(start) {
+		r.each(start, 0, fn)
+		return
+	}
+
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	f := from.In(start.Location())
+	var periods int
+	switch r.Freq {
+	case Daily:
+		periods = int(f.Sub(start).Hours() / 24)
+	case Weekly:
+		periods = int(f.Sub(start).Hours() / 24 / 7)
+	case Monthly:
+		periods = (f.Year()-start.Year())*12 + int(f.Month()) - int(start.Month())
+	default:
+		periods = f.Year() - start.Year()
+	}
+
+	r.each(start, max(periods/interval-1, 0), fn)
+}
+
+// each is Each from the skip-th period of the rule's interval on. Skipping
+// leaves start out too.
+func (r Recurrence) each(start time.Time, skip int, fn func(time.Time) bool) {
+	loc := start.Location()
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	// Occurrences are worked out as dates, and given start's time of day after
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	at := func(d time.Time) time.Time {
+		return time.Date(d.Year(), d.Month(), d.Day(), start.Hour(), start.Minute(), start.Second(), 0, loc)
+	}
+
+	ended := func(t time.Time) bool {
+		switch {
+		case r.Until.IsZero():
+			return false
+		case r.untilDate:
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).After(r.Until)
+		case r.untilUTC:
+			return t.After(r.Until)
+		default:
+			u := r.Until
+			return t.After(time.Date(u.Year(), u.Month(), u.Day(), u.Hour(), u.Minute(), u.Second(), 0, loc))
+		}
+	}
+
+	count := 1
+	if skip == 0 && (!fn(start) || (r.Count > 0 && count >= r.Count)) {
+		return
+	}
+
+	lastMatch, _ := r.period(day, skip*interval)
+	if skip == 0 {
+		lastMatch = day
+	}
+	// Period 0 holds start, and may have more occurrences after it
+	for period := skip; ; period++ {
+		first, days := r.period(day, period*interval)
+		if first.Year() > 9999 || first.Sub(lastMatch) > maxRecurrenceGap {
+			return
+		}
+
+		for _, d := range r.limitBySetPos(days) {
+			if !d.After(day) {
+				continue
+			}
+			t := at(d)
+			if ended(t) {
+				return
+			}
+			lastMatch = d
+			count++
+			if !fn(t) || (r.Count > 0 && count >= r.Count) {
+				return
+			}
+		}
+	}
+}
+
+// period returns the first day of the n-th period after the one of start,
+// e.g. its n-th next week, and the days in it the rule matches, in order.
+// Period 0 is that of start.
+func (r Recurrence) period(start time.Time, n int) (time.Time, []time.Time) {
+	var days []time.Time
+
+	switch r.Freq {
+	case Daily:
+		d := start.AddDate(0, 0, n)
+		if r.matchesMonth(d) && r.matchesMonthDay(d) && r.matchesWeekday(d) {
+			days = append(days, d)
+		}
+		return d, days
+
+	case Weekly:
+		offset := (int(start.Weekday()) - int(r.WeekStart) + 7) % 7
+		first := start.AddDate(0, 0, 7*n-offset)
+		for i := 0; i < 7; i++ {
+			d := first.AddDate(0, 0, i)
+			matches := d.Weekday() == start.Weekday()
+			if len(r.ByDay) > 0 {
+				matches = r.matchesWeekday(d)
+			}
+			if matches && r.matchesMonth(d) {
+				days = append(days, d)
+			}
+		}
+		return first, days
+
+	case Monthly:
+		first := time.Date(start.Year(), start.Month()+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+		if r.matchesMonth(first) {
+			days = r.monthDays(first, start.Day())
+		}
+		return first, days
+
+	default:
+		first := time.Date(start.Year()+n, 1, 1, 0, 0, 0, 0, time.UTC)
+		if len(r.ByMonth) == 0 && len(r.ByMonthDay) == 0 && len(r.ByDay) > 0 {
+			// Numbered weekdays count through the whole year
+			return first, r.weekdaysIn(first, first.AddDate(1, 0, 0))
+		}
+
+		months := r.ByMonth
+		if len(months) == 0 {
+			months = []int{int(start.Month())}
+		}
+		sorted := append([]int(nil), months...)
+		sort.Ints(sorted)
+		for _, m := range sorted {
+			days = append(days, r.monthDays(time.Date(first.Year(), time.Month(m), 1, 0, 0, 0, 0, time.UTC), start.Day())...)
+		}
+		return first, days
+	}
+}
+
+// monthDays returns the days of the month starting on first that the rule
+// matches, the day of month of start if it has no BYMONTHDAY or BYDAY
+func (r Recurrence) monthDays(first time.Time, startDay int) []time.Time {
+	next := first.AddDate(0, 1, 0)
+	length := next.AddDate(0, 0, -1).Day()
+
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		if startDay > length {
+			return nil
+		}
+		return []time.Time{first.AddDate(0, 0, startDay-1)}
+	}
+
+	if len(r.ByMonthDay) == 0 {
+		return r.weekdaysIn(first, next)
+	}
+
+	set := map[int]bool{}
+	for _, md := range r.ByMonthDay {
+		if md < 0 {
+			md = length + 1 + md
+		}
+		if md >= 1 && md <= length {
+			set[md] = true
+		}
+	}
+
+	var days []time.Time
+	for md := 1; md <= length; md++ {
+		d := first.AddDate(0, 0, md-1)
+		if set[md] && (len(r.ByDay) == 0 || r.matchesWeekday(d)) {
+			days = append(days, d)
+		}
+	}
+	return days
+}
+
+// weekdaysIn returns the days from first up to end that match BYDAY, where
+// numbered weekdays count from first or back from end
+func (r Recurrence) weekdaysIn(first, end time.Time) []time.Time {
+	set := map[time.Time]bool{}
+
+	for _, wd := range r.ByDay {
+		var matches []time.Time
+		for d := first; d.Before(end); d = d.AddDate(0, 0, 1) {
+			if d.Weekday() == wd.Weekday {
+				matches = append(matches, d)
+			}
+		}
+		switch {
+		case wd.N == 0:
+			for _, d := range matches {
+				set[d] = true
+			}
+		case wd.N > 0 && wd.N <= len(matches):
+			set[matches[wd.N-1]] = true
+		case wd.N < 0 && -wd.N <= len(matches):
+			set[matches[len(matches)+wd.N]] = true
+		}
+	}
+
+	var days []time.Time
+	for d := first; d.Before(end); d = d.AddDate(0, 0, 1) {
+		if set[d] && r.matchesMonth(d) {
+			days = append(days, d)
+		}
+	}
+	return days
+}
+
+func (r Recurrence) matchesMonth(d time.Time) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, m := range r.ByMonth {
+		if time.Month(m) == d.Month() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Recurrence) matchesMonthDay(d time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	length := time.Date(d.Year(), d.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, md := range r.ByMonthDay {
+		if md == d.Day() || (md < 0 && length+1+md == d.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Recurrence) matchesWeekday(d time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, wd := range r.ByDay {
+		if wd.Weekday == d.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// limitBySetPos keeps the days at the BYSETPOS positions of a period
+func (r Recurrence) limitBySetPos(days []time.Time) []time.Time {
+	if len(r.BySetPos) == 0 {
+		return days
+	}
+
+	keep := map[int]bool{}
+	for _, pos := range r.BySetPos {
+		switch {
+		case pos > 0 && pos <= len(days):
+			keep[pos-1] = true
+		case pos < 0 && -pos <= len(days):
+			keep[len(days)+pos] = true
+		}
+	}
+
+	var limited []time.Time
+	for i, d := range days {
+		if keep[i] {
+			limited = append(limited, d)
+		}
+	}
+	return limited
+}
@@ -0,0 +1,184 @@
+package ical
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Years the yearly rules of a timezone are checked against after the one
+// they are taken from, enough for the weekdays of every date to come round
+const timezoneRuleYears = 7
+
+// transition is a change of a timezone's UTC offset
+type transition struct {
+	at           time.Time // In UTC
+	from, to     int       // Offsets, in seconds east of UTC
+	name         string
+	daylightTime bool
+}
+
+// yearlyRule is a transition on the same weekday of a month every year, the
+// n-th one of the month, or the last one if n is -1
+type yearlyRule struct {
+	month   time.Month
+	n       int
+	weekday time.Weekday
+	// clock is the time of day of the transition in the offset before it
+	clock time.Duration
+}
+
+// writeTimezone writes the VTIMEZONE a TZID parameter refers to (RFC 5545,
+// section 3.6.5), describing the timezone by its offsets in the year of at,
+// or this year if at is before it as recurring events go on until now: the
+// one it has if it has no others, or its daylight saving time as yearly
+// rules. It writes nothing and returns false if the timezone has changes of
+// offset that don't follow such rules.
+func (cw *Writer) writeTimezone(tzid string, loc *time.Location, at time.Time) bool {
+	if now := time.Now(); at.Before(now) {
+		at = now
+	}
+	transitions := yearTransitions(loc, at.Year())
+	var rules []yearlyRule
+	for _, t := range transitions {
+		if rule, ok := findYearlyRule(loc, t); ok {
+			rules = append(rules, rule)
+		}
+	}
+	if len(transitions) != 0 && (len(transitions) != 2 || len(rules) != 2) {
+		return false
+	}
+
+	cw.line("BEGIN:VTIMEZONE")
+	cw.line("TZID:" + tzid)
+	if len(transitions) == 0 {
+		name, offset := at.In(loc).Zone()
+		cw.writeObservance(transition{from: offset, to: offset, name: name}, nil)
+	}
+	for i, t := range transitions {
+		cw.writeObservance(t, &rules[i])
+	}
+	cw.line("END:VTIMEZONE")
+	return true
+}
+
+// writeObservance writes a STANDARD or DAYLIGHT component. Observances
+// without a rule start in 1970 and never end.
+func (cw *Writer) writeObservance(t transition, rule *yearlyRule) {
+	kind := "STANDARD"
+	if t.daylightTime {
+		kind = "DAYLIGHT"
+	}
+	cw.line("BEGIN:" + kind)
+
+	start := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	if rule != nil {
+		start = rule.in(1970)
+	}
+	cw.line("DTSTART:" + start.Format(dateTimeLayout))
+	cw.line("TZOFFSETFROM:" + formatOffset(t.from))
+	cw.line("TZOFFSETTO:" + formatOffset(t.to))
+	if t.name != "" {
+		cw.line("TZNAME:" + escapeText(t.name))
+	}
+	if rule != nil {
+		day := strings.ToUpper(rule.weekday.String()[:2])
+		cw.line(fmt.Sprintf("RRULE:FREQ=YEARLY;BYMONTH=%d;BYDAY=%d%s", rule.month, rule.n, day))
+	}
+	cw.line("END:" + kind)
+}
+
+// yearTransitions lists the changes of loc's offset in a year
+func yearTransitions(loc *time.Location, year int) []transition {
+	var transitions []transition
+	t := time.Date(year, 1, 1, 0, 0, 0, 0, loc)
+	for {
+		_, end := t.ZoneBounds()
+		if end.IsZero() || end.Year() > year {
+			return transitions
+		}
+		_, from := t.Zone()
+		name, to := end.Zone()
+		transitions = append(transitions, transition{
+			at:           end.UTC(),
+			from:         from,
+			to:           to,
+			name:         name,
+			daylightTime: end.IsDST(),
+		})
+		t = end
+	}
+}
+
+// findYearlyRule finds the weekday rule of a transition, if the transitions
+// of loc in the years after follow it
+func findYearlyRule(loc *time.Location, t transition) (yearlyRule, bool) {
+	// The wall clock time the transition is at, in the offset before it
+	local := t.at.Add(time.Duration(t.from) * time.Second)
+	rule := yearlyRule{
+		month:   local.Month(),
+		n:       (local.Day()-1)/7 + 1,
+		weekday: local.Weekday(),
+		clock:   local.Sub(time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)),
+	}
+
+	// A weekday in the last seven days of the month may be the last one of
+	// every year, or the fourth one
+	candidates := []yearlyRule{rule}
+	if local.AddDate(0, 0, 7).Month() != local.Month() {
+		last := rule
+		last.n = -1
+		candidates = []yearlyRule{last, rule}
+		if rule.n == 5 {
+			candidates = candidates[:1]
+		}
+	}
+
+	for _, c := range candidates {
+		if c.follows(loc, t, local.Year()) {
+			return c, true
+		}
+	}
+	return yearlyRule{}, false
+}
+
+// follows tells if loc changes offsets from t.from to t.to by the rule in
+// the years after year
+func (r yearlyRule) follows(loc *time.Location, t transition, year int) bool {
+	for y := year + 1; y <= year+timezoneRuleYears; y++ {
+		at := r.in(y).Add(-time.Duration(t.from) * time.Second)
+		_, before := at.Add(-time.Second).In(loc).Zone()
+		_, after := at.In(loc).Zone()
+		if before != t.from || after != t.to {
+			return false
+		}
+	}
+	return true
+}
+
+// in is the wall clock time of the transition in a year, as a UTC time
+func (r yearlyRule) in(year int) time.Time {
+	var day time.Time
+	if r.n > 0 {
+		first := time.Date(year, r.month, 1, 0, 0, 0, 0, time.UTC)
+		day = first.AddDate(0, 0, (int(r.weekday)-int(first.Weekday())+7)%7+7*(r.n-1))
+	} else {
+		last := time.Date(year, r.month+1, 0, 0, 0, 0, 0, time.UTC)
+		day = last.AddDate(0, 0, -((int(last.Weekday()) - int(r.weekday) + 7) % 7))
+	}
+	return day.Add(r.clock)
+}
+
+// formatOffset writes a UTC offset as a UTC-OFFSET value
+func formatOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign = "-"
+		seconds = -seconds
+	}
+	s := fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds/60%60)
+	if seconds%60 != 0 {
+		s += fmt.Sprintf("%02d", seconds%60)
+	}
+	return s
+}
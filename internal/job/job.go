@@ -0,0 +1,273 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/db"
+	"github.com/collabreef/collabreef/internal/model"
+	"github.com/collabreef/collabreef/internal/queue"
+	"github.com/collabreef/collabreef/internal/util"
+	"gorm.io/gorm"
+)
+
+const (
+	// A claimed job is run again after the lease if its worker never reports
+	// back, so handlers must finish well within it
+	leaseDuration = 5 * time.Minute
+
+	retryBase = 30 * time.Second
+	retryMax  = time.Hour
+)
+
+// Func runs a job. Returning an error retries it later, until it has been
+// tried MaxAttempts times.
+type Func func(ctx context.Context, j model.Job) error
+
+type Options struct {
+	// MaxAttempts is how often a job is tried before it is marked failed
+	MaxAttempts int
+	// Retention is how long finished jobs are kept, 0 keeps them forever
+	Retention time.Duration
+}
+
+// Runner runs jobs queued in the database in the background. Jobs survive
+// restarts, and when several server instances share the database each job is
+// run by only one of them. Recurring jobs are queued from cron schedules the
+// same way.
+type Runner struct {
+	db        db.DB
+	opts      Options
+	handlers  map[string]Func
+	schedules map[string]schedule
+	loop      *queue.Loop[model.Job]
+}
+
+func NewRunner(r db.DB, opts Options) *Runner {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 1
+	}
+
+	runner := &Runner{
+		db:        r,
+		opts:      opts,
+		handlers:  map[string]Func{},
+		schedules: map[string]schedule{},
+	}
+
+	cfg := queue.Config[model.Job]{
+		Name:    "jobs",
+		Due:     runner.findDue,
+		Claim:   runner.claim,
+		Run:     runner.attempt,
+		Lease:   leaseDuration,
+		Prepare: runner.queueScheduled,
+	}
+	if opts.Retention > 0 {
+		cfg.Purge = runner.purge
+	}
+	runner.loop = queue.New(cfg)
+
+	return runner
+}
+
+// Handle sets what runs jobs of a type. Handlers are registered before Start;
+// jobs of types without one are left for instances that have it.
+func (r *Runner) Handle(jobType string, fn Func) {
+	r.handlers[jobType] = fn
+}
+
+// Schedule queues a job of the type called name at every time the cron
+// expression matches, in UTC
+func (r *Runner) Schedule(name string, spec string) error {
+	c, err := ParseCron(spec)
+	if err != nil {
+		return fmt.Errorf("invalid schedule for %s: %w", name, err)
+	}
+	if c.Next(time.Now()).IsZero() {
+		return fmt.Errorf("schedule for %s never runs", name)
+	}
+
+	r.schedules[name] = schedule{spec: spec, cron: c}
+	return nil
+}
+
+type schedule struct {
+	spec string
+	cron Cron
+}
+
+// Enqueue queues a job to run at runAt, or right away if that is zero. key
+// names what the job is for, so that Cancel can drop it.
+func (r *Runner) Enqueue(jobType string, key string, payload string, runAt time.Time) (model.Job, error) {
+	now := time.Now().UTC()
+	if runAt.IsZero() || runAt.Before(now) {
+		runAt = now
+	}
+
+	j := model.Job{
+		ID:          util.NewId(),
+		Type:        jobType,
+		Key:         key,
+		Payload:     payload,
+		Status:      model.JobStatusPending,
+		MaxAttempts: r.opts.MaxAttempts,
+		RunAt:       runAt.UTC().Format(time.RFC3339),
+		CreatedAt:   now.Format(time.RFC3339),
+		UpdatedAt:   now.Format(time.RFC3339),
+	}
+	if err := r.db.CreateJob(j); err != nil {
+		return model.Job{}, err
+	}
+
+	if !runAt.After(now) {
+		r.loop.Notify()
+	}
+
+	return j, nil
+}
+
+// Cancel drops the pending jobs queued with a key. A job that is running
+// already finishes.
+func (r *Runner) Cancel(key string) error {
+	_, err := r.db.DeletePendingJobs(key)
+	return err
+}
+
+// Start runs the job loop until ctx is cancelled
+func (r *Runner) Start(ctx context.Context) {
+	r.initSchedules()
+	r.loop.Start(ctx)
+}
+
+// initSchedules stores the schedules that aren't yet, and moves the next run
+// of those whose expression changed
+func (r *Runner) initSchedules() {
+	now := time.Now().UTC()
+
+	for name, sc := range r.schedules {
+		next := sc.cron.Next(now).Format(time.RFC3339)
+
+		existing, err := r.db.FindJobSchedule(name)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			err = r.db.CreateJobSchedule(model.JobSchedule{Name: name, Spec: sc.spec, NextRunAt: next})
+		case err == nil && existing.Spec != sc.spec:
+			existing.Spec = sc.spec
+			existing.NextRunAt = next
+			err = r.db.UpdateJobSchedule(existing)
+		}
+		if err != nil {
+			log.Printf("Failed to store job schedule %s: %v", name, err)
+		}
+	}
+}
+
+// queueScheduled queues the recurring jobs that are due. Moving a schedule on
+// only succeeds for one instance, so each run is queued once. Runs missed
+// while no instance was up are made up for with a single one.
+func (r *Runner) queueScheduled() {
+	now := time.Now().UTC()
+
+	for name, sc := range r.schedules {
+		s, err := r.db.FindJobSchedule(name)
+		if err != nil {
+			log.Printf("Failed to load job schedule %s: %v", name, err)
+			continue
+		}
+		if s.NextRunAt > now.Format(time.RFC3339) {
+			continue
+		}
+
+		following := sc.cron.Next(now).Format(time.RFC3339)
+		claimed, err := r.db.ClaimJobSchedule(name, s.NextRunAt, following, now.Format(time.RFC3339))
+		if err != nil {
+			log.Printf("Failed to claim job schedule %s: %v", name, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		if _, err := r.Enqueue(name, "schedule:"+name, "", now); err != nil {
+			log.Printf("Failed to queue scheduled job %s: %v", name, err)
+		}
+	}
+}
+
+// findDue returns the due jobs of the types this instance has handlers for
+func (r *Runner) findDue(now string, limit int) ([]model.Job, error) {
+	if len(r.handlers) == 0 {
+		return nil, nil
+	}
+
+	types := make([]string, 0, len(r.handlers))
+	for t := range r.handlers {
+		types = append(types, t)
+	}
+
+	return r.db.FindDueJobs(types, now, limit)
+}
+
+func (r *Runner) claim(j model.Job, lease string) (bool, error) {
+	claimed, err := r.db.ClaimJob(j.ID, j.RunAt, lease)
+	if err != nil {
+		return false, fmt.Errorf("job %s: %w", j.ID, err)
+	}
+	return claimed, nil
+}
+
+func (r *Runner) attempt(ctx context.Context, j model.Job) {
+	started := time.Now().UTC()
+
+	err := r.call(ctx, j)
+
+	j.Attempts++
+	j.LastRunAt = started.Format(time.RFC3339)
+	j.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	j.Error = ""
+
+	switch {
+	case err == nil:
+		j.Status = model.JobStatusSucceeded
+	case j.Attempts >= j.MaxAttempts:
+		j.Status = model.JobStatusFailed
+		j.Error = err.Error()
+		log.Printf("Job %s (%s) failed: %v", j.ID, j.Type, err)
+	default:
+		j.Error = err.Error()
+		j.RunAt = started.Add(queue.Backoff(j.Attempts, retryBase, retryMax)).Format(time.RFC3339)
+	}
+
+	if err := r.db.UpdateJob(j); err != nil {
+		log.Printf("Failed to update job %s: %v", j.ID, err)
+	}
+}
+
+// call runs a job's handler, turning a panic into an error so a broken job
+// doesn't take the runner down
+func (r *Runner) call(ctx context.Context, j model.Job) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+
+	return r.handlers[j.Type](ctx, j)
+}
+
+func (r *Runner) purge() {
+	cutoff := time.Now().UTC().Add(-r.opts.Retention).Format(time.RFC3339)
+
+	n, err := r.db.DeleteJobsBefore(cutoff)
+	if err != nil {
+		log.Printf("Failed to purge jobs: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("Purged %d jobs", n)
+	}
+}
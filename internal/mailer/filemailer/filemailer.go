@@ -0,0 +1,42 @@
+package filemailer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/mailer"
+	"github.com/collabreef/collabreef/internal/util"
+)
+
+// FileMailer stores every outgoing message as an .eml file in a directory.
+// Useful for tests and local development.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir string, from string) mailer.Mailer {
+	return &FileMailer{dir: dir, from: from}
+}
+
+func (f *FileMailer) Send(m mailer.Message) error {
+	if err := os.MkdirAll(f.dir, os.ModePerm); err != nil {
+		return errors.New("Failed to create mail directory")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", f.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(m.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", m.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().UTC().Format(time.RFC1123Z))
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(m.Body)
+
+	name := time.Now().UTC().Format("20060102T150405") + "-" + util.NewId() + ".eml"
+
+	return os.WriteFile(filepath.Join(f.dir, name), []byte(b.String()), 0o644)
+}
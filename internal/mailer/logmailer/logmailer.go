@@ -0,0 +1,23 @@
+package logmailer
+
+import (
+	"log"
+	"strings"
+
+	"github.com/collabreef/collabreef/internal/mailer"
+)
+
+// LogMailer writes outgoing messages to the server log instead of delivering them.
+// It is the default driver so that links are still reachable without an SMTP server.
+type LogMailer struct {
+	from string
+}
+
+func NewLogMailer(from string) mailer.Mailer {
+	return &LogMailer{from: from}
+}
+
+func (l *LogMailer) Send(m mailer.Message) error {
+	log.Printf("Mail from=%s to=%s subject=%q\n%s", l.from, strings.Join(m.To, ","), m.Subject, m.Body)
+	return nil
+}
@@ -0,0 +1,11 @@
+package mailer
+
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(m Message) error
+}
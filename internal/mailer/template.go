@@ -0,0 +1,85 @@
+package mailer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PasswordResetMessage builds the email sent when a user requests a password reset
+func PasswordResetMessage(to string, name string, link string) Message {
+	return Message{
+		To:      []string{to},
+		Subject: "Reset your CollabReef password",
+		Body: fmt.Sprintf(`Hi %s,
+
+We received a request to reset the password for your CollabReef account.
+Open the link below to choose a new password:
+
+%s
+
+This link expires in one hour and can only be used once.
+If you did not request a password reset, you can safely ignore this email.
+`, name, link),
+	}
+}
+
+// EmailVerificationMessage builds the email sent to confirm a new account's address
+func EmailVerificationMessage(to string, name string, link string) Message {
+	return Message{
+		To:      []string{to},
+		Subject: "Verify your CollabReef email address",
+		Body: fmt.Sprintf(`Hi %s,
+
+Welcome to CollabReef! Please confirm your email address by opening the link below:
+
+%s
+
+This link expires in 24 hours.
+`, name, link),
+	}
+}
+
+// WorkspaceInvitationMessage builds the email sent to invite someone to a workspace
+func WorkspaceInvitationMessage(to string, inviterName string, workspaceName string, link string) Message {
+	return Message{
+		To:      []string{to},
+		Subject: fmt.Sprintf("%s invited you to %s on CollabReef", inviterName, workspaceName),
+		Body: fmt.Sprintf(`Hi,
+
+%s has invited you to join the workspace "%s" on CollabReef.
+Open the link below to accept the invitation:
+
+%s
+
+If you do not have an account yet, sign up with this email address and
+you will be added to the workspace automatically.
+
+This invitation expires in 7 days.
+`, inviterName, workspaceName, link),
+	}
+}
+
+// NotificationDigestMessage builds the daily email listing a user's unread notifications
+func NotificationDigestMessage(to string, name string, notifications []string, link string) Message {
+	subject := "You have 1 unread notification on CollabReef"
+	if len(notifications) != 1 {
+		subject = fmt.Sprintf("You have %d unread notifications on CollabReef", len(notifications))
+	}
+
+	return Message{
+		To:      []string{to},
+		Subject: subject,
+		Body: fmt.Sprintf(`Hi %s,
+
+Here is what happened since your last digest:
+
+- %s
+
+Open CollabReef to see them:
+
+%s
+
+You can turn this email off in your notification preferences.
+`, name, strings.Join(notifications, "\n- "), link),
+	}
+}
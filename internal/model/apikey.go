@@ -12,6 +12,7 @@ type APIKey struct {
 	Name       string `json:"name"`
 	KeyHash    string `json:"-"` // Never expose hash in JSON
 	Prefix     string `json:"prefix"`
+	RateLimit  int    `json:"rate_limit"` // Requests per minute, 0 uses the server default
 	LastUsedAt string `json:"last_used_at"`
 	ExpiresAt  string `json:"expires_at"`
 	CreatedAt  string `json:"created_at"`
@@ -24,6 +25,7 @@ type APIKeyResponse struct {
 	UserID     string `json:"user_id"`
 	Name       string `json:"name"`
 	Prefix     string `json:"prefix"`
+	RateLimit  int    `json:"rate_limit"`
 	LastUsedAt string `json:"last_used_at"`
 	ExpiresAt  string `json:"expires_at"`
 	CreatedAt  string `json:"created_at"`
@@ -0,0 +1,87 @@
+package model
+
+type AuditEventFilter struct {
+	WorkspaceID string
+	ActorID     string
+	Action      string
+	TargetType  string
+	TargetID    string
+	From        string // RFC3339, inclusive
+	To          string // RFC3339, exclusive
+	// BeforeCreatedAt and BeforeID, if set, only match the events listed
+	// after that one, to page through events that are still being added
+	BeforeCreatedAt string
+	BeforeID        string
+	PageSize        int
+	PageNumber      int
+}
+
+// AuditEvent records a security or data relevant action. Before and After hold
+// JSON snapshots of the values that changed.
+type AuditEvent struct {
+	ID          string `json:"id"`
+	WorkspaceID string `json:"workspace_id"`
+	ActorID     string `json:"actor_id"`
+	ActorName   string `json:"actor_name"`
+	Action      string `json:"action"`
+	TargetType  string `json:"target_type"`
+	TargetID    string `json:"target_id"`
+	Before      string `json:"before"`
+	After       string `json:"after"`
+	IP          string `json:"ip"`
+	UserAgent   string `json:"user_agent"`
+	CreatedAt   string `json:"created_at"`
+}
+
+const (
+	AuditActionUserCreate         = "user.create"
+	AuditActionUserRoleUpdate     = "user.role_update"
+	AuditActionUserPasswordUpdate = "user.password_update"
+	AuditActionUserDisable        = "user.disable"
+	AuditActionUserEnable         = "user.enable"
+	AuditActionUserDelete         = "user.delete"
+
+	AuditActionAPIKeyCreate = "api_key.create"
+	AuditActionAPIKeyDelete = "api_key.delete"
+
+	AuditActionWorkspaceUpdate = "workspace.update"
+	AuditActionWorkspaceDelete = "workspace.delete"
+
+	AuditActionMemberAdd        = "workspace_member.add"
+	AuditActionMemberJoin       = "workspace_member.join"
+	AuditActionMemberRoleUpdate = "workspace_member.role_update"
+	AuditActionMemberRemove     = "workspace_member.remove"
+
+	AuditActionInvitationCreate = "workspace_invitation.create"
+	AuditActionInvitationRevoke = "workspace_invitation.revoke"
+	AuditActionInviteLinkCreate = "workspace_invite_link.create"
+	AuditActionInviteLinkRevoke = "workspace_invite_link.revoke"
+
+	AuditActionNoteVisibilityUpdate = "note.visibility_update"
+	AuditActionNoteDelete           = "note.delete"
+	AuditActionViewVisibilityUpdate = "view.visibility_update"
+	AuditActionViewDelete           = "view.delete"
+	AuditActionViewObjectDelete     = "view_object.delete"
+	AuditActionWidgetDelete         = "widget.delete"
+	AuditActionFileDelete           = "file.delete"
+
+	AuditActionWebhookCreate       = "webhook.create"
+	AuditActionWebhookUpdate       = "webhook.update"
+	AuditActionWebhookSecretRotate = "webhook.secret_rotate"
+	AuditActionWebhookDelete       = "webhook.delete"
+)
+
+const (
+	AuditTargetUser                = "user"
+	AuditTargetAPIKey              = "api_key"
+	AuditTargetWorkspace           = "workspace"
+	AuditTargetWorkspaceMember     = "workspace_member"
+	AuditTargetWorkspaceInvitation = "workspace_invitation"
+	AuditTargetWorkspaceInviteLink = "workspace_invite_link"
+	AuditTargetNote                = "note"
+	AuditTargetView                = "view"
+	AuditTargetViewObject          = "view_object"
+	AuditTargetWidget              = "widget"
+	AuditTargetFile                = "file"
+	AuditTargetWebhook             = "webhook"
+)
@@ -0,0 +1,28 @@
+package model
+
+type CalendarFeedFilter struct {
+	ViewID string
+	UserID string
+}
+
+// CalendarFeed lets calendar apps subscribe to a calendar view with a token
+// in the URL instead of signing in. Each user has at most one per view, and
+// it only works as long as they can still see the view.
+type CalendarFeed struct {
+	ID          string `json:"id"`
+	WorkspaceID string `json:"workspace_id"`
+	ViewID      string `json:"view_id"`
+	UserID      string `json:"user_id"`
+	TokenHash   string `json:"-"` // Never expose hash in JSON
+	Prefix      string `json:"prefix"`
+	LastUsedAt  string `json:"last_used_at"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// CalendarFeedCreationResponse includes the feed URL (only returned once)
+type CalendarFeedCreationResponse struct {
+	CalendarFeed
+	Token     string `json:"token"`
+	URL       string `json:"url"`
+	WebcalURL string `json:"webcal_url"`
+}
@@ -0,0 +1,35 @@
+package model
+
+type CommentFilter struct {
+	WorkspaceID string
+	TargetType  string
+	TargetID    string
+	ViewID      string
+	ThreadID    string
+}
+
+// Comment is a remark on a note or a view object. Comments that start a thread
+// have no ThreadID, replies have the ID of the comment that started it. Only
+// threads are resolved. BlockID optionally anchors a thread on a note to one
+// of its TipTap blocks.
+type Comment struct {
+	ID          string `json:"id"`
+	WorkspaceID string `json:"workspace_id"`
+	TargetType  string `json:"target_type"`
+	TargetID    string `json:"target_id"`
+	ViewID      string `json:"view_id"`
+	BlockID     string `json:"block_id"`
+	ThreadID    string `json:"thread_id"`
+	Content     string `json:"content"`
+	ResolvedAt  string `json:"resolved_at"`
+	ResolvedBy  string `json:"resolved_by"`
+	CreatedAt   string `json:"created_at"`
+	CreatedBy   string `json:"created_by"`
+	UpdatedAt   string `json:"updated_at"`
+	UpdatedBy   string `json:"updated_by"`
+}
+
+const (
+	CommentTargetNote       = "note"
+	CommentTargetViewObject = "view_object"
+)
@@ -0,0 +1,21 @@
+package model
+
+type DailyNoteFilter struct {
+	WorkspaceID string
+	UserID      string
+	From        string // First date, YYYY-MM-DD
+	To          string // Last date, YYYY-MM-DD
+	Descending  bool   // Latest date first
+	PageSize    int
+	PageNumber  int
+}
+
+// DailyNote ties a user's note for a calendar day in a workspace to its date,
+// which is in the user's own timezone
+type DailyNote struct {
+	WorkspaceID string `json:"workspace_id"`
+	UserID      string `json:"user_id"`
+	Date        string `json:"date"`
+	NoteID      string `json:"note_id"`
+	CreatedAt   string `json:"created_at"`
+}
@@ -0,0 +1,42 @@
+package model
+
+const (
+	JobStatusPending   = "pending"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+)
+
+type JobFilter struct {
+	Type       string
+	Key        string
+	Status     string
+	PageSize   int
+	PageNumber int
+}
+
+// Job is a unit of background work. RunAt is when it's due; while a worker
+// runs it, RunAt is pushed out by a lease so no other instance picks it up.
+// Key names what the job is for, e.g. a reminder, so it can be cancelled.
+type Job struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	Key         string `json:"key"`
+	Payload     string `json:"payload"`
+	Status      string `json:"status"`
+	Attempts    int    `json:"attempts"`
+	MaxAttempts int    `json:"max_attempts"`
+	RunAt       string `json:"run_at"`
+	LastRunAt   string `json:"last_run_at"`
+	Error       string `json:"error"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+// JobSchedule is a recurring job. Whichever instance moves NextRunAt on
+// queues the job for that run.
+type JobSchedule struct {
+	Name      string `json:"name"`
+	Spec      string `json:"spec"` // Cron expression, in UTC
+	NextRunAt string `json:"next_run_at"`
+	LastRunAt string `json:"last_run_at"`
+}
@@ -0,0 +1,20 @@
+package model
+
+// KanbanMove is a card entering, leaving or moving between the columns of a
+// kanban view. Cards added to the board have no FromColumnID and cards taken
+// off it no ToColumnID.
+type KanbanMove struct {
+	ID           string `json:"id"`
+	ViewID       string `json:"view_id"`
+	NoteID       string `json:"note_id"`
+	FromColumnID string `json:"from_column_id"`
+	ToColumnID   string `json:"to_column_id"`
+	MovedAt      string `json:"moved_at"`
+	MovedBy      string `json:"moved_by"`
+}
+
+type KanbanMoveFilter struct {
+	ViewID string
+	// Until limits the moves to those made at or before it, in RFC3339
+	Until string
+}
@@ -0,0 +1,29 @@
+package model
+
+// MapLocationFilter finds the locations of the objects of a view whose
+// bounds overlap a bounding box. MinLng is greater than MaxLng for boxes that
+// cross the antimeridian.
+type MapLocationFilter struct {
+	ViewID string
+	MinLat float64
+	MinLng float64
+	MaxLat float64
+	MaxLng float64
+}
+
+// MapLocation is where a map marker or line is, indexed from its data so that
+// the objects of a map view can be found by position. Markers have a
+// bounding box of one point; Lat and Lng are the middle of a line's. Geohash
+// is the marker's, or that of the smallest cell holding the whole line.
+type MapLocation struct {
+	ViewObjectID    string  `json:"view_object_id"`
+	ViewID          string  `json:"view_id"`
+	Lat             float64 `json:"lat"`
+	Lng             float64 `json:"lng"`
+	MinLat          float64 `json:"min_lat"`
+	MinLng          float64 `json:"min_lng"`
+	MaxLat          float64 `json:"max_lat"`
+	MaxLng          float64 `json:"max_lng"`
+	Geohash         string  `json:"geohash"`
+	ObjectUpdatedAt string  `json:"object_updated_at"`
+}
@@ -0,0 +1,42 @@
+package model
+
+type NoteTemplateFilter struct {
+	WorkspaceID string
+}
+
+// NoteTemplate is the structure of a kind of note, e.g. meeting notes, that
+// new notes can start from. Title and Content may hold placeholders such as
+// {{date}}, {{user}}, {{workspace}} and the names of the template's Prompts,
+// which is a JSON array of NoteTemplatePrompt.
+type NoteTemplate struct {
+	ID          string `json:"id"`
+	WorkspaceID string `json:"workspace_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Title       string `json:"title"`
+	Format      string `json:"format"`
+	Content     string `json:"content"`
+	Prompts     string `json:"prompts"`
+	CreatedAt   string `json:"created_at"`
+	CreatedBy   string `json:"created_by"`
+	UpdatedAt   string `json:"updated_at"`
+	UpdatedBy   string `json:"updated_by"`
+}
+
+// NoteTemplatePrompt is a value asked for when a note is created from a
+// template, used as {{name}}
+type NoteTemplatePrompt struct {
+	Name     string `json:"name" validate:"required"`
+	Label    string `json:"label"`
+	Default  string `json:"default"`
+	Required bool   `json:"required"`
+}
+
+const (
+	NoteTemplateFormatTipTap   = "tiptap"
+	NoteTemplateFormatMarkdown = "markdown"
+)
+
+func IsValidNoteTemplateFormat(input string) bool {
+	return input == NoteTemplateFormatTipTap || input == NoteTemplateFormatMarkdown
+}
@@ -0,0 +1,116 @@
+package model
+
+import "encoding/json"
+
+type NotificationFilter struct {
+	UserID     string
+	Unread     bool // Only notifications that haven't been read
+	Unemailed  bool // Only notifications that haven't been sent in a digest
+	PageSize   int
+	PageNumber int
+}
+
+// Notification tells a user about something others did that concerns them.
+// TargetType and TargetID name what it is about, e.g. a note.
+type Notification struct {
+	ID          string `json:"id"`
+	UserID      string `json:"user_id"`
+	WorkspaceID string `json:"workspace_id"`
+	Type        string `json:"type"`
+	ActorID     string `json:"actor_id"`
+	ActorName   string `json:"actor_name"`
+	TargetType  string `json:"target_type"`
+	TargetID    string `json:"target_id"`
+	Message     string `json:"message"`
+	ReadAt      string `json:"read_at"`
+	EmailedAt   string `json:"-"`
+	CreatedAt   string `json:"created_at"`
+}
+
+const (
+	NotificationMention      = "mention"
+	NotificationMemberAdded  = "member_added"
+	NotificationRoleChanged  = "role_changed"
+	NotificationNoteAttached = "note_attached"
+	NotificationReminder     = "reminder"
+)
+
+const (
+	NotificationTargetNote       = "note"
+	NotificationTargetWorkspace  = "workspace"
+	NotificationTargetViewObject = "view_object"
+	NotificationTargetComment    = "comment"
+)
+
+// NotificationPreferences are kept under the "notifications" key of a user's
+// preferences. Types maps a notification type to whether it's wanted; types
+// that aren't listed are.
+type NotificationPreferences struct {
+	Types       map[string]bool `json:"types"`
+	EmailDigest bool            `json:"email_digest"`
+}
+
+// Wants reports whether the user wants notifications of a type
+func (p NotificationPreferences) Wants(notificationType string) bool {
+	enabled, ok := p.Types[notificationType]
+	return !ok || enabled
+}
+
+// NotificationPreferences reads the user's notification preferences
+func (u User) NotificationPreferences() NotificationPreferences {
+	var prefs struct {
+		Notifications NotificationPreferences `json:"notifications"`
+	}
+	json.Unmarshal([]byte(u.Preferences), &prefs)
+	return prefs.Notifications
+}
+
+// SetNotificationPreferences stores notification preferences in the user's
+// preferences, keeping the other settings there
+func (u *User) SetNotificationPreferences(p NotificationPreferences) error {
+	prefs := map[string]json.RawMessage{}
+	if u.Preferences != "" {
+		if err := json.Unmarshal([]byte(u.Preferences), &prefs); err != nil || prefs == nil {
+			prefs = map[string]json.RawMessage{}
+		}
+	}
+
+	value, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	prefs["notifications"] = value
+
+	data, err := json.Marshal(prefs)
+	if err != nil {
+		return err
+	}
+	u.Preferences = string(data)
+	return nil
+}
+
+// MentionIndex records the members mentioned in a note when its mentions were
+// last notified, so each change, however it was made, only notifies the
+// members mentioned anew
+type MentionIndex struct {
+	NoteID      string `json:"note_id"`
+	WorkspaceID string `json:"workspace_id"`
+	// UserIDs are the IDs of the mentioned members, like ",a,b,"
+	UserIDs string `json:"user_ids"`
+	// Known is false for the notes from before mentions were indexed, whose
+	// mentions the next change records without notifying anyone
+	Known bool `json:"known"`
+}
+
+var validNotificationTypes = map[string]struct{}{
+	NotificationMention:      {},
+	NotificationMemberAdded:  {},
+	NotificationRoleChanged:  {},
+	NotificationNoteAttached: {},
+	NotificationReminder:     {},
+}
+
+func IsValidNotificationType(input string) bool {
+	_, exists := validNotificationTypes[input]
+	return exists
+}
@@ -0,0 +1,43 @@
+package model
+
+const (
+	ReminderTargetNote       = "note"
+	ReminderTargetViewObject = "view_object"
+)
+
+const (
+	ReminderChannelNotification = "notification"
+	ReminderChannelWebhook      = "webhook"
+)
+
+type ReminderFilter struct {
+	WorkspaceID string
+	UserID      string
+	TargetType  string
+	TargetID    string
+	ViewID      string
+	Pending     bool // Only reminders that haven't fired
+	PageSize    int
+	PageNumber  int
+}
+
+// Reminder is a user's reminder about a note or a view object such as a
+// calendar slot. RemindAt is in UTC; Timezone is the one the user set it in,
+// which times relative to a calendar slot are worked out in.
+type Reminder struct {
+	ID          string `json:"id"`
+	WorkspaceID string `json:"workspace_id"`
+	UserID      string `json:"user_id"`
+	TargetType  string `json:"target_type"`
+	TargetID    string `json:"target_id"`
+	ViewID      string `json:"view_id"`
+	RemindAt    string `json:"remind_at"`
+	Timezone    string `json:"timezone"`
+	// MinutesBefore makes the reminder follow the start of its calendar slot
+	MinutesBefore *int   `json:"minutes_before"`
+	Channel       string `json:"channel"`
+	Message       string `json:"message"`
+	FiredAt       string `json:"fired_at"`
+	CreatedAt     string `json:"created_at"`
+	UpdatedAt     string `json:"updated_at"`
+}
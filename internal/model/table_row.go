@@ -0,0 +1,52 @@
+package model
+
+// Types of the properties of table views
+const (
+	TablePropertyText        = "text"
+	TablePropertyNumber      = "number"
+	TablePropertySelect      = "select"
+	TablePropertyMultiSelect = "multi_select"
+	TablePropertyDate        = "date"
+	TablePropertyCheckbox    = "checkbox"
+	TablePropertyUser        = "user"
+	TablePropertyRelation    = "relation"
+)
+
+// TableViewData is the Data of table views, the schema of their rows
+type TableViewData struct {
+	Properties []TableProperty `json:"properties"`
+}
+
+// TableProperty is a typed column of a table view. Options are the choices
+// of select and multi-select properties.
+type TableProperty struct {
+	ID      string        `json:"id"`
+	Name    string        `json:"name"`
+	Type    string        `json:"type"`
+	Options []TableOption `json:"options,omitempty"`
+}
+
+type TableOption struct {
+	Name  string `json:"name"`
+	Color string `json:"color,omitempty"`
+}
+
+type TableRowFilter struct {
+	ViewID string
+	NoteID string
+}
+
+// TableRow is a note in a table view, with the values of its properties as
+// a JSON object keyed by property ID. Text, select and date values are
+// strings (dates YYYY-MM-DD), numbers are numbers, checkboxes are booleans,
+// users are user IDs, and multi-select and relation values are arrays of
+// option names and note IDs.
+type TableRow struct {
+	ViewID    string `json:"view_id"`
+	NoteID    string `json:"note_id"`
+	Values    string `json:"values"`
+	CreatedAt string `json:"created_at"`
+	CreatedBy string `json:"created_by"`
+	UpdatedAt string `json:"updated_at"`
+	UpdatedBy string `json:"updated_by"`
+}
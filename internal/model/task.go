@@ -0,0 +1,43 @@
+package model
+
+type TaskFilter struct {
+	WorkspaceID string
+	NoteID      string
+	ViewerID    string // Leaves out tasks in other users' private notes
+	Status      string
+	AssigneeID  string
+	DueFrom     string // First due date, YYYY-MM-DD
+	DueTo       string // Last due date, YYYY-MM-DD
+	Tag         string
+	PageSize    int
+	PageNumber  int
+}
+
+// Task is a task item of a note, indexed from its content whenever the note
+// is saved. Position is its index among the note's task items.
+type Task struct {
+	ID          string `json:"id"`
+	WorkspaceID string `json:"workspace_id"`
+	NoteID      string `json:"note_id"`
+	Position    int    `json:"position"`
+	Text        string `json:"text"`
+	Checked     bool   `json:"checked"`
+	DueDate     string `json:"due_date"`
+	AssigneeID  string `json:"assignee_id"`
+	Tags        string `json:"tags"` // Comma separated, with a comma at each end
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+// TaskIndex records the version of a note its tasks were indexed from, so
+// notes changed elsewhere, e.g. by the collab service, can be indexed again
+type TaskIndex struct {
+	NoteID        string `json:"note_id"`
+	WorkspaceID   string `json:"workspace_id"`
+	NoteUpdatedAt string `json:"note_updated_at"`
+}
+
+const (
+	TaskStatusOpen = "open"
+	TaskStatusDone = "done"
+)
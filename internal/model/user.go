@@ -3,22 +3,25 @@ package model
 type UserFilter struct {
 	UserID      string
 	NameOrEmail string
-	Disabled    bool
+	// Email matches the address regardless of case
+	Email    string
+	Disabled bool
 }
 
 type User struct {
-	ID           string `json:"id"`
-	Email        string `json:"email"`
-	Name         string `json:"name"`
-	PasswordHash string `json:"-"`
-	Role         string `json:"role"`
-	AvatarUrl    string `json:"avatar_url"`
-	Disabled     bool   `json:"disabled"`
-	CreatedBy    string `json:"created_by"`
-	CreatedAt    string `json:"created_at"`
-	UpdatedBy    string `json:"updated_by"`
-	UpdatedAt    string `json:"updated_at"`
-	Preferences  string `json:"preferences"`
+	ID            string `json:"id"`
+	Email         string `json:"email"`
+	Name          string `json:"name"`
+	PasswordHash  string `json:"-"`
+	Role          string `json:"role"`
+	AvatarUrl     string `json:"avatar_url"`
+	Disabled      bool   `json:"disabled"`
+	EmailVerified bool   `json:"email_verified"`
+	CreatedBy     string `json:"created_by"`
+	CreatedAt     string `json:"created_at"`
+	UpdatedBy     string `json:"updated_by"`
+	UpdatedAt     string `json:"updated_at"`
+	Preferences   string `json:"preferences"`
 }
 
 const (
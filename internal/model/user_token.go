@@ -0,0 +1,24 @@
+package model
+
+// UserTokenType defines what a single-use user token can be redeemed for
+type UserTokenType string
+
+const (
+	UserTokenTypePasswordReset     UserTokenType = "password_reset"
+	UserTokenTypeEmailVerification UserTokenType = "email_verification"
+)
+
+type UserTokenFilter struct {
+	UserID string
+	Type   string
+}
+
+type UserToken struct {
+	ID        string `json:"id"`
+	UserID    string `json:"user_id"`
+	Type      string `json:"type"`
+	TokenHash string `json:"-"` // Only the SHA-256 hash is stored
+	ExpiresAt string `json:"expires_at"`
+	UsedAt    string `json:"used_at"`
+	CreatedAt string `json:"created_at"`
+}
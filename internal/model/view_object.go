@@ -22,8 +22,69 @@ type ViewObject struct {
 
 // CalendarSlotData represents the data structure for calendar slots stored in the Data field
 type CalendarSlotData struct {
-	Date      string  `json:"date"`       // YYYY-MM-DD format
-	StartTime *string `json:"start_time"` // HH:MM format (optional)
-	EndTime   *string `json:"end_time"`   // HH:MM format (optional)
-	IsAllDay  bool    `json:"is_all_day"` // true for all-day events
-}
\ No newline at end of file
+	Date      string  `json:"date"`               // YYYY-MM-DD format
+	EndDate   string  `json:"end_date,omitempty"` // YYYY-MM-DD format, last day of multi-day slots (optional)
+	StartTime *string `json:"start_time"`         // HH:MM format (optional)
+	EndTime   *string `json:"end_time"`           // HH:MM format (optional)
+	IsAllDay  bool    `json:"is_all_day"`         // true for all-day events
+	Color     string  `json:"color,omitempty"`
+	// Timezone is the IANA timezone of the date and times. Slots without one
+	// are at the same wall clock time in every timezone.
+	Timezone string `json:"timezone,omitempty"`
+	// RRule is an RFC 5545 recurrence rule such as FREQ=WEEKLY;BYDAY=MO, and
+	// ExDates the YYYY-MM-DD dates of the occurrences it skips
+	RRule   string   `json:"rrule,omitempty"`
+	ExDates []string `json:"exdates,omitempty"`
+	// UID is the iCalendar UID of the event the slot was imported from
+	UID string `json:"uid,omitempty"`
+	// CalDAVName is the resource name a calendar app stored the slot under,
+	// which is up to the app and needn't be its UID
+	CalDAVName string `json:"caldav_name,omitempty"`
+}
+
+// MapMarkerData represents the data structure for map markers stored in the Data field
+type MapMarkerData struct {
+	Lat   float64 `json:"lat"`
+	Lng   float64 `json:"lng"`
+	Color string  `json:"color,omitempty"`
+	// FeatureID is the ID of the feature the marker was imported from
+	FeatureID string `json:"feature_id,omitempty"`
+}
+
+// MapLineData represents the data structure for map lines, such as tracks
+// and routes, stored in the Data field
+type MapLineData struct {
+	Points    []MapPoint `json:"points"`
+	Color     string     `json:"color,omitempty"`
+	FeatureID string     `json:"feature_id,omitempty"`
+}
+
+type MapPoint struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// KanbanColumnData represents the data structure for kanban columns stored in
+// the Data field. Cards are the notes attached to a column.
+type KanbanColumnData struct {
+	Color string `json:"color,omitempty"`
+	// WIPLimit is the most cards the column may hold, 0 for no limit
+	WIPLimit int `json:"wip_limit,omitempty"`
+	// Done columns mark the cards moved to them as completed
+	Done bool `json:"done,omitempty"`
+	// CheckTasks checks all tasks in the notes of the cards moved to the column
+	CheckTasks bool `json:"check_tasks,omitempty"`
+}
+
+// TimelineItemData represents the data structure for timeline items stored in
+// the Data field. Dates are YYYY-MM-DD like those of calendar slots.
+type TimelineItemData struct {
+	Date    string `json:"date"`               // First day
+	EndDate string `json:"end_date,omitempty"` // Last day, the same as Date if empty
+	// Progress is how much of the item is done, from 0 to 100
+	Progress int    `json:"progress,omitempty"`
+	Color    string `json:"color,omitempty"`
+	// DependsOn are the IDs of the items of the same view that must finish
+	// before this one starts
+	DependsOn []string `json:"depends_on,omitempty"`
+}
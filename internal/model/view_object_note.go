@@ -5,9 +5,15 @@ type ViewObjectNote struct {
 	NoteID       string `json:"note_id"`
 	CreatedAt    string `json:"created_at"`
 	CreatedBy    string `json:"created_by"`
+	// Position orders the cards of a kanban column. It is a fractional key,
+	// so a card can be put between two others without moving the rest.
+	Position string `json:"position"`
+	// CompletedAt is when the card was moved to a done kanban column
+	CompletedAt string `json:"completed_at"`
 }
 
 type ViewObjectNoteFilter struct {
-	ViewObjectID string
-	NoteID       string
+	ViewObjectID  string
+	ViewObjectIDs []string
+	NoteID        string
 }
\ No newline at end of file
@@ -0,0 +1,85 @@
+package model
+
+import "strings"
+
+type WebhookFilter struct {
+	WorkspaceID string
+	Enabled     bool // Only enabled webhooks
+}
+
+type Webhook struct {
+	ID          string `json:"id"`
+	WorkspaceID string `json:"workspace_id"`
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	Secret      string `json:"-"` // Encrypted with APP_SECRET, never exposed
+	Events      string `json:"events"`
+	Enabled     bool   `json:"enabled"`
+	CreatedAt   string `json:"created_at"`
+	CreatedBy   string `json:"created_by"`
+	UpdatedAt   string `json:"updated_at"`
+	UpdatedBy   string `json:"updated_by"`
+}
+
+// Subscribes reports whether the webhook's event filter matches an event type.
+// Filters are comma separated event types, "note.*" style prefixes or "*".
+func (w Webhook) Subscribes(eventType string) bool {
+	for _, f := range strings.Split(w.Events, ",") {
+		f = strings.TrimSpace(f)
+		if f == "*" || f == eventType {
+			return true
+		}
+		if strings.HasSuffix(f, ".*") && strings.HasPrefix(eventType, strings.TrimSuffix(f, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidWebhookEventFilter checks a single entry of a webhook's event filter
+func IsValidWebhookEventFilter(f string) bool {
+	if f == "*" || IsValidWorkspaceEventType(f) {
+		return true
+	}
+
+	if prefix, ok := strings.CutSuffix(f, ".*"); ok {
+		for t := range workspaceEventTypes {
+			if strings.HasPrefix(t, prefix+".") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+const (
+	WebhookDeliveryStatusPending   = "pending"
+	WebhookDeliveryStatusSucceeded = "succeeded"
+	WebhookDeliveryStatusFailed    = "failed"
+)
+
+type WebhookDeliveryFilter struct {
+	WebhookID  string
+	Status     string
+	PageSize   int
+	PageNumber int
+}
+
+type WebhookDelivery struct {
+	ID             string `json:"id"`
+	WebhookID      string `json:"webhook_id"`
+	WorkspaceID    string `json:"workspace_id"`
+	Event          string `json:"event"`
+	Payload        string `json:"payload"`
+	Status         string `json:"status"`
+	Attempts       int    `json:"attempts"`
+	NextAttemptAt  string `json:"next_attempt_at"`
+	LastAttemptAt  string `json:"last_attempt_at"`
+	ResponseStatus int    `json:"response_status"`
+	ResponseBody   string `json:"response_body"`
+	Error          string `json:"error"`
+	DurationMs     int    `json:"duration_ms"`
+	RedeliveryOf   string `json:"redelivery_of"`
+	CreatedAt      string `json:"created_at"`
+}
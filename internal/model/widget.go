@@ -41,4 +41,10 @@ type Widget struct {
 	CreatedBy   string `json:"created_by"`
 	UpdatedAt   string `json:"updated_at"`
 	UpdatedBy   string `json:"updated_by"`
-}
\ No newline at end of file
+}
+
+// NoteFormWidgetConfig is the part of a note_form widget's config the server
+// looks at. TemplateID names a note template that pre-fills the form.
+type NoteFormWidgetConfig struct {
+	TemplateID string `json:"templateId"`
+}
@@ -0,0 +1,96 @@
+package model
+
+// WorkspaceEvent describes a change inside a workspace that other systems
+// can subscribe to
+type WorkspaceEvent struct {
+	ID          string     `json:"id"`
+	Type        string     `json:"event"`
+	WorkspaceID string     `json:"workspace_id"`
+	Actor       *EventUser `json:"actor,omitempty"`
+	Data        any        `json:"data"`
+	CreatedAt   string     `json:"created_at"`
+
+	// Events about private notes and views only reach their owner
+	Visibility string `json:"-"`
+	OwnerID    string `json:"-"`
+}
+
+func (e WorkspaceEvent) IsPrivate() bool {
+	return e.Visibility == "private"
+}
+
+// VisibleTo reports whether a workspace member may see the event
+func (e WorkspaceEvent) VisibleTo(userID string) bool {
+	return !e.IsPrivate() || e.OwnerID == userID
+}
+
+type EventUser struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+const (
+	EventNoteCreated = "note.created"
+	EventNoteUpdated = "note.updated"
+	EventNoteDeleted = "note.deleted"
+
+	EventViewCreated = "view.created"
+	EventViewUpdated = "view.updated"
+	EventViewDeleted = "view.deleted"
+
+	EventViewObjectCreated = "view_object.created"
+	EventViewObjectUpdated = "view_object.updated"
+	EventViewObjectDeleted = "view_object.deleted"
+
+	EventWidgetCreated = "widget.created"
+	EventWidgetUpdated = "widget.updated"
+	EventWidgetDeleted = "widget.deleted"
+
+	EventFileUploaded = "file.uploaded"
+	EventFileRenamed  = "file.renamed"
+	EventFileDeleted  = "file.deleted"
+
+	EventCommentCreated = "comment.created"
+	EventCommentUpdated = "comment.updated"
+	EventCommentDeleted = "comment.deleted"
+
+	EventMemberAdded   = "member.added"
+	EventMemberUpdated = "member.updated"
+	EventMemberRemoved = "member.removed"
+
+	// EventReminderDue is sent when a reminder set to go to webhooks is due
+	EventReminderDue = "reminder.due"
+
+	// EventPing is only sent when testing a webhook
+	EventPing = "ping"
+)
+
+var workspaceEventTypes = map[string]struct{}{
+	EventNoteCreated:       {},
+	EventNoteUpdated:       {},
+	EventNoteDeleted:       {},
+	EventViewCreated:       {},
+	EventViewUpdated:       {},
+	EventViewDeleted:       {},
+	EventViewObjectCreated: {},
+	EventViewObjectUpdated: {},
+	EventViewObjectDeleted: {},
+	EventWidgetCreated:     {},
+	EventWidgetUpdated:     {},
+	EventWidgetDeleted:     {},
+	EventFileUploaded:      {},
+	EventFileRenamed:       {},
+	EventFileDeleted:       {},
+	EventCommentCreated:    {},
+	EventCommentUpdated:    {},
+	EventCommentDeleted:    {},
+	EventMemberAdded:       {},
+	EventMemberUpdated:     {},
+	EventMemberRemoved:     {},
+	EventReminderDue:       {},
+}
+
+func IsValidWorkspaceEventType(input string) bool {
+	_, exists := workspaceEventTypes[input]
+	return exists
+}
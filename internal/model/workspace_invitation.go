@@ -0,0 +1,20 @@
+package model
+
+type WorkspaceInvitationFilter struct {
+	WorkspaceID string
+	Email       string
+	Pending     bool // Only invitations that have not been accepted yet
+}
+
+type WorkspaceInvitation struct {
+	ID          string `json:"id"`
+	WorkspaceID string `json:"workspace_id"`
+	Email       string `json:"email"`
+	Role        string `json:"role"`
+	TokenHash   string `json:"-"`
+	ExpiresAt   string `json:"expires_at"`
+	AcceptedAt  string `json:"accepted_at"`
+	AcceptedBy  string `json:"accepted_by"`
+	CreatedAt   string `json:"created_at"`
+	CreatedBy   string `json:"created_by"`
+}
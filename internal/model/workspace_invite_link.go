@@ -0,0 +1,26 @@
+package model
+
+type WorkspaceInviteLinkFilter struct {
+	WorkspaceID string
+}
+
+type WorkspaceInviteLink struct {
+	ID            string `json:"id"`
+	WorkspaceID   string `json:"workspace_id"`
+	Role          string `json:"role"`
+	TokenHash     string `json:"-"` // Never expose hash in JSON
+	Prefix        string `json:"prefix"`
+	AllowedDomain string `json:"allowed_domain"`
+	MaxUses       int    `json:"max_uses"` // 0 means unlimited
+	UseCount      int    `json:"use_count"`
+	ExpiresAt     string `json:"expires_at"`
+	CreatedAt     string `json:"created_at"`
+	CreatedBy     string `json:"created_by"`
+}
+
+// WorkspaceInviteLinkCreationResponse includes the shareable URL (only returned once)
+type WorkspaceInviteLinkCreationResponse struct {
+	WorkspaceInviteLink
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
@@ -0,0 +1,9 @@
+package model
+
+// YjsDocument is the stored state of a collaborative document, encoded as a
+// Yjs update. Names are "<type>:<id>", e.g. "note:<noteId>".
+type YjsDocument struct {
+	Name      string `json:"name"`
+	Data      []byte `json:"-"`
+	UpdatedAt string `json:"updated_at"`
+}
@@ -0,0 +1,167 @@
+// Package presence tracks who has notes and views open through their realtime
+// connections, so it can be queried outside of the editors.
+package presence
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Kinds of things people can have open
+const (
+	KindNote = "note"
+	KindView = "view"
+)
+
+// Target is a note or view people can have open
+type Target struct {
+	Kind        string
+	ID          string
+	WorkspaceID string
+}
+
+// Connection is one open realtime connection. UserID is empty for anonymous
+// viewers of public views.
+type Connection struct {
+	UserID   string
+	UserName string
+	ReadOnly bool
+}
+
+// User is someone who has a target open, possibly in several tabs
+type User struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Editing is set when any of the user's connections may edit
+	Editing     bool   `json:"editing"`
+	Connections int    `json:"connections"`
+	Since       string `json:"since"`
+}
+
+// Presence is who has a target open
+type Presence struct {
+	Type             string `json:"type"`
+	ID               string `json:"id"`
+	Users            []User `json:"users"`
+	AnonymousViewers int    `json:"anonymous_viewers"`
+}
+
+type connection struct {
+	Connection
+	since time.Time
+}
+
+type target struct {
+	Target
+	conns map[*connection]struct{}
+}
+
+// Tracker keeps the open connections of all targets in memory. With several
+// instances, each only knows the connections it serves.
+type Tracker struct {
+	mu      sync.Mutex
+	targets map[Target]*target
+}
+
+func New() *Tracker {
+	return &Tracker{targets: map[Target]*target{}}
+}
+
+// Join records a connection to a target until the returned function is called
+func (t *Tracker) Join(tg Target, c Connection) (leave func()) {
+	conn := &connection{Connection: c, since: time.Now()}
+
+	t.mu.Lock()
+	entry := t.targets[tg]
+	if entry == nil {
+		entry = &target{Target: tg, conns: map[*connection]struct{}{}}
+		t.targets[tg] = entry
+	}
+	entry.conns[conn] = struct{}{}
+	t.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+
+			delete(entry.conns, conn)
+			if len(entry.conns) == 0 {
+				delete(t.targets, tg)
+			}
+		})
+	}
+}
+
+// Get returns who has a target open
+func (t *Tracker) Get(tg Target) Presence {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if entry := t.targets[tg]; entry != nil {
+		return entry.presence()
+	}
+	return Presence{Type: tg.Kind, ID: tg.ID, Users: []User{}}
+}
+
+// Workspace returns the targets of a workspace that someone has open, with who
+// has them open
+func (t *Tracker) Workspace(workspaceID string) []Presence {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	res := []Presence{}
+	for tg, entry := range t.targets {
+		if tg.WorkspaceID == workspaceID {
+			res = append(res, entry.presence())
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Type != res[j].Type {
+			return res[i].Type < res[j].Type
+		}
+		return res[i].ID < res[j].ID
+	})
+	return res
+}
+
+func (tg *target) presence() Presence {
+	p := Presence{Type: tg.Kind, ID: tg.ID, Users: []User{}}
+
+	users := map[string]*User{}
+	since := map[string]time.Time{}
+	for c := range tg.conns {
+		if c.UserID == "" {
+			p.AnonymousViewers++
+			continue
+		}
+
+		u := users[c.UserID]
+		if u == nil {
+			u = &User{ID: c.UserID, Name: c.UserName}
+			users[c.UserID] = u
+			since[c.UserID] = c.since
+		}
+		u.Connections++
+		u.Editing = u.Editing || !c.ReadOnly
+		if c.since.Before(since[c.UserID]) {
+			since[c.UserID] = c.since
+		}
+	}
+
+	for id, u := range users {
+		u.Since = since[id].UTC().Format(time.RFC3339)
+		p.Users = append(p.Users, *u)
+	}
+	sort.Slice(p.Users, func(i, j int) bool {
+		if p.Users[i].Since != p.Users[j].Since {
+			return p.Users[i].Since < p.Users[j].Since
+		}
+		return p.Users[i].ID < p.Users[j].ID
+	})
+
+	return p
+}
@@ -0,0 +1,148 @@
+// Package queue runs work stored in the database from a polling loop, for the
+// job runner and the webhook dispatcher alike
+package queue
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	pollInterval = 5 * time.Second
+	batchSize    = 20
+	workers      = 4
+	purgeEvery   = time.Hour
+)
+
+// Config says where a loop finds its items and what it does with them. Times
+// are passed as RFC 3339 strings in UTC, like they are stored.
+type Config[T any] struct {
+	// Name is what the items are called in log messages, e.g. "jobs"
+	Name string
+	// Due returns up to limit items that are due at now
+	Due func(now string, limit int) ([]T, error)
+	// Claim takes an item until lease, and reports false if another worker
+	// has taken it first
+	Claim func(item T, lease string) (bool, error)
+	// Run works on a claimed item and stores the outcome
+	Run func(ctx context.Context, item T)
+	// Lease is how long a claimed item is left to its worker before it is
+	// run again, so Run must finish well within it
+	Lease time.Duration
+	// Prepare, if set, is called before looking for due items, e.g. to queue
+	// recurring ones
+	Prepare func()
+	// Purge, if set, deletes finished items at most once an hour
+	Purge func()
+}
+
+// Loop runs the items of a Config as they become due. Items are claimed before
+// they are run, so several server instances can share them.
+type Loop[T any] struct {
+	cfg  Config[T]
+	wake chan struct{}
+}
+
+func New[T any](cfg Config[T]) *Loop[T] {
+	return &Loop[T]{cfg: cfg, wake: make(chan struct{}, 1)}
+}
+
+// Notify looks for due items right away instead of at the next poll
+func (l *Loop[T]) Notify() {
+	select {
+	case l.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start runs the loop until ctx is cancelled
+func (l *Loop[T]) Start(ctx context.Context) {
+	go l.run(ctx)
+}
+
+func (l *Loop[T]) run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	lastPurge := time.Time{}
+
+	for {
+		if l.cfg.Prepare != nil {
+			l.cfg.Prepare()
+		}
+		l.runDue(ctx)
+
+		if l.cfg.Purge != nil && time.Since(lastPurge) > purgeEvery {
+			l.cfg.Purge()
+			lastPurge = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-l.wake:
+		}
+	}
+}
+
+func (l *Loop[T]) runDue(ctx context.Context) {
+	for {
+		now := time.Now().UTC()
+
+		due, err := l.cfg.Due(now.Format(time.RFC3339), batchSize)
+		if err != nil {
+			log.Printf("Failed to load due %s: %v", l.cfg.Name, err)
+			return
+		}
+
+		lease := now.Add(l.cfg.Lease).Format(time.RFC3339)
+		sem := make(chan struct{}, workers)
+		var wg sync.WaitGroup
+
+		for _, item := range due {
+			claimed, err := l.cfg.Claim(item, lease)
+			if err != nil {
+				log.Printf("Failed to claim %s: %v", l.cfg.Name, err)
+				continue
+			}
+			if !claimed {
+				continue
+			}
+
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(item T) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				l.cfg.Run(ctx, item)
+			}(item)
+		}
+
+		wg.Wait()
+
+		if len(due) < batchSize || ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// Backoff is the wait before retrying after the given number of failed
+// attempts: base, doubling with each attempt up to max
+func Backoff(attempts int, base, max time.Duration) time.Duration {
+	if attempts < 1 {
+		return 0
+	}
+	if attempts > 20 {
+		return max
+	}
+
+	wait := base << (attempts - 1)
+	if wait > max {
+		return max
+	}
+
+	return wait
+}
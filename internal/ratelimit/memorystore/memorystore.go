@@ -0,0 +1,88 @@
+package memorystore
+
+import (
+	"sync"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/ratelimit"
+)
+
+// staleAfter is how long idle entries are kept before being swept
+const staleAfter = 24 * time.Hour
+
+// MemoryStore keeps limiter state in process memory, which is enough
+// for a single instance deployment
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]ratelimit.Bucket
+	failures  map[string]ratelimit.Failures
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:  map[string]ratelimit.Bucket{},
+		failures: map[string]ratelimit.Failures{},
+	}
+}
+
+func (s *MemoryStore) Take(key string, l ratelimit.Limit, now time.Time) (ratelimit.Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	b, res := s.buckets[key].Take(l, now)
+	s.buckets[key] = b
+
+	return res, nil
+}
+
+func (s *MemoryStore) AddFailure(key string, l ratelimit.Lockout, now time.Time) (ratelimit.Failures, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	f := s.failures[key].Add(l, now)
+	s.failures[key] = f
+
+	return f, nil
+}
+
+func (s *MemoryStore) GetFailures(key string) (ratelimit.Failures, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.failures[key], nil
+}
+
+func (s *MemoryStore) ResetFailures(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.failures, key)
+
+	return nil
+}
+
+// sweep drops idle entries so memory doesn't grow with every client ever seen.
+// Callers must hold the lock.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for k, b := range s.buckets {
+		if now.Sub(b.UpdatedAt) > staleAfter {
+			delete(s.buckets, k)
+		}
+	}
+
+	for k, f := range s.failures {
+		if now.Sub(f.UpdatedAt) > staleAfter && now.After(f.LockedUntil) {
+			delete(s.failures, k)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package postgresstore
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/ratelimit"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+// staleAfter is how long idle rows are kept before being deleted
+const staleAfter = 24 * time.Hour
+
+type rateLimitBucket struct {
+	ID        string `gorm:"primaryKey"`
+	Tokens    float64
+	UpdatedAt int64 `gorm:"autoUpdateTime:false"` // unix milliseconds
+}
+
+type rateLimitFailure struct {
+	ID          string `gorm:"primaryKey"`
+	Failures    int
+	LockedUntil int64 // unix milliseconds
+	UpdatedAt   int64 `gorm:"autoUpdateTime:false"` // unix milliseconds
+}
+
+// PostgresStore keeps limiter state in postgres so that limits are shared
+// between all instances of a deployment
+type PostgresStore struct {
+	db *gorm.DB
+}
+
+func NewPostgresStore(dsn string) (*PostgresStore, error) {
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s := &PostgresStore{db: db}
+
+	go s.sweep()
+
+	return s, nil
+}
+
+func (s *PostgresStore) Take(key string, l ratelimit.Limit, now time.Time) (ratelimit.Result, error) {
+	var res ratelimit.Result
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var row rateLimitBucket
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", key).
+			Take(&row).Error
+
+		var b ratelimit.Bucket
+		switch {
+		case err == nil:
+			b = ratelimit.Bucket{Tokens: row.Tokens, UpdatedAt: time.UnixMilli(row.UpdatedAt)}
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		b, res = b.Take(l, now)
+
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"tokens", "updated_at"}),
+		}).Create(&rateLimitBucket{
+			ID:        key,
+			Tokens:    b.Tokens,
+			UpdatedAt: b.UpdatedAt.UnixMilli(),
+		}).Error
+	})
+
+	return res, err
+}
+
+func (s *PostgresStore) AddFailure(key string, l ratelimit.Lockout, now time.Time) (ratelimit.Failures, error) {
+	var f ratelimit.Failures
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var row rateLimitFailure
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", key).
+			Take(&row).Error
+
+		switch {
+		case err == nil:
+			f = toFailures(row)
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		f = f.Add(l, now)
+
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"failures", "locked_until", "updated_at"}),
+		}).Create(&rateLimitFailure{
+			ID:          key,
+			Failures:    f.Count,
+			LockedUntil: f.LockedUntil.UnixMilli(),
+			UpdatedAt:   f.UpdatedAt.UnixMilli(),
+		}).Error
+	})
+
+	return f, err
+}
+
+func (s *PostgresStore) GetFailures(key string) (ratelimit.Failures, error) {
+	var row rateLimitFailure
+
+	err := s.db.Where("id = ?", key).Take(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ratelimit.Failures{}, nil
+	}
+	if err != nil {
+		return ratelimit.Failures{}, err
+	}
+
+	return toFailures(row), nil
+}
+
+func (s *PostgresStore) ResetFailures(key string) error {
+	return s.db.Where("id = ?", key).Delete(&rateLimitFailure{}).Error
+}
+
+// sweep periodically deletes rows that haven't been touched for a while
+func (s *PostgresStore) sweep() {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := time.Now()
+		cutoff := now.Add(-staleAfter).UnixMilli()
+
+		if err := s.db.Where("updated_at < ?", cutoff).Delete(&rateLimitBucket{}).Error; err != nil {
+			log.Printf("Failed to sweep rate limit buckets: %v", err)
+		}
+
+		if err := s.db.Where("updated_at < ? AND locked_until < ?", cutoff, now.UnixMilli()).Delete(&rateLimitFailure{}).Error; err != nil {
+			log.Printf("Failed to sweep rate limit failures: %v", err)
+		}
+	}
+}
+
+func toFailures(row rateLimitFailure) ratelimit.Failures {
+	f := ratelimit.Failures{
+		Count:     row.Failures,
+		UpdatedAt: time.UnixMilli(row.UpdatedAt),
+	}
+
+	if row.LockedUntil > 0 {
+		f.LockedUntil = time.UnixMilli(row.LockedUntil)
+	}
+
+	return f
+}
@@ -0,0 +1,191 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// Limit describes a token bucket: it holds up to Burst tokens and refills
+// at Rate tokens per second
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute allows n requests per minute, all of which may be used at once
+func PerMinute(n int) Limit {
+	return Limit{Rate: float64(n) / 60, Burst: n}
+}
+
+// Lockout describes how repeated failures lock a key out. Once Threshold
+// consecutive failures are recorded the key is locked for Base, doubling with
+// every further failure up to Max. Failures older than Max are forgotten.
+type Lockout struct {
+	Threshold int
+	Base      time.Duration
+	Max       time.Duration
+}
+
+// Duration returns how long a key with the given number of failures stays locked
+func (l Lockout) Duration(failures int) time.Duration {
+	if l.Threshold <= 0 || failures < l.Threshold {
+		return 0
+	}
+
+	shift := failures - l.Threshold
+	if shift >= 32 {
+		return l.Max
+	}
+
+	d := l.Base << shift
+	if d <= 0 || d > l.Max {
+		return l.Max
+	}
+
+	return d
+}
+
+type Result struct {
+	Allowed    bool
+	RetryAfter time.Duration
+}
+
+// RetryAfterSeconds rounds RetryAfter up to whole seconds for the Retry-After header
+func (r Result) RetryAfterSeconds() int {
+	return int(math.Ceil(r.RetryAfter.Seconds()))
+}
+
+// Bucket is the persisted state of a token bucket
+type Bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// Take refills the bucket up to now and removes one token if available
+func (b Bucket) Take(l Limit, now time.Time) (Bucket, Result) {
+	if b.UpdatedAt.IsZero() {
+		b.Tokens = float64(l.Burst)
+	} else if elapsed := now.Sub(b.UpdatedAt).Seconds(); elapsed > 0 {
+		b.Tokens = math.Min(float64(l.Burst), b.Tokens+elapsed*l.Rate)
+	}
+	b.UpdatedAt = now
+
+	if b.Tokens >= 1 {
+		b.Tokens--
+		return b, Result{Allowed: true}
+	}
+
+	if l.Rate <= 0 {
+		return b, Result{Allowed: false, RetryAfter: time.Hour}
+	}
+
+	wait := time.Duration((1 - b.Tokens) / l.Rate * float64(time.Second))
+	return b, Result{Allowed: false, RetryAfter: wait}
+}
+
+// Failures is the persisted state of a failure counter
+type Failures struct {
+	Count       int
+	LockedUntil time.Time
+	UpdatedAt   time.Time
+}
+
+// Add records one more failure at now and locks the key according to the policy
+func (f Failures) Add(l Lockout, now time.Time) Failures {
+	if !f.UpdatedAt.IsZero() && now.Sub(f.UpdatedAt) > l.Max {
+		f.Count = 0
+	}
+
+	f.Count++
+	f.UpdatedAt = now
+
+	if d := l.Duration(f.Count); d > 0 {
+		f.LockedUntil = now.Add(d)
+	}
+
+	return f
+}
+
+// Store keeps limiter state, either in-process or shared between instances
+type Store interface {
+	// Take removes a token from the bucket stored under key
+	Take(key string, l Limit, now time.Time) (Result, error)
+	// AddFailure records a failure for key and returns the updated counter
+	AddFailure(key string, l Lockout, now time.Time) (Failures, error)
+	// GetFailures returns the failure counter for key (zero value if none)
+	GetFailures(key string) (Failures, error)
+	// ResetFailures forgets all failures recorded for key
+	ResetFailures(key string) error
+}
+
+// Limiter applies rate limits and lockouts on top of a Store.
+// A nil *Limiter allows everything, which is how rate limiting is disabled.
+type Limiter struct {
+	store   Store
+	lockout Lockout
+	now     func() time.Time
+}
+
+func NewLimiter(store Store, lockout Lockout) *Limiter {
+	return &Limiter{
+		store:   store,
+		lockout: lockout,
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token from the bucket identified by key
+func (l *Limiter) Allow(key string, limit Limit) (Result, error) {
+	if l == nil {
+		return Result{Allowed: true}, nil
+	}
+
+	return l.store.Take(key, limit, l.now())
+}
+
+// Locked reports whether key is currently locked out
+func (l *Limiter) Locked(key string) (Result, error) {
+	if l == nil {
+		return Result{Allowed: true}, nil
+	}
+
+	f, err := l.store.GetFailures(key)
+	if err != nil {
+		return Result{Allowed: true}, err
+	}
+
+	return lockResult(f, l.now()), nil
+}
+
+// Fail records a failed attempt for key, locking it out once the threshold is reached
+func (l *Limiter) Fail(key string) (Result, error) {
+	if l == nil {
+		return Result{Allowed: true}, nil
+	}
+
+	now := l.now()
+
+	f, err := l.store.AddFailure(key, l.lockout, now)
+	if err != nil {
+		return Result{Allowed: true}, err
+	}
+
+	return lockResult(f, now), nil
+}
+
+// Succeed clears the failures recorded for key
+func (l *Limiter) Succeed(key string) error {
+	if l == nil {
+		return nil
+	}
+
+	return l.store.ResetFailures(key)
+}
+
+func lockResult(f Failures, now time.Time) Result {
+	if f.LockedUntil.After(now) {
+		return Result{Allowed: false, RetryAfter: f.LockedUntil.Sub(now)}
+	}
+
+	return Result{Allowed: true}
+}
@@ -13,17 +13,31 @@ import (
 	"github.com/collabreef/collabreef/internal/api/middlewares"
 	"github.com/collabreef/collabreef/internal/api/route"
 	"github.com/collabreef/collabreef/internal/api/validate"
+	"github.com/collabreef/collabreef/internal/collab"
 	"github.com/collabreef/collabreef/internal/config"
 	"github.com/collabreef/collabreef/internal/db"
+	"github.com/collabreef/collabreef/internal/eventbus"
+	"github.com/collabreef/collabreef/internal/job"
+	"github.com/collabreef/collabreef/internal/mailer"
+	"github.com/collabreef/collabreef/internal/ratelimit"
 	"github.com/collabreef/collabreef/internal/storage"
+	"github.com/collabreef/collabreef/internal/webhook"
 )
 
 //go:embed dist/*
 var webAssets embed.FS
 
-func New(db db.DB, storage storage.Storage, collabURL *url.URL) (*echo.Echo, error) {
+func New(db db.DB, storage storage.Storage, mailer mailer.Mailer, limiter *ratelimit.Limiter, webhooks *webhook.Dispatcher, jobs *job.Runner, bus *eventbus.Bus, collabURL *url.URL, collabServer *collab.Server) (*echo.Echo, error) {
 	e := echo.New()
 
+	// Only trust X-Forwarded-For when running behind a reverse proxy, otherwise
+	// clients could pick their own address and dodge per-IP rate limits
+	if config.C.GetBool(config.RATELIMIT_TRUST_PROXY) {
+		e.IPExtractor = echo.ExtractIPFromXFFHeader()
+	} else {
+		e.IPExtractor = echo.ExtractIPDirect()
+	}
+
 	subFS, err := fs.Sub(webAssets, "dist")
 	if err != nil {
 		return nil, err
@@ -42,21 +56,30 @@ func New(db db.DB, storage storage.Storage, collabURL *url.URL) (*echo.Echo, err
 
 	apiRoot := config.C.GetString(config.SERVER_API_ROOT_PATH)
 
-	handler := handler.NewHandler(db, storage, collabURL)
-	auth := middlewares.NewAuthMiddleware(db)
+	handler := handler.NewHandler(db, storage, mailer, limiter, webhooks, jobs, bus, collabURL, collabServer)
+	auth := middlewares.NewAuthMiddleware(db, limiter)
+	rateLimit := middlewares.NewRateLimitMiddleware(limiter)
 	workspace := middlewares.NewWorkspaceMiddleware(db)
 
 	// Register REST API routes under /api/v1
 	api := e.Group(apiRoot)
-	route.RegisterAuth(api, *handler)
+	route.RegisterAuth(api, *handler, *rateLimit)
+	route.RegisterInvitation(api, *handler, *auth)
 	route.RegisterAdmin(api, *handler, *auth)
 	route.RegisterUser(api, *handler, *auth)
+	route.RegisterNotification(api, *handler, *auth)
 	route.RegisterWorkspace(api, *handler, *auth, *workspace)
 	route.RegisterTool(api, *handler, *auth)
 	route.RegisterPublic(api, *handler, *auth)
+	// Tokens only need checking by the collab service editors are proxied to
+	if collabServer == nil {
+		route.RegisterCollab(api, *handler, *rateLimit)
+	}
+	route.RegisterFeed(api, *handler)
 
 	// Register WebSocket routes directly under /ws (not under /api/v1)
 	route.RegisterWebSocket(e, *handler, *auth)
+	route.RegisterCalDAV(e, *handler, *auth)
 
 	return e, nil
 }
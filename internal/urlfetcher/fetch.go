@@ -1,6 +1,7 @@
 package urlfetcher
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"errors"
@@ -9,30 +10,87 @@ import (
 	"net/http"
 	"net/netip"
 	"net/url"
+	"strings"
 	"time"
 )
 
 const (
-	MaxDownloadBytes = 10 << 20 // 10MB
-	RequestTimeout   = 15 * time.Second
+	MaxDownloadBytes        = 10 << 20 // 10MB
+	MaxResponseSnippetBytes = 4 << 10  // 4KB
+	RequestTimeout          = 15 * time.Second
 )
 
 var blockedPrefixes = []netip.Prefix{
 	netip.MustParsePrefix("127.0.0.0/8"),    // loopback
 	netip.MustParsePrefix("10.0.0.0/8"),     // private
+	netip.MustParsePrefix("100.64.0.0/10"),  // carrier-grade NAT
 	netip.MustParsePrefix("172.16.0.0/12"),  // private
 	netip.MustParsePrefix("192.168.0.0/16"), // private
 	netip.MustParsePrefix("169.254.0.0/16"), // link-local
 	netip.MustParsePrefix("0.0.0.0/8"),      // reserved
 	netip.MustParsePrefix("224.0.0.0/4"),    // multicast
 	netip.MustParsePrefix("240.0.0.0/4"),    // reserved
+	netip.MustParsePrefix("::/128"),         // IPv6 unspecified
 	netip.MustParsePrefix("::1/128"),        // IPv6 loopback
 	netip.MustParsePrefix("fc00::/7"),       // IPv6 ULA
 	netip.MustParsePrefix("fe80::/10"),      // IPv6 link-local
 	netip.MustParsePrefix("ff00::/8"),       // IPv6 multicast
 }
 
+// Allowlist names internal targets that may be reached even though they fall
+// into one of the blocked ranges, e.g. a CI server on the local network
+type Allowlist struct {
+	prefixes []netip.Prefix
+	hosts    map[string]struct{}
+}
+
+// ParseAllowlist accepts IP addresses, CIDR ranges and host names
+func ParseAllowlist(entries []string) (Allowlist, error) {
+	a := Allowlist{hosts: map[string]struct{}{}}
+
+	for _, e := range entries {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+
+		if p, err := netip.ParsePrefix(e); err == nil {
+			a.prefixes = append(a.prefixes, p.Masked())
+			continue
+		}
+
+		if addr, err := netip.ParseAddr(e); err == nil {
+			a.prefixes = append(a.prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		if strings.ContainsAny(e, "/:@ ") {
+			return Allowlist{}, errors.New("invalid allowlist entry: " + e)
+		}
+
+		a.hosts[strings.ToLower(e)] = struct{}{}
+	}
+
+	return a, nil
+}
+
+func (a Allowlist) allows(host string, addr netip.Addr) bool {
+	if _, ok := a.hosts[strings.ToLower(host)]; ok {
+		return true
+	}
+
+	for _, p := range a.prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func isBlocked(addr netip.Addr) bool {
+	// IPv4 addresses are often returned in their IPv6 mapped form (::ffff:127.0.0.1)
+	addr = addr.Unmap()
 	for _, p := range blockedPrefixes {
 		if p.Contains(addr) {
 			return true
@@ -41,7 +99,7 @@ func isBlocked(addr netip.Addr) bool {
 	return false
 }
 
-func validateURL(raw string) (*url.URL, []netip.Addr, error) {
+func validateURL(raw string, allow Allowlist) (*url.URL, []netip.Addr, error) {
 	u, err := url.Parse(raw)
 	if err != nil {
 		return nil, nil, err
@@ -61,7 +119,12 @@ func validateURL(raw string) (*url.URL, []netip.Addr, error) {
 
 	var out []netip.Addr
 	for _, ip := range ips {
-		if addr, ok := netip.AddrFromSlice(ip); ok && !isBlocked(addr) {
+		addr, ok := netip.AddrFromSlice(ip)
+		if !ok {
+			continue
+		}
+		addr = addr.Unmap()
+		if !isBlocked(addr) || allow.allows(host, addr) {
 			out = append(out, addr)
 		}
 	}
@@ -71,15 +134,15 @@ func validateURL(raw string) (*url.URL, []netip.Addr, error) {
 	return u, out, nil
 }
 
-func SafeFetchFile(ctx context.Context, rawURL string) ([]byte, string, error) {
-	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
-	defer cancel()
-
-	u, ips, err := validateURL(rawURL)
-	if err != nil {
-		return nil, "", err
-	}
+// CheckURL reports whether rawURL may be requested, without requesting it
+func CheckURL(rawURL string, allow Allowlist) error {
+	_, _, err := validateURL(rawURL, allow)
+	return err
+}
 
+// newSafeClient returns a client that only ever connects to the already
+// validated addresses of u, so DNS can't be rebound to an internal address
+func newSafeClient(u *url.URL, ips []netip.Addr) *http.Client {
 	dialer := &net.Dialer{Timeout: 7 * time.Second}
 	transport := &http.Transport{
 		DisableKeepAlives: true,
@@ -93,17 +156,31 @@ func SafeFetchFile(ctx context.Context, rawURL string) ([]byte, string, error) {
 					port = "80"
 				}
 			}
+			lastErr := errors.New("no allowed IPs")
 			for _, ip := range ips {
-				if isBlocked(ip) {
-					continue
+				conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip.String(), port))
+				if err == nil {
+					return conn, nil
 				}
-				return dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip.String(), port))
+				lastErr = err
 			}
-			return nil, errors.New("no allowed IPs")
+			return nil, lastErr
 		},
 	}
 
-	client := &http.Client{Transport: transport}
+	return &http.Client{Transport: transport}
+}
+
+func SafeFetchFile(ctx context.Context, rawURL string) ([]byte, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+
+	u, ips, err := validateURL(rawURL, Allowlist{})
+	if err != nil {
+		return nil, "", err
+	}
+
+	client := newSafeClient(u, ips)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
 		return nil, "", err
@@ -130,3 +207,39 @@ func SafeFetchFile(ctx context.Context, rawURL string) ([]byte, string, error) {
 
 	return data, resp.Header.Get("Content-Type"), nil
 }
+
+// SafePost sends body to rawURL through the same guarded dialer as SafeFetchFile.
+// Redirects are not followed. It returns the status code and the start of the
+// response body, which callers may keep for diagnostics.
+func SafePost(ctx context.Context, rawURL string, body []byte, header http.Header, allow Allowlist) (int, []byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+
+	u, ips, err := validateURL(rawURL, allow)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	client := newSafeClient(u, ips)
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, MaxResponseSnippetBytes))
+
+	return resp.StatusCode, data, nil
+}
@@ -0,0 +1,81 @@
+package util
+
+import (
+	"errors"
+	"strings"
+)
+
+// fractionalDigits are the digits of fractional keys, in byte order so that
+// keys sort as plain strings
+const fractionalDigits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+var ErrInvalidKey = errors.New("invalid fractional key")
+
+// KeyBetween returns a fractional key that sorts after a and before b, so
+// that an item can be put between two others without renumbering the rest.
+// An empty a or b means no bound on that side. Keys are made of the digits
+// 0-9, A-Z and a-z and never end in 0, which keeps them from running out of
+// room. They sort byte by byte, so columns holding them need a binary
+// collation such as Postgres' "C".
+func KeyBetween(a, b string) (string, error) {
+	if !validKey(a) || !validKey(b) || (b != "" && a >= b) {
+		return "", ErrInvalidKey
+	}
+	return midpoint(a, b), nil
+}
+
+func validKey(k string) bool {
+	if strings.HasSuffix(k, "0") {
+		return false
+	}
+	for i := 0; i < len(k); i++ {
+		if strings.IndexByte(fractionalDigits, k[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// midpoint is the shortest key between a and b, read as the fractional
+// parts of numbers in base 62
+func midpoint(a, b string) string {
+	if b != "" {
+		// Keep the prefix they share, with a padded with zeros
+		n := 0
+		for n < len(b) {
+			digit := byte('0')
+			if n < len(a) {
+				digit = a[n]
+			}
+			if digit != b[n] {
+				break
+			}
+			n++
+		}
+		if n > 0 {
+			return b[:n] + midpoint(a[min(n, len(a)):], b[n:])
+		}
+	}
+
+	lo := 0
+	if a != "" {
+		lo = strings.IndexByte(fractionalDigits, a[0])
+	}
+	hi := len(fractionalDigits)
+	if b != "" {
+		hi = strings.IndexByte(fractionalDigits, b[0])
+	}
+	if hi-lo > 1 {
+		return string(fractionalDigits[(lo+hi+1)/2])
+	}
+
+	// The first digits are consecutive
+	if len(b) > 1 {
+		return b[:1]
+	}
+	rest := ""
+	if a != "" {
+		rest = a[1:]
+	}
+	return string(fractionalDigits[lo]) + midpoint(rest, "")
+}
@@ -0,0 +1,52 @@
+package util
+
+import (
+	"encoding/json"
+	"regexp"
+)
+
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// ReplacePlaceholders replaces {{name}} in s with values[name]. Placeholders
+// without a value are left as they are.
+func ReplacePlaceholders(s string, values map[string]string) string {
+	return placeholderPattern.ReplaceAllStringFunc(s, func(m string) string {
+		name := placeholderPattern.FindStringSubmatch(m)[1]
+		if v, ok := values[name]; ok {
+			return v
+		}
+		return m
+	})
+}
+
+// TipTapReplacePlaceholders replaces placeholders in the text of TipTap JSON
+// content, so values can't break its structure. A placeholder has to be in a
+// single text node, i.e. without formatting changes inside it.
+func TipTapReplacePlaceholders(content string, values map[string]string) (string, error) {
+	var doc any
+	if err := json.Unmarshal([]byte(content), &doc); err != nil {
+		return "", err
+	}
+
+	var walk func(v any)
+	walk = func(v any) {
+		switch n := v.(type) {
+		case map[string]any:
+			if text, ok := n["text"].(string); ok {
+				n["text"] = ReplacePlaceholders(text, values)
+			}
+			walk(n["content"])
+		case []any:
+			for _, child := range n {
+				walk(child)
+			}
+		}
+	}
+	walk(doc)
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,167 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var ErrTaskNotFound = errors.New("task not found")
+
+var (
+	taskDuePattern = regexp.MustCompile(`(?:^|\s)(?:due:|📅\s*)(\d{4}-\d{2}-\d{2})\b`)
+	taskTagPattern = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_-]+)`)
+)
+
+// TipTapTask is a task item of TipTap content. DueDate comes from
+// due:YYYY-MM-DD or 📅 YYYY-MM-DD in its text and Tags from #tag.
+type TipTapTask struct {
+	Text     string
+	Checked  bool
+	DueDate  string
+	Tags     []string
+	Mentions []string // IDs of the users mentioned with mention nodes
+}
+
+// TipTapTasks returns the task items of TipTap JSON content in document order,
+// nested ones after the item they are in
+func TipTapTasks(content string) []TipTapTask {
+	var doc TipTapNode
+	if err := json.Unmarshal([]byte(content), &doc); err != nil || doc.Type == "" {
+		return nil
+	}
+
+	var tasks []TipTapTask
+	var walk func(n TipTapNode)
+	walk = func(n TipTapNode) {
+		if n.Type == "taskItem" {
+			tasks = append(tasks, tipTapTask(n))
+		}
+		for _, child := range n.Content {
+			walk(child)
+		}
+	}
+	walk(doc)
+
+	return tasks
+}
+
+// TipTapSetTaskChecked checks or unchecks the task item at position, counted
+// the way TipTapTasks lists them, and returns the changed content along with
+// the task as it was
+func TipTapSetTaskChecked(content string, position int, checked bool) (string, TipTapTask, error) {
+	var doc any
+	if err := json.Unmarshal([]byte(content), &doc); err != nil {
+		return "", TipTapTask{}, err
+	}
+
+	var found map[string]any
+	i := 0
+	var walk func(v any)
+	walk = func(v any) {
+		switch n := v.(type) {
+		case map[string]any:
+			if found != nil {
+				return
+			}
+			if n["type"] == "taskItem" {
+				if i == position {
+					found = n
+					return
+				}
+				i++
+			}
+			walk(n["content"])
+		case []any:
+			for _, child := range n {
+				walk(child)
+			}
+		}
+	}
+	walk(doc)
+
+	if found == nil {
+		return "", TipTapTask{}, ErrTaskNotFound
+	}
+
+	// Read the task the same way TipTapTasks does
+	b, err := json.Marshal(found)
+	if err != nil {
+		return "", TipTapTask{}, err
+	}
+	var node TipTapNode
+	if err := json.Unmarshal(b, &node); err != nil {
+		return "", TipTapTask{}, err
+	}
+	task := tipTapTask(node)
+
+	attrs, _ := found["attrs"].(map[string]any)
+	if attrs == nil {
+		attrs = map[string]any{}
+		found["attrs"] = attrs
+	}
+	attrs["checked"] = checked
+
+	b, err = json.Marshal(doc)
+	if err != nil {
+		return "", TipTapTask{}, err
+	}
+	return string(b), task, nil
+}
+
+// tipTapTask reads a task item. Lists nested in it are left out of its text.
+func tipTapTask(item TipTapNode) TipTapTask {
+	task := TipTapTask{}
+	if checked, ok := item.Attrs["checked"].(bool); ok {
+		task.Checked = checked
+	}
+
+	var b strings.Builder
+	var walk func(n TipTapNode)
+	walk = func(n TipTapNode) {
+		switch n.Type {
+		case "taskList", "bulletList", "orderedList":
+			return
+		case "text":
+			b.WriteString(n.Text)
+		case "mention":
+			if id, ok := n.Attrs["id"].(string); ok && id != "" {
+				task.Mentions = append(task.Mentions, id)
+			}
+			if label, ok := n.Attrs["label"].(string); ok {
+				b.WriteString("@" + label)
+			}
+		case "hardBreak":
+			b.WriteString(" ")
+		}
+		for _, child := range n.Content {
+			walk(child)
+		}
+		if n.Type == "paragraph" {
+			b.WriteString(" ")
+		}
+	}
+	for _, child := range item.Content {
+		walk(child)
+	}
+	task.Text = strings.Join(strings.Fields(b.String()), " ")
+
+	if m := taskDuePattern.FindStringSubmatch(task.Text); m != nil {
+		if _, err := time.Parse("2006-01-02", m[1]); err == nil {
+			task.DueDate = m[1]
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, m := range taskTagPattern.FindAllStringSubmatch(task.Text, -1) {
+		tag := strings.ToLower(m[1])
+		if !seen[tag] {
+			seen[tag] = true
+			task.Tags = append(task.Tags, tag)
+		}
+	}
+
+	return task
+}
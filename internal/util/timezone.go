@@ -0,0 +1,61 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	// Timezones also work where the system has no zoneinfo, e.g. in containers
+	_ "time/tzdata"
+)
+
+var utcOffsetPattern = regexp.MustCompile(`^(?:UTC|GMT)?([+-])(\d{1,2})(?::?(\d{2}))?$`)
+
+// LoadTimezone reads an IANA timezone such as Europe/Berlin, or a fixed offset
+// from UTC such as +02:00, -0530 or UTC+8
+func LoadTimezone(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("timezone is empty")
+	}
+
+	if m := utcOffsetPattern.FindStringSubmatch(name); m != nil {
+		hours, _ := strconv.Atoi(m[2])
+		minutes := 0
+		if m[3] != "" {
+			minutes, _ = strconv.Atoi(m[3])
+		}
+		if hours > 14 || minutes > 59 {
+			return nil, fmt.Errorf("invalid UTC offset %q", name)
+		}
+
+		offset := hours*60 + minutes
+		if m[1] == "-" {
+			offset = -offset
+		}
+		return time.FixedZone(FormatUTCOffset(offset), offset*60), nil
+	}
+
+	// Local would be the server's timezone, not the user's
+	if name == "Local" {
+		return nil, fmt.Errorf("unknown timezone %q", name)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", name)
+	}
+	return loc, nil
+}
+
+// FormatUTCOffset writes an offset from UTC in minutes as +hh:mm
+func FormatUTCOffset(minutes int) string {
+	sign := "+"
+	if minutes < 0 {
+		sign = "-"
+		minutes = -minutes
+	}
+	return fmt.Sprintf("%s%02d:%02d", sign, minutes/60, minutes%60)
+}
@@ -0,0 +1,44 @@
+package util
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// TipTapText returns the text of a note's TipTap JSON content with blocks on
+// separate lines, and the IDs of the users mentioned with mention nodes.
+// Content that isn't TipTap JSON is returned as it is.
+func TipTapText(content string) (string, []string) {
+	var doc TipTapNode
+	if err := json.Unmarshal([]byte(content), &doc); err != nil || doc.Type == "" {
+		return content, nil
+	}
+
+	var b strings.Builder
+	var mentions []string
+	var walk func(n TipTapNode)
+	walk = func(n TipTapNode) {
+		switch n.Type {
+		case "text":
+			b.WriteString(n.Text)
+		case "mention":
+			if id, ok := n.Attrs["id"].(string); ok && id != "" {
+				mentions = append(mentions, id)
+			}
+			if label, ok := n.Attrs["label"].(string); ok {
+				b.WriteString("@" + label)
+			}
+		case "hardBreak":
+			b.WriteString("\n")
+		}
+		for _, child := range n.Content {
+			walk(child)
+		}
+		if len(n.Content) > 0 && n.Type != "doc" {
+			b.WriteString("\n")
+		}
+	}
+	walk(doc)
+
+	return b.String(), mentions
+}
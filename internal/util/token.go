@@ -0,0 +1,27 @@
+package util
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// GenerateToken generates a random URL-safe token for single-use links
+// Returns: token, SHA-256 hash of the token (what gets stored), error
+func GenerateToken() (string, string, error) {
+	randomBytes := make([]byte, 32)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+
+	token := hex.EncodeToString(randomBytes)
+
+	return token, HashToken(token), nil
+}
+
+// HashToken returns the hex encoded SHA-256 hash of a token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,285 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/collabreef/collabreef/internal/db"
+	"github.com/collabreef/collabreef/internal/model"
+	"github.com/collabreef/collabreef/internal/queue"
+	"github.com/collabreef/collabreef/internal/urlfetcher"
+	"github.com/collabreef/collabreef/internal/util"
+)
+
+const (
+	HeaderEvent     = "X-Collabreef-Event"
+	HeaderDelivery  = "X-Collabreef-Delivery"
+	HeaderTimestamp = "X-Collabreef-Timestamp"
+	HeaderSignature = "X-Collabreef-Signature"
+
+	// A claimed delivery is retried after the lease if its worker never reports back
+	leaseDuration = 2 * time.Minute
+
+	retryBase = time.Minute
+	retryMax  = 6 * time.Hour
+)
+
+type Options struct {
+	// SecretKey decrypts the stored signing secrets
+	SecretKey string
+	// Allow lets deliveries reach hosts that would otherwise be blocked, such as
+	// services on a private network
+	Allow urlfetcher.Allowlist
+	// MaxAttempts is how often a delivery is tried before it is marked failed
+	MaxAttempts int
+	// Retention is how long finished deliveries are kept, 0 keeps them forever
+	Retention time.Duration
+}
+
+// Dispatcher queues workspace events for every subscribed webhook and delivers
+// them in the background. Deliveries live in the database, so pending ones
+// survive restarts and several server instances can share the work.
+type Dispatcher struct {
+	db   db.DB
+	opts Options
+	loop *queue.Loop[model.WebhookDelivery]
+}
+
+func NewDispatcher(r db.DB, opts Options) *Dispatcher {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 1
+	}
+
+	d := &Dispatcher{
+		db:   r,
+		opts: opts,
+	}
+
+	cfg := queue.Config[model.WebhookDelivery]{
+		Name:  "webhook deliveries",
+		Due:   r.FindDueWebhookDeliveries,
+		Claim: d.claim,
+		Run:   d.attempt,
+		Lease: leaseDuration,
+	}
+	if opts.Retention > 0 {
+		cfg.Purge = d.purge
+	}
+	d.loop = queue.New(cfg)
+
+	return d
+}
+
+// Sign returns the signature header value for a payload. Receivers recompute it
+// over "<timestamp>.<body>" with their secret and compare in constant time.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// CheckURL reports whether a webhook URL can be delivered to
+func (d *Dispatcher) CheckURL(rawURL string) error {
+	return urlfetcher.CheckURL(rawURL, d.opts.Allow)
+}
+
+// Publish queues the event for every enabled webhook of its workspace that
+// subscribes to it. Private events are never sent out. It does not block the
+// caller; a nil dispatcher is a no-op.
+func (d *Dispatcher) Publish(e model.WorkspaceEvent) {
+	if d == nil || e.IsPrivate() {
+		return
+	}
+
+	go func() {
+		if err := d.enqueue(e); err != nil {
+			log.Printf("Failed to queue webhook event %s: %v", e.Type, err)
+		}
+	}()
+}
+
+func (d *Dispatcher) enqueue(e model.WorkspaceEvent) error {
+	hooks, err := d.db.FindWebhooks(model.WebhookFilter{WorkspaceID: e.WorkspaceID, Enabled: true})
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	queued := false
+
+	for _, w := range hooks {
+		if !w.Subscribes(e.Type) {
+			continue
+		}
+
+		if payload == nil {
+			if payload, err = json.Marshal(e); err != nil {
+				return err
+			}
+		}
+
+		if err := d.db.CreateWebhookDelivery(newDelivery(w, e.Type, string(payload))); err != nil {
+			return err
+		}
+		queued = true
+	}
+
+	if queued {
+		d.loop.Notify()
+	}
+
+	return nil
+}
+
+// Ping queues a ping event for one webhook regardless of its event filter
+func (d *Dispatcher) Ping(w model.Webhook, actor *model.EventUser) (model.WebhookDelivery, error) {
+	payload, err := json.Marshal(model.WorkspaceEvent{
+		ID:          util.NewId(),
+		Type:        model.EventPing,
+		WorkspaceID: w.WorkspaceID,
+		Actor:       actor,
+		Data:        map[string]string{"webhook_id": w.ID},
+		CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return model.WebhookDelivery{}, err
+	}
+
+	delivery := newDelivery(w, model.EventPing, string(payload))
+	if err := d.db.CreateWebhookDelivery(delivery); err != nil {
+		return model.WebhookDelivery{}, err
+	}
+
+	d.loop.Notify()
+
+	return delivery, nil
+}
+
+// Redeliver queues the payload of an earlier delivery again as a new delivery
+func (d *Dispatcher) Redeliver(w model.Webhook, previous model.WebhookDelivery) (model.WebhookDelivery, error) {
+	delivery := newDelivery(w, previous.Event, previous.Payload)
+	delivery.RedeliveryOf = previous.ID
+
+	if err := d.db.CreateWebhookDelivery(delivery); err != nil {
+		return model.WebhookDelivery{}, err
+	}
+
+	d.loop.Notify()
+
+	return delivery, nil
+}
+
+func newDelivery(w model.Webhook, event string, payload string) model.WebhookDelivery {
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	return model.WebhookDelivery{
+		ID:            util.NewId(),
+		WebhookID:     w.ID,
+		WorkspaceID:   w.WorkspaceID,
+		Event:         event,
+		Payload:       payload,
+		Status:        model.WebhookDeliveryStatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+}
+
+// Start runs the delivery loop until ctx is cancelled
+func (d *Dispatcher) Start(ctx context.Context) {
+	d.loop.Start(ctx)
+}
+
+func (d *Dispatcher) claim(delivery model.WebhookDelivery, lease string) (bool, error) {
+	claimed, err := d.db.ClaimWebhookDelivery(delivery.ID, delivery.NextAttemptAt, lease)
+	if err != nil {
+		return false, fmt.Errorf("delivery %s: %w", delivery.ID, err)
+	}
+	return claimed, nil
+}
+
+func (d *Dispatcher) attempt(ctx context.Context, delivery model.WebhookDelivery) {
+	started := time.Now().UTC()
+
+	status, body, err := d.send(ctx, delivery)
+
+	delivery.Attempts++
+	delivery.LastAttemptAt = started.Format(time.RFC3339)
+	delivery.DurationMs = int(time.Since(started).Milliseconds())
+	delivery.ResponseStatus = status
+	delivery.ResponseBody = string(body)
+	delivery.Error = ""
+
+	switch {
+	case err != nil:
+		delivery.Error = err.Error()
+	case status < 200 || status >= 300:
+		delivery.Error = "unexpected status " + strconv.Itoa(status)
+	}
+
+	switch {
+	case delivery.Error == "":
+		delivery.Status = model.WebhookDeliveryStatusSucceeded
+		delivery.NextAttemptAt = ""
+	case errors.Is(err, errWebhookGone) || delivery.Attempts >= d.opts.MaxAttempts:
+		delivery.Status = model.WebhookDeliveryStatusFailed
+		delivery.NextAttemptAt = ""
+	default:
+		delivery.NextAttemptAt = started.Add(queue.Backoff(delivery.Attempts, retryBase, retryMax)).Format(time.RFC3339)
+	}
+
+	if err := d.db.UpdateWebhookDelivery(delivery); err != nil {
+		log.Printf("Failed to update webhook delivery %s: %v", delivery.ID, err)
+	}
+}
+
+var errWebhookGone = errors.New("webhook is disabled or was deleted")
+
+func (d *Dispatcher) send(ctx context.Context, delivery model.WebhookDelivery) (int, []byte, error) {
+	w, err := d.db.FindWebhookByID(delivery.WebhookID)
+	if err != nil || !w.Enabled {
+		return 0, nil, errWebhookGone
+	}
+
+	secret, err := util.Decrypt(w.Secret, d.opts.SecretKey)
+	if err != nil {
+		return 0, nil, errors.New("failed to decrypt webhook secret")
+	}
+
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("User-Agent", "Collabreef-Webhook")
+	header.Set(HeaderEvent, delivery.Event)
+	header.Set(HeaderDelivery, delivery.ID)
+	header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	header.Set(HeaderSignature, Sign(secret, timestamp, body))
+
+	return urlfetcher.SafePost(ctx, w.URL, body, header, d.opts.Allow)
+}
+
+func (d *Dispatcher) purge() {
+	cutoff := time.Now().UTC().Add(-d.opts.Retention).Format(time.RFC3339)
+
+	n, err := d.db.DeleteWebhookDeliveriesBefore(cutoff)
+	if err != nil {
+		log.Printf("Failed to purge webhook deliveries: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("Purged %d webhook deliveries", n)
+	}
+}
@@ -0,0 +1,195 @@
+package yjs
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+)
+
+// Values stored in Y.Map entries and Y.Array items use lib0's "any" encoding.
+// They decode to nil, Undefined, bool, float64, BigInt, string, []byte, []any
+// and map[string]any. Numbers are float64 like in JavaScript.
+
+type undefined struct{}
+
+// Undefined is JavaScript's undefined, which is distinct from null
+var Undefined = undefined{}
+
+// BigInt is a JavaScript bigint
+type BigInt int64
+
+const (
+	anyUndefined = 127
+	anyNull      = 126
+	anyInteger   = 125
+	anyFloat32   = 124
+	anyFloat64   = 123
+	anyBigInt    = 122
+	anyFalse     = 121
+	anyTrue      = 120
+	anyString    = 119
+	anyObject    = 118
+	anyArray     = 117
+	anyBytes     = 116
+)
+
+// maxAnyDepth bounds nesting so hostile input can't exhaust the stack
+const maxAnyDepth = 100
+
+func (e *Encoder) WriteAny(v any) {
+	switch v := v.(type) {
+	case nil:
+		e.WriteUint8(anyNull)
+	case undefined:
+		e.WriteUint8(anyUndefined)
+	case bool:
+		if v {
+			e.WriteUint8(anyTrue)
+		} else {
+			e.WriteUint8(anyFalse)
+		}
+	case string:
+		e.WriteUint8(anyString)
+		e.WriteVarString(v)
+	case int:
+		e.writeNumber(float64(v))
+	case int64:
+		e.writeNumber(float64(v))
+	case float32:
+		e.writeNumber(float64(v))
+	case float64:
+		e.writeNumber(v)
+	case BigInt:
+		e.WriteUint8(anyBigInt)
+		e.buf = binary.BigEndian.AppendUint64(e.buf, uint64(v))
+	case []byte:
+		e.WriteUint8(anyBytes)
+		e.WriteVarBytes(v)
+	case []any:
+		e.WriteUint8(anyArray)
+		e.WriteVarUint(uint64(len(v)))
+		for _, x := range v {
+			e.WriteAny(x)
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		e.WriteUint8(anyObject)
+		e.WriteVarUint(uint64(len(keys)))
+		for _, k := range keys {
+			e.WriteVarString(k)
+			e.WriteAny(v[k])
+		}
+	default:
+		e.WriteUint8(anyUndefined)
+	}
+}
+
+// writeNumber picks the smallest encoding that keeps the value, the same way
+// lib0 does
+func (e *Encoder) writeNumber(f float64) {
+	switch {
+	case f == math.Trunc(f) && math.Abs(f) <= math.MaxInt32:
+		e.WriteUint8(anyInteger)
+		e.writeVarInt(uint64(math.Abs(f)), math.Signbit(f))
+	case float64(float32(f)) == f:
+		e.WriteUint8(anyFloat32)
+		e.writeFloat32(float32(f))
+	default:
+		e.WriteUint8(anyFloat64)
+		e.writeFloat64(f)
+	}
+}
+
+func (d *Decoder) ReadAny() (any, error) {
+	return d.readAny(0)
+}
+
+func (d *Decoder) readAny(depth int) (any, error) {
+	if depth > maxAnyDepth {
+		return nil, ErrInvalidData
+	}
+
+	t, err := d.ReadUint8()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t {
+	case anyUndefined:
+		return Undefined, nil
+	case anyNull:
+		return nil, nil
+	case anyInteger:
+		return d.readVarInt()
+	case anyFloat32:
+		b, err := d.readFixed(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), nil
+	case anyFloat64:
+		b, err := d.readFixed(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+	case anyBigInt:
+		b, err := d.readFixed(8)
+		if err != nil {
+			return nil, err
+		}
+		return BigInt(int64(binary.BigEndian.Uint64(b))), nil
+	case anyFalse:
+		return false, nil
+	case anyTrue:
+		return true, nil
+	case anyString:
+		return d.ReadVarString()
+	case anyObject:
+		n, err := d.readLen()
+		if err != nil {
+			return nil, err
+		}
+		obj := map[string]any{}
+		for i := 0; i < n; i++ {
+			k, err := d.ReadVarString()
+			if err != nil {
+				return nil, err
+			}
+			if obj[k], err = d.readAny(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return obj, nil
+	case anyArray:
+		n, err := d.readLen()
+		if err != nil {
+			return nil, err
+		}
+		var arr []any
+		for i := 0; i < n; i++ {
+			v, err := d.readAny(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		if arr == nil {
+			arr = []any{}
+		}
+		return arr, nil
+	case anyBytes:
+		b, err := d.ReadVarBytes()
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	}
+
+	return nil, ErrInvalidData
+}
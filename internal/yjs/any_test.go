@@ -0,0 +1,61 @@
+package yjs
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// Encodings as lib0's encoding.writeAny produces them
+func TestWriteAny(t *testing.T) {
+	for _, tt := range []struct {
+		value any
+		want  []byte
+	}{
+		{nil, []byte{126}},
+		{Undefined, []byte{127}},
+		{true, []byte{120}},
+		{false, []byte{121}},
+		{"hé", []byte{119, 3, 'h', 0xc3, 0xa9}},
+		{5, []byte{125, 0x05}},
+		{-5, []byte{125, 0x45}},
+		{100, []byte{125, 0xa4, 0x01}},
+		{1.5, []byte{124, 0x3f, 0xc0, 0x00, 0x00}},
+		{0.1, []byte{123, 0x3f, 0xb9, 0x99, 0x99, 0x99, 0x99, 0x99, 0x9a}},
+		{[]byte{1, 2}, []byte{116, 2, 1, 2}},
+		{[]any{"a", 1.0}, []byte{117, 2, 119, 1, 'a', 125, 1}},
+		{map[string]any{"b": nil, "a": true}, []byte{118, 2, 1, 'a', 120, 1, 'b', 126}},
+	} {
+		e := NewEncoder()
+		e.WriteAny(tt.value)
+		if got := e.Bytes(); !bytes.Equal(got, tt.want) {
+			t.Errorf("WriteAny(%#v) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestReadAny(t *testing.T) {
+	for _, tt := range []struct {
+		b    []byte
+		want any
+	}{
+		{[]byte{126}, nil},
+		{[]byte{127}, Undefined},
+		{[]byte{125, 0x45}, -5.0},
+		{[]byte{125, 0xa4, 0x01}, 100.0},
+		{[]byte{124, 0x3f, 0xc0, 0x00, 0x00}, 1.5},
+		{[]byte{122, 0, 0, 0, 0, 0, 0, 0x01, 0x00}, BigInt(256)},
+		{[]byte{118, 2, 1, 'a', 120, 1, 'b', 117, 1, 119, 0}, map[string]any{"a": true, "b": []any{""}}},
+	} {
+		got, err := NewDecoder(tt.b).ReadAny()
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ReadAny(%v) = %#v, %v, want %#v", tt.b, got, err, tt.want)
+		}
+	}
+
+	// Arrays nested deeper than any document needs
+	deep := bytes.Repeat([]byte{117, 1}, maxAnyDepth+1)
+	if _, err := NewDecoder(append(deep, 126)).ReadAny(); err == nil {
+		t.Error("ReadAny() of deeply nested arrays = nil, want an error")
+	}
+}
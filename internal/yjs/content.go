@@ -0,0 +1,296 @@
+package yjs
+
+import (
+	"encoding/json"
+)
+
+// Content refs as they appear in the lower five bits of a struct's info byte
+const (
+	refGC      = 0
+	refDeleted = 1
+	refJSON    = 2
+	refBinary  = 3
+	refString  = 4
+	refEmbed   = 5
+	refFormat  = 6
+	refType    = 7
+	refAny     = 8
+	refDoc     = 9
+	refSkip    = 10
+)
+
+// Type refs of shared types nested in a document
+const (
+	TypeArray       = 0
+	TypeMap         = 1
+	TypeText        = 2
+	TypeXmlElement  = 3
+	TypeXmlFragment = 4
+	TypeXmlHook     = 5
+	TypeXmlText     = 6
+)
+
+// content is what an item holds. Items are split at clock offsets, so content
+// that spans several clocks can be split as well.
+type content interface {
+	ref() byte
+	length() int
+	countable() bool
+	// splice keeps the first offset clocks and returns the rest
+	splice(offset int) content
+	write(e *Encoder, offset int)
+}
+
+type contentDeleted struct{ n int }
+
+func (c *contentDeleted) ref() byte       { return refDeleted }
+func (c *contentDeleted) length() int     { return c.n }
+func (c *contentDeleted) countable() bool { return false }
+
+func (c *contentDeleted) splice(offset int) content {
+	right := &contentDeleted{c.n - offset}
+	c.n = offset
+	return right
+}
+
+func (c *contentDeleted) write(e *Encoder, offset int) {
+	e.WriteVarUint(uint64(c.n - offset))
+}
+
+// contentJSON is the legacy JSON array content, values are kept as their
+// JSON text
+type contentJSON struct{ arr []string }
+
+func (c *contentJSON) ref() byte       { return refJSON }
+func (c *contentJSON) length() int     { return len(c.arr) }
+func (c *contentJSON) countable() bool { return true }
+
+func (c *contentJSON) splice(offset int) content {
+	right := &contentJSON{c.arr[offset:]}
+	c.arr = c.arr[:offset:offset]
+	return right
+}
+
+func (c *contentJSON) write(e *Encoder, offset int) {
+	e.WriteVarUint(uint64(len(c.arr) - offset))
+	for _, s := range c.arr[offset:] {
+		e.WriteVarString(s)
+	}
+}
+
+type contentBinary struct{ b []byte }
+
+func (c *contentBinary) ref() byte                 { return refBinary }
+func (c *contentBinary) length() int               { return 1 }
+func (c *contentBinary) countable() bool           { return true }
+func (c *contentBinary) splice(offset int) content { return nil }
+func (c *contentBinary) write(e *Encoder, _ int)   { e.WriteVarBytes(c.b) }
+
+type contentString struct{ s []uint16 }
+
+func (c *contentString) ref() byte       { return refString }
+func (c *contentString) length() int     { return len(c.s) }
+func (c *contentString) countable() bool { return true }
+
+func (c *contentString) splice(offset int) content {
+	right := &contentString{append([]uint16(nil), c.s[offset:]...)}
+	c.s = c.s[:offset:offset]
+
+	// Never leave half a surrogate pair on either side
+	if last := c.s[offset-1]; last >= 0xd800 && last <= 0xdbff {
+		c.s[offset-1] = 0xfffd
+		right.s[0] = 0xfffd
+	}
+
+	return right
+}
+
+func (c *contentString) write(e *Encoder, offset int) {
+	e.WriteVarString(fromUTF16(c.s[offset:]))
+}
+
+type contentEmbed struct{ json string }
+
+func (c *contentEmbed) ref() byte                 { return refEmbed }
+func (c *contentEmbed) length() int               { return 1 }
+func (c *contentEmbed) countable() bool           { return true }
+func (c *contentEmbed) splice(offset int) content { return nil }
+func (c *contentEmbed) write(e *Encoder, _ int)   { e.WriteVarString(c.json) }
+
+type contentFormat struct{ key, json string }
+
+func (c *contentFormat) ref() byte                 { return refFormat }
+func (c *contentFormat) length() int               { return 1 }
+func (c *contentFormat) countable() bool           { return false }
+func (c *contentFormat) splice(offset int) content { return nil }
+
+func (c *contentFormat) write(e *Encoder, _ int) {
+	e.WriteVarString(c.key)
+	e.WriteVarString(c.json)
+}
+
+type contentType struct{ t *Type }
+
+func (c *contentType) ref() byte                 { return refType }
+func (c *contentType) length() int               { return 1 }
+func (c *contentType) countable() bool           { return true }
+func (c *contentType) splice(offset int) content { return nil }
+
+func (c *contentType) write(e *Encoder, _ int) {
+	e.WriteVarUint(uint64(c.t.ref))
+	if c.t.ref == TypeXmlElement || c.t.ref == TypeXmlHook {
+		e.WriteVarString(c.t.nodeName)
+	}
+}
+
+type contentAny struct{ arr []any }
+
+func (c *contentAny) ref() byte       { return refAny }
+func (c *contentAny) length() int     { return len(c.arr) }
+func (c *contentAny) countable() bool { return true }
+
+func (c *contentAny) splice(offset int) content {
+	right := &contentAny{c.arr[offset:]}
+	c.arr = c.arr[:offset:offset]
+	return right
+}
+
+func (c *contentAny) write(e *Encoder, offset int) {
+	e.WriteVarUint(uint64(len(c.arr) - offset))
+	for _, v := range c.arr[offset:] {
+		e.WriteAny(v)
+	}
+}
+
+// contentDoc is a subdocument, only its reference is kept
+type contentDoc struct {
+	guid string
+	opts any
+}
+
+func (c *contentDoc) ref() byte                 { return refDoc }
+func (c *contentDoc) length() int               { return 1 }
+func (c *contentDoc) countable() bool           { return true }
+func (c *contentDoc) splice(offset int) content { return nil }
+
+func (c *contentDoc) write(e *Encoder, _ int) {
+	e.WriteVarString(c.guid)
+	e.WriteAny(c.opts)
+}
+
+func readContent(d *Decoder, ref byte) (content, error) {
+	switch ref {
+	case refDeleted:
+		n, err := d.readLen()
+		return &contentDeleted{n}, err
+
+	case refJSON:
+		n, err := d.readLen()
+		if err != nil {
+			return nil, err
+		}
+		c := &contentJSON{}
+		for i := 0; i < n; i++ {
+			s, err := d.ReadVarString()
+			if err != nil {
+				return nil, err
+			}
+			c.arr = append(c.arr, s)
+		}
+		return c, nil
+
+	case refBinary:
+		b, err := d.ReadVarBytes()
+		return &contentBinary{append([]byte(nil), b...)}, err
+
+	case refString:
+		s, err := d.ReadVarString()
+		return &contentString{toUTF16(s)}, err
+
+	case refEmbed:
+		s, err := d.ReadVarString()
+		return &contentEmbed{s}, err
+
+	case refFormat:
+		key, err := d.ReadVarString()
+		if err != nil {
+			return nil, err
+		}
+		s, err := d.ReadVarString()
+		return &contentFormat{key, s}, err
+
+	case refType:
+		ref, err := d.ReadVarUint()
+		if err != nil {
+			return nil, err
+		}
+		if ref > TypeXmlText {
+			return nil, ErrInvalidData
+		}
+		t := &Type{ref: byte(ref)}
+		if t.ref == TypeXmlElement || t.ref == TypeXmlHook {
+			if t.nodeName, err = d.ReadVarString(); err != nil {
+				return nil, err
+			}
+		}
+		return &contentType{t}, nil
+
+	case refAny:
+		n, err := d.readLen()
+		if err != nil {
+			return nil, err
+		}
+		c := &contentAny{}
+		for i := 0; i < n; i++ {
+			v, err := d.ReadAny()
+			if err != nil {
+				return nil, err
+			}
+			c.arr = append(c.arr, v)
+		}
+		return c, nil
+
+	case refDoc:
+		guid, err := d.ReadVarString()
+		if err != nil {
+			return nil, err
+		}
+		opts, err := d.ReadAny()
+		return &contentDoc{guid, opts}, err
+	}
+
+	return nil, ErrInvalidData
+}
+
+// lastValue returns what a map entry holding this content resolves to
+func lastValue(c content) any {
+	switch c := c.(type) {
+	case *contentAny:
+		if len(c.arr) > 0 {
+			return c.arr[len(c.arr)-1]
+		}
+	case *contentString:
+		return fromUTF16(c.s)
+	case *contentJSON:
+		if len(c.arr) > 0 {
+			s := c.arr[len(c.arr)-1]
+			if s == "undefined" {
+				return Undefined
+			}
+			var v any
+			if json.Unmarshal([]byte(s), &v) == nil {
+				return v
+			}
+		}
+	case *contentBinary:
+		return c.b
+	case *contentEmbed:
+		var v any
+		if json.Unmarshal([]byte(c.json), &v) == nil {
+			return v
+		}
+	case *contentType:
+		return c.t
+	}
+	return nil
+}
@@ -0,0 +1,493 @@
+// Package yjs reads and writes Yjs documents in the update v1 format, so the
+// server can look into and change the collaborative documents the browser
+// editors work on. It implements the parts of the Yjs CRDT the server needs:
+// integrating updates, text and map edits, deletions with garbage collection,
+// state vectors and encoding the state as an update. Undo metadata, formatting
+// attributes and subdocuments are carried along but not interpreted.
+package yjs
+
+import (
+	"math/rand/v2"
+	"sort"
+)
+
+// ID identifies the first clock of a struct, clocks are counted per client
+type ID struct {
+	Client uint64
+	Clock  int
+}
+
+// item is a struct in the document. GC structs, which stand in for deleted
+// content whose parent was removed, are items with gc set and no content.
+type item struct {
+	id          ID
+	length      int
+	origin      *ID
+	rightOrigin *ID
+	left        *item
+	right       *item
+	parent      *Type
+	parentSub   *string
+	content     content
+	deleted     bool
+	gc          bool
+
+	// Parent as read from an update, resolved when the item is integrated
+	parentName string
+	parentID   *ID
+	hasParent  bool
+}
+
+func (it *item) lastID() ID {
+	return ID{it.id.Client, it.id.Clock + it.length - 1}
+}
+
+// Doc is a Yjs document. It is not safe for concurrent use.
+type Doc struct {
+	// ClientID is used for the changes made through this document
+	ClientID uint64
+
+	clients map[uint64][]*item
+	share   map[string]*Type
+
+	// Structs and deletions whose dependencies have not arrived yet
+	pending        []*item
+	pendingDeletes []deleteRange
+
+	// Items deleted since the last garbage collection
+	deleted []*item
+}
+
+func NewDoc() *Doc {
+	return &Doc{
+		ClientID: uint64(rand.Uint32()),
+		clients:  map[uint64][]*item{},
+		share:    map[string]*Type{},
+	}
+}
+
+// Get returns the root type with the given name, creating it if needed. Yjs
+// root types are untyped in updates, the caller decides how to read them.
+func (d *Doc) Get(name string) *Type {
+	t, ok := d.share[name]
+	if !ok {
+		t = &Type{doc: d, name: name, m: map[string]*item{}}
+		d.share[name] = t
+	}
+	return t
+}
+
+func (d *Doc) state(client uint64) int {
+	structs := d.clients[client]
+	if len(structs) == 0 {
+		return 0
+	}
+	last := structs[len(structs)-1]
+	return last.id.Clock + last.length
+}
+
+// StateVector returns the next expected clock of every client
+func (d *Doc) StateVector() map[uint64]int {
+	sv := make(map[uint64]int, len(d.clients))
+	for client := range d.clients {
+		sv[client] = d.state(client)
+	}
+	return sv
+}
+
+// findIndex returns the index of the struct holding clock
+func findIndex(structs []*item, clock int) int {
+	return sort.Search(len(structs), func(i int) bool {
+		return structs[i].id.Clock+structs[i].length > clock
+	})
+}
+
+// find returns the struct holding id, or nil if it's unknown
+func (d *Doc) find(id ID) *item {
+	structs := d.clients[id.Client]
+	i := findIndex(structs, id.Clock)
+	if i == len(structs) || structs[i].id.Clock > id.Clock {
+		return nil
+	}
+	return structs[i]
+}
+
+// cleanStart returns the item starting at id, splitting the item holding it
+func (d *Doc) cleanStart(id ID) *item {
+	it := d.find(id)
+	if it == nil || it.gc || it.id.Clock == id.Clock {
+		return it
+	}
+	return d.split(it, id.Clock-it.id.Clock)
+}
+
+// cleanEnd returns the item ending at id, splitting the item holding it
+func (d *Doc) cleanEnd(id ID) *item {
+	it := d.find(id)
+	if it == nil || it.gc || it.lastID().Clock == id.Clock {
+		return it
+	}
+	d.split(it, id.Clock-it.id.Clock+1)
+	return it
+}
+
+// split cuts an item after diff clocks and returns the right part
+func (d *Doc) split(left *item, diff int) *item {
+	right := &item{
+		id:          ID{left.id.Client, left.id.Clock + diff},
+		origin:      &ID{left.id.Client, left.id.Clock + diff - 1},
+		left:        left,
+		right:       left.right,
+		rightOrigin: left.rightOrigin,
+		parent:      left.parent,
+		parentSub:   left.parentSub,
+		content:     left.content.splice(diff),
+		deleted:     left.deleted,
+	}
+	right.length = right.content.length()
+	left.length = diff
+
+	left.right = right
+	if right.right != nil {
+		right.right.left = right
+	}
+	if right.parentSub != nil && right.right == nil {
+		right.parent.m[*right.parentSub] = right
+	}
+
+	structs := d.clients[left.id.Client]
+	i := findIndex(structs, left.id.Clock) + 1
+	structs = append(structs, nil)
+	copy(structs[i+1:], structs[i:])
+	structs[i] = right
+	d.clients[left.id.Client] = structs
+
+	return right
+}
+
+// missing reports whether the item refers to structs the document doesn't have
+func (d *Doc) missing(it *item) bool {
+	if it.gc {
+		return false
+	}
+	for _, id := range []*ID{it.origin, it.rightOrigin, it.parentID} {
+		if id != nil && id.Clock >= d.state(id.Client) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve links an item to its neighbours and parent before it is integrated
+func (d *Doc) resolve(it *item) {
+	if it.gc {
+		return
+	}
+
+	if it.origin != nil {
+		it.left = d.cleanEnd(*it.origin)
+		o := it.left.lastID()
+		it.origin = &o
+	}
+	if it.rightOrigin != nil {
+		it.right = d.cleanStart(*it.rightOrigin)
+		r := it.right.id
+		it.rightOrigin = &r
+	}
+
+	switch {
+	case (it.left != nil && it.left.gc) || (it.right != nil && it.right.gc):
+		it.parent = nil
+	case !it.hasParent:
+		if it.left != nil {
+			it.parent, it.parentSub = it.left.parent, it.left.parentSub
+		}
+		if it.right != nil {
+			it.parent, it.parentSub = it.right.parent, it.right.parentSub
+		}
+	case it.parentID != nil:
+		it.parent = nil
+		if p := d.find(*it.parentID); p != nil && !p.gc {
+			if c, ok := p.content.(*contentType); ok {
+				it.parent = c.t
+			}
+		}
+	default:
+		it.parent = d.Get(it.parentName)
+	}
+}
+
+// integrate inserts a resolved item into its parent, skipping the first
+// offset clocks the document already has
+func (d *Doc) integrate(it *item, offset int) {
+	if offset > 0 {
+		it.id.Clock += offset
+		it.left = d.cleanEnd(ID{it.id.Client, it.id.Clock - 1})
+		o := it.left.lastID()
+		it.origin = &o
+		if !it.gc {
+			it.content = it.content.splice(offset)
+		}
+		it.length -= offset
+	}
+
+	if it.gc || it.parent == nil {
+		it.toGC()
+		d.addStruct(it)
+		return
+	}
+
+	parent := it.parent
+
+	// Find the position among concurrent inserts at the same place (YATA)
+	if (it.left == nil && (it.right == nil || it.right.left != nil)) || (it.left != nil && it.left.right != it.right) {
+		left := it.left
+
+		var o *item
+		switch {
+		case left != nil:
+			o = left.right
+		case it.parentSub != nil:
+			o = parent.m[*it.parentSub]
+			for o != nil && o.left != nil {
+				o = o.left
+			}
+		default:
+			o = parent.start
+		}
+
+		conflicting := map[*item]bool{}
+		beforeOrigin := map[*item]bool{}
+
+		for o != nil && o != it.right {
+			beforeOrigin[o] = true
+			conflicting[o] = true
+
+			if sameID(it.origin, o.origin) {
+				if o.id.Client < it.id.Client {
+					left = o
+					clear(conflicting)
+				} else if sameID(it.rightOrigin, o.rightOrigin) {
+					break
+				}
+			} else if o.origin != nil && beforeOrigin[d.find(*o.origin)] {
+				if !conflicting[d.find(*o.origin)] {
+					left = o
+					clear(conflicting)
+				}
+			} else {
+				break
+			}
+
+			o = o.right
+		}
+
+		it.left = left
+	}
+
+	if it.left != nil {
+		it.right = it.left.right
+		it.left.right = it
+	} else {
+		var r *item
+		if it.parentSub != nil {
+			r = parent.m[*it.parentSub]
+			for r != nil && r.left != nil {
+				r = r.left
+			}
+		} else {
+			r = parent.start
+			parent.start = it
+		}
+		it.right = r
+	}
+
+	if it.right != nil {
+		it.right.left = it
+	} else if it.parentSub != nil {
+		// The newest entry of a map key is its value, the previous one is deleted
+		parent.m[*it.parentSub] = it
+		if it.left != nil {
+			d.delete(it.left)
+		}
+	}
+
+	if it.parentSub == nil && it.content.countable() && !it.deleted {
+		parent.length += it.length
+	}
+
+	d.addStruct(it)
+
+	switch c := it.content.(type) {
+	case *contentDeleted:
+		it.deleted = true
+	case *contentType:
+		c.t.doc = d
+		c.t.item = it
+		if c.t.m == nil {
+			c.t.m = map[string]*item{}
+		}
+	}
+
+	if (parent.item != nil && parent.item.deleted) || (it.parentSub != nil && it.right != nil) {
+		d.delete(it)
+	}
+}
+
+func (d *Doc) addStruct(it *item) {
+	d.clients[it.id.Client] = append(d.clients[it.id.Client], it)
+}
+
+func (it *item) toGC() {
+	it.gc = true
+	it.deleted = true
+	it.content = nil
+	it.parent = nil
+	it.parentSub = nil
+}
+
+func sameID(a, b *ID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func (d *Doc) delete(it *item) {
+	if it.deleted {
+		return
+	}
+
+	if it.parentSub == nil && it.content.countable() {
+		it.parent.length -= it.length
+	}
+	it.deleted = true
+	d.deleted = append(d.deleted, it)
+
+	if c, ok := it.content.(*contentType); ok {
+		for child := c.t.start; child != nil; child = child.right {
+			d.delete(child)
+		}
+		for _, child := range c.t.m {
+			d.delete(child)
+		}
+	}
+}
+
+// collectGarbage drops the content of deleted items, only their length is
+// needed to keep the clocks intact
+func (d *Doc) collectGarbage() {
+	for _, it := range d.deleted {
+		if it.deleted && !it.gc {
+			d.gcItem(it, false)
+		}
+	}
+	d.deleted = nil
+}
+
+func (d *Doc) gcItem(it *item, parentGCd bool) {
+	if c, ok := it.content.(*contentType); ok {
+		for child := c.t.start; child != nil; child = child.right {
+			if child.deleted {
+				d.gcItem(child, true)
+			}
+		}
+		for _, child := range c.t.m {
+			for ; child != nil; child = child.left {
+				if child.deleted {
+					d.gcItem(child, true)
+				}
+			}
+		}
+		c.t.start = nil
+		c.t.m = map[string]*item{}
+	}
+
+	if parentGCd {
+		it.toGC()
+	} else {
+		it.content = &contentDeleted{it.length}
+	}
+}
+
+type deleteRange struct {
+	client uint64
+	clock  int
+	length int
+}
+
+// applyDeletes marks the ranges deleted. Ranges of structs the document
+// doesn't have yet are returned to be retried later.
+func (d *Doc) applyDeletes(ranges []deleteRange) []deleteRange {
+	var unapplied []deleteRange
+
+	for _, r := range ranges {
+		end := r.clock + r.length
+		state := d.state(r.client)
+
+		if r.clock >= state {
+			unapplied = append(unapplied, r)
+			continue
+		}
+		if state < end {
+			unapplied = append(unapplied, deleteRange{r.client, state, end - state})
+		}
+
+		structs := d.clients[r.client]
+		i := findIndex(structs, r.clock)
+		if it := structs[i]; !it.deleted && it.id.Clock < r.clock {
+			d.split(it, r.clock-it.id.Clock)
+			i++
+		}
+
+		for ; i < len(d.clients[r.client]); i++ {
+			it := d.clients[r.client][i]
+			if it.id.Clock >= end {
+				break
+			}
+			if it.deleted {
+				continue
+			}
+			if end < it.id.Clock+it.length {
+				d.split(it, end-it.id.Clock)
+			}
+			d.delete(it)
+		}
+	}
+
+	return unapplied
+}
+
+// deleteSet returns the deleted ranges of every client, adjacent ones merged
+func (d *Doc) deleteSet() []deleteRange {
+	var ranges []deleteRange
+
+	for _, client := range d.sortedClients() {
+		structs := d.clients[client]
+		for i := 0; i < len(structs); i++ {
+			it := structs[i]
+			if !it.deleted {
+				continue
+			}
+			r := deleteRange{client, it.id.Clock, it.length}
+			for i+1 < len(structs) && structs[i+1].deleted {
+				i++
+				r.length += structs[i].length
+			}
+			ranges = append(ranges, r)
+		}
+	}
+
+	return ranges
+}
+
+// sortedClients returns the client IDs in descending order, the order Yjs
+// writes them in
+func (d *Doc) sortedClients() []uint64 {
+	clients := make([]uint64, 0, len(d.clients))
+	for client := range d.clients {
+		clients = append(clients, client)
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i] > clients[j] })
+	return clients
+}
@@ -0,0 +1,201 @@
+package yjs
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"unicode/utf16"
+)
+
+var (
+	ErrUnexpectedEOF = errors.New("yjs: unexpected end of data")
+	ErrInvalidData   = errors.New("yjs: invalid data")
+)
+
+// Encoder writes the lib0 binary encoding used by Yjs and the y-protocols
+type Encoder struct {
+	buf []byte
+}
+
+func NewEncoder() *Encoder {
+	return &Encoder{}
+}
+
+func (e *Encoder) Bytes() []byte {
+	return e.buf
+}
+
+func (e *Encoder) WriteUint8(b byte) {
+	e.buf = append(e.buf, b)
+}
+
+func (e *Encoder) WriteVarUint(n uint64) {
+	for n > 0x7f {
+		e.buf = append(e.buf, byte(0x80|(n&0x7f)))
+		n >>= 7
+	}
+	e.buf = append(e.buf, byte(n))
+}
+
+// writeVarInt writes a signed integer, the sign is kept in the first byte so
+// that -0 survives a round trip
+func (e *Encoder) writeVarInt(u uint64, negative bool) {
+	b := byte(u & 0x3f)
+	if u > 0x3f {
+		b |= 0x80
+	}
+	if negative {
+		b |= 0x40
+	}
+	e.buf = append(e.buf, b)
+	u >>= 6
+
+	for u > 0 {
+		b := byte(u & 0x7f)
+		if u > 0x7f {
+			b |= 0x80
+		}
+		e.buf = append(e.buf, b)
+		u >>= 7
+	}
+}
+
+func (e *Encoder) WriteVarString(s string) {
+	e.WriteVarUint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *Encoder) WriteVarBytes(b []byte) {
+	e.WriteVarUint(uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+func (e *Encoder) writeFloat32(f float32) {
+	e.buf = binary.BigEndian.AppendUint32(e.buf, math.Float32bits(f))
+}
+
+func (e *Encoder) writeFloat64(f float64) {
+	e.buf = binary.BigEndian.AppendUint64(e.buf, math.Float64bits(f))
+}
+
+// Decoder reads the lib0 binary encoding
+type Decoder struct {
+	buf []byte
+	pos int
+}
+
+func NewDecoder(b []byte) *Decoder {
+	return &Decoder{buf: b}
+}
+
+// HasContent reports whether there is data left to read
+func (d *Decoder) HasContent() bool {
+	return d.pos < len(d.buf)
+}
+
+func (d *Decoder) ReadUint8() (byte, error) {
+	if d.pos >= len(d.buf) {
+		return 0, ErrUnexpectedEOF
+	}
+	b := d.buf[d.pos]
+	d.pos++
+	return b, nil
+}
+
+func (d *Decoder) ReadVarUint() (uint64, error) {
+	var n uint64
+	var shift uint
+
+	for {
+		b, err := d.ReadUint8()
+		if err != nil {
+			return 0, err
+		}
+		// JavaScript numbers are safe up to 53 bits
+		if shift > 49 {
+			return 0, ErrInvalidData
+		}
+		n |= uint64(b&0x7f) << shift
+		shift += 7
+		if b < 0x80 {
+			return n, nil
+		}
+	}
+}
+
+// readLen reads an unsigned integer that is used as a length or clock
+func (d *Decoder) readLen() (int, error) {
+	n, err := d.ReadVarUint()
+	if err != nil {
+		return 0, err
+	}
+	if n > math.MaxInt32 {
+		return 0, ErrInvalidData
+	}
+	return int(n), nil
+}
+
+func (d *Decoder) readVarInt() (float64, error) {
+	b, err := d.ReadUint8()
+	if err != nil {
+		return 0, err
+	}
+
+	n := uint64(b & 0x3f)
+	negative := b&0x40 != 0
+	shift := uint(6)
+
+	for b&0x80 != 0 {
+		if b, err = d.ReadUint8(); err != nil {
+			return 0, err
+		}
+		if shift > 49 {
+			return 0, ErrInvalidData
+		}
+		n |= uint64(b&0x7f) << shift
+		shift += 7
+	}
+
+	if negative {
+		return -float64(n), nil
+	}
+	return float64(n), nil
+}
+
+func (d *Decoder) ReadVarBytes() ([]byte, error) {
+	n, err := d.readLen()
+	if err != nil {
+		return nil, err
+	}
+	if len(d.buf)-d.pos < n {
+		return nil, ErrUnexpectedEOF
+	}
+	b := d.buf[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *Decoder) ReadVarString() (string, error) {
+	b, err := d.ReadVarBytes()
+	return string(b), err
+}
+
+func (d *Decoder) readFixed(n int) ([]byte, error) {
+	if len(d.buf)-d.pos < n {
+		return nil, ErrUnexpectedEOF
+	}
+	b := d.buf[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// Strings are measured in UTF-16 code units like in JavaScript, so they are
+// kept in that form wherever lengths and offsets matter.
+
+func toUTF16(s string) []uint16 {
+	return utf16.Encode([]rune(s))
+}
+
+func fromUTF16(u []uint16) string {
+	return string(utf16.Decode(u))
+}
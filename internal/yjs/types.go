@@ -0,0 +1,150 @@
+package yjs
+
+// Type is a shared type: a root type of the document or a nested one. It can
+// be read and edited as a text, an array or a map; which of those it is
+// depends on how the clients use it.
+type Type struct {
+	doc      *Doc
+	item     *item
+	name     string
+	ref      byte
+	nodeName string
+	start    *item
+	m        map[string]*item
+	length   int
+}
+
+// Len returns the number of elements, for a text the length in UTF-16 code units
+func (t *Type) Len() int {
+	return t.length
+}
+
+// String returns the text content, ignoring embeds and formatting
+func (t *Type) String() string {
+	var s []uint16
+	for it := t.start; it != nil; it = it.right {
+		if c, ok := it.content.(*contentString); ok && !it.deleted {
+			s = append(s, c.s...)
+		}
+	}
+	return fromUTF16(s)
+}
+
+// Get returns the value of a map key
+func (t *Type) Get(key string) (any, bool) {
+	it := t.m[key]
+	if it == nil || it.deleted {
+		return nil, false
+	}
+	return lastValue(it.content), true
+}
+
+// Keys returns the keys the map has values for
+func (t *Type) Keys() []string {
+	var keys []string
+	for k, it := range t.m {
+		if !it.deleted {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// Set sets a map key to a value that can be written with WriteAny
+func (t *Type) Set(key string, value any) {
+	left := t.m[key]
+
+	it := &item{
+		id:        ID{t.doc.ClientID, t.doc.state(t.doc.ClientID)},
+		left:      left,
+		parent:    t,
+		parentSub: &key,
+		content:   &contentAny{[]any{value}},
+	}
+	if left != nil {
+		o := left.lastID()
+		it.origin = &o
+	}
+
+	t.doc.integrateLocal(it)
+}
+
+// Remove deletes a map key
+func (t *Type) Remove(key string) {
+	if it := t.m[key]; it != nil {
+		t.doc.delete(it)
+		t.doc.collectGarbage()
+	}
+}
+
+// Insert inserts text at an index counted in UTF-16 code units
+func (t *Type) Insert(index int, s string) {
+	if s == "" {
+		return
+	}
+
+	left, right := t.position(index)
+
+	it := &item{
+		id:      ID{t.doc.ClientID, t.doc.state(t.doc.ClientID)},
+		left:    left,
+		right:   right,
+		parent:  t,
+		content: &contentString{toUTF16(s)},
+	}
+	if left != nil {
+		o := left.lastID()
+		it.origin = &o
+	}
+	if right != nil {
+		r := right.id
+		it.rightOrigin = &r
+	}
+
+	t.doc.integrateLocal(it)
+}
+
+// Delete removes n elements starting at index
+func (t *Type) Delete(index, n int) {
+	if n <= 0 {
+		return
+	}
+
+	_, it := t.position(index)
+
+	for ; it != nil && n > 0; it = it.right {
+		if it.deleted || !it.content.countable() {
+			continue
+		}
+		if n < it.length {
+			t.doc.split(it, n)
+		}
+		n -= it.length
+		t.doc.delete(it)
+	}
+
+	t.doc.collectGarbage()
+}
+
+// position returns the items around index, splitting the one it falls into
+func (t *Type) position(index int) (left, right *item) {
+	right = t.start
+
+	for right != nil && index > 0 {
+		if !right.deleted && right.content.countable() {
+			if index < right.length {
+				t.doc.split(right, index)
+			}
+			index -= right.length
+		}
+		left, right = right, right.right
+	}
+
+	return left, right
+}
+
+func (d *Doc) integrateLocal(it *item) {
+	it.length = it.content.length()
+	d.integrate(it, 0)
+	d.collectGarbage()
+}
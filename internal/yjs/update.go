@@ -0,0 +1,373 @@
+package yjs
+
+import (
+	"sort"
+)
+
+const (
+	infoOrigin      = 0x80
+	infoRightOrigin = 0x40
+	infoParentSub   = 0x20
+	infoRef         = 0x1f
+)
+
+// ApplyUpdate integrates an update in the v1 format. Structs that depend on
+// changes the document hasn't seen yet are kept and integrated once those
+// arrive, as Yjs does.
+func (d *Doc) ApplyUpdate(update []byte) error {
+	dec := NewDecoder(update)
+
+	structs, err := readStructs(dec)
+	if err != nil {
+		return err
+	}
+	deletes, err := readDeleteSet(dec)
+	if err != nil {
+		return err
+	}
+
+	d.integrateStructs(append(d.pending, structs...))
+	d.pendingDeletes = d.applyDeletes(append(d.pendingDeletes, deletes...))
+	d.collectGarbage()
+
+	return nil
+}
+
+// integrateStructs integrates the structs in causal order, whatever the order
+// of the clients in the update
+func (d *Doc) integrateStructs(structs []*item) {
+	queues := map[uint64][]*item{}
+	for _, it := range structs {
+		queues[it.id.Client] = append(queues[it.id.Client], it)
+	}
+	for _, q := range queues {
+		sort.SliceStable(q, func(i, j int) bool { return q[i].id.Clock < q[j].id.Clock })
+	}
+
+	for progress := true; progress; {
+		progress = false
+
+		for client, q := range queues {
+			for len(q) > 0 {
+				it := q[0]
+				state := d.state(client)
+
+				if it.id.Clock > state || d.missing(it) {
+					break
+				}
+				if it.id.Clock+it.length > state {
+					d.resolve(it)
+					d.integrate(it, state-it.id.Clock)
+					progress = true
+				}
+				q = q[1:]
+			}
+			queues[client] = q
+		}
+	}
+
+	d.pending = nil
+	for _, q := range queues {
+		d.pending = append(d.pending, q...)
+	}
+}
+
+func readID(dec *Decoder) (ID, error) {
+	client, err := dec.ReadVarUint()
+	if err != nil {
+		return ID{}, err
+	}
+	clock, err := dec.readLen()
+	return ID{client, clock}, err
+}
+
+func readStructs(dec *Decoder) ([]*item, error) {
+	var structs []*item
+
+	clients, err := dec.readLen()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < clients; i++ {
+		n, err := dec.readLen()
+		if err != nil {
+			return nil, err
+		}
+		client, err := dec.ReadVarUint()
+		if err != nil {
+			return nil, err
+		}
+		clock, err := dec.readLen()
+		if err != nil {
+			return nil, err
+		}
+
+		for j := 0; j < n; j++ {
+			info, err := dec.ReadUint8()
+			if err != nil {
+				return nil, err
+			}
+
+			switch info & infoRef {
+			case refGC:
+				length, err := dec.readLen()
+				if err != nil {
+					return nil, err
+				}
+				it := &item{id: ID{client, clock}, length: length}
+				it.toGC()
+				structs = append(structs, it)
+				clock += length
+
+			case refSkip:
+				// A gap in a merged update, there is nothing to integrate
+				length, err := dec.readLen()
+				if err != nil {
+					return nil, err
+				}
+				clock += length
+
+			default:
+				it, err := readItem(dec, ID{client, clock}, info)
+				if err != nil {
+					return nil, err
+				}
+				structs = append(structs, it)
+				clock += it.length
+			}
+		}
+	}
+
+	return structs, nil
+}
+
+func readItem(dec *Decoder, id ID, info byte) (*item, error) {
+	it := &item{id: id}
+
+	if info&infoOrigin != 0 {
+		o, err := readID(dec)
+		if err != nil {
+			return nil, err
+		}
+		it.origin = &o
+	}
+	if info&infoRightOrigin != 0 {
+		r, err := readID(dec)
+		if err != nil {
+			return nil, err
+		}
+		it.rightOrigin = &r
+	}
+
+	// The parent is only written when it can't be taken from a neighbour
+	if info&(infoOrigin|infoRightOrigin) == 0 {
+		isKey, err := dec.ReadVarUint()
+		if err != nil {
+			return nil, err
+		}
+		it.hasParent = true
+		if isKey == 1 {
+			if it.parentName, err = dec.ReadVarString(); err != nil {
+				return nil, err
+			}
+		} else {
+			p, err := readID(dec)
+			if err != nil {
+				return nil, err
+			}
+			it.parentID = &p
+		}
+
+		if info&infoParentSub != 0 {
+			sub, err := dec.ReadVarString()
+			if err != nil {
+				return nil, err
+			}
+			it.parentSub = &sub
+		}
+	}
+
+	c, err := readContent(dec, info&infoRef)
+	if err != nil {
+		return nil, err
+	}
+	it.content = c
+	it.length = c.length()
+
+	return it, nil
+}
+
+func readDeleteSet(dec *Decoder) ([]deleteRange, error) {
+	var ranges []deleteRange
+
+	clients, err := dec.readLen()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < clients; i++ {
+		client, err := dec.ReadVarUint()
+		if err != nil {
+			return nil, err
+		}
+		n, err := dec.readLen()
+		if err != nil {
+			return nil, err
+		}
+		for j := 0; j < n; j++ {
+			clock, err := dec.readLen()
+			if err != nil {
+				return nil, err
+			}
+			length, err := dec.readLen()
+			if err != nil {
+				return nil, err
+			}
+			if length > 0 {
+				ranges = append(ranges, deleteRange{client, clock, length})
+			}
+		}
+	}
+
+	return ranges, nil
+}
+
+// EncodeStateAsUpdate encodes what the document has beyond the given state
+// vector, or the whole document when it is nil, plus all deletions
+func (d *Doc) EncodeStateAsUpdate(sv map[uint64]int) []byte {
+	e := NewEncoder()
+
+	var clients []uint64
+	for _, client := range d.sortedClients() {
+		if d.state(client) > sv[client] {
+			clients = append(clients, client)
+		}
+	}
+
+	e.WriteVarUint(uint64(len(clients)))
+	for _, client := range clients {
+		structs := d.clients[client]
+		clock := max(sv[client], structs[0].id.Clock)
+		i := findIndex(structs, clock)
+
+		e.WriteVarUint(uint64(len(structs) - i))
+		e.WriteVarUint(client)
+		e.WriteVarUint(uint64(clock))
+
+		structs[i].write(e, clock-structs[i].id.Clock)
+		for _, it := range structs[i+1:] {
+			it.write(e, 0)
+		}
+	}
+
+	writeDeleteSet(e, d.deleteSet())
+
+	return e.Bytes()
+}
+
+func (it *item) write(e *Encoder, offset int) {
+	if it.gc {
+		e.WriteUint8(refGC)
+		e.WriteVarUint(uint64(it.length - offset))
+		return
+	}
+
+	origin := it.origin
+	if offset > 0 {
+		origin = &ID{it.id.Client, it.id.Clock + offset - 1}
+	}
+
+	info := it.content.ref()
+	if origin != nil {
+		info |= infoOrigin
+	}
+	if it.rightOrigin != nil {
+		info |= infoRightOrigin
+	}
+	if it.parentSub != nil {
+		info |= infoParentSub
+	}
+	e.WriteUint8(info)
+
+	if origin != nil {
+		writeID(e, *origin)
+	}
+	if it.rightOrigin != nil {
+		writeID(e, *it.rightOrigin)
+	}
+	if origin == nil && it.rightOrigin == nil {
+		if it.parent.item == nil {
+			e.WriteVarUint(1)
+			e.WriteVarString(it.parent.name)
+		} else {
+			e.WriteVarUint(0)
+			writeID(e, it.parent.item.id)
+		}
+		if it.parentSub != nil {
+			e.WriteVarString(*it.parentSub)
+		}
+	}
+
+	it.content.write(e, offset)
+}
+
+func writeID(e *Encoder, id ID) {
+	e.WriteVarUint(id.Client)
+	e.WriteVarUint(uint64(id.Clock))
+}
+
+func writeDeleteSet(e *Encoder, ranges []deleteRange) {
+	var clients []uint64
+	byClient := map[uint64][]deleteRange{}
+	for _, r := range ranges {
+		if byClient[r.client] == nil {
+			clients = append(clients, r.client)
+		}
+		byClient[r.client] = append(byClient[r.client], r)
+	}
+
+	e.WriteVarUint(uint64(len(clients)))
+	for _, client := range clients {
+		e.WriteVarUint(client)
+		e.WriteVarUint(uint64(len(byClient[client])))
+		for _, r := range byClient[client] {
+			e.WriteVarUint(uint64(r.clock))
+			e.WriteVarUint(uint64(r.length))
+		}
+	}
+}
+
+// EncodeStateVector encodes the document's state vector for a sync step 1
+func (d *Doc) EncodeStateVector() []byte {
+	e := NewEncoder()
+
+	clients := d.sortedClients()
+	e.WriteVarUint(uint64(len(clients)))
+	for _, client := range clients {
+		e.WriteVarUint(client)
+		e.WriteVarUint(uint64(d.state(client)))
+	}
+
+	return e.Bytes()
+}
+
+func DecodeStateVector(b []byte) (map[uint64]int, error) {
+	dec := NewDecoder(b)
+
+	n, err := dec.readLen()
+	if err != nil {
+		return nil, err
+	}
+
+	sv := map[uint64]int{}
+	for i := 0; i < n; i++ {
+		id, err := readID(dec)
+		if err != nil {
+			return nil, err
+		}
+		sv[id.Client] = id.Clock
+	}
+
+	return sv, nil
+}
@@ -0,0 +1,258 @@
+package yjs
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// Fixtures are updates in the v1 format as Yjs writes them, byte for byte,
+// for the edits described above each one. They follow Item.write and
+// writeStructs in yjs/src/utils/encoding.js: clients in descending order,
+// an info byte of content ref | 0x80 origin | 0x40 right origin | 0x20
+// parent sub, the parent only for items without origins, and strings as
+// UTF-8 with their byte length.
+var (
+	// doc.clientID = 1; doc.getText('t').insert(0, 'abc')
+	fixtureInsertABC = []byte{
+		1,       // one client
+		1, 1, 0, // one struct of client 1 from clock 0
+		0x04,      // string content, no origins
+		1, 1, 't', // parent is the root type "t"
+		3, 'a', 'b', 'c',
+		0, // no deletions
+	}
+
+	// doc.clientID = 2, after fixtureInsertABC; text.insert(2, 'X')
+	fixtureInsertX = []byte{
+		1,
+		1, 2, 0,
+		0xc4, // string content between its origins
+		1, 1, // origin: client 1 clock 1 ("b")
+		1, 2, // right origin: client 1 clock 2 ("c")
+		1, 'X',
+		0,
+	}
+
+	// doc.clientID = 1, after both; text.delete(0, 1)
+	fixtureDeleteA = []byte{
+		0,          // no structs
+		1,          // deletions of one client
+		1, 1, 0, 1, // client 1, one range, clock 0, length 1
+	}
+
+	// doc.clientID = 1; doc.getMap('m').set('title', 'hi')
+	fixtureMapSet = []byte{
+		1,
+		1, 1, 0,
+		0x28,      // any content with a parent sub
+		1, 1, 'm', // parent is the root type "m"
+		5, 't', 'i', 't', 'l', 'e', // key
+		1, 119, 2, 'h', 'i', // one value, a string
+		0,
+	}
+
+	// doc.clientID = 1, after fixtureMapSet; map.set('title', 'yo'). An
+	// item with an origin has the parent sub bit but no parent or key.
+	fixtureMapOverwrite = []byte{
+		1,
+		1, 1, 1,
+		0xa8,
+		1, 0, // origin: the previous value
+		1, 119, 2, 'y', 'o',
+		1, 1, 1, 0, 1, // the previous value is deleted
+	}
+
+	// doc.clientID = 3; doc.getText('t').insert(0, 'a😀b')
+	fixtureSurrogates = []byte{
+		1,
+		1, 3, 0,
+		0x04,
+		1, 1, 't',
+		6, 'a', 0xf0, 0x9f, 0x98, 0x80, 'b',
+		0,
+	}
+)
+
+func apply(t *testing.T, d *Doc, updates ...[]byte) {
+	t.Helper()
+	for _, u := range updates {
+		if err := d.ApplyUpdate(u); err != nil {
+			t.Fatalf("ApplyUpdate(%v): %v", u, err)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for name, fixture := range map[string][]byte{
+		"text":       fixtureInsertABC,
+		"map":        fixtureMapSet,
+		"surrogates": fixtureSurrogates,
+	} {
+		t.Run(name, func(t *testing.T) {
+			d := NewDoc()
+			apply(t, d, fixture)
+			if got := d.EncodeStateAsUpdate(nil); !bytes.Equal(got, fixture) {
+				t.Errorf("EncodeStateAsUpdate() = %v, want %v", got, fixture)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	d := NewDoc()
+	apply(t, d, fixtureInsertABC, fixtureInsertX)
+	if got := d.Get("t").String(); got != "abXc" {
+		t.Errorf("text = %q, want %q", got, "abXc")
+	}
+
+	m := NewDoc()
+	apply(t, m, fixtureMapSet)
+	if v, ok := m.Get("m").Get("title"); !ok || v != "hi" {
+		t.Errorf("title = %v, %v, want hi", v, ok)
+	}
+
+	s := NewDoc()
+	apply(t, s, fixtureSurrogates)
+	if got, n := s.Get("t").String(), s.Get("t").Len(); got != "a😀b" || n != 4 {
+		t.Errorf("text = %q of length %d, want %q of length 4", got, n, "a😀b")
+	}
+}
+
+func TestMapOverwrite(t *testing.T) {
+	d := NewDoc()
+	apply(t, d, fixtureMapSet, fixtureMapOverwrite)
+	if v, _ := d.Get("m").Get("title"); v != "yo" {
+		t.Errorf("title = %v, want yo", v)
+	}
+	if keys := d.Get("m").Keys(); !reflect.DeepEqual(keys, []string{"title"}) {
+		t.Errorf("Keys() = %v", keys)
+	}
+
+	want := []byte{
+		1,
+		2, 1, 0,
+		0x21, 1, 1, 'm', 5, 't', 'i', 't', 'l', 'e', 1, // deleted content
+		0xa8, 1, 0, 1, 119, 2, 'y', 'o',
+		1, 1, 1, 0, 1,
+	}
+	if got := d.EncodeStateAsUpdate(nil); !bytes.Equal(got, want) {
+		t.Errorf("EncodeStateAsUpdate() = %v, want %v", got, want)
+	}
+}
+
+func TestEncodeSplitAndDeletedItems(t *testing.T) {
+	d := NewDoc()
+	apply(t, d, fixtureInsertABC, fixtureInsertX, fixtureDeleteA)
+	if got := d.Get("t").String(); got != "bXc" {
+		t.Fatalf("text = %q, want %q", got, "bXc")
+	}
+
+	// "abc" is split around "X" and "a" is garbage collected to deleted
+	// content, which keeps its length
+	want := []byte{
+		2,
+		1, 2, 0, 0xc4, 1, 1, 1, 2, 1, 'X',
+		3, 1, 0,
+		0x01, 1, 1, 't', 1, // deleted content of length 1
+		0x84, 1, 0, 1, 'b', // origin: client 1 clock 0
+		0x84, 1, 1, 1, 'c', // origin: client 1 clock 1
+		1, 1, 1, 0, 1,
+	}
+	if got := d.EncodeStateAsUpdate(nil); !bytes.Equal(got, want) {
+		t.Errorf("EncodeStateAsUpdate() = %v, want %v", got, want)
+	}
+
+	// What another document is missing
+	sv := map[uint64]int{1: 3}
+	diff := []byte{1, 1, 2, 0, 0xc4, 1, 1, 1, 2, 1, 'X', 1, 1, 1, 0, 1}
+	if got := d.EncodeStateAsUpdate(sv); !bytes.Equal(got, diff) {
+		t.Errorf("EncodeStateAsUpdate(%v) = %v, want %v", sv, got, diff)
+	}
+
+	e := NewDoc()
+	apply(t, e, d.EncodeStateAsUpdate(nil))
+	if got := e.Get("t").String(); got != "bXc" {
+		t.Errorf("text after re-encoding = %q, want %q", got, "bXc")
+	}
+}
+
+func TestPendingStructs(t *testing.T) {
+	d := NewDoc()
+
+	// "X" refers to "abc", which hasn't arrived, and so does the deletion
+	apply(t, d, fixtureInsertX, fixtureDeleteA)
+	if got := d.Get("t").String(); got != "" {
+		t.Errorf("text = %q before its dependencies arrived, want it empty", got)
+	}
+	if sv := d.StateVector(); len(sv) != 0 {
+		t.Errorf("StateVector() = %v, want pending structs left out", sv)
+	}
+
+	apply(t, d, fixtureInsertABC)
+	if got := d.Get("t").String(); got != "bXc" {
+		t.Errorf("text = %q, want %q", got, "bXc")
+	}
+	if sv, want := d.StateVector(), map[uint64]int{1: 3, 2: 1}; !reflect.DeepEqual(sv, want) {
+		t.Errorf("StateVector() = %v, want %v", sv, want)
+	}
+}
+
+func TestDuplicateUpdates(t *testing.T) {
+	d := NewDoc()
+	apply(t, d, fixtureInsertABC, fixtureInsertX, fixtureInsertABC, fixtureInsertX)
+	if got := d.Get("t").String(); got != "abXc" {
+		t.Errorf("text = %q, want %q", got, "abXc")
+	}
+}
+
+func TestStateVector(t *testing.T) {
+	d := NewDoc()
+	apply(t, d, fixtureInsertABC, fixtureInsertX)
+
+	// clients in descending order, each with its next clock
+	want := []byte{2, 2, 1, 1, 3}
+	if got := d.EncodeStateVector(); !bytes.Equal(got, want) {
+		t.Fatalf("EncodeStateVector() = %v, want %v", got, want)
+	}
+	sv, err := DecodeStateVector(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sv, map[uint64]int{1: 3, 2: 1}) {
+		t.Errorf("DecodeStateVector() = %v", sv)
+	}
+}
+
+func TestLocalEditsSyncToAnotherDoc(t *testing.T) {
+	d := NewDoc()
+	d.ClientID = 7
+	apply(t, d, fixtureInsertABC)
+	before := d.StateVector()
+
+	text := d.Get("t")
+	text.Insert(1, "😀")
+	text.Delete(3, 1)
+	d.Get("m").Set("title", "hello")
+
+	e := NewDoc()
+	apply(t, e, fixtureInsertABC, d.EncodeStateAsUpdate(before))
+	if got := e.Get("t").String(); got != "a😀c" {
+		t.Errorf("text = %q, want %q", got, "a😀c")
+	}
+	if v, _ := e.Get("m").Get("title"); v != "hello" {
+		t.Errorf("title = %v, want hello", v)
+	}
+}
+
+func TestInvalidUpdates(t *testing.T) {
+	for _, u := range [][]byte{
+		fixtureInsertABC[:len(fixtureInsertABC)-4],
+		{1, 1, 1, 0, 0x1f},
+		{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+	} {
+		if err := NewDoc().ApplyUpdate(u); err == nil {
+			t.Errorf("ApplyUpdate(%v) = nil, want an error", u)
+		}
+	}
+}
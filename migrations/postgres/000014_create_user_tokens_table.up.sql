@@ -0,0 +1,14 @@
+CREATE TABLE user_tokens (
+    id VARCHAR(255),
+    user_id VARCHAR(255) NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    token_hash VARCHAR(255) NOT NULL,
+    expires_at TEXT NOT NULL,
+    used_at TEXT,
+    created_at TEXT NOT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_user_tokens_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT uni_user_tokens_token_hash UNIQUE (token_hash)
+);
+
+CREATE INDEX idx_user_tokens_user_id ON user_tokens (user_id);
@@ -0,0 +1,18 @@
+CREATE TABLE workspace_invitations (
+    id VARCHAR(255),
+    workspace_id VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(50) NOT NULL,
+    token_hash VARCHAR(255) NOT NULL,
+    expires_at TEXT NOT NULL,
+    accepted_at TEXT,
+    accepted_by VARCHAR(255),
+    created_at TEXT NOT NULL,
+    created_by VARCHAR(255) NOT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_workspace_invitations_workspace FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
+    CONSTRAINT uni_workspace_invitations_token_hash UNIQUE (token_hash)
+);
+
+CREATE INDEX idx_workspace_invitations_workspace_id ON workspace_invitations (workspace_id);
+CREATE INDEX idx_workspace_invitations_email ON workspace_invitations (email);
@@ -0,0 +1,18 @@
+CREATE TABLE workspace_invite_links (
+    id VARCHAR(255),
+    workspace_id VARCHAR(255) NOT NULL,
+    role VARCHAR(50) NOT NULL,
+    token_hash VARCHAR(255) NOT NULL,
+    prefix VARCHAR(50) NOT NULL,
+    allowed_domain VARCHAR(255),
+    max_uses INTEGER DEFAULT 0,
+    use_count INTEGER DEFAULT 0,
+    expires_at TEXT,
+    created_at TEXT NOT NULL,
+    created_by VARCHAR(255) NOT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_workspace_invite_links_workspace FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
+    CONSTRAINT uni_workspace_invite_links_token_hash UNIQUE (token_hash)
+);
+
+CREATE INDEX idx_workspace_invite_links_workspace_id ON workspace_invite_links (workspace_id);
@@ -0,0 +1,3 @@
+ALTER TABLE api_keys DROP COLUMN rate_limit;
+DROP TABLE IF EXISTS rate_limit_failures;
+DROP TABLE IF EXISTS rate_limit_buckets;
@@ -0,0 +1,19 @@
+CREATE TABLE rate_limit_buckets (
+    id VARCHAR(255),
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at BIGINT NOT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE rate_limit_failures (
+    id VARCHAR(255),
+    failures INTEGER NOT NULL,
+    locked_until BIGINT NOT NULL DEFAULT 0,
+    updated_at BIGINT NOT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX idx_rate_limit_buckets_updated_at ON rate_limit_buckets (updated_at);
+CREATE INDEX idx_rate_limit_failures_updated_at ON rate_limit_failures (updated_at);
+
+ALTER TABLE api_keys ADD COLUMN rate_limit INTEGER DEFAULT 0;
@@ -0,0 +1,20 @@
+CREATE TABLE audit_events (
+    id VARCHAR(255),
+    workspace_id VARCHAR(255),
+    actor_id VARCHAR(255),
+    actor_name VARCHAR(255),
+    action VARCHAR(100) NOT NULL,
+    target_type VARCHAR(100),
+    target_id VARCHAR(255),
+    before TEXT,
+    after TEXT,
+    ip VARCHAR(100),
+    user_agent TEXT,
+    created_at TEXT NOT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX idx_audit_events_workspace_id ON audit_events (workspace_id);
+CREATE INDEX idx_audit_events_actor_id ON audit_events (actor_id);
+CREATE INDEX idx_audit_events_target ON audit_events (target_type, target_id);
+CREATE INDEX idx_audit_events_created_at ON audit_events (created_at);
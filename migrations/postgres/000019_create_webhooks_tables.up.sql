@@ -0,0 +1,40 @@
+CREATE TABLE webhooks (
+    id VARCHAR(255),
+    workspace_id VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT NOT NULL,
+    enabled BOOLEAN DEFAULT TRUE,
+    created_at TEXT NOT NULL,
+    created_by VARCHAR(255) NOT NULL,
+    updated_at TEXT,
+    updated_by VARCHAR(255),
+    PRIMARY KEY (id),
+    CONSTRAINT fk_webhooks_workspace FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhooks_workspace_id ON webhooks (workspace_id);
+
+CREATE TABLE webhook_deliveries (
+    id VARCHAR(255),
+    webhook_id VARCHAR(255) NOT NULL,
+    workspace_id VARCHAR(255) NOT NULL,
+    event VARCHAR(100) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    attempts INTEGER DEFAULT 0,
+    next_attempt_at TEXT,
+    last_attempt_at TEXT,
+    response_status INTEGER DEFAULT 0,
+    response_body TEXT,
+    error TEXT,
+    duration_ms INTEGER DEFAULT 0,
+    redelivery_of VARCHAR(255),
+    created_at TEXT NOT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_webhook_deliveries_webhook FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id);
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
@@ -0,0 +1,19 @@
+CREATE TABLE notifications (
+    id VARCHAR(255),
+    user_id VARCHAR(255) NOT NULL,
+    workspace_id VARCHAR(255),
+    type VARCHAR(100) NOT NULL,
+    actor_id VARCHAR(255),
+    actor_name VARCHAR(255),
+    target_type VARCHAR(100),
+    target_id VARCHAR(255),
+    message TEXT NOT NULL,
+    read_at TEXT,
+    emailed_at TEXT,
+    created_at TEXT NOT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_notifications_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_notifications_user_id ON notifications (user_id, created_at);
+CREATE INDEX idx_notifications_unread ON notifications (user_id, read_at);
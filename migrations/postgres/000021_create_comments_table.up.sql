@@ -0,0 +1,22 @@
+CREATE TABLE comments (
+    id VARCHAR(255),
+    workspace_id VARCHAR(255) NOT NULL,
+    target_type VARCHAR(50) NOT NULL,
+    target_id VARCHAR(255) NOT NULL,
+    view_id VARCHAR(255),
+    block_id VARCHAR(255),
+    thread_id VARCHAR(255),
+    content TEXT NOT NULL,
+    resolved_at TEXT,
+    resolved_by VARCHAR(255),
+    created_at TEXT NOT NULL,
+    created_by VARCHAR(255) NOT NULL,
+    updated_at TEXT,
+    updated_by VARCHAR(255),
+    PRIMARY KEY (id),
+    CONSTRAINT fk_comments_workspace FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_comments_target ON comments (target_type, target_id);
+CREATE INDEX idx_comments_thread_id ON comments (thread_id);
+CREATE INDEX idx_comments_view_id ON comments (view_id);
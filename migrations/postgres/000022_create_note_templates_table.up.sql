@@ -0,0 +1,18 @@
+CREATE TABLE note_templates (
+    id VARCHAR(255),
+    workspace_id VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    title TEXT,
+    format VARCHAR(50) NOT NULL,
+    content TEXT NOT NULL,
+    prompts TEXT,
+    created_at TEXT NOT NULL,
+    created_by VARCHAR(255) NOT NULL,
+    updated_at TEXT,
+    updated_by VARCHAR(255),
+    PRIMARY KEY (id),
+    CONSTRAINT fk_note_templates_workspace FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_note_templates_workspace_id ON note_templates (workspace_id);
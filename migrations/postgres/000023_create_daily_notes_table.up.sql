@@ -0,0 +1,12 @@
+CREATE TABLE daily_notes (
+    workspace_id VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    date VARCHAR(10) NOT NULL,
+    note_id VARCHAR(255) NOT NULL,
+    created_at TEXT NOT NULL,
+    PRIMARY KEY (workspace_id, user_id, date),
+    CONSTRAINT fk_daily_notes_workspace FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
+    CONSTRAINT fk_daily_notes_note FOREIGN KEY (note_id) REFERENCES notes(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_daily_notes_note_id ON daily_notes (note_id);
@@ -0,0 +1,30 @@
+CREATE TABLE tasks (
+    id VARCHAR(255),
+    workspace_id VARCHAR(255) NOT NULL,
+    note_id VARCHAR(255) NOT NULL,
+    position INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    checked BOOLEAN DEFAULT FALSE,
+    due_date VARCHAR(10),
+    assignee_id VARCHAR(255),
+    tags TEXT,
+    created_at TEXT NOT NULL,
+    updated_at TEXT,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_tasks_workspace FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
+    CONSTRAINT fk_tasks_note FOREIGN KEY (note_id) REFERENCES notes(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_tasks_workspace_id ON tasks (workspace_id, due_date);
+CREATE INDEX idx_tasks_note_id ON tasks (note_id);
+CREATE INDEX idx_tasks_assignee_id ON tasks (assignee_id);
+
+CREATE TABLE task_indices (
+    note_id VARCHAR(255),
+    workspace_id VARCHAR(255) NOT NULL,
+    note_updated_at TEXT,
+    PRIMARY KEY (note_id),
+    CONSTRAINT fk_task_indices_note FOREIGN KEY (note_id) REFERENCES notes(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_task_indices_workspace_id ON task_indices (workspace_id);
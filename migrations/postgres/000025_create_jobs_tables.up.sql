@@ -0,0 +1,26 @@
+CREATE TABLE jobs (
+    id VARCHAR(255),
+    type VARCHAR(255) NOT NULL,
+    key VARCHAR(255),
+    payload TEXT,
+    status VARCHAR(50) NOT NULL,
+    attempts INTEGER DEFAULT 0,
+    max_attempts INTEGER DEFAULT 1,
+    run_at TEXT,
+    last_run_at TEXT,
+    error TEXT,
+    created_at TEXT NOT NULL,
+    updated_at TEXT,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX idx_jobs_due ON jobs (status, run_at);
+CREATE INDEX idx_jobs_key ON jobs (key);
+
+CREATE TABLE job_schedules (
+    name VARCHAR(255),
+    spec VARCHAR(255) NOT NULL,
+    next_run_at TEXT NOT NULL,
+    last_run_at TEXT,
+    PRIMARY KEY (name)
+);
@@ -0,0 +1,23 @@
+CREATE TABLE reminders (
+    id VARCHAR(255),
+    workspace_id VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    target_type VARCHAR(50) NOT NULL,
+    target_id VARCHAR(255) NOT NULL,
+    view_id VARCHAR(255),
+    remind_at TEXT NOT NULL,
+    timezone VARCHAR(255),
+    minutes_before INTEGER,
+    channel VARCHAR(50) NOT NULL,
+    message TEXT,
+    fired_at TEXT,
+    created_at TEXT NOT NULL,
+    updated_at TEXT,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_reminders_workspace FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
+    CONSTRAINT fk_reminders_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_reminders_user_id ON reminders (user_id, remind_at);
+CREATE INDEX idx_reminders_target ON reminders (target_type, target_id);
+CREATE INDEX idx_reminders_view_id ON reminders (view_id);
@@ -0,0 +1,17 @@
+CREATE TABLE calendar_feeds (
+    id VARCHAR(255),
+    workspace_id VARCHAR(255) NOT NULL,
+    view_id VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    token_hash VARCHAR(255) NOT NULL,
+    prefix VARCHAR(50) NOT NULL,
+    last_used_at TEXT,
+    created_at TEXT NOT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_calendar_feeds_workspace FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
+    CONSTRAINT fk_calendar_feeds_view FOREIGN KEY (view_id) REFERENCES views(id) ON DELETE CASCADE,
+    CONSTRAINT fk_calendar_feeds_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX idx_calendar_feeds_token_hash ON calendar_feeds (token_hash);
+CREATE UNIQUE INDEX idx_calendar_feeds_view_user ON calendar_feeds (view_id, user_id);
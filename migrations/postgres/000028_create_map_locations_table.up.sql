@@ -0,0 +1,18 @@
+CREATE TABLE map_locations (
+    view_object_id VARCHAR(255),
+    view_id VARCHAR(255) NOT NULL,
+    lat DOUBLE PRECISION NOT NULL,
+    lng DOUBLE PRECISION NOT NULL,
+    min_lat DOUBLE PRECISION NOT NULL,
+    min_lng DOUBLE PRECISION NOT NULL,
+    max_lat DOUBLE PRECISION NOT NULL,
+    max_lng DOUBLE PRECISION NOT NULL,
+    geohash VARCHAR(12),
+    object_updated_at TEXT,
+    PRIMARY KEY (view_object_id),
+    CONSTRAINT fk_map_locations_view_object FOREIGN KEY (view_object_id) REFERENCES view_objects(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_map_locations_lat ON map_locations (view_id, min_lat, max_lat);
+CREATE INDEX idx_map_locations_lng ON map_locations (view_id, min_lng, max_lng);
+CREATE INDEX idx_map_locations_geohash ON map_locations (view_id, geohash);
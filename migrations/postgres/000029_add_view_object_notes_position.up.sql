@@ -0,0 +1,2 @@
+ALTER TABLE view_object_notes ADD COLUMN position TEXT COLLATE "C" NOT NULL DEFAULT '';
+ALTER TABLE view_object_notes ADD COLUMN completed_at TEXT NOT NULL DEFAULT '';
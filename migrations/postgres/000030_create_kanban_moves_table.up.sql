@@ -0,0 +1,13 @@
+CREATE TABLE kanban_moves (
+    id VARCHAR(255),
+    view_id VARCHAR(255) NOT NULL,
+    note_id VARCHAR(255) NOT NULL,
+    from_column_id VARCHAR(255) NOT NULL DEFAULT '',
+    to_column_id VARCHAR(255) NOT NULL DEFAULT '',
+    moved_at TEXT NOT NULL,
+    moved_by VARCHAR(255) NOT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_kanban_moves_view FOREIGN KEY (view_id) REFERENCES views(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_kanban_moves_view_moved_at ON kanban_moves (view_id, moved_at);
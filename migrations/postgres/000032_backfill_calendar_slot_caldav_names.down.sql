@@ -0,0 +1,16 @@
+DO $$
+DECLARE
+    r RECORD;
+    d JSONB;
+BEGIN
+    FOR r IN SELECT id, data FROM view_objects WHERE type = 'calendar_slot' LOOP
+        BEGIN
+            d := r.data::jsonb;
+        EXCEPTION WHEN others THEN
+            CONTINUE;
+        END;
+        IF jsonb_typeof(d) = 'object' AND d ? 'caldav_name' THEN
+            UPDATE view_objects SET data = (d - 'caldav_name')::text WHERE id = r.id;
+        END IF;
+    END LOOP;
+END $$;
@@ -0,0 +1,19 @@
+-- Calendar apps saw slots with a UID under that UID. The resource name is
+-- stored apart from the UID now, so keep the names they already know. Data
+-- that isn't JSON is left alone.
+DO $$
+DECLARE
+    r RECORD;
+    d JSONB;
+BEGIN
+    FOR r IN SELECT id, data FROM view_objects WHERE type = 'calendar_slot' LOOP
+        BEGIN
+            d := r.data::jsonb;
+        EXCEPTION WHEN others THEN
+            CONTINUE;
+        END;
+        IF jsonb_typeof(d) = 'object' AND COALESCE(d->>'uid', '') <> '' AND NOT d ? 'caldav_name' THEN
+            UPDATE view_objects SET data = jsonb_set(d, '{caldav_name}', d->'uid')::text WHERE id = r.id;
+        END IF;
+    END LOOP;
+END $$;
@@ -0,0 +1,13 @@
+CREATE TABLE mention_indices (
+    note_id VARCHAR(255),
+    workspace_id VARCHAR(255) NOT NULL,
+    user_ids TEXT,
+    known BOOLEAN DEFAULT FALSE,
+    PRIMARY KEY (note_id),
+    CONSTRAINT fk_mention_indices_note FOREIGN KEY (note_id) REFERENCES notes(id) ON DELETE CASCADE
+);
+
+-- Mentions in existing notes were notified when they were made, so the next
+-- change only records them
+INSERT INTO mention_indices (note_id, workspace_id, user_ids, known)
+SELECT id, workspace_id, '', FALSE FROM notes;
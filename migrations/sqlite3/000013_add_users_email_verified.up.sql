@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN email_verified integer DEFAULT 0;
+UPDATE users SET email_verified = 1;
@@ -0,0 +1,14 @@
+CREATE TABLE `user_tokens` (
+    `id` text,
+    `user_id` text NOT NULL,
+    `type` text NOT NULL,
+    `token_hash` text NOT NULL,
+    `expires_at` text NOT NULL,
+    `used_at` text,
+    `created_at` text NOT NULL,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_user_tokens_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE,
+    CONSTRAINT `uni_user_tokens_token_hash` UNIQUE (`token_hash`)
+);
+
+CREATE INDEX `idx_user_tokens_user_id` ON `user_tokens` (`user_id`);
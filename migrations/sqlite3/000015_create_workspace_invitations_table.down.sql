@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS `idx_workspace_invitations_email`;
+DROP INDEX IF EXISTS `idx_workspace_invitations_workspace_id`;
+DROP TABLE IF EXISTS `workspace_invitations`;
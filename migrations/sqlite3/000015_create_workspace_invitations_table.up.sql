@@ -0,0 +1,18 @@
+CREATE TABLE `workspace_invitations` (
+    `id` text,
+    `workspace_id` text NOT NULL,
+    `email` text NOT NULL,
+    `role` text NOT NULL,
+    `token_hash` text NOT NULL,
+    `expires_at` text NOT NULL,
+    `accepted_at` text,
+    `accepted_by` text,
+    `created_at` text NOT NULL,
+    `created_by` text NOT NULL,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_workspace_invitations_workspace` FOREIGN KEY (`workspace_id`) REFERENCES `workspaces`(`id`) ON DELETE CASCADE,
+    CONSTRAINT `uni_workspace_invitations_token_hash` UNIQUE (`token_hash`)
+);
+
+CREATE INDEX `idx_workspace_invitations_workspace_id` ON `workspace_invitations` (`workspace_id`);
+CREATE INDEX `idx_workspace_invitations_email` ON `workspace_invitations` (`email`);
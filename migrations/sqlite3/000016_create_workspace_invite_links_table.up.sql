@@ -0,0 +1,18 @@
+CREATE TABLE `workspace_invite_links` (
+    `id` text,
+    `workspace_id` text NOT NULL,
+    `role` text NOT NULL,
+    `token_hash` text NOT NULL,
+    `prefix` text NOT NULL,
+    `allowed_domain` text,
+    `max_uses` integer DEFAULT 0,
+    `use_count` integer DEFAULT 0,
+    `expires_at` text,
+    `created_at` text NOT NULL,
+    `created_by` text NOT NULL,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_workspace_invite_links_workspace` FOREIGN KEY (`workspace_id`) REFERENCES `workspaces`(`id`) ON DELETE CASCADE,
+    CONSTRAINT `uni_workspace_invite_links_token_hash` UNIQUE (`token_hash`)
+);
+
+CREATE INDEX `idx_workspace_invite_links_workspace_id` ON `workspace_invite_links` (`workspace_id`);
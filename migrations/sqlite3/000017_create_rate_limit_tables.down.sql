@@ -0,0 +1,5 @@
+ALTER TABLE `api_keys` DROP COLUMN `rate_limit`;
+DROP INDEX IF EXISTS `idx_rate_limit_failures_updated_at`;
+DROP INDEX IF EXISTS `idx_rate_limit_buckets_updated_at`;
+DROP TABLE IF EXISTS `rate_limit_failures`;
+DROP TABLE IF EXISTS `rate_limit_buckets`;
@@ -0,0 +1,20 @@
+CREATE TABLE `audit_events` (
+    `id` text,
+    `workspace_id` text,
+    `actor_id` text,
+    `actor_name` text,
+    `action` text NOT NULL,
+    `target_type` text,
+    `target_id` text,
+    `before` text,
+    `after` text,
+    `ip` text,
+    `user_agent` text,
+    `created_at` text NOT NULL,
+    PRIMARY KEY (`id`)
+);
+
+CREATE INDEX `idx_audit_events_workspace_id` ON `audit_events` (`workspace_id`);
+CREATE INDEX `idx_audit_events_actor_id` ON `audit_events` (`actor_id`);
+CREATE INDEX `idx_audit_events_target` ON `audit_events` (`target_type`, `target_id`);
+CREATE INDEX `idx_audit_events_created_at` ON `audit_events` (`created_at`);
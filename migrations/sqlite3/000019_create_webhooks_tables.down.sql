@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS `idx_webhook_deliveries_due`;
+DROP INDEX IF EXISTS `idx_webhook_deliveries_webhook_id`;
+DROP TABLE IF EXISTS `webhook_deliveries`;
+DROP INDEX IF EXISTS `idx_webhooks_workspace_id`;
+DROP TABLE IF EXISTS `webhooks`;
@@ -0,0 +1,40 @@
+CREATE TABLE `webhooks` (
+    `id` text,
+    `workspace_id` text NOT NULL,
+    `name` text NOT NULL,
+    `url` text NOT NULL,
+    `secret` text NOT NULL,
+    `events` text NOT NULL,
+    `enabled` integer DEFAULT 1,
+    `created_at` text NOT NULL,
+    `created_by` text NOT NULL,
+    `updated_at` text,
+    `updated_by` text,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_webhooks_workspace` FOREIGN KEY (`workspace_id`) REFERENCES `workspaces`(`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_webhooks_workspace_id` ON `webhooks` (`workspace_id`);
+
+CREATE TABLE `webhook_deliveries` (
+    `id` text,
+    `webhook_id` text NOT NULL,
+    `workspace_id` text NOT NULL,
+    `event` text NOT NULL,
+    `payload` text NOT NULL,
+    `status` text NOT NULL,
+    `attempts` integer DEFAULT 0,
+    `next_attempt_at` text,
+    `last_attempt_at` text,
+    `response_status` integer DEFAULT 0,
+    `response_body` text,
+    `error` text,
+    `duration_ms` integer DEFAULT 0,
+    `redelivery_of` text,
+    `created_at` text NOT NULL,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_webhook_deliveries_webhook` FOREIGN KEY (`webhook_id`) REFERENCES `webhooks`(`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_webhook_deliveries_webhook_id` ON `webhook_deliveries` (`webhook_id`);
+CREATE INDEX `idx_webhook_deliveries_due` ON `webhook_deliveries` (`status`, `next_attempt_at`);
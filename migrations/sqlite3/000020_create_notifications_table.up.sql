@@ -0,0 +1,19 @@
+CREATE TABLE `notifications` (
+    `id` text,
+    `user_id` text NOT NULL,
+    `workspace_id` text,
+    `type` text NOT NULL,
+    `actor_id` text,
+    `actor_name` text,
+    `target_type` text,
+    `target_id` text,
+    `message` text NOT NULL,
+    `read_at` text,
+    `emailed_at` text,
+    `created_at` text NOT NULL,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_notifications_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_notifications_user_id` ON `notifications` (`user_id`, `created_at`);
+CREATE INDEX `idx_notifications_unread` ON `notifications` (`user_id`, `read_at`);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS `idx_comments_view_id`;
+DROP INDEX IF EXISTS `idx_comments_thread_id`;
+DROP INDEX IF EXISTS `idx_comments_target`;
+DROP TABLE IF EXISTS `comments`;
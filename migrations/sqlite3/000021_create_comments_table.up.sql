@@ -0,0 +1,22 @@
+CREATE TABLE `comments` (
+    `id` text,
+    `workspace_id` text NOT NULL,
+    `target_type` text NOT NULL,
+    `target_id` text NOT NULL,
+    `view_id` text,
+    `block_id` text,
+    `thread_id` text,
+    `content` text NOT NULL,
+    `resolved_at` text,
+    `resolved_by` text,
+    `created_at` text NOT NULL,
+    `created_by` text NOT NULL,
+    `updated_at` text,
+    `updated_by` text,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_comments_workspace` FOREIGN KEY (`workspace_id`) REFERENCES `workspaces`(`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_comments_target` ON `comments` (`target_type`, `target_id`);
+CREATE INDEX `idx_comments_thread_id` ON `comments` (`thread_id`);
+CREATE INDEX `idx_comments_view_id` ON `comments` (`view_id`);
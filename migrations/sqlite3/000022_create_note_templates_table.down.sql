@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS `idx_note_templates_workspace_id`;
+DROP TABLE IF EXISTS `note_templates`;
@@ -0,0 +1,18 @@
+CREATE TABLE `note_templates` (
+    `id` text,
+    `workspace_id` text NOT NULL,
+    `name` text NOT NULL,
+    `description` text,
+    `title` text,
+    `format` text NOT NULL,
+    `content` text NOT NULL,
+    `prompts` text,
+    `created_at` text NOT NULL,
+    `created_by` text NOT NULL,
+    `updated_at` text,
+    `updated_by` text,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_note_templates_workspace` FOREIGN KEY (`workspace_id`) REFERENCES `workspaces`(`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_note_templates_workspace_id` ON `note_templates` (`workspace_id`);
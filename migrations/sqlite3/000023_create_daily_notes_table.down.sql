@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS `idx_daily_notes_note_id`;
+DROP TABLE IF EXISTS `daily_notes`;
@@ -0,0 +1,12 @@
+CREATE TABLE `daily_notes` (
+    `workspace_id` text NOT NULL,
+    `user_id` text NOT NULL,
+    `date` text NOT NULL,
+    `note_id` text NOT NULL,
+    `created_at` text NOT NULL,
+    PRIMARY KEY (`workspace_id`, `user_id`, `date`),
+    CONSTRAINT `fk_daily_notes_workspace` FOREIGN KEY (`workspace_id`) REFERENCES `workspaces`(`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_daily_notes_note` FOREIGN KEY (`note_id`) REFERENCES `notes`(`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_daily_notes_note_id` ON `daily_notes` (`note_id`);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS `idx_task_indices_workspace_id`;
+DROP TABLE IF EXISTS `task_indices`;
+DROP INDEX IF EXISTS `idx_tasks_assignee_id`;
+DROP INDEX IF EXISTS `idx_tasks_note_id`;
+DROP INDEX IF EXISTS `idx_tasks_workspace_id`;
+DROP TABLE IF EXISTS `tasks`;
@@ -0,0 +1,30 @@
+CREATE TABLE `tasks` (
+    `id` text,
+    `workspace_id` text NOT NULL,
+    `note_id` text NOT NULL,
+    `position` integer NOT NULL,
+    `text` text NOT NULL,
+    `checked` integer DEFAULT 0,
+    `due_date` text,
+    `assignee_id` text,
+    `tags` text,
+    `created_at` text NOT NULL,
+    `updated_at` text,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_tasks_workspace` FOREIGN KEY (`workspace_id`) REFERENCES `workspaces`(`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_tasks_note` FOREIGN KEY (`note_id`) REFERENCES `notes`(`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_tasks_workspace_id` ON `tasks` (`workspace_id`, `due_date`);
+CREATE INDEX `idx_tasks_note_id` ON `tasks` (`note_id`);
+CREATE INDEX `idx_tasks_assignee_id` ON `tasks` (`assignee_id`);
+
+CREATE TABLE `task_indices` (
+    `note_id` text,
+    `workspace_id` text NOT NULL,
+    `note_updated_at` text,
+    PRIMARY KEY (`note_id`),
+    CONSTRAINT `fk_task_indices_note` FOREIGN KEY (`note_id`) REFERENCES `notes`(`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_task_indices_workspace_id` ON `task_indices` (`workspace_id`);
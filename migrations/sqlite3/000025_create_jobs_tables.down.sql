@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS `job_schedules`;
+DROP INDEX IF EXISTS `idx_jobs_key`;
+DROP INDEX IF EXISTS `idx_jobs_due`;
+DROP TABLE IF EXISTS `jobs`;
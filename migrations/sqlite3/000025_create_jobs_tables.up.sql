@@ -0,0 +1,26 @@
+CREATE TABLE `jobs` (
+    `id` text,
+    `type` text NOT NULL,
+    `key` text,
+    `payload` text,
+    `status` text NOT NULL,
+    `attempts` integer DEFAULT 0,
+    `max_attempts` integer DEFAULT 1,
+    `run_at` text,
+    `last_run_at` text,
+    `error` text,
+    `created_at` text NOT NULL,
+    `updated_at` text,
+    PRIMARY KEY (`id`)
+);
+
+CREATE INDEX `idx_jobs_due` ON `jobs` (`status`, `run_at`);
+CREATE INDEX `idx_jobs_key` ON `jobs` (`key`);
+
+CREATE TABLE `job_schedules` (
+    `name` text,
+    `spec` text NOT NULL,
+    `next_run_at` text NOT NULL,
+    `last_run_at` text,
+    PRIMARY KEY (`name`)
+);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS `idx_reminders_view_id`;
+DROP INDEX IF EXISTS `idx_reminders_target`;
+DROP INDEX IF EXISTS `idx_reminders_user_id`;
+DROP TABLE IF EXISTS `reminders`;
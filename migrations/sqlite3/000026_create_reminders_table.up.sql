@@ -0,0 +1,23 @@
+CREATE TABLE `reminders` (
+    `id` text,
+    `workspace_id` text NOT NULL,
+    `user_id` text NOT NULL,
+    `target_type` text NOT NULL,
+    `target_id` text NOT NULL,
+    `view_id` text,
+    `remind_at` text NOT NULL,
+    `timezone` text,
+    `minutes_before` integer,
+    `channel` text NOT NULL,
+    `message` text,
+    `fired_at` text,
+    `created_at` text NOT NULL,
+    `updated_at` text,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_reminders_workspace` FOREIGN KEY (`workspace_id`) REFERENCES `workspaces`(`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_reminders_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_reminders_user_id` ON `reminders` (`user_id`, `remind_at`);
+CREATE INDEX `idx_reminders_target` ON `reminders` (`target_type`, `target_id`);
+CREATE INDEX `idx_reminders_view_id` ON `reminders` (`view_id`);
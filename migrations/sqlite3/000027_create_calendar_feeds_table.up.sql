@@ -0,0 +1,17 @@
+CREATE TABLE `calendar_feeds` (
+    `id` text,
+    `workspace_id` text NOT NULL,
+    `view_id` text NOT NULL,
+    `user_id` text NOT NULL,
+    `token_hash` text NOT NULL,
+    `prefix` text NOT NULL,
+    `last_used_at` text,
+    `created_at` text NOT NULL,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_calendar_feeds_workspace` FOREIGN KEY (`workspace_id`) REFERENCES `workspaces`(`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_calendar_feeds_view` FOREIGN KEY (`view_id`) REFERENCES `views`(`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_calendar_feeds_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX `idx_calendar_feeds_token_hash` ON `calendar_feeds` (`token_hash`);
+CREATE UNIQUE INDEX `idx_calendar_feeds_view_user` ON `calendar_feeds` (`view_id`, `user_id`);
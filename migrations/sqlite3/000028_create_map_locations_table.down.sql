@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS `idx_map_locations_geohash`;
+DROP INDEX IF EXISTS `idx_map_locations_lng`;
+DROP INDEX IF EXISTS `idx_map_locations_lat`;
+DROP TABLE IF EXISTS `map_locations`;
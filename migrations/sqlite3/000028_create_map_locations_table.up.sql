@@ -0,0 +1,18 @@
+CREATE TABLE `map_locations` (
+    `view_object_id` text,
+    `view_id` text NOT NULL,
+    `lat` real NOT NULL,
+    `lng` real NOT NULL,
+    `min_lat` real NOT NULL,
+    `min_lng` real NOT NULL,
+    `max_lat` real NOT NULL,
+    `max_lng` real NOT NULL,
+    `geohash` text,
+    `object_updated_at` text,
+    PRIMARY KEY (`view_object_id`),
+    CONSTRAINT `fk_map_locations_view_object` FOREIGN KEY (`view_object_id`) REFERENCES `view_objects`(`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_map_locations_lat` ON `map_locations` (`view_id`, `min_lat`, `max_lat`);
+CREATE INDEX `idx_map_locations_lng` ON `map_locations` (`view_id`, `min_lng`, `max_lng`);
+CREATE INDEX `idx_map_locations_geohash` ON `map_locations` (`view_id`, `geohash`);
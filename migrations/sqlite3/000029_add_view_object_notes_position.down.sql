@@ -0,0 +1,2 @@
+ALTER TABLE view_object_notes DROP COLUMN completed_at;
+ALTER TABLE view_object_notes DROP COLUMN position;
@@ -0,0 +1,13 @@
+CREATE TABLE `kanban_moves` (
+    `id` text,
+    `view_id` text NOT NULL,
+    `note_id` text NOT NULL,
+    `from_column_id` text NOT NULL DEFAULT '',
+    `to_column_id` text NOT NULL DEFAULT '',
+    `moved_at` text NOT NULL,
+    `moved_by` text NOT NULL,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_kanban_moves_view` FOREIGN KEY (`view_id`) REFERENCES `views`(`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_kanban_moves_view_moved_at` ON `kanban_moves` (`view_id`, `moved_at`);
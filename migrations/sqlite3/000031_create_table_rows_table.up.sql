@@ -0,0 +1,14 @@
+CREATE TABLE `table_rows` (
+    `view_id` text NOT NULL,
+    `note_id` text NOT NULL,
+    `values` text NOT NULL DEFAULT '{}',
+    `created_at` text NOT NULL,
+    `created_by` text NOT NULL,
+    `updated_at` text NOT NULL,
+    `updated_by` text NOT NULL,
+    PRIMARY KEY (`view_id`, `note_id`),
+    CONSTRAINT `fk_table_rows_view` FOREIGN KEY (`view_id`) REFERENCES `views`(`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_table_rows_note` FOREIGN KEY (`note_id`) REFERENCES `notes`(`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_table_rows_note` ON `table_rows` (`note_id`);
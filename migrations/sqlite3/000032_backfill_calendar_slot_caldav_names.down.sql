@@ -0,0 +1,4 @@
+UPDATE `view_objects`
+SET `data` = json_remove(`data`, '$.caldav_name')
+WHERE `type` = 'calendar_slot'
+  AND CASE WHEN json_valid(`data`) THEN json_type(`data`, '$.caldav_name') IS NOT NULL ELSE 0 END;
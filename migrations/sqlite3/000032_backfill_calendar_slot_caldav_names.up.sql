@@ -0,0 +1,10 @@
+-- Calendar apps saw slots with a UID under that UID. The resource name is
+-- stored apart from the UID now, so keep the names they already know.
+UPDATE `view_objects`
+SET `data` = json_set(`data`, '$.caldav_name', json_extract(`data`, '$.uid'))
+WHERE `type` = 'calendar_slot'
+  AND CASE
+    WHEN json_valid(`data`) AND json_type(`data`) = 'object' THEN
+      COALESCE(json_extract(`data`, '$.uid'), '') <> '' AND json_type(`data`, '$.caldav_name') IS NULL
+    ELSE 0
+  END;
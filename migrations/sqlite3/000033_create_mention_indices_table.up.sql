@@ -0,0 +1,13 @@
+CREATE TABLE `mention_indices` (
+    `note_id` text,
+    `workspace_id` text NOT NULL,
+    `user_ids` text,
+    `known` integer DEFAULT 0,
+    PRIMARY KEY (`note_id`),
+    CONSTRAINT `fk_mention_indices_note` FOREIGN KEY (`note_id`) REFERENCES `notes`(`id`) ON DELETE CASCADE
+);
+
+-- Mentions in existing notes were notified when they were made, so the next
+-- change only records them
+INSERT INTO `mention_indices` (`note_id`, `workspace_id`, `user_ids`, `known`)
+SELECT `id`, `workspace_id`, '', 0 FROM `notes`;
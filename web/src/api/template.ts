@@ -0,0 +1,47 @@
+import axios from 'axios';
+
+export interface NoteTemplatePrompt {
+  name: string;
+  label?: string;
+  default?: string;
+  required?: boolean;
+}
+
+export interface NoteTemplate {
+  id: string;
+  workspace_id: string;
+  name: string;
+  description: string;
+  title: string;
+  format: 'tiptap' | 'markdown';
+  content: string;
+  prompts: NoteTemplatePrompt[];
+  created_at: string;
+  created_by: string;
+  updated_at: string;
+  updated_by: string;
+}
+
+export interface RenderedNoteTemplate {
+  title: string;
+  content: string;
+}
+
+export const getNoteTemplates = async (workspaceId: string): Promise<NoteTemplate[]> => {
+  const response = await axios.get(`/api/v1/workspaces/${workspaceId}/templates`, { withCredentials: true });
+  return response.data;
+};
+
+export const renderNoteTemplate = async (
+  workspaceId: string,
+  templateId: string,
+  values?: Record<string, string>
+): Promise<RenderedNoteTemplate> => {
+  const timezoneOffset = -new Date().getTimezoneOffset();
+  const response = await axios.post(
+    `/api/v1/workspaces/${workspaceId}/templates/${templateId}/render?timezoneOffset=${timezoneOffset}`,
+    { values },
+    { withCredentials: true }
+  );
+  return response.data;
+};
@@ -1,5 +1,5 @@
 import axios from 'axios';
-import { View, CreateViewRequest, UpdateViewRequest, ViewType, ViewObject, CreateViewObjectRequest, UpdateViewObjectRequest, ViewObjectType, ViewObjectWithView } from '@/types/view';
+import { View, CreateViewRequest, UpdateViewRequest, ViewType, ViewObject, CreateViewObjectRequest, UpdateViewObjectRequest, ViewObjectType, ViewObjectWithView, TableRow, TableRowsResponse, TableGroupsResponse, TableValue, Timeline } from '@/types/view';
 
 export const getViews = async (workspaceId: string, pageNum: number = 1, pageSize: number = 100, type?: ViewType) => {
   const params = new URLSearchParams({
@@ -86,6 +86,69 @@ export const removeNoteFromViewObject = async (workspaceId: string, viewId: stri
   return response.data;
 };
 
+// Move a kanban card, i.e. a note of a column, after or before another card or
+// to the bottom of a column
+export const moveKanbanCard = async (workspaceId: string, viewId: string, fromColumnId: string, noteId: string, move: { column_id: string; after_note_id?: string; before_note_id?: string }) => {
+  const response = await axios.post(`/api/v1/workspaces/${workspaceId}/views/${viewId}/objects/${fromColumnId}/notes/${noteId}/move`, move);
+  return response.data;
+};
+
+// Table views
+export interface TableRowsQuery {
+  filters?: string[]; // property:operator:value
+  sort?: string; // property,-property
+  pageSize?: number;
+  pageNumber?: number;
+}
+
+const tableRowsParams = (query: TableRowsQuery, groupBy?: string) => {
+  const params = new URLSearchParams();
+  query.filters?.forEach((f) => params.append('filter', f));
+  if (query.sort) params.append('sort', query.sort);
+  if (groupBy) params.append('group_by', groupBy);
+  if (query.pageSize) params.append('pageSize', query.pageSize.toString());
+  if (query.pageNumber) params.append('pageNumber', query.pageNumber.toString());
+  return params;
+};
+
+export const getTableRows = async (workspaceId: string, viewId: string, query: TableRowsQuery = {}) => {
+  const response = await axios.get(`/api/v1/workspaces/${workspaceId}/views/${viewId}/rows?${tableRowsParams(query).toString()}`, { withCredentials: true });
+  return response.data as TableRowsResponse;
+};
+
+export const getTableGroups = async (workspaceId: string, viewId: string, groupBy: string, query: TableRowsQuery = {}) => {
+  const response = await axios.get(`/api/v1/workspaces/${workspaceId}/views/${viewId}/rows?${tableRowsParams(query, groupBy).toString()}`, { withCredentials: true });
+  return response.data as TableGroupsResponse;
+};
+
+export const addTableRow = async (workspaceId: string, viewId: string, noteId: string, values: Record<string, TableValue> = {}) => {
+  const response = await axios.post(`/api/v1/workspaces/${workspaceId}/views/${viewId}/rows`, { note_id: noteId, values }, { withCredentials: true });
+  return response.data as TableRow;
+};
+
+// A null value clears the property
+export const updateTableRow = async (workspaceId: string, viewId: string, noteId: string, values: Record<string, TableValue | null>) => {
+  const response = await axios.patch(`/api/v1/workspaces/${workspaceId}/views/${viewId}/rows/${noteId}`, { values }, { withCredentials: true });
+  return response.data as TableRow;
+};
+
+export const removeTableRow = async (workspaceId: string, viewId: string, noteId: string) => {
+  await axios.delete(`/api/v1/workspaces/${workspaceId}/views/${viewId}/rows/${noteId}`, { withCredentials: true });
+};
+
+// Timeline views
+export const getTimeline = async (workspaceId: string, viewId: string) => {
+  const response = await axios.get(`/api/v1/workspaces/${workspaceId}/views/${viewId}/timeline`, { withCredentials: true });
+  return response.data as Timeline;
+};
+
+// Moves an item and pushes the items that depend on it, or with shift moves
+// them all by as many days
+export const rescheduleTimelineItem = async (workspaceId: string, viewId: string, objectId: string, dates: { date: string; end_date?: string; shift?: boolean }) => {
+  const response = await axios.post(`/api/v1/workspaces/${workspaceId}/views/${viewId}/objects/${objectId}/reschedule`, dates, { withCredentials: true });
+  return response.data.items as ViewObject[];
+};
+
 // Get view objects for a note
 export const getViewObjectsForNote = async (workspaceId: string, noteId: string) => {
   const response = await axios.get(`/api/v1/workspaces/${workspaceId}/notes/${noteId}/view-objects`, { withCredentials: true });
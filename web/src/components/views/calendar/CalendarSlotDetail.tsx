@@ -97,7 +97,13 @@ const CalendarSlotDetail = () => {
     const handleSave = () => {
         const updatedData: CalendarSlotData = {
             date: editDate,
-            is_all_day: editIsAllDay
+            is_all_day: editIsAllDay,
+            // Keep the fields this form doesn't edit
+            timezone: slotData?.timezone,
+            rrule: slotData?.rrule,
+            exdates: slotData?.exdates,
+            uid: slotData?.uid,
+            caldav_name: slotData?.caldav_name
         }
         if (editEndDate) {
             updatedData.end_date = editEndDate
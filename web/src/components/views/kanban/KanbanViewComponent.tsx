@@ -2,7 +2,7 @@ import { useMemo, useState } from 'react'
 import { useNavigate, useParams } from 'react-router-dom'
 import { useQuery, useQueryClient, useMutation } from '@tanstack/react-query'
 import { KanbanColumnData, KanbanViewData, View } from '../../../types/view'
-import { getNotesForViewObject, removeNoteFromViewObject, addNoteToViewObject, deleteViewObject, updateViewObject, updateView } from '../../../api/view'
+import { getNotesForViewObject, removeNoteFromViewObject, moveKanbanCard, deleteViewObject, updateViewObject, updateView } from '../../../api/view'
 import { PlusCircle, MoreVertical, Edit2, Trash2, ChevronLeft, ChevronRight, X } from 'lucide-react'
 import * as DropdownMenu from '@radix-ui/react-dropdown-menu'
 import { Dialog } from 'radix-ui'
@@ -85,18 +85,16 @@ const KanbanViewComponent = ({
 
     const moveNoteMutation = useMutation({
         mutationFn: async ({ noteId, fromColumnId, toColumnId }: { noteId: string, fromColumnId: string, toColumnId: string }) => {
-            // First remove from old column
-            await removeNoteFromViewObject(currentWorkspaceId!, currentViewId!, fromColumnId, noteId)
-            // Then add to new column
-            await addNoteToViewObject(currentWorkspaceId!, currentViewId!, toColumnId, noteId)
+            await moveKanbanCard(currentWorkspaceId!, currentViewId!, fromColumnId, noteId, { column_id: toColumnId })
         },
         onSuccess: (_, variables) => {
             // Invalidate both columns' note queries
             queryClient.invalidateQueries({ queryKey: ['column-notes', currentWorkspaceId, currentViewId, variables.fromColumnId] })
             queryClient.invalidateQueries({ queryKey: ['column-notes', currentWorkspaceId, currentViewId, variables.toColumnId] })
         },
-        onError: () => {
-            addToast({ title: t('views.noteMovedError'), type: 'error' })
+        onError: (error: any) => {
+            // e.g. the column is at its WIP limit
+            addToast({ title: error?.response?.data?.message || t('views.noteMovedError'), type: 'error' })
         }
     })
 
@@ -123,9 +123,11 @@ const MapMarkersList = ({
                                                     <MapPin size={16} className="text-gray-500 flex-shrink-0 mt-0.5" />
                                                     <div className="flex-1 min-w-0">
                                                         <div className="font-medium truncate">{marker.name}</div>
-                                                        <div className="text-xs text-gray-500 mt-1">
-                                                            {markerData.lat?.toFixed(4)}, {markerData.lng?.toFixed(4)}
-                                                        </div>
+                                                        {markerData.lat !== undefined && markerData.lng !== undefined && (
+                                                            <div className="text-xs text-gray-500 mt-1">
+                                                                {markerData.lat.toFixed(4)}, {markerData.lng.toFixed(4)}
+                                                            </div>
+                                                        )}
                                                     </div>
                                                 </div>
                                                 <div onClick={(e) => e.stopPropagation()}>
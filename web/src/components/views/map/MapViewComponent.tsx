@@ -1,7 +1,7 @@
 import { useMemo, useState, useEffect } from 'react'
-import { MapContainer, TileLayer, Marker, Tooltip } from 'react-leaflet'
+import { MapContainer, TileLayer, Marker, Polyline, Tooltip } from 'react-leaflet'
 import { DivIcon } from 'leaflet'
-import { ViewObject, View, MapViewData } from '@/types/view'
+import { ViewObject, View, MapViewData, MapLineData } from '@/types/view'
 import { useNavigate, useParams } from 'react-router-dom'
 import { useTwoColumn } from '@/components/twocolumn/TwoColumn'
 import { getNotesForViewObject, getPublicNotesForViewObject } from '@/api/view'
@@ -107,6 +107,29 @@ const MapViewComponent = ({ viewObjects = [], view, focusedObjectId, isPublic =
             .filter((marker): marker is NonNullable<typeof marker> => marker !== null)
     }, [viewObjects])
 
+    // Parse viewObjects to extract map lines, such as imported tracks and routes
+    const lines = useMemo(() => {
+        return viewObjects
+            .filter(obj => obj.type === 'map_line')
+            .map(obj => {
+                try {
+                    const data: MapLineData = JSON.parse(obj.data)
+                    const positions = (data.points || []).map(p => [p.lat, p.lng] as [number, number])
+                    if (positions.length < 2) return null
+                    return {
+                        id: obj.id,
+                        name: obj.name,
+                        color: data.color || '#3b82f6',
+                        positions
+                    }
+                } catch (e) {
+                    console.error('Failed to parse line data:', obj.data, 'Error:', e)
+                }
+                return null
+            })
+            .filter((line): line is NonNullable<typeof line> => line !== null)
+    }, [viewObjects])
+
     // Load notes for each marker
     useEffect(() => {
         const loadNotesForMarkers = async () => {
@@ -274,6 +297,26 @@ const MapViewComponent = ({ viewObjects = [], view, focusedObjectId, isPublic =
                         url="https://{s}.tile.openstreetmap.org/{z}/{x}/{y}.png"
                     />
 
+                    {lines.map(line => (
+                        <Polyline
+                            key={line.id}
+                            positions={line.positions}
+                            pathOptions={{ color: line.color, weight: 4 }}
+                            eventHandlers={{
+                                click: () => {
+                                    openBottomSheet()
+                                    navigate(isPublic
+                                        ? `/explore/map/${mapId}/marker/${line.id}`
+                                        : `/workspaces/${workspaceId}/map/${mapId}/marker/${line.id}`)
+                                }
+                            }}
+                        >
+                            <Tooltip sticky>
+                                <div className="text-sm font-medium">{line.name}</div>
+                            </Tooltip>
+                        </Polyline>
+                    ))}
+
                     {markers.map(marker => {
                         const markerData = markersWithNotes.get(marker.id)
                         const icon = markerData ? createMarkerIcon(markerData) : new DivIcon({
@@ -1,8 +1,9 @@
-import { FC, useState } from 'react';
-import { useMutation, useQueryClient } from '@tanstack/react-query';
+import { FC, useEffect, useState } from 'react';
+import { useMutation, useQuery, useQueryClient } from '@tanstack/react-query';
 import { useTranslation } from 'react-i18next';
 import { Send, Bold, Italic, Underline, Strikethrough, Code, Heading, List, ListOrdered, Quote, FileCode, Table, Trash2, ListTodo, ChevronDown } from 'lucide-react';
 import { createNote, NoteData } from '@/api/note';
+import { getNoteTemplates, renderNoteTemplate, RenderedNoteTemplate } from '@/api/template';
 import useCurrentWorkspaceId from '@/hooks/use-currentworkspace-id';
 import { useToastStore } from '@/stores/toast';
 import { NoteFormWidgetConfig } from '@/types/widget';
@@ -34,6 +35,15 @@ const NoteFormWidget: FC<NoteFormWidgetProps> = ({ config }) => {
     content: initContent
   });
 
+  // The template pre-fills the form, and again after each note is created
+  const { data: template, refetch: refetchTemplate } = useQuery({
+    queryKey: ['note-template-render', workspaceId, config.templateId],
+    queryFn: () => renderNoteTemplate(workspaceId, config.templateId!),
+    enabled: !!workspaceId && !!config.templateId,
+    staleTime: Infinity,
+    retry: false,
+  });
+
   const editor = useEditor({
     extensions: [
       StarterKit.configure({
@@ -86,10 +96,21 @@ const NoteFormWidget: FC<NoteFormWidgetProps> = ({ config }) => {
     onUpdate: ({ editor }) => {
       const json = editor.getJSON();
       const content = JSON.stringify(json);
-      setNote({ content });
+      setNote((prev) => ({ ...prev, content }));
     }
   });
 
+  const applyTemplate = (rendered: RenderedNoteTemplate) => {
+    editor?.commands.setContent(rendered.content ? JSON.parse(rendered.content) : '');
+    setNote({ title: rendered.title, content: rendered.content });
+  };
+
+  useEffect(() => {
+    if (editor && template) {
+      applyTemplate(template);
+    }
+  }, [editor, template]);
+
   const createMutation = useMutation({
     mutationFn: () => {
       return createNote(workspaceId, {
@@ -102,6 +123,10 @@ const NoteFormWidget: FC<NoteFormWidgetProps> = ({ config }) => {
       // Reset editor content
       editor?.commands.clearContent();
       setNote({ content: initContent });
+      if (config.templateId) {
+        // Re-render so placeholders such as {{time}} are current
+        refetchTemplate().then(({ data }) => data && applyTemplate(data));
+      }
     },
     onError: () => {
       addToast({ type: 'error', title: t('notes.createError') });
@@ -327,6 +352,13 @@ export const NoteFormWidgetConfigForm: FC<WidgetConfigFormProps<NoteFormWidgetCo
   onChange,
 }) => {
   const { t } = useTranslation();
+  const workspaceId = useCurrentWorkspaceId();
+
+  const { data: templates = [] } = useQuery({
+    queryKey: ['note-templates', workspaceId],
+    queryFn: () => getNoteTemplates(workspaceId),
+    enabled: !!workspaceId,
+  });
 
   const toolbar = config.toolbar || {};
 
@@ -353,6 +385,22 @@ export const NoteFormWidgetConfigForm: FC<WidgetConfigFormProps<NoteFormWidgetCo
         />
       </div>
 
+      <div>
+        <label className="block text-sm font-medium mb-2">{t('widgets.config.template')}</label>
+        <select
+          value={config.templateId || ''}
+          onChange={(e) => onChange({ ...config, templateId: e.target.value || undefined })}
+          className="w-full px-3 py-2 rounded-lg border dark:border-neutral-600 bg-white dark:bg-neutral-800"
+        >
+          <option value="">{t('widgets.config.noTemplate')}</option>
+          {templates.map((template) => (
+            <option key={template.id} value={template.id}>
+              {template.name}
+            </option>
+          ))}
+        </select>
+      </div>
+
       {/* Toolbar Configuration */}
       <div>
         <label className="block text-sm font-medium mb-2">{t('widgets.config.toolbar')}</label>
@@ -6,6 +6,7 @@ interface UseNoteCollabOptions {
   noteId: string
   workspaceId: string
   enabled: boolean
+  isPublic?: boolean
 }
 
 interface UserInfo {
@@ -14,7 +15,7 @@ interface UserInfo {
 }
 
 export function useNoteCollab(options: UseNoteCollabOptions) {
-  const { noteId, workspaceId, enabled } = options
+  const { noteId, workspaceId, enabled, isPublic = false } = options
 
   const providerRef = useRef<HocuspocusProvider | null>(null)
   const yDocRef = useRef<Y.Doc | null>(null)
@@ -27,7 +28,8 @@ export function useNoteCollab(options: UseNoteCollabOptions) {
   const [activeUsers, setActiveUsers] = useState<UserInfo[]>([])
 
   useEffect(() => {
-    if (!enabled || !noteId || !workspaceId) return
+    if (!enabled || !noteId) return
+    if (!isPublic && !workspaceId) return
 
     // Create Y.js document
     const yDoc = new Y.Doc()
@@ -40,7 +42,8 @@ export function useNoteCollab(options: UseNoteCollabOptions) {
 
     // Build WebSocket URL
     const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:'
-    const url = `${protocol}//${window.location.host}/ws/notes/${noteId}`
+    const path = isPublic ? `/ws/public/notes/${noteId}` : `/ws/notes/${noteId}`
+    const url = `${protocol}//${window.location.host}${path}`
 
     // Create HocuspocusProvider
     const provider = new HocuspocusProvider({
@@ -98,7 +101,7 @@ export function useNoteCollab(options: UseNoteCollabOptions) {
       setTitle('')
       setActiveUsers([])
     }
-  }, [noteId, workspaceId, enabled])
+  }, [noteId, workspaceId, enabled, isPublic])
 
   const sendUpdateTitle = useCallback((newTitle: string) => {
     const yMeta = yMetaRef.current
@@ -699,6 +699,8 @@ export default {
       defaultVisibility: "Default Visibility",
       placeholder: "Placeholder Text",
       placeholderHint: "Text shown when the form is empty",
+      template: "Template",
+      noTemplate: "No template",
       toolbar: "Toolbar Options",
       statType: "Statistic Type",
       noteCount: "Total Note Count",
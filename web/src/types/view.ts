@@ -1,5 +1,5 @@
-export type ViewType = 'map' | 'calendar' | 'kanban' | 'whiteboard' | 'spreadsheet';
-export type ViewObjectType = 'calendar_slot' | 'map_marker' | 'kanban_column' | 'whiteboard_stroke' | 'whiteboard_shape' | 'whiteboard_text' | 'whiteboard_note' | 'whiteboard_view' | 'whiteboard_edge';
+export type ViewType = 'map' | 'calendar' | 'kanban' | 'whiteboard' | 'spreadsheet' | 'table' | 'timeline';
+export type ViewObjectType = 'calendar_slot' | 'map_marker' | 'map_line' | 'kanban_column' | 'whiteboard_stroke' | 'whiteboard_shape' | 'whiteboard_text' | 'whiteboard_note' | 'whiteboard_view' | 'whiteboard_edge' | 'timeline_item';
 
 // View data structures
 export interface MapViewData {
@@ -20,6 +20,49 @@ export interface KanbanViewData {
   columns?: string[];
 }
 
+export type TablePropertyType = 'text' | 'number' | 'select' | 'multi_select' | 'date' | 'checkbox' | 'user' | 'relation';
+
+export interface TableOption {
+  name: string;
+  color?: string;
+}
+
+export interface TableProperty {
+  id: string;
+  name: string;
+  type: TablePropertyType;
+  options?: TableOption[]; // Only for select and multi_select
+}
+
+export interface TableViewData {
+  properties?: TableProperty[];
+}
+
+// Values are strings, numbers, booleans or lists of strings, by property id.
+// Users and relations are IDs of users and notes, dates are YYYY-MM-DD.
+export type TableValue = string | number | boolean | string[];
+
+export interface TableRow {
+  note_id: string;
+  title: string;
+  visibility: string;
+  values: Record<string, TableValue>;
+  created_at: string;
+  created_by: string;
+  updated_at: string;
+  updated_by: string;
+}
+
+export interface TableRowsResponse {
+  total: number;
+  rows: TableRow[];
+}
+
+export interface TableGroupsResponse {
+  total: number;
+  groups: { value: TableValue | null; count: number; rows: TableRow[] }[];
+}
+
 export interface View {
   id: string;
   workspace_id: string;
@@ -83,16 +126,63 @@ export interface CalendarSlotData {
   end_time?: string; // HH:MM format (optional, for timed events)
   is_all_day?: boolean; // true for all-day events, false or undefined for timed events
   color?: string;
+  timezone?: string; // IANA timezone of date and times (optional, floating if unset)
+  rrule?: string; // RFC 5545 recurrence rule, e.g. FREQ=WEEKLY;BYDAY=MO (optional)
+  exdates?: string[]; // YYYY-MM-DD dates of skipped occurrences (optional)
+  uid?: string; // iCalendar UID of the imported event (optional)
+  caldav_name?: string; // resource name the event was stored under over CalDAV (optional)
 }
 
 export interface MapMarkerData {
   lat: number;
   lng: number;
   color?: string;
+  feature_id?: string; // ID of the imported GeoJSON, KML or GPX feature (optional)
+}
+
+export interface MapLineData {
+  points: { lat: number; lng: number }[];
+  color?: string;
+  feature_id?: string;
 }
 
 export interface KanbanColumnData {
   color?: string; // Column header color
+  wip_limit?: number; // Most cards the column may hold (optional)
+  done?: boolean; // Cards moved here are completed
+  check_tasks?: boolean; // Moving a card here checks the tasks of its note
+}
+
+export interface TimelineItemData {
+  date: string; // YYYY-MM-DD format, first day
+  end_date?: string; // YYYY-MM-DD format, last day (optional)
+  progress?: number; // 0 to 100
+  color?: string;
+  depends_on?: string[]; // IDs of the items that must finish before this one starts
+}
+
+export interface TimelineItemSchedule {
+  id: string;
+  name: string;
+  date: string;
+  end_date: string;
+  duration: number; // Days
+  progress: number;
+  color?: string;
+  depends_on: string[];
+  latest_end_date?: string; // Last day the item can end on without delaying the timeline
+  slack: number | null; // Days the item can slip, null if dependencies form a cycle
+  critical: boolean;
+}
+
+export interface Timeline {
+  date?: string;
+  end_date?: string;
+  progress: number;
+  items: TimelineItemSchedule[];
+  critical_path: string[];
+  conflicts: { predecessor: string; successor: string }[];
+  cycle?: string[];
 }
 
 // Whiteboard view data
@@ -31,6 +31,7 @@ export interface Widget {
 export interface NoteFormWidgetConfig {
   defaultTitle?: string;
   placeholder?: string;
+  templateId?: string; // Note template that pre-fills the form
   toolbar?: {
     showBold?: boolean;
     showItalic?: boolean;